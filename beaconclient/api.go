@@ -0,0 +1,156 @@
+package beaconclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-utils/blocksub"
+)
+
+// Genesis is the genesis of the beacon chain.
+type Genesis struct {
+	GenesisTime           uint64        `json:"genesis_time,string"`
+	GenesisValidatorsRoot common.Hash   `json:"genesis_validators_root"`
+	GenesisForkVersion    hexutil.Bytes `json:"genesis_fork_version"`
+}
+
+// Spec is the configuration of the beacon chain, e.g. SECONDS_PER_SLOT. Most values are strings, others are JSON
+// values such as the array of BLOB_SCHEDULE.
+type Spec map[string]json.RawMessage
+
+// String returns the string value of the key
+func (s Spec) String(key string) (string, error) {
+	raw, ok := s[key]
+	if !ok {
+		return "", fmt.Errorf("spec has no %s", key)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("spec %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Uint returns the integer value of the key
+func (s Spec) Uint(key string) (uint64, error) {
+	value, err := s.String(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// SecondsPerSlot returns SECONDS_PER_SLOT as duration
+func (s Spec) SecondsPerSlot() (time.Duration, error) {
+	seconds, err := s.Uint("SECONDS_PER_SLOT")
+	return time.Duration(seconds) * time.Second, err
+}
+
+// SlotsPerEpoch returns SLOTS_PER_EPOCH
+func (s Spec) SlotsPerEpoch() (uint64, error) {
+	return s.Uint("SLOTS_PER_EPOCH")
+}
+
+// ProposerDuty is the validator proposing a slot.
+type ProposerDuty struct {
+	Pubkey         hexutil.Bytes `json:"pubkey"`
+	ValidatorIndex uint64        `json:"validator_index,string"`
+	Slot           uint64        `json:"slot,string"`
+}
+
+// Genesis returns the genesis of the chain
+func (c *Client) Genesis(ctx context.Context) (*Genesis, error) {
+	genesis := new(Genesis)
+	if err := c.getData(ctx, "/eth/v1/beacon/genesis", genesis); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// Spec returns the configuration of the chain
+func (c *Client) Spec(ctx context.Context) (Spec, error) {
+	spec := make(Spec)
+	if err := c.getData(ctx, "/eth/v1/config/spec", &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// SlotClock returns the slot clock of the chain, from its genesis and spec
+func (c *Client) SlotClock(ctx context.Context) (blocksub.SlotClock, error) {
+	genesis, err := c.Genesis(ctx)
+	if err != nil {
+		return blocksub.SlotClock{}, err
+	}
+	spec, err := c.Spec(ctx)
+	if err != nil {
+		return blocksub.SlotClock{}, err
+	}
+	slotDuration, err := spec.SecondsPerSlot()
+	if err != nil {
+		return blocksub.SlotClock{}, err
+	}
+	return blocksub.SlotClock{
+		GenesisTime:  time.Unix(int64(genesis.GenesisTime), 0),
+		SlotDuration: slotDuration,
+	}, nil
+}
+
+// HeadSlot returns the slot of the head block
+func (c *Client) HeadSlot(ctx context.Context) (uint64, error) {
+	var header struct {
+		Header struct {
+			Message struct {
+				Slot uint64 `json:"slot,string"`
+			} `json:"message"`
+		} `json:"header"`
+	}
+	if err := c.getData(ctx, "/eth/v1/beacon/headers/head", &header); err != nil {
+		return 0, err
+	}
+	return header.Header.Message.Slot, nil
+}
+
+// ProposerDuties returns the proposers of the slots of the epoch, available for the current and next epoch
+func (c *Client) ProposerDuties(ctx context.Context, epoch uint64) ([]*ProposerDuty, error) {
+	var duties []*ProposerDuty
+	if err := c.getData(ctx, fmt.Sprintf("/eth/v1/validator/duties/proposer/%d", epoch), &duties); err != nil {
+		return nil, err
+	}
+	return duties, nil
+}
+
+// UpcomingProposerDuties returns the proposers of the current and next epoch, from the head slot
+func (c *Client) UpcomingProposerDuties(ctx context.Context) ([]*ProposerDuty, error) {
+	spec, err := c.Spec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slotsPerEpoch, err := spec.SlotsPerEpoch()
+	if err != nil {
+		return nil, err
+	}
+	if slotsPerEpoch == 0 {
+		return nil, fmt.Errorf("invalid SLOTS_PER_EPOCH 0")
+	}
+	headSlot, err := c.HeadSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	epoch := headSlot / slotsPerEpoch
+	duties, err := c.ProposerDuties(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	next, err := c.ProposerDuties(ctx, epoch+1)
+	if err != nil {
+		return nil, err
+	}
+	return append(duties, next...), nil
+}
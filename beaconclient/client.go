@@ -0,0 +1,120 @@
+// Package beaconclient is a minimal beacon node API client, for the genesis, spec, head events and proposer duties
+// needed by builder timing logic.
+package beaconclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/flashbots/go-utils/blocksub"
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/flashbots/go-utils/retry"
+)
+
+// APIError is a non-2xx response of the beacon node.
+type APIError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("beacon node returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("beacon node returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client sends requests to a beacon node.
+type Client struct {
+	beaconURI    string
+	httpClient   *http.Client
+	retryOptions []retry.Option
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with the client instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithRetry configures the retries of the requests failing with network errors or HTTP 429, 502, 503 and 504, 3
+// attempts with exponential backoff by default (see the retry package). WithRetry(retry.WithMaxAttempts(1)) disables
+// retries.
+func WithRetry(opts ...retry.Option) Option {
+	return func(c *Client) {
+		c.retryOptions = append([]retry.Option{}, opts...)
+	}
+}
+
+// New creates a client for the beacon node, e.g. http://localhost:5052
+func New(beaconURI string, opts ...Option) *Client {
+	c := &Client{
+		beaconURI:  strings.TrimRight(beaconURI, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	httpClient := *c.httpClient
+	httpClient.Transport = httputil.NewRetryTransport(c.httpClient.Transport, httputil.RetryTransportOpts{
+		Name:         "beaconclient",
+		RetryOptions: c.retryOptions,
+	})
+	c.httpClient = &httpClient
+	return c
+}
+
+// SubscribeEvents starts a BeaconSub for the head and finalized checkpoint events of the node, which uses the SSE
+// event stream with reconnects and falls back to polling. It is stopped when the context is done.
+func (c *Client) SubscribeEvents(ctx context.Context) (*blocksub.BeaconSub, error) {
+	sub := blocksub.NewBeaconSub(ctx, c.beaconURI)
+	if err := sub.Start(); err != nil {
+		sub.Stop()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// getData decodes the data field of the JSON response of the path into out
+func (c *Client) getData(ctx context.Context, path string, out any) error {
+	res := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	return c.get(ctx, path, &res)
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.beaconURI+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(body, apiErr)
+		return apiErr
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decoding response of %s: %w", path, err)
+	}
+	return nil
+}
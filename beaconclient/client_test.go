@@ -0,0 +1,91 @@
+package beaconclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBeaconServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"genesis_time":"1606824023","genesis_validators_root":"0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95","genesis_fork_version":"0x00000000"}}`)
+	})
+	mux.HandleFunc("/eth/v1/config/spec", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"SECONDS_PER_SLOT":"12","SLOTS_PER_EPOCH":"32","CONFIG_NAME":"mainnet","BLOB_SCHEDULE":[{"EPOCH":"412672","MAX_BLOBS_PER_BLOCK":"15"}]}}`)
+	})
+	mux.HandleFunc("/eth/v1/beacon/headers/head", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"root":"0x0000000000000000000000000000000000000000000000000000000000000001","header":{"message":{"slot":"100","state_root":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}}`)
+	})
+	mux.HandleFunc("/eth/v1/validator/duties/proposer/", func(w http.ResponseWriter, r *http.Request) {
+		var epoch uint64
+		_, err := fmt.Sscanf(r.URL.Path, "/eth/v1/validator/duties/proposer/%d", &epoch)
+		require.NoError(t, err)
+		fmt.Fprintf(w, `{"dependent_root":"0x01","data":[{"pubkey":"0xaa","validator_index":"%d","slot":"%d"}]}`, epoch, epoch*32)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient(t *testing.T) {
+	server := newTestBeaconServer(t)
+	defer server.Close()
+	client := New(server.URL)
+	ctx := context.Background()
+
+	clock, err := client.SlotClock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1606824023, 0), clock.GenesisTime)
+	require.Equal(t, 12*time.Second, clock.SlotDuration)
+
+	spec, err := client.Spec(ctx)
+	require.NoError(t, err)
+	name, err := spec.String("CONFIG_NAME")
+	require.NoError(t, err)
+	require.Equal(t, "mainnet", name)
+	slotsPerEpoch, err := spec.SlotsPerEpoch()
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), slotsPerEpoch)
+	_, err = spec.String("BLOB_SCHEDULE")
+	require.Error(t, err)
+	_, err = spec.Uint("MISSING")
+	require.Error(t, err)
+
+	// head slot 100 is in epoch 3
+	duties, err := client.UpcomingProposerDuties(ctx)
+	require.NoError(t, err)
+	require.Len(t, duties, 2)
+	require.Equal(t, uint64(96), duties[0].Slot)
+	require.Equal(t, uint64(128), duties[1].Slot)
+	require.Equal(t, uint64(4), duties[1].ValidatorIndex)
+}
+
+func TestClientRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"code":503,"message":"node is syncing"}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"genesis_time":"1606824023"}}`)
+	}))
+	defer server.Close()
+
+	genesis, err := New(server.URL, WithRetry(retry.WithBackoff(time.Millisecond, 0))).Genesis(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1606824023), genesis.GenesisTime)
+
+	calls.Store(0)
+	_, err = New(server.URL, WithRetry(retry.WithMaxAttempts(1))).Genesis(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "node is syncing", apiErr.Message)
+}
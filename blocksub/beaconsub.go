@@ -0,0 +1,397 @@
+package blocksub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"go.uber.org/atomic"
+)
+
+const (
+	BeaconTopicHead                = "head"
+	BeaconTopicFinalizedCheckpoint = "finalized_checkpoint"
+)
+
+var (
+	errBeaconStreamTimeout = errors.New("event stream timeout")
+	errBeaconStreamClosed  = errors.New("event stream closed")
+
+	// beaconReconnectDelay is the delay between reconnection attempts of the event stream
+	beaconReconnectDelay = time.Second
+)
+
+// BeaconHeadEvent is the payload of the beacon node's "head" event.
+type BeaconHeadEvent struct {
+	Slot                uint64      `json:"slot,string"`
+	Block               common.Hash `json:"block"`
+	State               common.Hash `json:"state"`
+	EpochTransition     bool        `json:"epoch_transition"`
+	ExecutionOptimistic bool        `json:"execution_optimistic"`
+}
+
+// BeaconFinalizedCheckpointEvent is the payload of the beacon node's "finalized_checkpoint" event.
+type BeaconFinalizedCheckpointEvent struct {
+	Block               common.Hash `json:"block"`
+	State               common.Hash `json:"state"`
+	Epoch               uint64      `json:"epoch,string"`
+	ExecutionOptimistic bool        `json:"execution_optimistic"`
+}
+
+// BeaconEvent is delivered to BeaconSub subscribers. Exactly one of Head and FinalizedCheckpoint is set,
+// depending on Topic.
+type BeaconEvent struct {
+	Topic               string
+	Head                *BeaconHeadEvent
+	FinalizedCheckpoint *BeaconFinalizedCheckpointEvent
+}
+
+// BeaconSub subscribes to head and finalized checkpoint events of a beacon node. It uses the SSE event stream
+// (/eth/v1/events) and falls back to polling the beacon API, reconnecting the stream if it stalls.
+type BeaconSub struct {
+	PollTimeout time.Duration // 10 seconds by default
+	SubTimeout  time.Duration // 60 seconds by default, after this timeout the event stream will reconnect
+	DebugOutput bool
+
+	beaconURI  string // usually port 5052 or 3500
+	httpClient *http.Client
+
+	publisher *Publisher[BeaconEvent]
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped atomic.Bool
+
+	internalEventC chan BeaconEvent // internal subscription channel
+
+	// Current* fields are updated by the listener goroutine, use LatestHead() and LatestFinalized() for synchronized
+	// access
+	CurrentHead      *BeaconHeadEvent
+	CurrentFinalized *BeaconFinalizedCheckpointEvent
+	currentMu        sync.RWMutex
+}
+
+func NewBeaconSub(ctx context.Context, beaconURI string) *BeaconSub {
+	ctx, cancel := context.WithCancel(ctx)
+	return &BeaconSub{
+		PollTimeout:    10 * time.Second,
+		SubTimeout:     60 * time.Second,
+		beaconURI:      strings.TrimRight(beaconURI, "/"),
+		httpClient:     &http.Client{},
+		ctx:            ctx,
+		cancel:         cancel,
+		internalEventC: make(chan BeaconEvent),
+		publisher:      NewPublisher[BeaconEvent](PublisherOpts{Name: "beaconsub"}),
+	}
+}
+
+func (s *BeaconSub) IsRunning() bool {
+	return !s.stopped.Load()
+}
+
+// Subscribe is used to create a new subscription to head and finalized checkpoint events.
+func (s *BeaconSub) Subscribe(ctx context.Context) BeaconSubscription {
+	if s.stopped.Load() {
+		sub := NewBeaconSubscription(ctx)
+		sub.Unsubscribe()
+		return sub
+	}
+	return s.publisher.Subscribe(ctx)
+}
+
+// LatestHead returns the most recently delivered head event, or nil if none was received yet.
+func (s *BeaconSub) LatestHead() *BeaconHeadEvent {
+	s.currentMu.RLock()
+	defer s.currentMu.RUnlock()
+	return s.CurrentHead
+}
+
+// LatestFinalized returns the most recently delivered finalized checkpoint event, or nil if none was received yet.
+func (s *BeaconSub) LatestFinalized() *BeaconFinalizedCheckpointEvent {
+	s.currentMu.RLock()
+	defer s.currentMu.RUnlock()
+	return s.CurrentFinalized
+}
+
+// Start starts the event stream and polling threads.
+func (s *BeaconSub) Start() error {
+	if s.stopped.Load() {
+		return ErrStopped
+	}
+
+	go s.runListener()
+
+	// Ensure that polling works
+	log.Info("BeaconSub:Start - connecting...", "uri", s.beaconURI)
+	if err := s._pollNow(); err != nil {
+		return err
+	}
+	log.Info("BeaconSub:Start - connected", "uri", s.beaconURI)
+
+	go s.runEventStream()
+	go s.runPoller()
+	return nil
+}
+
+// Stop closes all subscriptions and stops the event stream and polling threads.
+func (s *BeaconSub) Stop() {
+	if s.stopped.Swap(true) {
+		return
+	}
+
+	s.publisher.UnsubscribeAll()
+
+	s.cancel()
+}
+
+// Listens to internal events and forwards them to the subscribers if they are newer than the previous ones.
+func (s *BeaconSub) runListener() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.Stop() // ensures all subscribers are properly closed
+			return
+
+		case ev := <-s.internalEventC:
+			switch {
+			case ev.Head != nil:
+				if s.CurrentHead != nil && (ev.Head.Slot < s.CurrentHead.Slot || ev.Head.Block == s.CurrentHead.Block) {
+					continue
+				}
+				s.currentMu.Lock()
+				s.CurrentHead = ev.Head
+				s.currentMu.Unlock()
+			case ev.FinalizedCheckpoint != nil:
+				if s.CurrentFinalized != nil && ev.FinalizedCheckpoint.Epoch <= s.CurrentFinalized.Epoch {
+					continue
+				}
+				s.currentMu.Lock()
+				s.CurrentFinalized = ev.FinalizedCheckpoint
+				s.currentMu.Unlock()
+			default:
+				continue
+			}
+
+			// Send to each subscriber
+			s.publisher.Publish(ev)
+		}
+	}
+}
+
+func (s *BeaconSub) runPoller() {
+	ch := time.After(s.PollTimeout)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ch:
+			err := s._pollNow()
+			if err != nil {
+				log.Error("BeaconSub: polling beacon node failed", "err", err)
+			}
+			ch = time.After(s.PollTimeout)
+		}
+	}
+}
+
+func (s *BeaconSub) _pollNow() error {
+	var headRes struct {
+		Data struct {
+			Root   common.Hash `json:"root"`
+			Header struct {
+				Message struct {
+					Slot      uint64      `json:"slot,string"`
+					StateRoot common.Hash `json:"state_root"`
+				} `json:"message"`
+			} `json:"header"`
+		} `json:"data"`
+		ExecutionOptimistic bool `json:"execution_optimistic"`
+	}
+	if err := s.getJSON("/eth/v1/beacon/headers/head", &headRes); err != nil {
+		return err
+	}
+	head := &BeaconHeadEvent{
+		Slot:                headRes.Data.Header.Message.Slot,
+		Block:               headRes.Data.Root,
+		State:               headRes.Data.Header.Message.StateRoot,
+		ExecutionOptimistic: headRes.ExecutionOptimistic,
+	}
+	if s.DebugOutput {
+		log.Debug("BeaconSub: polled head", "slot", head.Slot, "block", head.Block.Hex())
+	}
+	if err := s.sendEvent(BeaconEvent{Topic: BeaconTopicHead, Head: head}); err != nil {
+		return err
+	}
+
+	var finalityRes struct {
+		Data struct {
+			Finalized struct {
+				Epoch uint64      `json:"epoch,string"`
+				Root  common.Hash `json:"root"`
+			} `json:"finalized"`
+		} `json:"data"`
+		ExecutionOptimistic bool `json:"execution_optimistic"`
+	}
+	if err := s.getJSON("/eth/v1/beacon/states/head/finality_checkpoints", &finalityRes); err != nil {
+		return err
+	}
+	finalized := &BeaconFinalizedCheckpointEvent{
+		Block:               finalityRes.Data.Finalized.Root,
+		Epoch:               finalityRes.Data.Finalized.Epoch,
+		ExecutionOptimistic: finalityRes.ExecutionOptimistic,
+	}
+	return s.sendEvent(BeaconEvent{Topic: BeaconTopicFinalizedCheckpoint, FinalizedCheckpoint: finalized})
+}
+
+// sendEvent passes the event to the listener, unless the BeaconSub is stopped.
+func (s *BeaconSub) sendEvent(ev BeaconEvent) error {
+	select {
+	case s.internalEventC <- ev:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *BeaconSub) getJSON(path string, out any) error {
+	ctx, cancel := context.WithTimeout(s.ctx, s.PollTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.beaconURI+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status code %d", path, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// runEventStream keeps the SSE event stream connected until the BeaconSub is stopped.
+func (s *BeaconSub) runEventStream() {
+	for {
+		err := s.streamEvents()
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		log.Warn("BeaconSub: event stream failed, reconnect now", "err", err)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(beaconReconnectDelay):
+		}
+	}
+}
+
+// streamEvents connects to the event stream and forwards events until the connection fails or times out.
+func (s *BeaconSub) streamEvents() error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	uri := s.beaconURI + "/eth/v1/events?topics=" + BeaconTopicHead + "," + BeaconTopicFinalizedCheckpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	log.Info("BeaconSub:streamEvents - connecting...", "uri", s.beaconURI)
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("event stream: unexpected status code %d", res.StatusCode)
+	}
+	log.Info("BeaconSub:streamEvents - connected", "uri", s.beaconURI)
+
+	// cancel the request if no event arrives within SubTimeout
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(s.SubTimeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+
+	var (
+		topic string
+		data  strings.Builder
+	)
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "": // end of event
+			if topic != "" && data.Len() > 0 {
+				timer.Reset(s.SubTimeout)
+				s.handleEvent(topic, []byte(data.String()))
+			}
+			topic = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			topic = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+
+	if timedOut.Load() {
+		return errBeaconStreamTimeout
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errBeaconStreamClosed
+}
+
+func (s *BeaconSub) handleEvent(topic string, data []byte) {
+	ev := BeaconEvent{Topic: topic}
+	var err error
+	switch topic {
+	case BeaconTopicHead:
+		ev.Head = new(BeaconHeadEvent)
+		err = json.Unmarshal(data, ev.Head)
+	case BeaconTopicFinalizedCheckpoint:
+		ev.FinalizedCheckpoint = new(BeaconFinalizedCheckpointEvent)
+		err = json.Unmarshal(data, ev.FinalizedCheckpoint)
+	default:
+		return
+	}
+	if err != nil {
+		log.Error("BeaconSub: failed to decode event", "topic", topic, "err", err)
+		return
+	}
+
+	if s.DebugOutput {
+		log.Debug("BeaconSub: stream event", "topic", topic, "data", string(data))
+	}
+
+	_ = s.sendEvent(ev)
+}
+
+// BeaconSubscription will push new beacon events to a subscriber until the context is done or Unsubscribe()
+// is called, at which point the subscription is stopped and the event channel closed.
+type BeaconSubscription = EventSubscription[BeaconEvent]
+
+func NewBeaconSubscription(ctx context.Context) BeaconSubscription {
+	return NewEventSubscription[BeaconEvent](ctx, 0)
+}
@@ -0,0 +1,86 @@
+package blocksub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBeaconServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/headers/head", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"execution_optimistic":false,"data":{"root":"0x0000000000000000000000000000000000000000000000000000000000000001","header":{"message":{"slot":"100","state_root":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}}`)
+	})
+	mux.HandleFunc("/eth/v1/beacon/states/head/finality_checkpoints", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"finalized":{"epoch":"1","root":"0x0000000000000000000000000000000000000000000000000000000000000003"}}}`)
+	})
+	mux.HandleFunc("/eth/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "head,finalized_checkpoint", r.URL.Query().Get("topics"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond) // subscribers only receive events they're ready for
+		fmt.Fprint(w, "event: head\ndata: {\"slot\":\"101\",\"block\":\"0x0000000000000000000000000000000000000000000000000000000000000004\",\"state\":\"0x0000000000000000000000000000000000000000000000000000000000000005\",\"epoch_transition\":false}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "event: finalized_checkpoint\ndata: {\"block\":\"0x0000000000000000000000000000000000000000000000000000000000000006\",\"state\":\"0x0000000000000000000000000000000000000000000000000000000000000007\",\"epoch\":\"2\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestBeaconSub(t *testing.T) {
+	server := newTestBeaconServer(t)
+	defer server.Close()
+
+	beaconSub := NewBeaconSub(context.Background(), server.URL)
+	defer beaconSub.Stop()
+
+	sub := beaconSub.Subscribe(context.Background())
+	require.NoError(t, beaconSub.Start())
+
+	require.Equal(t, uint64(100), beaconSub.LatestHead().Slot)
+	require.Eventually(t, func() bool { return beaconSub.LatestFinalized() != nil }, time.Second, time.Millisecond)
+	require.Equal(t, uint64(1), beaconSub.LatestFinalized().Epoch)
+
+	var head, finalized bool
+	timeout := time.After(5 * time.Second)
+	for !head || !finalized {
+		select {
+		case ev := <-sub.C:
+			switch ev.Topic {
+			case BeaconTopicHead:
+				if ev.Head.Slot == 101 {
+					head = true
+				}
+			case BeaconTopicFinalizedCheckpoint:
+				if ev.FinalizedCheckpoint.Epoch == 2 {
+					finalized = true
+				}
+			}
+		case <-timeout:
+			t.Fatal("timeout waiting for stream events")
+		}
+	}
+
+	beaconSub.Stop()
+	_, ok := <-sub.C
+	require.False(t, ok)
+}
+
+func TestBeaconSubPollStopped(t *testing.T) {
+	server := newTestBeaconServer(t)
+	defer server.Close()
+
+	// without the listener the polled events are never received, the poll returns once the BeaconSub is stopped
+	beaconSub := NewBeaconSub(context.Background(), server.URL)
+	time.AfterFunc(50*time.Millisecond, beaconSub.Stop)
+	require.ErrorIs(t, beaconSub._pollNow(), context.Canceled)
+}
@@ -4,6 +4,8 @@ package blocksub
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -11,10 +13,24 @@ import (
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/flashbots/go-utils/retry"
 	"go.uber.org/atomic"
 )
 
-var ErrStopped = errors.New("already stopped")
+var (
+	ErrStopped        = errors.New("already stopped")
+	ErrAlreadyStarted = errors.New("already started")
+	ErrNotConnected   = errors.New("not connected to a node")
+
+	ErrChainIDMismatch = errors.New("chain ID mismatch between endpoints")
+)
+
+// wsReconnectBackoff and wsReconnectMaxBackoff bound the delay between websocket reconnection attempts
+var (
+	wsReconnectBackoff    = 100 * time.Millisecond
+	wsReconnectMaxBackoff = 5 * time.Second
+)
 
 type BlockSubscriber interface {
 	IsRunning() bool
@@ -28,23 +44,41 @@ type BlockSub struct {
 	SubTimeout  time.Duration // 60 seconds by default, after this timeout the subscriber will reconnect
 	DebugOutput bool
 
+	// OnDiscontinuity is called (from the listener goroutine) whenever a delivered header is not the direct child of
+	// the previously delivered header, i.e. when blocks were skipped or a reorg happened. Can be nil.
+	OnDiscontinuity func(d Discontinuity)
+
+	// ClientOptions are used when dialing the HTTP and websocket endpoints, e.g. to set custom headers
+	// (rpc.WithHeaders), a custom transport (rpc.WithHTTPClient) or JWT auth (rpc.WithHTTPAuth(node.NewJWTAuth(secret))).
+	ClientOptions []rpc.ClientOption
+
 	ethNodeHTTPURI      string // usually port 8545
 	ethNodeWebsocketURI string // usually port 8546
 
-	subscriptions []*Subscription
+	publisher *Publisher[*ethtypes.Header]
 
-	ctx     context.Context
-	cancel  context.CancelFunc
-	stopped atomic.Bool
+	parentCtx context.Context
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopped   atomic.Bool
+	started   bool
+	startMu   sync.Mutex
+	wg        sync.WaitGroup // tracks all internal goroutines, see Wait()
 
 	httpClient      *ethclient.Client
 	wsClient        *ethclient.Client
 	wsClientSub     ethereum.Subscription
+	wsClientShared  bool                  // wsClient was provided by the caller, it is reused on reconnect and never closed
 	internalHeaderC chan *ethtypes.Header // internal subscription channel
 
+	// Current* fields are updated by the listener goroutine, use LatestHeader() for synchronized access
 	CurrentHeader      *ethtypes.Header
 	CurrentBlockNumber uint64
 	CurrentBlockHash   string
+	currentMu          sync.RWMutex
+
+	chainID   *big.Int // set on the first successful connection, all endpoints must match it
+	chainIDMu sync.Mutex
 
 	latestWsHeader   *ethtypes.Header
 	wsIsConnecting   atomic.Bool
@@ -52,55 +86,151 @@ type BlockSub struct {
 }
 
 func NewBlockSub(ctx context.Context, ethNodeHTTPURI, ethNodeWebsocketURI string) *BlockSub {
+	parentCtx := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	sub := &BlockSub{
 		PollTimeout:         10 * time.Second,
 		SubTimeout:          60 * time.Second,
 		ethNodeHTTPURI:      ethNodeHTTPURI,
 		ethNodeWebsocketURI: ethNodeWebsocketURI,
+		parentCtx:           parentCtx,
 		ctx:                 ctx,
 		cancel:              cancel,
 		internalHeaderC:     make(chan *ethtypes.Header),
+		publisher:           NewPublisher[*ethtypes.Header](PublisherOpts{Name: "blocksub"}),
 		wsConnectingCond:    sync.NewCond(new(sync.Mutex)),
 	}
 	return sub
 }
 
+// NewBlockSubFromClients creates a BlockSub that uses already established connections instead of dialing URIs,
+// e.g. to reuse authenticated clients or custom transports (for an ethclient.Client, pass ethClient.Client()).
+// Either client can be nil. The clients are not closed by the BlockSub, and on websocket failures the
+// subscription is re-established on the same wsClient.
+func NewBlockSubFromClients(ctx context.Context, httpClient, wsClient *rpc.Client) *BlockSub {
+	sub := NewBlockSub(ctx, "", "")
+	if httpClient != nil {
+		sub.httpClient = ethclient.NewClient(httpClient)
+	}
+	if wsClient != nil {
+		sub.wsClient = ethclient.NewClient(wsClient)
+		sub.wsClientShared = true
+	}
+	return sub
+}
+
 func (s *BlockSub) IsRunning() bool {
 	return !s.stopped.Load()
 }
 
 // Subscribe is used to create a new subscription.
 func (s *BlockSub) Subscribe(ctx context.Context) Subscription {
-	sub := NewSubscription(ctx)
+	return s.SubscribeWithFilter(ctx, nil)
+}
+
+// SubscribeWithFilter is used to create a new subscription that only receives headers for which filter returns
+// true (e.g. EveryNthBlock or MinBaseFee). The filter is called from the listener goroutine and must not block.
+func (s *BlockSub) SubscribeWithFilter(ctx context.Context, filter HeaderFilter) Subscription {
 	if s.stopped.Load() {
+		sub := NewSubscription(ctx)
 		sub.Unsubscribe()
-	} else {
-		go sub.run()
-		s.subscriptions = append(s.subscriptions, &sub)
+		return sub
 	}
-	return sub
+	return s.publisher.SubscribeWithFilter(ctx, filter)
+}
+
+// WaitForBlock blocks until a header with a block number greater than or equal to the given one is received,
+// and returns it. It returns early with an error if the context is done or the BlockSub is stopped.
+func (s *BlockSub) WaitForBlock(ctx context.Context, number uint64) (*ethtypes.Header, error) {
+	// the subscription is closed by cancelling its context, see Subscription.run(). It's created before checking the
+	// latest header, so that a header received in between isn't missed. The header is taken by the filter, which sees
+	// every header even if this goroutine isn't receiving on the channel yet.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	found := make(chan *ethtypes.Header, 1)
+	sub := s.SubscribeWithFilter(ctx, func(header *ethtypes.Header) bool {
+		if header.Number.Uint64() >= number {
+			select {
+			case found <- header:
+			default:
+			}
+		}
+		return false
+	})
+
+	if header := s.LatestHeader(); header != nil && header.Number.Uint64() >= number {
+		return header, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case header := <-found:
+		return header, nil
+	case <-sub.Done():
+		// the subscription is stopped together with the BlockSub
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrStopped
+	}
+}
+
+// WaitForNextBlock blocks until a header with a block number greater than the current one is received, and returns it.
+func (s *BlockSub) WaitForNextBlock(ctx context.Context) (*ethtypes.Header, error) {
+	var next uint64
+	if header := s.LatestHeader(); header != nil {
+		next = header.Number.Uint64() + 1
+	}
+	return s.WaitForBlock(ctx, next)
+}
+
+// LatestHeader returns the most recently delivered header, or nil if none was received yet.
+func (s *BlockSub) LatestHeader() *ethtypes.Header {
+	s.currentMu.RLock()
+	defer s.currentMu.RUnlock()
+	return s.CurrentHeader
 }
 
 // Start starts polling and websocket threads.
+//
+// A stopped BlockSub can be started again (as long as the context it was created with is not done), in which case
+// Start waits for the goroutines of the previous run to exit first. Subscriptions are closed by Stop, so consumers
+// need to subscribe again after a restart. Start and Stop must not be called concurrently.
 func (s *BlockSub) Start() (err error) {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+
 	if s.stopped.Load() {
-		return ErrStopped
+		if s.parentCtx.Err() != nil {
+			return ErrStopped
+		}
+		s.reset()
+	} else if s.started {
+		return ErrAlreadyStarted
 	}
+	s.started = true
 
-	go s.runListener()
+	s.goRun(s.runListener)
 
-	if s.ethNodeWebsocketURI != "" {
+	if s.ethNodeWebsocketURI != "" || s.wsClientShared {
 		err = s.startWebsocket(false)
 		if err != nil {
 			return err
 		}
 	}
 
-	if s.ethNodeHTTPURI != "" {
-		log.Info("BlockSub:Start - HTTP connecting...", "uri", s.ethNodeHTTPURI)
-		s.httpClient, err = ethclient.Dial(s.ethNodeHTTPURI)
-		if err != nil { // using an invalid port will NOT return an error here, only at polling
+	if s.ethNodeHTTPURI != "" || s.httpClient != nil {
+		if s.httpClient == nil {
+			log.Info("BlockSub:Start - HTTP connecting...", "uri", s.ethNodeHTTPURI)
+			rpcClient, err := rpc.DialOptions(s.ctx, s.ethNodeHTTPURI, s.ClientOptions...)
+			if err != nil { // using an invalid port will NOT return an error here, only at polling
+				return err
+			}
+			s.httpClient = ethclient.NewClient(rpcClient)
+		}
+
+		if err = s.checkChainID(s.httpClient, s.ethNodeHTTPURI); err != nil {
 			return err
 		}
 
@@ -111,25 +241,79 @@ func (s *BlockSub) Start() (err error) {
 		}
 
 		log.Info("BlockSub:Start - HTTP connected", "uri", s.ethNodeHTTPURI)
-		go s.runPoller()
+		s.goRun(s.runPoller)
+	}
+
+	return nil
+}
+
+// ChainID returns the chain ID reported by the node(s), or nil if it's not known yet (i.e. before Start).
+func (s *BlockSub) ChainID() *big.Int {
+	s.chainIDMu.Lock()
+	defer s.chainIDMu.Unlock()
+	if s.chainID == nil {
+		return nil
+	}
+	return new(big.Int).Set(s.chainID)
+}
+
+// checkChainID queries the chain ID of the endpoint and ensures it matches the one of the other endpoints.
+func (s *BlockSub) checkChainID(client *ethclient.Client, uri string) error {
+	chainID, err := client.ChainID(s.ctx)
+	if err != nil {
+		return err
 	}
 
+	// the websocket endpoint is checked again on reconnect, concurrently with ChainID
+	s.chainIDMu.Lock()
+	defer s.chainIDMu.Unlock()
+	if s.chainID == nil {
+		s.chainID = chainID
+		return nil
+	}
+
+	if s.chainID.Cmp(chainID) != 0 {
+		log.Error("BlockSub: chain ID mismatch", "uri", uri, "chainID", chainID, "expected", s.chainID)
+		return fmt.Errorf("%w: %s reports %s, expected %s", ErrChainIDMismatch, uri, chainID, s.chainID)
+	}
 	return nil
 }
 
-// Stop closes all subscriptions and stops the polling and websocket threads.
+// Stop closes all subscriptions and stops the polling and websocket threads. Use Wait to block until they have exited.
 func (s *BlockSub) Stop() {
 	if s.stopped.Swap(true) {
 		return
 	}
 
-	for _, sub := range s.subscriptions {
-		sub.Unsubscribe()
-	}
+	s.publisher.UnsubscribeAll()
 
 	s.cancel()
 }
 
+// Wait blocks until all internal goroutines have exited, which happens after Stop is called (or the context
+// is done).
+func (s *BlockSub) Wait() {
+	s.wg.Wait()
+}
+
+// reset prepares a stopped BlockSub to be started again.
+func (s *BlockSub) reset() {
+	s.Wait()
+
+	s.ctx, s.cancel = context.WithCancel(s.parentCtx)
+	s.latestWsHeader = nil
+	s.stopped.Store(false)
+}
+
+// goRun runs fn in a goroutine that is tracked by Wait.
+func (s *BlockSub) goRun(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
 // Listens to internal headers and forwards them to the subscriber if the header has a greater blockNumber or different hash than the previous one.
 func (s *BlockSub) runListener() {
 	for {
@@ -141,21 +325,21 @@ func (s *BlockSub) runListener() {
 		case header := <-s.internalHeaderC:
 			// use the new header if it's later or has a different hash than the previous known one
 			if header.Number.Uint64() >= s.CurrentBlockNumber && header.Hash().Hex() != s.CurrentBlockHash {
+				if d := checkContinuity(s.CurrentHeader, header); d != nil {
+					log.Warn("BlockSub: header discontinuity", "kind", d.Kind, "prevNumber", d.Previous.Number.Uint64(), "prevHash", d.Previous.Hash().Hex(), "number", header.Number.Uint64(), "hash", header.Hash().Hex(), "parentHash", header.ParentHash.Hex())
+					if s.OnDiscontinuity != nil {
+						s.OnDiscontinuity(*d)
+					}
+				}
+
+				s.currentMu.Lock()
 				s.CurrentHeader = header
 				s.CurrentBlockNumber = header.Number.Uint64()
 				s.CurrentBlockHash = header.Hash().Hex()
+				s.currentMu.Unlock()
 
 				// Send to each subscriber
-				for _, sub := range s.subscriptions {
-					if sub.stopped.Load() {
-						continue
-					}
-
-					select {
-					case sub.C <- header:
-					default:
-					}
-				}
+				s.publisher.Publish(header)
 			}
 		}
 	}
@@ -186,12 +370,16 @@ func (s *BlockSub) _pollNow() error {
 	if s.DebugOutput {
 		log.Debug("BlockSub: polled block", "number", header.Number.Uint64(), "hash", header.Hash().Hex())
 	}
-	s.internalHeaderC <- header
+	select {
+	case s.internalHeaderC <- header:
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
 
 	// Ensure websocket is still working (force a reconnect if it lags behind)
 	if s.latestWsHeader != nil && s.latestWsHeader.Number.Uint64() < header.Number.Uint64()-2 {
 		log.Warn("BlockSub: forcing websocket reconnect from polling", "wsBlockNum", s.latestWsHeader.Number.Uint64(), "pollBlockNum", header.Number.Uint64())
-		go s.startWebsocket(true)
+		s.goRun(s.restartWebsocket)
 	}
 
 	return nil
@@ -212,25 +400,41 @@ func (s *BlockSub) startWebsocket(retryForever bool) error {
 		s.wsConnectingCond.Broadcast()
 	}()
 
-	for {
-		if s.wsClient != nil {
+	attempts := 1
+	if retryForever {
+		attempts = 0
+	}
+	return retry.Do(s.ctx, func(ctx context.Context) error {
+		if s.wsClient != nil && !s.wsClientShared {
 			s.wsClient.Close()
 		}
+		return s._startWebsocket()
+	},
+		retry.WithMaxAttempts(attempts),
+		retry.WithBackoff(wsReconnectBackoff, wsReconnectMaxBackoff),
+		retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			log.Error("BlockSub:startWebsocket failed, retrying...", "err", err, "attempt", attempt, "delay", delay)
+		}),
+	)
+}
 
-		err := s._startWebsocket()
-		if err != nil && retryForever {
-			log.Error("BlockSub:startWebsocket failed, retrying...", "err", err)
-		} else {
-			return err
-		}
-	}
+// restartWebsocket reconnects the websocket, retrying until it succeeds or the BlockSub is stopped.
+func (s *BlockSub) restartWebsocket() {
+	_ = s.startWebsocket(true)
 }
 
 func (s *BlockSub) _startWebsocket() (err error) {
 	log.Info("BlockSub:_startWebsocket - connecting...", "uri", s.ethNodeWebsocketURI)
 
-	s.wsClient, err = ethclient.Dial(s.ethNodeWebsocketURI)
-	if err != nil {
+	if !s.wsClientShared {
+		rpcClient, err := rpc.DialOptions(s.ctx, s.ethNodeWebsocketURI, s.ClientOptions...)
+		if err != nil {
+			return err
+		}
+		s.wsClient = ethclient.NewClient(rpcClient)
+	}
+
+	if err = s.checkChainID(s.wsClient, s.ethNodeWebsocketURI); err != nil {
 		return err
 	}
 
@@ -241,7 +445,7 @@ func (s *BlockSub) _startWebsocket() (err error) {
 	}
 
 	// Listen for headers and errors, and reconnect if needed
-	go func() {
+	s.goRun(func() {
 		timer := time.NewTimer(s.SubTimeout)
 
 		for {
@@ -256,12 +460,12 @@ func (s *BlockSub) _startWebsocket() (err error) {
 
 				// reconnect
 				log.Warn("BlockSub: headerSub failed, reconnect now", "err", err)
-				go s.startWebsocket(true)
+				s.goRun(s.restartWebsocket)
 				return
 
 			case <-timer.C:
 				log.Warn("BlockSub: timeout, reconnect now", "timeout", s.SubTimeout)
-				go s.startWebsocket(true)
+				s.goRun(s.restartWebsocket)
 				return
 
 			case header := <-wsHeaderC:
@@ -270,10 +474,14 @@ func (s *BlockSub) _startWebsocket() (err error) {
 					log.Debug("BlockSub: sub block", "number", header.Number.Uint64(), "hash", header.Hash().Hex())
 				}
 				s.latestWsHeader = header
-				s.internalHeaderC <- header
+				select {
+				case s.internalHeaderC <- header:
+				case <-s.ctx.Done():
+					return
+				}
 			}
 		}
-	}()
+	})
 
 	log.Info("BlockSub:_startWebsocket - connected", "uri", s.ethNodeWebsocketURI)
 	return nil
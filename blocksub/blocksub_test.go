@@ -0,0 +1,265 @@
+package blocksub
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+type testEthService struct {
+	head    atomic.Uint64
+	chainID uint64
+}
+
+func (s *testEthService) ChainId() hexutil.Uint64 { //nolint:revive,stylecheck
+	return hexutil.Uint64(s.chainID)
+}
+
+// NewHeads serves eth_subscribe("newHeads"), the current head is sent right away
+func (s *testEthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	// buffered until the subscription is returned to the client
+	if err := notifier.Notify(sub.ID, testHeader(s.head.Load())); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *testEthService) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (*ethtypes.Header, error) {
+	head := s.head.Load()
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(head)
+	}
+	if uint64(number) > head {
+		return nil, nil
+	}
+	return testHeader(uint64(number)), nil
+}
+
+func newTestRPCClient(t *testing.T, head uint64) *rpc.Client {
+	t.Helper()
+	_, client := newTestService(t, head)
+	return client
+}
+
+// newTestService returns an in-process node of chain 1 whose head can be moved by the test
+func newTestService(t *testing.T, head uint64) (*testEthService, *rpc.Client) {
+	t.Helper()
+	service, server := newTestServer(t, head, 1)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	return service, client
+}
+
+func newTestServer(t *testing.T, head, chainID uint64) (*testEthService, *rpc.Server) {
+	t.Helper()
+	service := &testEthService{chainID: chainID}
+	service.head.Store(head)
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	require.NoError(t, server.RegisterName("eth", service))
+	return service, server
+}
+
+// newTestNode serves a node of the chain over HTTP, and returns its HTTP and websocket URIs
+func newTestNode(t *testing.T, head, chainID uint64) (httpURI, wsURI string) {
+	t.Helper()
+	_, server := newTestServer(t, head, chainID)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	wsServer := httptest.NewServer(server.WebsocketHandler(nil))
+	t.Cleanup(wsServer.Close)
+	return httpServer.URL, "ws" + strings.TrimPrefix(wsServer.URL, "http")
+}
+
+func testHeader(number uint64) *ethtypes.Header {
+	return &ethtypes.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Difficulty: big.NewInt(0),
+	}
+}
+
+func TestBlockSubRestart(t *testing.T) {
+	blockSub := NewBlockSubFromClients(context.Background(), newTestRPCClient(t, 100), nil)
+	blockSub.PollTimeout = 10 * time.Millisecond
+
+	require.NoError(t, blockSub.Start())
+	require.ErrorIs(t, blockSub.Start(), ErrAlreadyStarted)
+	require.Equal(t, uint64(1), blockSub.ChainID().Uint64())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	header, err := blockSub.WaitForBlock(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), header.Number.Uint64())
+
+	sub := blockSub.Subscribe(context.Background())
+	blockSub.Stop()
+	blockSub.Wait()
+	require.False(t, blockSub.IsRunning())
+	_, ok := <-sub.C
+	require.False(t, ok)
+
+	require.NoError(t, blockSub.Start())
+	require.True(t, blockSub.IsRunning())
+	blockSub.Stop()
+	blockSub.Wait()
+}
+
+func TestBlockSubStartAfterContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	blockSub := NewBlockSubFromClients(ctx, newTestRPCClient(t, 100), nil)
+	require.NoError(t, blockSub.Start())
+
+	cancel()
+	blockSub.Wait()
+	require.ErrorIs(t, blockSub.Start(), ErrStopped)
+}
+
+func TestWaitForBlock(t *testing.T) {
+	blockSub := NewBlockSub(context.Background(), "", "")
+	go blockSub.runListener()
+	pushHeader := func(number uint64) {
+		blockSub.internalHeaderC <- &ethtypes.Header{Number: new(big.Int).SetUint64(number)}
+	}
+	pushHeader(20)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// already received
+	header, err := blockSub.WaitForBlock(ctx, 15)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), header.Number.Uint64())
+
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		pushHeader(21)
+		pushHeader(22)
+	}()
+	header, err = blockSub.WaitForBlock(ctx, 22)
+	require.NoError(t, err)
+	require.Equal(t, uint64(22), header.Number.Uint64())
+	<-pushed
+
+	// new headers are received until the next one is returned
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		for number := uint64(23); ; number++ {
+			select {
+			case <-done:
+				return
+			case blockSub.internalHeaderC <- &ethtypes.Header{Number: new(big.Int).SetUint64(number)}:
+			}
+		}
+	}()
+	header, err = blockSub.WaitForNextBlock(ctx)
+	close(done)
+	<-exited
+	require.NoError(t, err)
+	require.Greater(t, header.Number.Uint64(), uint64(22))
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer timeoutCancel()
+	_, err = blockSub.WaitForBlock(timeoutCtx, 1_000_000)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go blockSub.Stop()
+	_, err = blockSub.WaitForBlock(ctx, 1_000_000)
+	require.ErrorIs(t, err, ErrStopped)
+	_, err = blockSub.WaitForBlock(ctx, 1_000_000)
+	require.ErrorIs(t, err, ErrStopped)
+}
+
+func TestBlockSubChainIDMismatch(t *testing.T) {
+	_, mainnet := newTestNode(t, 100, 1)
+	sepolia, _ := newTestNode(t, 100, 11155111)
+
+	// the websocket endpoint is checked first
+	blockSub := NewBlockSub(context.Background(), sepolia, mainnet)
+	defer blockSub.Stop()
+	require.ErrorIs(t, blockSub.Start(), ErrChainIDMismatch)
+	require.Equal(t, uint64(1), blockSub.ChainID().Uint64())
+}
+
+func TestBlockSubChainIDConcurrent(t *testing.T) {
+	uri, _ := newTestNode(t, 100, 1)
+	blockSub := NewBlockSub(context.Background(), uri, "")
+	client, err := ethclient.Dial(uri)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// the websocket reconnects check the chain ID while it is read
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, blockSub.checkChainID(client, uri))
+			_ = blockSub.ChainID()
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint64(1), blockSub.ChainID().Uint64())
+}
+
+func TestBlockSubFromClients(t *testing.T) {
+	// the headers are received over the websocket subscription only
+	blockSub := NewBlockSubFromClients(context.Background(), nil, newTestRPCClient(t, 100))
+	require.NoError(t, blockSub.Start())
+	defer blockSub.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	header, err := blockSub.WaitForBlock(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), header.Number.Uint64())
+}
+
+// roundTripperFunc serves the requests of an http.Client with a handler, without listening
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestBlockSubClientOptions(t *testing.T) {
+	_, server := newTestServer(t, 100, 1)
+	headers := make(chan string, 100)
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		headers <- r.Header.Get("X-Test")
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, r)
+		return rr.Result(), nil
+	})}
+
+	blockSub := NewBlockSub(context.Background(), "http://node.invalid", "")
+	blockSub.ClientOptions = []rpc.ClientOption{rpc.WithHTTPClient(httpClient), rpc.WithHeader("X-Test", "value")}
+	require.NoError(t, blockSub.Start())
+	defer blockSub.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	header, err := blockSub.WaitForBlock(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), header.Number.Uint64())
+	require.Equal(t, "value", <-headers)
+}
@@ -0,0 +1,50 @@
+package blocksub
+
+import (
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// DiscontinuityKind describes how a new header relates to the previously delivered one.
+type DiscontinuityKind string
+
+const (
+	// DiscontinuityGap means that one or more blocks between the previous and the new header were never received.
+	DiscontinuityGap DiscontinuityKind = "gap"
+	// DiscontinuityReorg means that the new header does not build on top of the previous one (either the
+	// ParentHash doesn't match, or the block number was seen already with a different hash).
+	DiscontinuityReorg DiscontinuityKind = "reorg"
+)
+
+// Discontinuity is reported via BlockSub.OnDiscontinuity when a header is delivered that doesn't directly
+// extend the previously delivered header.
+type Discontinuity struct {
+	Kind     DiscontinuityKind
+	Previous *ethtypes.Header // previously delivered header
+	Header   *ethtypes.Header // newly delivered header
+}
+
+// checkContinuity returns nil if header is the direct child of prev (or prev is nil), and the discontinuity otherwise.
+func checkContinuity(prev, header *ethtypes.Header) *Discontinuity {
+	if prev == nil {
+		return nil
+	}
+
+	prevNum := prev.Number.Uint64()
+	num := header.Number.Uint64()
+
+	var kind DiscontinuityKind
+	switch {
+	case num == prevNum+1 && header.ParentHash == prev.Hash():
+		return nil
+	case num > prevNum+1:
+		kind = DiscontinuityGap
+	default:
+		kind = DiscontinuityReorg
+	}
+
+	return &Discontinuity{
+		Kind:     kind,
+		Previous: prev,
+		Header:   header,
+	}
+}
@@ -0,0 +1,35 @@
+package blocksub
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContinuity(t *testing.T) {
+	parent := &ethtypes.Header{Number: big.NewInt(100)}
+	child := &ethtypes.Header{Number: big.NewInt(101), ParentHash: parent.Hash()}
+	sibling := &ethtypes.Header{Number: big.NewInt(100), Extra: []byte("sibling")}
+	orphan := &ethtypes.Header{Number: big.NewInt(101), ParentHash: common.HexToHash("0x01")}
+	distant := &ethtypes.Header{Number: big.NewInt(105)}
+
+	require.Nil(t, checkContinuity(nil, parent))
+	require.Nil(t, checkContinuity(parent, child))
+
+	d := checkContinuity(parent, sibling)
+	require.NotNil(t, d)
+	require.Equal(t, DiscontinuityReorg, d.Kind)
+
+	d = checkContinuity(parent, orphan)
+	require.NotNil(t, d)
+	require.Equal(t, DiscontinuityReorg, d.Kind)
+	require.Equal(t, parent, d.Previous)
+	require.Equal(t, orphan, d.Header)
+
+	d = checkContinuity(parent, distant)
+	require.NotNil(t, d)
+	require.Equal(t, DiscontinuityGap, d.Kind)
+}
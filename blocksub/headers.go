@@ -0,0 +1,92 @@
+package blocksub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	HeadersBatchSize   = 100 // max number of headers requested in a single batch request
+	HeadersConcurrency = 4   // max number of batch requests in flight
+)
+
+// GetHeaders fetches the headers of blocks from..to (inclusive) in order, using batched eth_getBlockByNumber
+// requests with bounded concurrency (see HeadersBatchSize and HeadersConcurrency).
+func GetHeaders(ctx context.Context, client *rpc.Client, from, to uint64) ([]*ethtypes.Header, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+
+	headers := make([]*ethtypes.Header, to-from+1)
+	batchSize := uint64(HeadersBatchSize)
+	if batchSize == 0 {
+		batchSize = 1
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	if HeadersConcurrency > 0 {
+		g.SetLimit(HeadersConcurrency)
+	}
+
+	for start := from; start <= to; start += batchSize {
+		end := start + batchSize - 1
+		if end > to || end < start { // end < start on overflow
+			end = to
+		}
+
+		batchStart, batchEnd := start, end
+		g.Go(func() error {
+			return fetchHeadersBatch(ctx, client, batchStart, headers[batchStart-from:batchEnd-from+1])
+		})
+
+		if end == to {
+			break
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// fetchHeadersBatch fetches len(out) consecutive headers starting at block number start into out.
+func fetchHeadersBatch(ctx context.Context, client *rpc.Client, start uint64, out []*ethtypes.Header) error {
+	batch := make([]rpc.BatchElem, len(out))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(start + uint64(i)), false},
+			Result: &out[i],
+		}
+	}
+
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return fmt.Errorf("header %d: %w", start+uint64(i), elem.Error)
+		}
+		if out[i] == nil {
+			return fmt.Errorf("header %d: %w", start+uint64(i), ethereum.NotFound)
+		}
+	}
+	return nil
+}
+
+// GetHeaders fetches the headers of blocks from..to (inclusive) from the connected node, see GetHeaders.
+func (s *BlockSub) GetHeaders(ctx context.Context, from, to uint64) ([]*ethtypes.Header, error) {
+	client := s.queryClient()
+	if client == nil {
+		return nil, ErrNotConnected
+	}
+	return GetHeaders(ctx, client.Client(), from, to)
+}
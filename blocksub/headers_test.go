@@ -0,0 +1,31 @@
+package blocksub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHeaders(t *testing.T) {
+	client := newTestRPCClient(t, 1000)
+
+	oldBatchSize := HeadersBatchSize
+	defer func() { HeadersBatchSize = oldBatchSize }()
+	HeadersBatchSize = 7
+
+	headers, err := GetHeaders(context.Background(), client, 10, 110)
+	require.NoError(t, err)
+	require.Len(t, headers, 101)
+	for i, header := range headers {
+		require.Equal(t, uint64(10+i), header.Number.Uint64())
+	}
+
+	_, err = GetHeaders(context.Background(), client, 990, 1010)
+	require.True(t, errors.Is(err, ethereum.NotFound))
+
+	_, err = GetHeaders(context.Background(), client, 5, 4)
+	require.Error(t, err)
+}
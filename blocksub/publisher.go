@@ -0,0 +1,245 @@
+package blocksub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	"go.uber.org/atomic"
+)
+
+const (
+	// incremented for every published event
+	publishedCounter = `goutils_blocksub_published_total{publisher="%s"}`
+	// incremented for every event that was not delivered to a subscriber because it was not ready to receive it
+	droppedCounter = `goutils_blocksub_dropped_total{publisher="%s"}`
+	// number of active subscriptions
+	subscribersGauge = `goutils_blocksub_subscribers{publisher="%s"}`
+)
+
+// BufferPolicy defines what happens when an event is published while a subscriber is not ready to receive it.
+type BufferPolicy int
+
+const (
+	// DropNewest drops the new event if the subscriber's buffer is full (or it's not waiting on an unbuffered channel).
+	DropNewest BufferPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for the new one. With a buffer size of 0 it behaves
+	// like DropNewest.
+	DropOldest
+	// Block waits until the subscriber receives the event or unsubscribes. A slow subscriber delays all others.
+	Block
+)
+
+// PublisherOpts configures a Publisher.
+type PublisherOpts struct {
+	// Name is used as the "publisher" label of the metrics
+	Name string
+	// BufferSize is the capacity of the subscription channels, 0 means unbuffered
+	BufferSize int
+	// Policy is applied when a subscriber is not ready to receive an event
+	Policy BufferPolicy
+}
+
+// Publisher fans out events of type T to any number of subscriptions. It is safe for concurrent use.
+type Publisher[T any] struct {
+	opts PublisherOpts
+
+	mu     sync.Mutex
+	subs   []*EventSubscription[T]
+	closed bool
+}
+
+func NewPublisher[T any](opts PublisherOpts) *Publisher[T] {
+	return &Publisher[T]{opts: opts}
+}
+
+// Subscribe creates a new subscription that receives all events published from now on.
+func (p *Publisher[T]) Subscribe(ctx context.Context) EventSubscription[T] {
+	return p.SubscribeWithFilter(ctx, nil)
+}
+
+// SubscribeWithFilter creates a new subscription that only receives events for which filter returns true.
+// The filter is called from the publishing goroutine and must not block. If the publisher is closed, the returned
+// subscription is already stopped.
+func (p *Publisher[T]) SubscribeWithFilter(ctx context.Context, filter func(T) bool) EventSubscription[T] {
+	sub := NewEventSubscription[T](ctx, p.opts.BufferSize)
+	sub.filter = filter
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		sub.Unsubscribe()
+		return sub
+	}
+
+	go sub.run()
+	p.subs = append(p.pruneLocked(), &sub)
+	p.updateSubscribersGaugeLocked()
+	return sub
+}
+
+// Publish delivers the event to all subscriptions according to the buffer policy.
+func (p *Publisher[T]) Publish(ev T) {
+	p.mu.Lock()
+	p.subs = p.pruneLocked()
+	p.updateSubscribersGaugeLocked()
+	subs := make([]*EventSubscription[T], len(p.subs))
+	copy(subs, p.subs)
+	p.mu.Unlock()
+
+	metrics.GetOrCreateCounter(fmt.Sprintf(publishedCounter, p.opts.Name)).Inc()
+	for _, sub := range subs {
+		if !sub.send(ev, p.opts.Policy) {
+			metrics.GetOrCreateCounter(fmt.Sprintf(droppedCounter, p.opts.Name)).Inc()
+		}
+	}
+}
+
+// NumSubscribers returns the number of active subscriptions.
+func (p *Publisher[T]) NumSubscribers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subs = p.pruneLocked()
+	return len(p.subs)
+}
+
+// UnsubscribeAll stops all current subscriptions. New subscriptions can still be created afterwards.
+func (p *Publisher[T]) UnsubscribeAll() {
+	p.mu.Lock()
+	subs := p.subs
+	p.subs = nil
+	p.updateSubscribersGaugeLocked()
+	p.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+}
+
+// Close stops all subscriptions, subscriptions created afterwards are stopped immediately.
+func (p *Publisher[T]) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.UnsubscribeAll()
+}
+
+// pruneLocked returns the subscriptions without the stopped ones, p.mu must be held.
+func (p *Publisher[T]) pruneLocked() []*EventSubscription[T] {
+	active := p.subs[:0]
+	for _, sub := range p.subs {
+		if !sub.stopped.Load() {
+			active = append(active, sub)
+		}
+	}
+	for i := len(active); i < len(p.subs); i++ {
+		p.subs[i] = nil // allow stopped subscriptions to be garbage collected
+	}
+	return active
+}
+
+func (p *Publisher[T]) updateSubscribersGaugeLocked() {
+	metrics.GetOrCreateGauge(fmt.Sprintf(subscribersGauge, p.opts.Name), nil).Set(float64(len(p.subs)))
+}
+
+// EventSubscription will push new events to a subscriber until the context is done or Unsubscribe() is called,
+// at which point the subscription is stopped and the event channel closed.
+type EventSubscription[T any] struct {
+	C chan T // Channel to receive the events on.
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	filter func(T) bool // optional, events for which it returns false are not delivered
+
+	// shared between copies of the subscription, guards against sending on a closed channel
+	mu      *sync.Mutex
+	stopped *atomic.Bool
+}
+
+// NewEventSubscription creates a subscription with the given channel buffer size that is not attached to a
+// publisher.
+func NewEventSubscription[T any](ctx context.Context, bufferSize int) EventSubscription[T] {
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	return EventSubscription[T]{
+		C:       make(chan T, bufferSize),
+		ctx:     ctxWithCancel,
+		cancel:  cancel,
+		mu:      new(sync.Mutex),
+		stopped: atomic.NewBool(false),
+	}
+}
+
+func (sub *EventSubscription[T]) run() {
+	<-sub.ctx.Done()
+	sub.Unsubscribe()
+}
+
+// send delivers the event to the subscriber according to the buffer policy.
+// Returns false if the event was not delivered.
+func (sub *EventSubscription[T]) send(ev T, policy BufferPolicy) bool {
+	if sub.filter != nil && !sub.filter(ev) {
+		return true // filtered out on purpose, not a delivery failure
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.stopped.Load() {
+		return false
+	}
+
+	switch policy {
+	case Block:
+		select {
+		case sub.C <- ev:
+			return true
+		case <-sub.ctx.Done():
+			return false
+		}
+
+	case DropOldest:
+		select {
+		case sub.C <- ev:
+			return true
+		default:
+		}
+		// make room by discarding the oldest event (only possible for buffered channels)
+		select {
+		case <-sub.C:
+		default:
+		}
+		select {
+		case sub.C <- ev:
+		default:
+		}
+		return false // an event was dropped either way
+
+	default:
+		select {
+		case sub.C <- ev:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Unsubscribe unsubscribes the notification and closes the event channel.
+// It can safely be called more than once.
+func (sub *EventSubscription[T]) Unsubscribe() {
+	sub.cancel() // unblocks a pending send
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.stopped.Swap(true) {
+		return
+	}
+	close(sub.C)
+}
+
+func (sub *EventSubscription[T]) Done() <-chan struct{} {
+	return sub.ctx.Done()
+}
@@ -0,0 +1,75 @@
+package blocksub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisherBufferPolicies(t *testing.T) {
+	t.Run("drop newest", func(t *testing.T) {
+		p := NewPublisher[int](PublisherOpts{Name: "test", BufferSize: 2, Policy: DropNewest})
+		sub := p.Subscribe(context.Background())
+		for i := 1; i <= 4; i++ {
+			p.Publish(i)
+		}
+		require.Equal(t, 1, <-sub.C)
+		require.Equal(t, 2, <-sub.C)
+		require.Empty(t, sub.C)
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		p := NewPublisher[int](PublisherOpts{Name: "test", BufferSize: 2, Policy: DropOldest})
+		sub := p.Subscribe(context.Background())
+		for i := 1; i <= 4; i++ {
+			p.Publish(i)
+		}
+		require.Equal(t, 3, <-sub.C)
+		require.Equal(t, 4, <-sub.C)
+		require.Empty(t, sub.C)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		p := NewPublisher[int](PublisherOpts{Name: "test", Policy: Block})
+		sub := p.Subscribe(context.Background())
+		go func() {
+			for i := 1; i <= 3; i++ {
+				p.Publish(i)
+			}
+			p.Close()
+		}()
+
+		var received []int
+		for ev := range sub.C {
+			received = append(received, ev)
+		}
+		require.Equal(t, []int{1, 2, 3}, received)
+	})
+}
+
+func TestPublisherSubscriptionLifecycle(t *testing.T) {
+	p := NewPublisher[int](PublisherOpts{Name: "test"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := p.Subscribe(ctx)
+	filtered := p.SubscribeWithFilter(context.Background(), func(ev int) bool { return ev%2 == 0 })
+	require.Equal(t, 2, p.NumSubscribers())
+
+	cancel()
+	<-sub.Done()
+	require.Eventually(t, func() bool { return p.NumSubscribers() == 1 }, time.Second, 10*time.Millisecond)
+	_, ok := <-sub.C
+	require.False(t, ok)
+
+	sub.Unsubscribe() // calling it again is safe
+
+	p.Close()
+	_, ok = <-filtered.C
+	require.False(t, ok)
+
+	closed := p.Subscribe(context.Background())
+	_, ok = <-closed.C
+	require.False(t, ok)
+}
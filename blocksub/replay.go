@@ -0,0 +1,148 @@
+package blocksub
+
+import (
+	"context"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/flashbots/go-utils/retry"
+)
+
+// replayRetryInterval is the delay before retrying a failed historical header fetch
+var replayRetryInterval = time.Second
+
+// SubscribeFrom creates a subscription that first delivers all headers starting at startBlock up to the
+// current head (fetched in order from the node), and then continues with live headers. Missing block
+// numbers between live headers are backfilled, so the subscriber sees every block number in sequence
+// (plus the reorgs of the latest delivered header as they arrive). If startBlock is above the current head, the
+// live headers below it are skipped.
+//
+// Unlike Subscribe, headers are never dropped when the subscriber is slow.
+func (s *BlockSub) SubscribeFrom(ctx context.Context, startBlock uint64) Subscription {
+	sub := NewSubscription(ctx)
+	if s.stopped.Load() {
+		sub.Unsubscribe()
+	} else {
+		s.goRun(func() { s.runReplay(sub, startBlock) })
+	}
+	return sub
+}
+
+func (s *BlockSub) runReplay(sub Subscription, next uint64) {
+	defer sub.Unsubscribe()
+
+	// a blocked send to a slow subscriber must not prevent the BlockSub from stopping, Unsubscribe unblocks it
+	done := make(chan struct{})
+	defer close(done)
+	go func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+			sub.Unsubscribe()
+		case <-done:
+		}
+	}(s.ctx)
+
+	// subscribe before catching up, so no live header is missed in between
+	live := s.Subscribe(sub.ctx)
+
+	// catchUp streams historical headers up to the current head. The live headers received in the meantime are
+	// dropped (the subscriber is not waiting on the channel), so the head is checked again until the replay has
+	// caught up.
+	var last *ethtypes.Header
+	catchUp := func() bool {
+		for {
+			header := s.LatestHeader()
+			if header == nil || next > header.Number.Uint64() {
+				return true
+			}
+			head := header.Number.Uint64()
+			if !s.replayRange(sub, next, head) {
+				return false
+			}
+			last = header
+			next = head + 1
+		}
+	}
+
+	if !catchUp() {
+		return
+	}
+	for header := range live.C {
+		number := header.Number.Uint64()
+		if number < next {
+			// below startBlock or already delivered, except for a reorg of the last delivered header
+			if last == nil || number != last.Number.Uint64() || header.Hash() == last.Hash() {
+				continue
+			}
+		}
+		if next < number && !s.replayRange(sub, next, number-1) {
+			return
+		}
+		if !sub.send(header, Block) {
+			return
+		}
+		last = header
+		next = number + 1
+		if !catchUp() {
+			return
+		}
+	}
+}
+
+// replayRange fetches the headers from..to (retrying until it succeeds) and sends them to the subscriber in order.
+// Returns false if the subscription or the BlockSub was stopped.
+func (s *BlockSub) replayRange(sub Subscription, from, to uint64) bool {
+	chunkSize := uint64(HeadersBatchSize * HeadersConcurrency)
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+
+	for from <= to {
+		if s.stopped.Load() {
+			return false
+		}
+
+		chunkEnd := from + chunkSize - 1
+		if chunkEnd > to || chunkEnd < from {
+			chunkEnd = to
+		}
+
+		headers, err := retry.DoValue(sub.ctx, func(ctx context.Context) ([]*ethtypes.Header, error) {
+			if s.stopped.Load() {
+				return nil, retry.Permanent(ErrStopped)
+			}
+			return s.GetHeaders(ctx, from, chunkEnd)
+		},
+			retry.WithMaxAttempts(0),
+			retry.WithBackoff(replayRetryInterval, replayRetryInterval),
+			retry.WithJitter(0),
+			retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+				log.Error("BlockSub: fetching historical headers failed", "from", from, "to", chunkEnd, "err", err)
+			}),
+		)
+		if err != nil {
+			return false
+		}
+
+		for _, header := range headers {
+			if !sub.send(header, Block) {
+				return false
+			}
+		}
+		if chunkEnd == to {
+			break
+		}
+		from = chunkEnd + 1
+	}
+	return true
+}
+
+// queryClient returns the client to use for regular requests, preferring HTTP over websocket.
+func (s *BlockSub) queryClient() *ethclient.Client {
+	if s.httpClient != nil {
+		return s.httpClient
+	}
+	return s.wsClient
+}
@@ -0,0 +1,116 @@
+package blocksub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReplayBlockSub returns a started BlockSub at the head of the node, new heads are only received through
+// pushHeader
+func newTestReplayBlockSub(t *testing.T, head uint64) (*BlockSub, func(number uint64)) {
+	t.Helper()
+	service, client := newTestService(t, head)
+	blockSub := NewBlockSubFromClients(context.Background(), client, nil)
+	blockSub.PollTimeout = time.Hour
+	require.NoError(t, blockSub.Start())
+	t.Cleanup(func() {
+		blockSub.Stop()
+		blockSub.Wait()
+	})
+
+	pushHeader := func(number uint64) {
+		service.head.Store(number)
+		blockSub.internalHeaderC <- testHeader(number)
+		require.Eventually(t, func() bool {
+			return blockSub.LatestHeader().Number.Uint64() >= number
+		}, time.Second, time.Millisecond)
+	}
+	return blockSub, pushHeader
+}
+
+func receiveHeaders(t *testing.T, sub Subscription, from, to uint64) {
+	t.Helper()
+	for number := from; number <= to; number++ {
+		select {
+		case header, ok := <-sub.C:
+			require.True(t, ok)
+			require.Equal(t, number, header.Number.Uint64())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for header %d", number)
+		}
+	}
+}
+
+func TestSubscribeFrom(t *testing.T) {
+	oldBatchSize := HeadersBatchSize
+	defer func() { HeadersBatchSize = oldBatchSize }()
+	HeadersBatchSize = 3
+
+	blockSub, pushHeader := newTestReplayBlockSub(t, 20)
+	sub := blockSub.SubscribeFrom(context.Background(), 5)
+	defer sub.Unsubscribe()
+
+	// the replay is in order
+	receiveHeaders(t, sub, 5, 10)
+
+	// the live headers received while the subscriber is slow are replayed
+	for number := uint64(21); number <= 25; number++ {
+		pushHeader(number)
+	}
+	receiveHeaders(t, sub, 11, 25)
+
+	// handoff to the live headers, the missing ones are backfilled
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		pushHeader(26)
+		pushHeader(29)
+	}()
+	receiveHeaders(t, sub, 26, 29)
+	<-pushed
+}
+
+func TestSubscribeFromAboveHead(t *testing.T) {
+	blockSub, pushHeader := newTestReplayBlockSub(t, 20)
+	sub := blockSub.SubscribeFrom(context.Background(), 25)
+	defer sub.Unsubscribe()
+
+	// the headers below startBlock are skipped
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		for number := uint64(21); number <= 26; number++ {
+			pushHeader(number)
+		}
+	}()
+	receiveHeaders(t, sub, 25, 26)
+	<-pushed
+}
+
+func TestSubscribeFromStop(t *testing.T) {
+	blockSub, _ := newTestReplayBlockSub(t, 20)
+	sub := blockSub.SubscribeFrom(context.Background(), 5)
+	receiveHeaders(t, sub, 5, 5)
+
+	// the replay is blocked on the slow subscriber
+	blockSub.Stop()
+	done := make(chan struct{})
+	go func() {
+		blockSub.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait blocked by the replay")
+	}
+
+	for range sub.C {
+	}
+
+	// the BlockSub can be restarted
+	require.NoError(t, blockSub.Start())
+}
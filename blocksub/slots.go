@@ -0,0 +1,126 @@
+package blocksub
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+var ErrInvalidSlotDuration = errors.New("slot duration must be positive")
+
+// MainnetSlotClock is the slot clock of Ethereum mainnet.
+var MainnetSlotClock = SlotClock{
+	GenesisTime:  time.Unix(1606824023, 0),
+	SlotDuration: 12 * time.Second,
+}
+
+// SlotClock converts between wall clock time and beacon chain slots.
+type SlotClock struct {
+	GenesisTime  time.Time
+	SlotDuration time.Duration
+}
+
+// SlotAt returns the slot at the given time (0 for times before genesis).
+func (c SlotClock) SlotAt(t time.Time) uint64 {
+	if t.Before(c.GenesisTime) || c.SlotDuration <= 0 {
+		return 0
+	}
+	return uint64(t.Sub(c.GenesisTime) / c.SlotDuration)
+}
+
+// SlotStart returns the time at which the given slot starts.
+func (c SlotClock) SlotStart(slot uint64) time.Time {
+	return c.GenesisTime.Add(time.Duration(slot) * c.SlotDuration)
+}
+
+// HeaderSlot returns the slot of an execution block, derived from its timestamp.
+func (c SlotClock) HeaderSlot(header *ethtypes.Header) uint64 {
+	return c.SlotAt(time.Unix(int64(header.Time), 0))
+}
+
+// SlotHeader is a header annotated with its slot timing.
+type SlotHeader struct {
+	Header *ethtypes.Header
+	Slot   uint64 // slot in which the block was proposed
+	// NextSlot is the slot of the next proposal, building on top of Header
+	NextSlot uint64
+	// ProposalDeadline is the start of NextSlot, when the next proposer is expected to propose
+	ProposalDeadline time.Time
+}
+
+// Annotate returns the header with its slot and the deadline of the next proposal.
+func (c SlotClock) Annotate(header *ethtypes.Header) SlotHeader {
+	slot := c.HeaderSlot(header)
+	return SlotHeader{
+		Header:           header,
+		Slot:             slot,
+		NextSlot:         slot + 1,
+		ProposalDeadline: c.SlotStart(slot + 1),
+	}
+}
+
+// SlotTick is emitted by a SlotTicker at the start of each slot.
+type SlotTick struct {
+	Slot  uint64
+	Start time.Time
+}
+
+// SlotTicker emits a SlotTick on C at every slot boundary until the context is done or Stop is called.
+// Like time.Ticker, ticks are dropped if the receiver is too slow.
+type SlotTicker struct {
+	C <-chan SlotTick
+
+	clock  SlotClock
+	cancel context.CancelFunc
+}
+
+// NewSlotTicker starts a ticker for the clock, returning ErrInvalidSlotDuration if its SlotDuration is not positive.
+func NewSlotTicker(ctx context.Context, clock SlotClock) (*SlotTicker, error) {
+	if clock.SlotDuration <= 0 {
+		return nil, ErrInvalidSlotDuration
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan SlotTick, 1)
+	t := &SlotTicker{
+		C:      c,
+		clock:  clock,
+		cancel: cancel,
+	}
+	go t.run(ctx, c)
+	return t, nil
+}
+
+func (t *SlotTicker) run(ctx context.Context, c chan<- SlotTick) {
+	for {
+		next := t.clock.SlotAt(time.Now()) + 1
+		if time.Now().Before(t.clock.GenesisTime) {
+			next = 0
+		}
+		start := t.clock.SlotStart(next)
+
+		timer := time.NewTimer(time.Until(start))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		select {
+		case c <- SlotTick{Slot: next, Start: start}:
+		default:
+		}
+	}
+}
+
+// Annotate returns the header with its slot timing, see SlotClock.Annotate.
+func (t *SlotTicker) Annotate(header *ethtypes.Header) SlotHeader {
+	return t.clock.Annotate(header)
+}
+
+// Stop stops the ticker. No more ticks will be sent after Stop returns, but C is not closed.
+func (t *SlotTicker) Stop() {
+	t.cancel()
+}
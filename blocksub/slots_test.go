@@ -0,0 +1,45 @@
+package blocksub
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotClock(t *testing.T) {
+	clock := MainnetSlotClock
+
+	require.Equal(t, uint64(0), clock.SlotAt(clock.GenesisTime.Add(-time.Hour)))
+	require.Equal(t, uint64(0), clock.SlotAt(clock.GenesisTime))
+	require.Equal(t, uint64(1), clock.SlotAt(clock.GenesisTime.Add(12*time.Second)))
+	require.Equal(t, clock.GenesisTime.Add(24*time.Second), clock.SlotStart(2))
+
+	header := &ethtypes.Header{Number: big.NewInt(20000000), Time: 1717281407}
+	annotated := clock.Annotate(header)
+	require.Equal(t, uint64(9204782), annotated.Slot)
+	require.Equal(t, uint64(9204783), annotated.NextSlot)
+	require.Equal(t, time.Unix(1717281419, 0), annotated.ProposalDeadline)
+}
+
+func TestSlotTicker(t *testing.T) {
+	clock := SlotClock{
+		GenesisTime:  time.Now(),
+		SlotDuration: 20 * time.Millisecond,
+	}
+	ticker, err := NewSlotTicker(context.Background(), clock)
+	require.NoError(t, err)
+	defer ticker.Stop()
+
+	first := <-ticker.C
+	second := <-ticker.C
+	require.Greater(t, second.Slot, first.Slot)
+	require.False(t, time.Now().Before(second.Start))
+	require.Equal(t, clock.SlotStart(second.Slot), second.Start)
+
+	_, err = NewSlotTicker(context.Background(), SlotClock{GenesisTime: time.Now()})
+	require.ErrorIs(t, err, ErrInvalidSlotDuration)
+}
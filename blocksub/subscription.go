@@ -2,46 +2,40 @@ package blocksub
 
 import (
 	"context"
+	"math/big"
 
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
-	"go.uber.org/atomic"
 )
 
 // Subscription will push new headers to a subscriber until the context is done or Unsubscribe() is called,
 // at which point the subscription is stopped and the header channel closed.
-type Subscription struct {
-	C chan *ethtypes.Header // Channel to receive the headers on.
-
-	ctx    context.Context
-	cancel context.CancelFunc
-
-	stopped atomic.Bool
-}
+type Subscription = EventSubscription[*ethtypes.Header]
 
 func NewSubscription(ctx context.Context) Subscription {
-	ctxWithCancel, cancel := context.WithCancel(ctx)
-	return Subscription{
-		C:      make(chan *ethtypes.Header),
-		ctx:    ctxWithCancel,
-		cancel: cancel,
-	}
+	return NewEventSubscription[*ethtypes.Header](ctx, 0)
 }
 
-func (sub *Subscription) run() {
-	<-sub.ctx.Done()
-	sub.Unsubscribe()
+// HeaderFilter decides whether a header is delivered to a subscription.
+type HeaderFilter func(header *ethtypes.Header) bool
+
+// NewSubscriptionWithFilter creates a subscription that only receives headers for which filter returns true.
+func NewSubscriptionWithFilter(ctx context.Context, filter HeaderFilter) Subscription {
+	sub := NewSubscription(ctx)
+	sub.filter = filter
+	return sub
 }
 
-// Unsubscribe unsubscribes the notification and closes the header channel.
-// It can safely be called more than once.
-func (sub *Subscription) Unsubscribe() {
-	if sub.stopped.Swap(true) {
-		return
+// EveryNthBlock returns a filter that only passes headers with block numbers divisible by n.
+func EveryNthBlock(n uint64) HeaderFilter {
+	return func(header *ethtypes.Header) bool {
+		return n == 0 || header.Number.Uint64()%n == 0
 	}
-	sub.cancel()
-	close(sub.C)
 }
 
-func (sub *Subscription) Done() <-chan struct{} {
-	return sub.ctx.Done()
+// MinBaseFee returns a filter that only passes headers with a base fee of at least minBaseFee (in wei).
+// Pre-London headers without a base fee are never passed.
+func MinBaseFee(minBaseFee *big.Int) HeaderFilter {
+	return func(header *ethtypes.Header) bool {
+		return header.BaseFee != nil && header.BaseFee.Cmp(minBaseFee) >= 0
+	}
 }
@@ -0,0 +1,45 @@
+package blocksub
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderFilters(t *testing.T) {
+	every10 := EveryNthBlock(10)
+	require.True(t, every10(&ethtypes.Header{Number: big.NewInt(20)}))
+	require.False(t, every10(&ethtypes.Header{Number: big.NewInt(21)}))
+
+	minBaseFee := MinBaseFee(big.NewInt(100))
+	require.True(t, minBaseFee(&ethtypes.Header{Number: big.NewInt(1), BaseFee: big.NewInt(100)}))
+	require.False(t, minBaseFee(&ethtypes.Header{Number: big.NewInt(1), BaseFee: big.NewInt(99)}))
+	require.False(t, minBaseFee(&ethtypes.Header{Number: big.NewInt(1)}))
+}
+
+func TestSubscriptionFilter(t *testing.T) {
+	sub := NewSubscriptionWithFilter(context.Background(), EveryNthBlock(2))
+	defer sub.Unsubscribe()
+
+	received := make(chan uint64, 10)
+	go func() {
+		for header := range sub.C {
+			received <- header.Number.Uint64()
+		}
+		close(received)
+	}()
+
+	for i := int64(1); i <= 4; i++ {
+		require.True(t, sub.send(&ethtypes.Header{Number: big.NewInt(i)}, Block))
+	}
+	sub.Unsubscribe()
+
+	var numbers []uint64
+	for n := range received {
+		numbers = append(numbers, n)
+	}
+	require.Equal(t, []uint64{2, 4}, numbers)
+}
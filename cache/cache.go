@@ -0,0 +1,214 @@
+// Package cache provides a generic LRU cache with TTL expiry, deduplicated loading and metrics.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// incremented on lookups of present / missing (or expired) keys, and when an entry is evicted to make room
+	hitsCounter      = `goutils_cache_hits_total{cache="%s"}`
+	missesCounter    = `goutils_cache_misses_total{cache="%s"}`
+	evictionsCounter = `goutils_cache_evictions_total{cache="%s"}`
+	// number of entries in the cache
+	entriesGauge = `goutils_cache_entries{cache="%s"}`
+)
+
+var ErrLoadPanicked = errors.New("cache load panicked")
+
+// Config configures a Cache.
+type Config struct {
+	// Name of the cache in the metrics
+	Name string
+	// Size is the maximum number of entries, the least recently used entry being evicted beyond it. Unbounded if 0.
+	Size int
+	// TTL is the default time to live of the entries, they don't expire if 0
+	TTL time.Duration
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time // zero if the entry doesn't expire
+}
+
+// call is an in-flight load of GetOrLoad
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is a LRU cache safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	items   map[K]*list.Element
+	lru     *list.List // front is the most recently used
+	loading map[K]*call[V]
+}
+
+// New creates an empty cache.
+func New[K comparable, V any](cfg Config) *Cache[K, V] {
+	return &Cache[K, V]{
+		cfg:     cfg,
+		now:     time.Now,
+		items:   make(map[K]*list.Element),
+		lru:     list.New(),
+		loading: make(map[K]*call[V]),
+	}
+}
+
+// Get returns the value of the key, if present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	value, ok := c.get(key)
+	c.mu.Unlock()
+
+	if ok {
+		metrics.GetOrCreateCounter(fmt.Sprintf(hitsCounter, c.cfg.Name)).Inc()
+	} else {
+		metrics.GetOrCreateCounter(fmt.Sprintf(missesCounter, c.cfg.Name)).Inc()
+	}
+	return value, ok
+}
+
+// Set stores the value with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.cfg.TTL)
+}
+
+// SetWithTTL stores the value, expiring after the ttl (never if 0).
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+// Delete removes the key.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+		c.updateGauge()
+	}
+}
+
+// Len returns the number of entries, including expired entries not removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge removes all entries.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[K]*list.Element)
+	c.lru.Init()
+	c.updateGauge()
+}
+
+// GetOrLoad returns the value of the key, loading and storing it on a miss. Concurrent loads of the same key are
+// deduplicated: the callers wait for the first one, whose context is passed to load. Errors are not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		metrics.GetOrCreateCounter(fmt.Sprintf(hitsCounter, c.cfg.Name)).Inc()
+		return value, nil
+	}
+	metrics.GetOrCreateCounter(fmt.Sprintf(missesCounter, c.cfg.Name)).Inc()
+
+	if cl, ok := c.loading[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.loading[key] = cl
+	c.mu.Unlock()
+
+	completed := false
+	defer func() {
+		if !completed {
+			// load panicked, the waiting callers fail
+			cl.err = ErrLoadPanicked
+		}
+		c.mu.Lock()
+		delete(c.loading, key)
+		if cl.err == nil {
+			c.set(key, cl.value, c.cfg.TTL)
+		}
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+	cl.value, cl.err = load(ctx)
+	completed = true
+	return cl.value, cl.err
+}
+
+// get returns the value of the key and marks it as recently used, the lock must be held
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[K, V])
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		c.remove(elem)
+		c.updateGauge()
+		return zero, false
+	}
+	c.lru.MoveToFront(elem)
+	return e.value, true
+}
+
+// set stores the value, evicting the least recently used entry if the cache is full, the lock must be held
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.cfg.Size > 0 && c.lru.Len() > c.cfg.Size {
+		c.remove(c.lru.Back())
+		metrics.GetOrCreateCounter(fmt.Sprintf(evictionsCounter, c.cfg.Name)).Inc()
+	}
+	c.updateGauge()
+}
+
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
+
+func (c *Cache[K, V]) updateGauge() {
+	metrics.GetOrCreateGauge(fmt.Sprintf(entriesGauge, c.cfg.Name), nil).Set(float64(c.lru.Len()))
+}
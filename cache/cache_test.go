@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheLRU(t *testing.T) {
+	c := New[string, int](Config{Name: "test_lru", Size: 2})
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	value, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	// evicts b, the least recently used
+	c.Set("c", 3)
+	require.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("a")
+	require.True(t, ok)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	require.False(t, ok)
+
+	c.Purge()
+	require.Equal(t, 0, c.Len())
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](Config{Name: "test_ttl", TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 0)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	require.False(t, ok)
+	_, ok = c.Get("b")
+	require.True(t, ok)
+	require.Equal(t, 1, c.Len())
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+	c := New[string, int](Config{Name: "test_load"})
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.GetOrLoad(context.Background(), "a", load)
+			require.NoError(t, err)
+			require.Equal(t, 42, value)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), loads.Load())
+
+	value, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 42, value)
+
+	// errors are not cached
+	errTest := errors.New("test")
+	_, err := c.GetOrLoad(context.Background(), "b", func(ctx context.Context) (int, error) { return 0, errTest })
+	require.ErrorIs(t, err, errTest)
+	_, ok = c.Get("b")
+	require.False(t, ok)
+}
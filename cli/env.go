@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/truthy"
+)
+
+var ErrInvalidEnv = errors.New("invalid environment variable")
+
+// GetEnvDuration returns the value of the environment variable named by key parsed by time.ParseDuration, or
+// defaultValue if the environment variable doesn't exist or is not a valid duration
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	return orDefault(ParseEnvDuration(key, defaultValue))
+}
+
+// GetEnvBool returns the truthy-ness of the environment variable named by key (see truthy.Is), or defaultValue if the
+// environment variable doesn't exist or is not a valid boolean
+func GetEnvBool(key string, defaultValue bool) bool {
+	return orDefault(ParseEnvBool(key, defaultValue))
+}
+
+// GetEnvBigInt returns the value of the environment variable named by key as decimal or 0x prefixed hex integer, or
+// defaultValue if the environment variable doesn't exist or is not a valid integer
+func GetEnvBigInt(key string, defaultValue *big.Int) *big.Int {
+	return orDefault(ParseEnvBigInt(key, defaultValue))
+}
+
+// GetEnvAddress returns the value of the environment variable named by key as Ethereum address, or defaultValue if the
+// environment variable doesn't exist or is not a valid address
+func GetEnvAddress(key string, defaultValue common.Address) common.Address {
+	return orDefault(ParseEnvAddress(key, defaultValue))
+}
+
+// ParseEnvInt is GetEnvInt, failing with ErrInvalidEnv if the value is not a valid integer
+func ParseEnvInt(key string, defaultValue int) (int, error) {
+	return parseEnv(key, defaultValue, strconv.Atoi)
+}
+
+// ParseEnvDuration is GetEnvDuration, failing with ErrInvalidEnv if the value is not a valid duration
+func ParseEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	return parseEnv(key, defaultValue, time.ParseDuration)
+}
+
+// ParseEnvBool is GetEnvBool, failing with ErrInvalidEnv if the value is not a valid boolean
+func ParseEnvBool(key string, defaultValue bool) (bool, error) {
+	return parseEnv(key, defaultValue, truthy.Is)
+}
+
+// ParseEnvBigInt is GetEnvBigInt, failing with ErrInvalidEnv if the value is not a valid integer
+func ParseEnvBigInt(key string, defaultValue *big.Int) (*big.Int, error) {
+	return parseEnv(key, defaultValue, func(value string) (*big.Int, error) {
+		i, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return nil, errors.New("not an integer")
+		}
+		return i, nil
+	})
+}
+
+// ParseEnvAddress is GetEnvAddress, failing with ErrInvalidEnv if the value is not a valid address
+func ParseEnvAddress(key string, defaultValue common.Address) (common.Address, error) {
+	return parseEnv(key, defaultValue, func(value string) (common.Address, error) {
+		if !common.IsHexAddress(value) {
+			return common.Address{}, errors.New("not an address")
+		}
+		return common.HexToAddress(value), nil
+	})
+}
+
+// parseEnv parses the value of the environment variable, or returns defaultValue if it doesn't exist. On errors the
+// default value is returned as well.
+func parseEnv[T any](key string, defaultValue T, parse func(string) (T, error)) (T, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	res, err := parse(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%w %s=%q: %v", ErrInvalidEnv, key, value, err)
+	}
+	return res, nil
+}
+
+func orDefault[T any](value T, _ error) T {
+	return value
+}
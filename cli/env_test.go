@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvGetters(t *testing.T) {
+	t.Setenv("TEST_DURATION", "1m30s")
+	t.Setenv("TEST_BOOL", "yes")
+	t.Setenv("TEST_BIGINT", "0x10")
+	t.Setenv("TEST_ADDRESS", "0x0000000000000000000000000000000000000001")
+	t.Setenv("TEST_INVALID", "invalid")
+
+	require.Equal(t, 90*time.Second, GetEnvDuration("TEST_DURATION", time.Second))
+	require.True(t, GetEnvBool("TEST_BOOL", false))
+	require.Equal(t, big.NewInt(16), GetEnvBigInt("TEST_BIGINT", nil))
+	require.Equal(t, common.HexToAddress("0x1"), GetEnvAddress("TEST_ADDRESS", common.Address{}))
+
+	// defaults
+	require.Equal(t, time.Second, GetEnvDuration("TEST_INVALID", time.Second))
+	require.True(t, GetEnvBool("TEST_MISSING", true))
+	require.Equal(t, big.NewInt(1), GetEnvBigInt("TEST_INVALID", big.NewInt(1)))
+	require.Equal(t, common.HexToAddress("0x2"), GetEnvAddress("TEST_INVALID", common.HexToAddress("0x2")))
+}
+
+func TestParseEnv(t *testing.T) {
+	t.Setenv("TEST_INT", "42")
+	t.Setenv("TEST_INVALID", "invalid")
+
+	value, err := ParseEnvInt("TEST_INT", 1)
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	value, err = ParseEnvInt("TEST_MISSING", 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, value)
+
+	_, err = ParseEnvInt("TEST_INVALID", 1)
+	require.ErrorIs(t, err, ErrInvalidEnv)
+	require.Contains(t, err.Error(), "TEST_INVALID")
+	_, err = ParseEnvDuration("TEST_INVALID", 0)
+	require.ErrorIs(t, err, ErrInvalidEnv)
+	_, err = ParseEnvBool("TEST_INVALID", false)
+	require.ErrorIs(t, err, ErrInvalidEnv)
+	_, err = ParseEnvBigInt("TEST_INVALID", nil)
+	require.ErrorIs(t, err, ErrInvalidEnv)
+	_, err = ParseEnvAddress("TEST_INVALID", common.Address{})
+	require.ErrorIs(t, err, ErrInvalidEnv)
+}
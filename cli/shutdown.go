@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownTimeout is the deadline of the cleanups of RunWithShutdown
+var ShutdownTimeout = 30 * time.Second
+
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// RunWithShutdown calls run with a context canceled on SIGINT or SIGTERM (or when ctx is done), then runs the cleanups
+// in order with a context expiring after ShutdownTimeout. It returns the errors of run (except the cancellation of
+// its context) and of the cleanups, and ErrShutdownTimeout without waiting for the cleanups beyond the deadline.
+func RunWithShutdown(ctx context.Context, run func(ctx context.Context) error, cleanup ...func(ctx context.Context) error) error {
+	runCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var errs []error
+	if err := run(runCtx); err != nil && !(errors.Is(err, context.Canceled) && runCtx.Err() != nil) {
+		errs = append(errs, err)
+	}
+	// restore the default behavior, a second signal terminates the process
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	done := make(chan []error, 1)
+	go func() {
+		var cleanupErrs []error
+		for _, fn := range cleanup {
+			if err := fn(shutdownCtx); err != nil {
+				cleanupErrs = append(cleanupErrs, err)
+			}
+		}
+		done <- cleanupErrs
+	}()
+
+	select {
+	case cleanupErrs := <-done:
+		errs = append(errs, cleanupErrs...)
+	case <-shutdownCtx.Done():
+		errs = append(errs, ErrShutdownTimeout)
+	}
+	return errors.Join(errs...)
+}
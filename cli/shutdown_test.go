@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithShutdown(t *testing.T) {
+	var order []string
+	err := RunWithShutdown(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+		<-ctx.Done()
+		return ctx.Err()
+	}, func(ctx context.Context) error {
+		order = append(order, "server")
+		return nil
+	}, func(ctx context.Context) error {
+		order = append(order, "db")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"server", "db"}, order)
+}
+
+func TestRunWithShutdownErrors(t *testing.T) {
+	errRun := errors.New("run")
+	errCleanup := errors.New("cleanup")
+	err := RunWithShutdown(context.Background(), func(ctx context.Context) error {
+		return errRun
+	}, func(ctx context.Context) error {
+		return errCleanup
+	})
+	require.ErrorIs(t, err, errRun)
+	require.ErrorIs(t, err, errCleanup)
+
+	defer func(timeout time.Duration) { ShutdownTimeout = timeout }(ShutdownTimeout)
+	ShutdownTimeout = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RunWithShutdown(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	require.ErrorIs(t, err, ErrShutdownTimeout)
+}
@@ -0,0 +1,43 @@
+// Package dedup detects duplicate orderflow (bundles, transactions, ...) by their rpctypes UniqueKey within a time
+// window, in memory or in Redis when shared by several instances.
+package dedup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/google/uuid"
+)
+
+const (
+	// incremented on each check, when the key was already seen, and when the store fails
+	checksCounter     = `goutils_dedup_checks_total{store="%s"}`
+	duplicatesCounter = `goutils_dedup_duplicates_total{store="%s"}`
+	errorsCounter     = `goutils_dedup_errors_total{store="%s"}`
+)
+
+// Keyer is implemented by the rpctypes args, e.g. EthSendBundleArgs and MevSendBundleArgs.
+type Keyer interface {
+	UniqueKey() uuid.UUID
+}
+
+// Store records the keys seen within its TTL window.
+type Store interface {
+	// CheckAndSet atomically records the key and reports whether it was already recorded within the window
+	CheckAndSet(ctx context.Context, key uuid.UUID) (seen bool, err error)
+}
+
+// IsDuplicate reports whether the order was already seen by the store, recording it otherwise.
+func IsDuplicate(ctx context.Context, store Store, order Keyer) (bool, error) {
+	return store.CheckAndSet(ctx, order.UniqueKey())
+}
+
+func record(name string, seen bool, err error) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(checksCounter, name)).Inc()
+	if err != nil {
+		metrics.GetOrCreateCounter(fmt.Sprintf(errorsCounter, name)).Inc()
+	} else if seen {
+		metrics.GetOrCreateCounter(fmt.Sprintf(duplicatesCounter, name)).Inc()
+	}
+}
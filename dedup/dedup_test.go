@@ -0,0 +1,85 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/flashbots/go-utils/rpctypes"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis implements SET NX with expiry
+type fakeRedis struct {
+	mu   sync.Mutex
+	now  time.Time
+	keys map[string]time.Time
+}
+
+func (r *fakeRedis) SetNX(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if expiresAt, ok := r.keys[key]; ok && r.now.Before(expiresAt) {
+		return false, nil
+	}
+	r.keys[key] = r.now.Add(ttl)
+	return true, nil
+}
+
+func testBundle(blockNumber int64) *rpctypes.EthSendBundleArgs {
+	signer := common.HexToAddress("0x1")
+	return &rpctypes.EthSendBundleArgs{
+		Txs:            []hexutil.Bytes{{0x01, 0x02}},
+		BlockNumber:    rpc.BlockNumber(blockNumber),
+		SigningAddress: &signer,
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	seen, err := IsDuplicate(ctx, store, testBundle(1))
+	require.NoError(t, err)
+	require.False(t, seen)
+
+	seen, err = IsDuplicate(ctx, store, testBundle(1))
+	require.NoError(t, err)
+	require.True(t, seen)
+
+	seen, err = IsDuplicate(ctx, store, testBundle(2))
+	require.NoError(t, err)
+	require.False(t, seen)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(MemoryConfig{Name: "test", TTL: time.Minute}))
+}
+
+func TestRedisStore(t *testing.T) {
+	redis := &fakeRedis{now: time.Now(), keys: make(map[string]time.Time)}
+	store, err := NewRedisStore(redis, RedisConfig{Name: "test_redis", TTL: time.Minute})
+	require.NoError(t, err)
+	testStore(t, store)
+
+	// the window expired
+	redis.now = redis.now.Add(time.Minute)
+	seen, err := IsDuplicate(context.Background(), store, testBundle(1))
+	require.NoError(t, err)
+	require.False(t, seen)
+
+	_, err = NewRedisStore(redis, RedisConfig{})
+	require.ErrorIs(t, err, ErrNoTTL)
+
+	errRedis := errors.New("connection refused")
+	store, err = NewRedisStore(RedisClientFunc(func(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+		return false, errRedis
+	}), RedisConfig{Name: "test_redis_error", TTL: time.Minute})
+	require.NoError(t, err)
+	_, err = IsDuplicate(context.Background(), store, testBundle(1))
+	require.ErrorIs(t, err, errRedis)
+}
@@ -0,0 +1,53 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-utils/cache"
+	"github.com/google/uuid"
+)
+
+const defaultMemorySize = 1_000_000
+
+// MemoryConfig configures a MemoryStore.
+type MemoryConfig struct {
+	// Name of the store in the metrics
+	Name string
+	// TTL is the deduplication window
+	TTL time.Duration
+	// Size is the maximum number of keys (1M by default), the oldest being forgotten beyond it
+	Size int
+}
+
+// MemoryStore is a Store local to the process.
+type MemoryStore struct {
+	name string
+	// mu makes the check and the set atomic
+	mu   sync.Mutex
+	keys *cache.Cache[uuid.UUID, struct{}]
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore(cfg MemoryConfig) *MemoryStore {
+	if cfg.Size <= 0 {
+		cfg.Size = defaultMemorySize
+	}
+	return &MemoryStore{
+		name: cfg.Name,
+		keys: cache.New[uuid.UUID, struct{}](cache.Config{Name: "dedup_" + cfg.Name, Size: cfg.Size, TTL: cfg.TTL}),
+	}
+}
+
+func (s *MemoryStore) CheckAndSet(_ context.Context, key uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	_, seen := s.keys.Get(key)
+	if !seen {
+		s.keys.Set(key, struct{}{})
+	}
+	s.mu.Unlock()
+
+	record(s.name, seen, nil)
+	return seen, nil
+}
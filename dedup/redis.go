@@ -0,0 +1,66 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultRedisPrefix = "dedup:"
+
+var ErrNoTTL = errors.New("dedup window TTL must be set")
+
+// RedisClient is the SET NX command of a Redis client, e.g. with go-redis:
+//
+//	dedup.RedisClientFunc(func(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+//		return rdb.SetNX(ctx, key, 1, ttl).Result()
+//	})
+type RedisClient interface {
+	// SetNX sets the key with the expiry if it doesn't exist, and reports whether it was set
+	SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// RedisClientFunc adapts a function to RedisClient.
+type RedisClientFunc func(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+func (f RedisClientFunc) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return f(ctx, key, ttl)
+}
+
+// RedisConfig configures a RedisStore.
+type RedisConfig struct {
+	// Name of the store in the metrics
+	Name string
+	// TTL is the deduplication window, required as the keys would never expire otherwise
+	TTL time.Duration
+	// Prefix of the Redis keys, "dedup:" by default
+	Prefix string
+}
+
+// RedisStore is a Store shared by the instances using the same Redis, the check and set being a single SET NX.
+type RedisStore struct {
+	cfg    RedisConfig
+	client RedisClient
+}
+
+// NewRedisStore creates a store using the client.
+func NewRedisStore(client RedisClient, cfg RedisConfig) (*RedisStore, error) {
+	if cfg.TTL <= 0 {
+		return nil, ErrNoTTL
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultRedisPrefix
+	}
+	return &RedisStore{cfg: cfg, client: client}, nil
+}
+
+func (s *RedisStore) CheckAndSet(ctx context.Context, key uuid.UUID) (bool, error) {
+	set, err := s.client.SetNX(ctx, s.cfg.Prefix+key.String(), s.cfg.TTL)
+	record(s.cfg.Name, !set, err)
+	if err != nil {
+		return false, err
+	}
+	return !set, nil
+}
@@ -0,0 +1,220 @@
+package envflag
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flashbots/go-utils/truthy"
+)
+
+var (
+	ErrNotStructPointer = errors.New("config must be a non-nil pointer to a struct")
+	ErrUnsupportedType  = errors.New("unsupported field type")
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// Bind registers a flag for every field of the struct pointed to by cfg
+// tagged with `flag:"name"`, the values are stored in the fields. The optional
+// tags are `env:"NAME"` (the environment variable, derived from the flag name
+// by default), `default:"value"` (the current value of the field by default),
+// `usage:"text"` and `secret:"true"` (masks the value in EffectiveConfig).
+// Nested structs without flag tag are walked as well.
+//
+//	type Config struct {
+//		ListenAddr string         `flag:"listen-addr" default:":8080" usage:"address to listen on"`
+//		Timeout    time.Duration  `flag:"timeout" default:"5s"`
+//		Builder    common.Address `flag:"builder" env:"BUILDER_ADDRESS"`
+//	}
+//
+// Supported field types are strings, booleans, integers, floats,
+// time.Duration, []string (comma separated) and types implementing
+// encoding.TextUnmarshaler. It returns the errors of parsing the defaults and
+// the environment variables, the flags are registered regardless.
+func Bind(cfg any) error {
+	return CommandLine().Bind(cfg)
+}
+
+// MustBind handles error (if any) returned by Bind according to the behaviour
+// configured by `flag.CommandLine.ErrorHandling()` by either ignoring it,
+// exiting the process with status code 2, or panicking.
+func MustBind(cfg any) {
+	CommandLine().MustBind(cfg)
+}
+
+// Bind is the FlagSet equivalent of the package-level Bind.
+func (fs *FlagSet) Bind(cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPointer
+	}
+	return fs.bindStruct(v.Elem())
+}
+
+// MustBind handles error (if any) returned by Bind according to the behaviour
+// configured by `fs.ErrorHandling()` by either ignoring it, exiting the process
+// with status code 2, or panicking.
+func (fs *FlagSet) MustBind(cfg any) {
+	handleError(fs.FlagSet, fs.Bind(cfg))
+}
+
+func (fs *FlagSet) bindStruct(v reflect.Value) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := field.Tag.Lookup("flag")
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+				errs = append(errs, fs.bindStruct(v.Field(i)))
+			}
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		errs = append(errs, fs.bindField(v.Field(i), field, name))
+	}
+	return errors.Join(errs...)
+}
+
+func (fs *FlagSet) bindField(v reflect.Value, field reflect.StructField, name string) error {
+	value := &fieldValue{v: v}
+	if !value.supported() {
+		return fmt.Errorf("%w %s of field %s", ErrUnsupportedType, field.Type, field.Name)
+	}
+
+	var errs []error
+	if def, found := field.Tag.Lookup("default"); found {
+		if err := value.Set(def); err != nil {
+			errs = append(errs, fmt.Errorf("invalid default value \"%s\" for flag %s: %w", def, name, err))
+		}
+	}
+
+	env := field.Tag.Get("env")
+	if env == "" {
+		env = flagToEnv(name)
+	}
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err != nil {
+		errs = append(errs, err)
+	} else if raw != "" {
+		if err := value.Set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value \"%s\" for environment variable %s: %w", raw, env, err))
+		} else {
+			fromEnv = true
+		}
+	}
+
+	fs.Var(value, name, field.Tag.Get("usage")+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, field.Tag.Get("secret") == "true")
+	return errors.Join(errs...)
+}
+
+// fieldValue is a flag.Value setting a struct field.
+type fieldValue struct {
+	v reflect.Value
+}
+
+func (f *fieldValue) supported() bool {
+	if reflect.PointerTo(f.v.Type()).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch f.v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return f.v.Type().Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// Set implements flag.Value.
+func (f *fieldValue) Set(raw string) error {
+	if u, ok := f.v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	if f.v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.v.Kind() {
+	case reflect.String:
+		f.v.SetString(raw)
+	case reflect.Bool:
+		b, err := truthy.Is(raw)
+		if err != nil {
+			return err
+		}
+		f.v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 0, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 0, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(raw, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetFloat(fl)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.v.Set(reflect.ValueOf(items).Convert(f.v.Type()))
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (f *fieldValue) String() string {
+	if f == nil || !f.v.IsValid() { // the flag package calls String on zero values
+		return ""
+	}
+	if m, ok := f.v.Addr().Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	}
+	if f.v.Kind() == reflect.Slice {
+		return strings.Join(f.v.Convert(reflect.TypeOf([]string(nil))).Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.v.Interface())
+}
+
+// IsBoolFlag allows boolean flags to be set without value, e.g. `-debug`.
+func (f *fieldValue) IsBoolFlag() bool {
+	return f.v.Kind() == reflect.Bool
+}
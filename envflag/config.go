@@ -0,0 +1,127 @@
+package envflag
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Sources of the flag values, see FlagInfo
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceCLI     = "cli"
+)
+
+// Masked replaces the values of secret flags in FlagInfo.
+const Masked = "********"
+
+// secretFlagNames are substrings of flag names whose values are masked.
+var secretFlagNames = []string{"secret", "password", "passwd", "token", "key", "mnemonic", "seed", "dsn"}
+
+// FlagInfo describes the effective value of a flag.
+type FlagInfo struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Env    string `json:"env,omitempty"`
+}
+
+// flagSource records where the value of a flag registered by this package
+// comes from. The flag.Value is left untouched, so the usage of the flags is
+// printed as usual.
+type flagSource struct {
+	env     string
+	fromEnv bool
+	secret  bool
+}
+
+// track records the environment variable of the flag, fromEnv tells whether
+// the value was taken from it. Values read from `<env>_FILE` are treated as
+// secrets.
+func (fs *FlagSet) track(name, env string, fromEnv, secret bool) {
+	if fs.Lookup(name) == nil {
+		return
+	}
+	if os.Getenv(env+"_FILE") != "" {
+		env += "_FILE"
+		secret = true
+	}
+
+	fs.sourcesMu.Lock()
+	defer fs.sourcesMu.Unlock()
+	if fs.sources == nil {
+		fs.sources = make(map[string]flagSource)
+	}
+	fs.sources[name] = flagSource{env: env, fromEnv: fromEnv, secret: secret || isSecretFlag(name)}
+}
+
+func isSecretFlag(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretFlagNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveConfig returns the flags of flag.CommandLine with their values and
+// sources, see FlagSet.EffectiveConfig.
+func EffectiveConfig() []FlagInfo {
+	return CommandLine().EffectiveConfig()
+}
+
+// EffectiveConfig returns all flags (sorted by name) with their value and
+// where it comes from: the command line, the environment variable or the
+// default. The values of secret flags (names containing e.g. "key" or
+// "password", `secret:"true"` fields of Bind and values read from
+// `<ENV>_FILE`) are masked. Call it after parsing the flags.
+func (fs *FlagSet) EffectiveConfig() []FlagInfo {
+	setOnCLI := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCLI[f.Name] = true
+	})
+
+	fs.sourcesMu.Lock()
+	defer fs.sourcesMu.Unlock()
+
+	var infos []FlagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		info := FlagInfo{
+			Name:   f.Name,
+			Value:  f.Value.String(),
+			Source: SourceDefault,
+		}
+		secret := isSecretFlag(f.Name)
+		if source, ok := fs.sources[f.Name]; ok {
+			info.Env = source.env
+			secret = source.secret
+			if source.fromEnv {
+				info.Source = SourceEnv
+			}
+		}
+		if setOnCLI[f.Name] {
+			info.Source = SourceCLI
+		}
+		if secret && info.Value != "" {
+			info.Value = Masked
+		}
+		infos = append(infos, info)
+	})
+	return infos
+}
+
+// WriteConfigTable writes the flags as a table with the columns flag, value,
+// source and env.
+func WriteConfigTable(w io.Writer, infos []FlagInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tVALUE\tSOURCE\tENV")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Name, info.Value, info.Source, info.Env)
+	}
+	return tw.Flush()
+}
@@ -5,102 +5,121 @@ package envflag
 import (
 	"flag"
 	"fmt"
+	"math/big"
 	"os"
-	"strconv"
 	"strings"
-
-	"github.com/flashbots/go-utils/truthy"
 )
 
 // Bool is a convenience wrapper for boolean flag that picks its default value
 // from the environment variable. It returns error if the environment variable's
 // value can not be resolved into definitive `true` or `false`.
 func Bool(name string, defaultValue bool, usage string) (*bool, error) {
-	var err error
-	value := defaultValue
-	env := flagToEnv(name)
-	if raw := os.Getenv(env); raw != "" {
-		if pValue, pErr := truthy.Is(raw); pErr == nil {
-			value = pValue
-		} else {
-			err = fmt.Errorf("invalid boolean value \"%s\" for environment variable %s: %w", raw, env, pErr)
-		}
-	}
-	return flag.Bool(name, value, usage+fmt.Sprintf(" (env \"%s\")", env)), err
+	return CommandLine().Bool(name, defaultValue, usage)
 }
 
 // MustBool handles error (if any) returned by Bool according to the behaviour
 // configured by `flag.CommandLine.ErrorHandling()` by either ignoring it,
 // exiting the process with status code 2, or panicking.
 func MustBool(name string, defaultValue bool, usage string) *bool {
-	res, err := Bool(name, defaultValue, usage)
-	if err != nil {
-		switch flag.CommandLine.ErrorHandling() {
-		case flag.ContinueOnError:
-			// continue
-		case flag.ExitOnError:
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(2)
-		case flag.PanicOnError:
-			panic(err)
-		}
-	}
-	if res == nil { // should never happen, guard added for NilAway
-		panic(fmt.Sprintf("MustBool res for '%s' is nil", name))
-	}
-	return res
+	return CommandLine().MustBool(name, defaultValue, usage)
 }
 
 // Int is a convenience wrapper for integer flag that picks its default value
 // from the environment variable. It returns error if the environment variable's
 // value can not be parsed into integer.
 func Int(name string, defaultValue int, usage string) (*int, error) {
-	var err error
-	value := defaultValue
-	env := flagToEnv(name)
-	if raw := os.Getenv(env); raw != "" {
-		if pValue, pErr := strconv.Atoi(raw); pErr == nil {
-			value = pValue
-		} else {
-			err = fmt.Errorf("invalid integer value \"%s\" for environment variable %s: %w", raw, env, pErr)
-		}
-	}
-	return flag.Int(name, value, usage+fmt.Sprintf(" (env \"%s\")", env)), err
+	return CommandLine().Int(name, defaultValue, usage)
 }
 
 // MustInt handles error (if any) returned by Int according to the behaviour
 // configured by `flag.CommandLine.ErrorHandling()` by either ignoring it,
 // exiting the process with status code 2, or panicking.
 func MustInt(name string, defaultValue int, usage string) *int {
-	res, err := Int(name, defaultValue, usage)
-	if err != nil {
-		switch flag.CommandLine.ErrorHandling() {
-		case flag.ContinueOnError:
-			// continue
-		case flag.ExitOnError:
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(2)
-		case flag.PanicOnError:
-			panic(err)
-		}
-	}
+	return CommandLine().MustInt(name, defaultValue, usage)
+}
 
-	if res == nil { // should never happen, guard added for NilAway
-		panic(fmt.Sprintf("MustInt res for '%s' is nil", name))
-	}
+// Int64 is a convenience wrapper for 64-bit integer flag that picks its
+// default value from the environment variable. It returns error if the
+// environment variable's value can not be parsed into integer.
+func Int64(name string, defaultValue int64, usage string) (*int64, error) {
+	return CommandLine().Int64(name, defaultValue, usage)
+}
+
+// MustInt64 handles error (if any) returned by Int64 according to the
+// behaviour configured by `flag.CommandLine.ErrorHandling()` by either
+// ignoring it, exiting the process with status code 2, or panicking.
+func MustInt64(name string, defaultValue int64, usage string) *int64 {
+	return CommandLine().MustInt64(name, defaultValue, usage)
+}
+
+// Uint64 is a convenience wrapper for unsigned 64-bit integer flag (e.g. chain
+// IDs and gas limits) that picks its default value from the environment
+// variable. It returns error if the environment variable's value can not be
+// parsed into unsigned integer.
+func Uint64(name string, defaultValue uint64, usage string) (*uint64, error) {
+	return CommandLine().Uint64(name, defaultValue, usage)
+}
+
+// MustUint64 handles error (if any) returned by Uint64 according to the
+// behaviour configured by `flag.CommandLine.ErrorHandling()` by either
+// ignoring it, exiting the process with status code 2, or panicking.
+func MustUint64(name string, defaultValue uint64, usage string) *uint64 {
+	return CommandLine().MustUint64(name, defaultValue, usage)
+}
+
+// BigInt is a convenience wrapper for arbitrary precision integer flag (e.g.
+// wei amounts) that picks its default value from the environment variable. It
+// returns error if the environment variable's value can not be parsed into
+// integer.
+func BigInt(name string, defaultValue *big.Int, usage string) (*big.Int, error) {
+	return CommandLine().BigInt(name, defaultValue, usage)
+}
 
-	return res
+// MustBigInt handles error (if any) returned by BigInt according to the
+// behaviour configured by `flag.CommandLine.ErrorHandling()` by either
+// ignoring it, exiting the process with status code 2, or panicking.
+func MustBigInt(name string, defaultValue *big.Int, usage string) *big.Int {
+	return CommandLine().MustBigInt(name, defaultValue, usage)
 }
 
 // String is a convenience wrapper for string flag that picks its default value
-// from the environment variable.
+// from the environment variable. Errors reading the `<ENV>_FILE` file are
+// handled like in MustBool.
 func String(name, defaultValue, usage string) *string {
-	value := defaultValue
-	env := flagToEnv(name)
-	if raw := os.Getenv(env); raw != "" {
-		value = raw
+	return CommandLine().String(name, defaultValue, usage)
+}
+
+// lookupEnv returns the value of the environment variable. If `<env>_FILE` is
+// set, the value is read from the file at that path instead (with trailing
+// newlines removed), which is how Docker and Kubernetes secrets are commonly
+// mounted.
+func lookupEnv(env string) (string, error) {
+	if path := os.Getenv(env + "_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read environment variable %s_FILE: %w", env, err)
+		}
+		return strings.TrimRight(string(raw), "\r\n"), nil
+	}
+	return os.Getenv(env), nil
+}
+
+// handleError handles the error according to the behaviour configured by
+// `fs.ErrorHandling()` by either ignoring it, exiting the process with status
+// code 2, or panicking.
+func handleError(fs *flag.FlagSet, err error) {
+	if err == nil {
+		return
+	}
+	switch fs.ErrorHandling() {
+	case flag.ContinueOnError:
+		// continue
+	case flag.ExitOnError:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
 	}
-	return flag.String(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
 }
 
 func flagToEnv(flag string) string {
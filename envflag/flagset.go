@@ -0,0 +1,221 @@
+package envflag
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/flashbots/go-utils/truthy"
+)
+
+// FlagSet wraps a flag.FlagSet, registering flags that pick their default
+// values from the environment variables. Unlike the package-level functions,
+// which use flag.CommandLine, it can be used in libraries and subcommands.
+type FlagSet struct {
+	*flag.FlagSet
+
+	// sources of the flags registered by this package by name, see EffectiveConfig
+	sourcesMu sync.Mutex
+	sources   map[string]flagSource
+}
+
+// wrappers are the FlagSets by wrapped flag.FlagSet, so the sources of the flags are kept across calls of Wrap
+var wrappers sync.Map
+
+// NewFlagSet returns a FlagSet wrapping a new flag.FlagSet.
+func NewFlagSet(name string, errorHandling flag.ErrorHandling) *FlagSet {
+	return Wrap(flag.NewFlagSet(name, errorHandling))
+}
+
+// Wrap returns the FlagSet registering flags in fs, the same one for every
+// call with fs.
+func Wrap(fs *flag.FlagSet) *FlagSet {
+	w, _ := wrappers.LoadOrStore(fs, &FlagSet{FlagSet: fs})
+	return w.(*FlagSet)
+}
+
+// CommandLine returns a FlagSet wrapping flag.CommandLine.
+func CommandLine() *FlagSet {
+	return Wrap(flag.CommandLine)
+}
+
+// Bool is a convenience wrapper for boolean flag that picks its default value
+// from the environment variable. It returns error if the environment variable's
+// value can not be resolved into definitive `true` or `false`.
+func (fs *FlagSet) Bool(name string, defaultValue bool, usage string) (*bool, error) {
+	value := defaultValue
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil {
+		if pValue, pErr := truthy.Parse(raw); pErr != nil {
+			err = fmt.Errorf("invalid boolean value \"%s\" for environment variable %s: %w", raw, env, pErr)
+		} else if pValue != nil {
+			value = *pValue
+			fromEnv = true
+		}
+	}
+	res := fs.FlagSet.Bool(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return res, err
+}
+
+// MustBool handles error (if any) returned by Bool according to the behaviour
+// configured by `fs.ErrorHandling()` by either ignoring it, exiting the process
+// with status code 2, or panicking.
+func (fs *FlagSet) MustBool(name string, defaultValue bool, usage string) *bool {
+	res, err := fs.Bool(name, defaultValue, usage)
+	handleError(fs.FlagSet, err)
+	if res == nil { // should never happen, guard added for NilAway
+		panic(fmt.Sprintf("MustBool res for '%s' is nil", name))
+	}
+	return res
+}
+
+// Int is a convenience wrapper for integer flag that picks its default value
+// from the environment variable. It returns error if the environment variable's
+// value can not be parsed into integer.
+func (fs *FlagSet) Int(name string, defaultValue int, usage string) (*int, error) {
+	value := defaultValue
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil && raw != "" {
+		if pValue, pErr := strconv.Atoi(raw); pErr == nil {
+			value = pValue
+			fromEnv = true
+		} else {
+			err = fmt.Errorf("invalid integer value \"%s\" for environment variable %s: %w", raw, env, pErr)
+		}
+	}
+	res := fs.FlagSet.Int(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return res, err
+}
+
+// MustInt handles error (if any) returned by Int according to the behaviour
+// configured by `fs.ErrorHandling()` by either ignoring it, exiting the process
+// with status code 2, or panicking.
+func (fs *FlagSet) MustInt(name string, defaultValue int, usage string) *int {
+	res, err := fs.Int(name, defaultValue, usage)
+	handleError(fs.FlagSet, err)
+
+	if res == nil { // should never happen, guard added for NilAway
+		panic(fmt.Sprintf("MustInt res for '%s' is nil", name))
+	}
+
+	return res
+}
+
+// Int64 is a convenience wrapper for 64-bit integer flag that picks its
+// default value from the environment variable. It returns error if the
+// environment variable's value can not be parsed into integer.
+func (fs *FlagSet) Int64(name string, defaultValue int64, usage string) (*int64, error) {
+	value := defaultValue
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil && raw != "" {
+		if pValue, pErr := strconv.ParseInt(raw, 0, 64); pErr == nil {
+			value = pValue
+			fromEnv = true
+		} else {
+			err = fmt.Errorf("invalid integer value \"%s\" for environment variable %s: %w", raw, env, pErr)
+		}
+	}
+	res := fs.FlagSet.Int64(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return res, err
+}
+
+// MustInt64 handles error (if any) returned by Int64 according to the
+// behaviour configured by `fs.ErrorHandling()` by either ignoring it, exiting
+// the process with status code 2, or panicking.
+func (fs *FlagSet) MustInt64(name string, defaultValue int64, usage string) *int64 {
+	res, err := fs.Int64(name, defaultValue, usage)
+	handleError(fs.FlagSet, err)
+	return res
+}
+
+// Uint64 is a convenience wrapper for unsigned 64-bit integer flag that picks
+// its default value from the environment variable. It returns error if the
+// environment variable's value can not be parsed into unsigned integer.
+func (fs *FlagSet) Uint64(name string, defaultValue uint64, usage string) (*uint64, error) {
+	value := defaultValue
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil && raw != "" {
+		if pValue, pErr := strconv.ParseUint(raw, 0, 64); pErr == nil {
+			value = pValue
+			fromEnv = true
+		} else {
+			err = fmt.Errorf("invalid unsigned integer value \"%s\" for environment variable %s: %w", raw, env, pErr)
+		}
+	}
+	res := fs.FlagSet.Uint64(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return res, err
+}
+
+// MustUint64 handles error (if any) returned by Uint64 according to the
+// behaviour configured by `fs.ErrorHandling()` by either ignoring it, exiting
+// the process with status code 2, or panicking.
+func (fs *FlagSet) MustUint64(name string, defaultValue uint64, usage string) *uint64 {
+	res, err := fs.Uint64(name, defaultValue, usage)
+	handleError(fs.FlagSet, err)
+	return res
+}
+
+// BigInt is a convenience wrapper for arbitrary precision integer flag (e.g.
+// wei amounts) that picks its default value from the environment variable.
+// Decimal and prefixed ("0x", "0b", "0o") values are accepted. It returns
+// error if the environment variable's value can not be parsed into integer.
+func (fs *FlagSet) BigInt(name string, defaultValue *big.Int, usage string) (*big.Int, error) {
+	value := new(big.Int)
+	if defaultValue != nil {
+		value.Set(defaultValue)
+	}
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil && raw != "" {
+		if pValue, ok := new(big.Int).SetString(raw, 0); ok {
+			value = pValue
+			fromEnv = true
+		} else {
+			err = fmt.Errorf("invalid integer value \"%s\" for environment variable %s", raw, env)
+		}
+	}
+	res := new(big.Int)
+	fs.TextVar(res, name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return res, err
+}
+
+// MustBigInt handles error (if any) returned by BigInt according to the
+// behaviour configured by `fs.ErrorHandling()` by either ignoring it, exiting
+// the process with status code 2, or panicking.
+func (fs *FlagSet) MustBigInt(name string, defaultValue *big.Int, usage string) *big.Int {
+	res, err := fs.BigInt(name, defaultValue, usage)
+	handleError(fs.FlagSet, err)
+	return res
+}
+
+// String is a convenience wrapper for string flag that picks its default value
+// from the environment variable. Errors reading the `<ENV>_FILE` file are
+// handled like in MustBool.
+func (fs *FlagSet) String(name, defaultValue, usage string) *string {
+	value := defaultValue
+	env := flagToEnv(name)
+	raw, err := lookupEnv(env)
+	handleError(fs.FlagSet, err)
+	if raw != "" {
+		value = raw
+	}
+	res := fs.FlagSet.String(name, value, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, raw != "", false)
+	return res
+}
@@ -0,0 +1,84 @@
+package envflag
+
+import "fmt"
+
+// Value is a flag.Value holding a value of an arbitrary type, parsed with the
+// provided function. It allows custom types (addresses, log levels, etc.) to
+// be used as flags.
+type Value[T any] struct {
+	value *T
+	parse func(string) (T, error)
+}
+
+// NewValue returns a Value storing into p, which is set to defaultValue.
+func NewValue[T any](p *T, defaultValue T, parse func(string) (T, error)) *Value[T] {
+	*p = defaultValue
+	return &Value[T]{value: p, parse: parse}
+}
+
+// Set implements flag.Value.
+func (v *Value[T]) Set(raw string) error {
+	value, err := v.parse(raw)
+	if err != nil {
+		return err
+	}
+	*v.value = value
+	return nil
+}
+
+// String implements flag.Value.
+func (v *Value[T]) String() string {
+	if v == nil || v.value == nil { // the flag package calls String on zero values
+		return ""
+	}
+	return fmt.Sprint(*v.value)
+}
+
+// Get implements flag.Getter.
+func (v *Value[T]) Get() any {
+	return *v.value
+}
+
+// Var is a convenience wrapper for a flag of custom type that picks its
+// default value from the environment variable. The values (from the
+// environment or the command line) are parsed with parse. It returns error if
+// the environment variable's value can not be parsed.
+func Var[T any](name string, defaultValue T, parse func(string) (T, error), usage string) (*T, error) {
+	return FlagSetVar(CommandLine(), name, defaultValue, parse, usage)
+}
+
+// MustVar handles error (if any) returned by Var according to the behaviour
+// configured by `flag.CommandLine.ErrorHandling()` by either ignoring it,
+// exiting the process with status code 2, or panicking.
+func MustVar[T any](name string, defaultValue T, parse func(string) (T, error), usage string) *T {
+	return MustFlagSetVar(CommandLine(), name, defaultValue, parse, usage)
+}
+
+// FlagSetVar is Var registering the flag in fs (methods can't have type
+// parameters).
+func FlagSetVar[T any](fs *FlagSet, name string, defaultValue T, parse func(string) (T, error), usage string) (*T, error) {
+	p := new(T)
+	v := NewValue(p, defaultValue, parse)
+	env := flagToEnv(name)
+	fromEnv := false
+	raw, err := lookupEnv(env)
+	if err == nil && raw != "" {
+		if pErr := v.Set(raw); pErr != nil {
+			err = fmt.Errorf("invalid value \"%s\" for environment variable %s: %w", raw, env, pErr)
+		} else {
+			fromEnv = true
+		}
+	}
+	fs.Var(v, name, usage+fmt.Sprintf(" (env \"%s\")", env))
+	fs.track(name, env, fromEnv, false)
+	return p, err
+}
+
+// MustFlagSetVar handles error (if any) returned by FlagSetVar according to
+// the behaviour configured by `fs.ErrorHandling()` by either ignoring it,
+// exiting the process with status code 2, or panicking.
+func MustFlagSetVar[T any](fs *FlagSet, name string, defaultValue T, parse func(string) (T, error), usage string) *T {
+	res, err := FlagSetVar(fs, name, defaultValue, parse, usage)
+	handleError(fs.FlagSet, err)
+	return res
+}
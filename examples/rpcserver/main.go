@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"time"
 
 	"github.com/flashbots/go-utils/rpcserver"
 )
@@ -24,13 +24,14 @@ func main() {
 		panic(err)
 	}
 
-	// server
-	server := &http.Server{
-		Addr:    listenAddr,
-		Handler: handler,
-	}
+	// server, shut down gracefully on SIGINT or SIGTERM
+	server := rpcserver.NewServer(handler, rpcserver.ServerOpts{
+		ListenAddr:  listenAddr,
+		DrainDelay:  5 * time.Second,
+		GracePeriod: 30 * time.Second,
+	})
 	fmt.Println("Starting server.", "listenAddr:", listenAddr)
-	if err := server.ListenAndServe(); err != nil {
+	if err := server.ListenAndServe(context.Background()); err != nil {
 		panic(err)
 	}
 }
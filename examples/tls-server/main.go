@@ -48,15 +48,14 @@ func main() {
 		_, _ = w.Write(cert)
 	})
 
+	tlsConfig := utils_tls.NewModernServerConfig()
+	tlsConfig.Certificates = []tls.Certificate{certificate}
+
 	srv := &http.Server{
 		Addr:              listenAddr,
 		Handler:           mux,
 		ReadHeaderTimeout: time.Second,
-		TLSConfig: &tls.Config{
-			Certificates:             []tls.Certificate{certificate},
-			MinVersion:               tls.VersionTLS13,
-			PreferServerCipherSuites: true,
-		},
+		TLSConfig:         tlsConfig,
 	}
 
 	fmt.Println("Starting HTTPS server", "addr", listenAddr)
@@ -0,0 +1,23 @@
+// Package goutils provides small generic helpers, e.g. for the pointer-typed optional fields of the rpctypes structs.
+package goutils
+
+// Must returns v, panicking if err is not nil
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Ptr returns a pointer to a copy of v
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or def if p is nil
+func Deref[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
@@ -0,0 +1,24 @@
+package goutils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMust(t *testing.T) {
+	require.Equal(t, 1, Must(1, nil))
+	require.Panics(t, func() { Must(1, errors.New("test")) })
+}
+
+func TestPtr(t *testing.T) {
+	v := 1
+	p := Ptr(v)
+	require.Equal(t, 1, *p)
+	*p = 2
+	require.Equal(t, 1, v)
+
+	require.Equal(t, 2, Deref(p, 0))
+	require.Equal(t, 3, Deref(nil, 3))
+}
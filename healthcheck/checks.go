@@ -0,0 +1,54 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/flashbots/go-utils/jsonrpc"
+)
+
+// RPCReachable checks that the JSON-RPC endpoint answers the method (e.g. eth_blockNumber) without error.
+func RPCReachable(url, method string) CheckFunc {
+	return func(ctx context.Context) error {
+		req := jsonrpc.JSONRPCRequest{ID: 1, Method: method, Params: []interface{}{}, Version: "2.0"}
+		res, err := jsonrpc.SendJSONRPCRequestWithContext(ctx, req, url)
+		if err != nil {
+			return err
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		return nil
+	}
+}
+
+// BlockFreshness checks that the latest block is not older than maxAge, e.g. with blocksub's LatestHeader.
+func BlockFreshness(latestHeader func() *types.Header, maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) error {
+		header := latestHeader()
+		if header == nil {
+			return fmt.Errorf("no block received yet")
+		}
+		age := time.Since(time.Unix(int64(header.Time), 0))
+		if age > maxAge {
+			return fmt.Errorf("latest block %d is %s old", header.Number.Uint64(), age.Truncate(time.Second))
+		}
+		return nil
+	}
+}
+
+// DiskSpace checks that the file system of the path has at least minFree bytes available.
+func DiskSpace(path string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		available, err := availableDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if available < minFree {
+			return fmt.Errorf("%d bytes available on %s, expected at least %d", available, path, minFree)
+		}
+		return nil
+	}
+}
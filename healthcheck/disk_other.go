@@ -0,0 +1,9 @@
+//go:build !unix
+
+package healthcheck
+
+import "errors"
+
+func availableDiskSpace(string) (uint64, error) {
+	return 0, errors.New("disk space check is not supported on this platform")
+}
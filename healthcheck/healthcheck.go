@@ -0,0 +1,192 @@
+// Package healthcheck provides a registry of named health checks, served by liveness and readiness handlers.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// 1 if the check passed at its last run, 0 otherwise
+const checkStatusGauge = `goutils_healthcheck_status{check="%s"}`
+
+const defaultTimeout = 5 * time.Second
+
+var ErrTimeout = errors.New("health check timed out")
+
+// CheckFunc returns an error if the checked dependency is unhealthy.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named health check.
+type Check struct {
+	Name  string
+	Check CheckFunc
+	// Timeout of the check, 5 seconds by default
+	Timeout time.Duration
+	// CacheTTL caches the result of the check, e.g. for expensive checks behind frequently probed handlers
+	CacheTTL time.Duration
+	// Liveness makes the check part of the liveness handler, by default checks only affect readiness
+	Liveness bool
+}
+
+// Result is the result of a check.
+type Result struct {
+	Name      string        `json:"name"`
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// Report is the result of all checks.
+type Report struct {
+	Healthy bool     `json:"healthy"`
+	Checks  []Result `json:"checks"`
+}
+
+type registeredCheck struct {
+	Check
+
+	mu     sync.Mutex
+	cached *Result
+}
+
+// Registry holds the health checks.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*registeredCheck
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]*registeredCheck)}
+}
+
+// Register adds the check, replacing a check with the same name.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[check.Name] = &registeredCheck{Check: check}
+}
+
+// Unregister removes the check.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Run runs all checks concurrently.
+func (r *Registry) Run(ctx context.Context) Report {
+	return r.run(ctx, false)
+}
+
+// RunLiveness runs the liveness checks concurrently.
+func (r *Registry) RunLiveness(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Report {
+	r.mu.RLock()
+	checks := make([]*registeredCheck, 0, len(r.checks))
+	for _, check := range r.checks {
+		if !livenessOnly || check.Liveness {
+			checks = append(checks, check)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	report := Report{Healthy: true, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *registeredCheck) {
+			defer wg.Done()
+			report.Checks[i] = check.run(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		report.Healthy = report.Healthy && result.Healthy
+	}
+	return report
+}
+
+func (c *registeredCheck) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.cached.CheckedAt) < c.CacheTTL {
+		return *c.cached
+	}
+
+	checkCtx, cancel := context.WithTimeoutCause(ctx, c.Timeout, ErrTimeout)
+	defer cancel()
+
+	start := time.Now()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- c.Check.Check(checkCtx)
+	}()
+	var err error
+	select {
+	case err = <-errC:
+	case <-checkCtx.Done():
+		// the check doesn't respect the context
+		err = checkCtx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) && context.Cause(checkCtx) == ErrTimeout {
+		err = ErrTimeout
+	}
+	result := Result{
+		Name:      c.Name,
+		Healthy:   err == nil,
+		Duration:  time.Since(start),
+		CheckedAt: start,
+	}
+	status := 1.0
+	if err != nil {
+		result.Error = err.Error()
+		status = 0
+	}
+	if ctx.Err() != nil {
+		// canceled by the caller, the result says nothing about the checked dependency
+		return result
+	}
+	metrics.GetOrCreateGauge(fmt.Sprintf(checkStatusGauge, c.Name), nil).Set(status)
+
+	c.cached = &result
+	return result
+}
+
+// ReadyHandler serves the report of all checks as JSON, with status 503 if a check failed.
+func (r *Registry) ReadyHandler() http.Handler {
+	return reportHandler(r.Run)
+}
+
+// LiveHandler serves the report of the liveness checks as JSON, with status 503 if a check failed.
+func (r *Registry) LiveHandler() http.Handler {
+	return reportHandler(r.RunLiveness)
+}
+
+func reportHandler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := run(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,127 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/flashbots/go-utils/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryHandlers(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Check{Name: "process", Liveness: true, Check: func(ctx context.Context) error { return nil }})
+	r.Register(Check{Name: "upstream", Check: func(ctx context.Context) error { return errors.New("down") }})
+
+	rec := httptest.NewRecorder()
+	r.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.False(t, report.Healthy)
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, "process", report.Checks[0].Name)
+	require.True(t, report.Checks[0].Healthy)
+	require.Equal(t, "upstream", report.Checks[1].Name)
+	require.Equal(t, "down", report.Checks[1].Error)
+
+	r.Unregister("upstream")
+	require.True(t, r.Run(context.Background()).Healthy)
+}
+
+func TestCheckTimeoutAndCache(t *testing.T) {
+	var calls atomic.Int32
+	r := NewRegistry()
+	r.Register(Check{
+		Name:     "slow",
+		Timeout:  10 * time.Millisecond,
+		CacheTTL: time.Hour,
+		Check: func(ctx context.Context) error {
+			calls.Add(1)
+			time.Sleep(time.Second) // ignores the context
+			return nil
+		},
+	})
+
+	report := r.Run(context.Background())
+	require.False(t, report.Healthy)
+	require.Equal(t, ErrTimeout.Error(), report.Checks[0].Error)
+	require.Less(t, report.Checks[0].Duration, time.Second)
+
+	// cached
+	report = r.Run(context.Background())
+	require.False(t, report.Healthy)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestCheckCanceledByCaller(t *testing.T) {
+	var calls atomic.Int32
+	r := NewRegistry()
+	r.Register(Check{
+		Name:     "blocking",
+		Timeout:  time.Hour,
+		CacheTTL: time.Hour,
+		Check: func(ctx context.Context) error {
+			if calls.Add(1) > 1 {
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	// the deadline of the caller is not the timeout of the check, and is not cached
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	report := r.Run(ctx)
+	require.False(t, report.Healthy)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+
+	report = r.Run(context.Background())
+	require.True(t, report.Healthy)
+	require.Equal(t, int32(2), calls.Load())
+
+	// errors returned by the check are kept
+	r.Register(Check{Name: "internal", Check: func(ctx context.Context) error { return context.DeadlineExceeded }})
+	report = r.Run(context.Background())
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[1].Error)
+}
+
+func TestRPCReachable(t *testing.T) {
+	server := jsonrpc.NewMockJSONRPCServer()
+	defer server.Close()
+	server.UseChain(jsonrpc.NewMockChain(1))
+
+	require.NoError(t, RPCReachable(server.URL, "eth_blockNumber")(context.Background()))
+	require.Error(t, RPCReachable(server.URL, "eth_unknown")(context.Background()))
+}
+
+func TestBlockFreshness(t *testing.T) {
+	var header *types.Header
+	check := BlockFreshness(func() *types.Header { return header }, time.Minute)
+	require.Error(t, check(context.Background()))
+
+	header = &types.Header{Number: big.NewInt(1), Time: uint64(time.Now().Unix())}
+	require.NoError(t, check(context.Background()))
+
+	header = &types.Header{Number: big.NewInt(1), Time: uint64(time.Now().Add(-time.Hour).Unix())}
+	require.Error(t, check(context.Background()))
+}
+
+func TestDiskSpace(t *testing.T) {
+	require.NoError(t, DiskSpace(t.TempDir(), 1)(context.Background()))
+	require.Error(t, DiskSpace(t.TempDir(), 1<<62)(context.Background()))
+}
@@ -0,0 +1,99 @@
+package httplogger
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const clientIPContextKey contextKey = "clientIP"
+
+// ParseTrustedProxies parses a list of CIDRs (e.g. "10.0.0.0/8") or single IP addresses into prefixes usable as
+// Options.TrustedProxies.
+func ParseTrustedProxies(cidrs ...string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ClientIP returns the IP address of the original client. Proxy headers (CF-Connecting-IP, X-Real-IP,
+// X-Forwarded-For, in that order) are only considered if the request comes from a trusted proxy, otherwise the
+// remote address of the connection is returned. X-Forwarded-For is walked from right to left, skipping trusted
+// proxies, so clients can't spoof their address by sending the header themselves.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remote := remoteAddr(r)
+	if !remote.IsValid() {
+		return r.RemoteAddr
+	}
+	if !isTrusted(remote, trustedProxies) {
+		return remote.String()
+	}
+
+	for _, header := range []string{"CF-Connecting-IP", "X-Real-IP"} {
+		if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(header))); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = addr.Unmap()
+		if i == 0 || !isTrusted(addr, trustedProxies) {
+			return addr.String()
+		}
+	}
+	return remote.String()
+}
+
+// ClientIPFromContext retrieves the client IP set by the logging middleware, or "" if there is none.
+func ClientIPFromContext(ctx context.Context) string {
+	if ip, found := ctx.Value(clientIPContextKey).(string); found {
+		return ip
+	}
+	return ""
+}
+
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
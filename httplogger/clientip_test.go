@@ -0,0 +1,43 @@
+package httplogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8", "192.168.1.1")
+	require.NoError(t, err)
+
+	_, err = ParseTrustedProxies("not-an-ip")
+	require.Error(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct", "1.2.3.4:1234", nil, "1.2.3.4"},
+		{"untrusted remote ignores headers", "1.2.3.4:1234", map[string]string{"X-Forwarded-For": "5.6.7.8"}, "1.2.3.4"},
+		{"trusted cloudflare", "10.0.0.1:1234", map[string]string{"CF-Connecting-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"trusted x-real-ip", "192.168.1.1:1234", map[string]string{"X-Real-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"xff skips trusted hops", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 5.6.7.8, 10.0.0.2"}, "5.6.7.8"},
+		{"xff all trusted", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"xff invalid", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "garbage"}, "10.0.0.1"},
+		{"ipv6", "[::1]:1234", nil, "::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			require.Equal(t, tt.want, ClientIP(r, trusted))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package httplogger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogEntry describes a finished request, it is turned into a log line by a Formatter.
+type AccessLogEntry struct {
+	Request       *http.Request
+	Start         time.Time
+	Duration      time.Duration
+	Status        int
+	ResponseBytes int64
+	RequestID     string
+	ClientIP      string
+
+	// Trace is the trace context of the request, if any
+	Trace *TraceContext
+
+	// RPCMethod and RPCID are only set if Options.LogJSONRPC is enabled and the request is a JSON-RPC request
+	RPCMethod string
+	RPCID     string
+
+	// RequestBody and ResponseBody are only set if body logging is enabled for the route, they are truncated
+	RequestBody  []byte
+	ResponseBody []byte
+
+	// Slow is set if the request took longer than Options.SlowRequestThreshold, Handler is the name of the handler
+	Slow    bool
+	Handler string
+}
+
+// Formatter turns an access log entry into the log message and key-value pairs.
+type Formatter func(e *AccessLogEntry) (msg string, args []any)
+
+// DefaultFormat is the default access log format of this package.
+func DefaultFormat(e *AccessLogEntry) (string, []any) {
+	r := e.Request
+
+	// Passing request stats both in-message (for the human reader)
+	// as well as inside the structured log (for the machine parser)
+	args := []any{
+		"status", e.Status,
+		"method", r.Method,
+		"path", r.URL.EscapedPath(),
+		"duration", fmt.Sprintf("%f", e.Duration.Seconds()),
+		"durationUs", e.Duration.Microseconds(),
+		"durationMs", e.Duration.Milliseconds(),
+		"responseBytes", e.ResponseBytes,
+		"httpRequestID", e.RequestID,
+		"clientIP", e.ClientIP,
+	}
+	if e.Trace != nil {
+		args = append(args, "trace_id", e.Trace.TraceID, "span_id", e.Trace.SpanID)
+	}
+	if e.RPCMethod != "" {
+		args = append(args, "rpcMethod", e.RPCMethod, "rpcID", e.RPCID)
+	}
+	if e.RequestBody != nil || e.ResponseBody != nil {
+		args = append(args, "requestBody", string(e.RequestBody), "responseBody", string(e.ResponseBody))
+	}
+	if e.Slow {
+		args = append(args,
+			"handler", e.Handler,
+			"query", r.URL.RawQuery,
+			"contentLength", r.ContentLength,
+		)
+	}
+	return fmt.Sprintf("http: %s %s %d", r.Method, r.URL.EscapedPath(), e.Status), args
+}
+
+// ECSFormat uses the field names of the Elastic Common Schema (https://www.elastic.co/guide/en/ecs/current/).
+func ECSFormat(e *AccessLogEntry) (string, []any) {
+	r := e.Request
+	args := []any{
+		"event.kind", "event",
+		"event.category", "web",
+		"event.start", e.Start.UTC().Format(time.RFC3339Nano),
+		"event.duration", e.Duration.Nanoseconds(),
+		"http.request.id", e.RequestID,
+		"http.request.method", r.Method,
+		"http.request.body.bytes", r.ContentLength,
+		"http.request.referrer", r.Referer(),
+		"http.response.status_code", e.Status,
+		"http.response.body.bytes", e.ResponseBytes,
+		"http.version", strings.TrimPrefix(r.Proto, "HTTP/"),
+		"url.path", r.URL.EscapedPath(),
+		"url.query", r.URL.RawQuery,
+		"client.ip", e.ClientIP,
+		"user_agent.original", r.UserAgent(),
+	}
+	if e.Trace != nil {
+		args = append(args, "trace.id", e.Trace.TraceID, "span.id", e.Trace.SpanID)
+	}
+	if e.RPCMethod != "" {
+		args = append(args, "rpc.method", e.RPCMethod, "rpc.id", e.RPCID)
+	}
+	if e.RequestBody != nil || e.ResponseBody != nil {
+		args = append(args,
+			"http.request.body.content", string(e.RequestBody),
+			"http.response.body.content", string(e.ResponseBody),
+		)
+	}
+	if e.Slow {
+		args = append(args, "event.action", "slow-request", "code.function", e.Handler)
+	}
+	return fmt.Sprintf("%s %s %d", r.Method, r.URL.EscapedPath(), e.Status), args
+}
+
+// CommonLogFormat formats the request as an Apache Common Log Format line, the message contains the whole line.
+func CommonLogFormat(e *AccessLogEntry) (string, []any) {
+	return commonLogLine(e), nil
+}
+
+// CombinedLogFormat formats the request as an Apache Combined Log Format line (Common Log Format with referer and
+// user agent), the message contains the whole line.
+func CombinedLogFormat(e *AccessLogEntry) (string, []any) {
+	r := e.Request
+	return fmt.Sprintf("%s %s %s", commonLogLine(e), strconv.Quote(r.Referer()), strconv.Quote(r.UserAgent())), nil
+}
+
+func commonLogLine(e *AccessLogEntry) string {
+	r := e.Request
+
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if username, _, ok := r.BasicAuth(); ok && username != "" {
+		user = username
+	}
+
+	size := "-"
+	if e.ResponseBytes > 0 {
+		size = strconv.FormatInt(e.ResponseBytes, 10)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %s %d %s",
+		e.ClientIP,
+		user,
+		e.Start.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), r.Proto)),
+		e.Status,
+		size,
+	)
+}
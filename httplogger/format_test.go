@@ -0,0 +1,48 @@
+package httplogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testAccessLogEntry() *AccessLogEntry {
+	r := httptest.NewRequest(http.MethodGet, "/foo?bar=1", nil)
+	r.Header.Set("Referer", "https://example.com/")
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.SetBasicAuth("alice", "secret")
+	return &AccessLogEntry{
+		Request:       r,
+		Start:         time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC),
+		Duration:      1500 * time.Microsecond,
+		Status:        http.StatusOK,
+		ResponseBytes: 42,
+		RequestID:     "abc",
+		ClientIP:      "1.2.3.4",
+	}
+}
+
+func TestCommonLogFormat(t *testing.T) {
+	msg, args := CommonLogFormat(testAccessLogEntry())
+	require.Nil(t, args)
+	require.Equal(t, `1.2.3.4 - alice [01/Jun/2024:12:30:00 +0000] "GET /foo?bar=1 HTTP/1.1" 200 42`, msg)
+
+	msg, _ = CombinedLogFormat(testAccessLogEntry())
+	require.Equal(t, `1.2.3.4 - alice [01/Jun/2024:12:30:00 +0000] "GET /foo?bar=1 HTTP/1.1" 200 42 "https://example.com/" "curl/8.0"`, msg)
+}
+
+func TestECSFormat(t *testing.T) {
+	_, args := ECSFormat(testAccessLogEntry())
+	fields := make(map[string]any)
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[args[i].(string)] = args[i+1]
+	}
+	require.Equal(t, http.StatusOK, fields["http.response.status_code"])
+	require.Equal(t, int64(1500000), fields["event.duration"])
+	require.Equal(t, "1.2.3.4", fields["client.ip"])
+	require.Equal(t, "abc", fields["http.request.id"])
+	require.Equal(t, "1.1", fields["http.version"])
+}
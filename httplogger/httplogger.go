@@ -2,26 +2,34 @@
 package httplogger
 
 import (
-	"encoding/base64"
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"runtime/debug"
 	"time"
 
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/flashbots/go-utils/logutils"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"go.uber.org/zap"
 )
 
 // responseWriter is a minimal wrapper for http.ResponseWriter that allows the
-// written HTTP status code to be captured for logging.
+// written HTTP status code and response size to be captured for logging.
+// http.Flusher, http.Hijacker and io.ReaderFrom are passed through to the wrapped writer, and it can be unwrapped by
+// http.ResponseController.
 type responseWriter struct {
 	http.ResponseWriter
-	status      int
-	wroteHeader bool
+	status       int
+	wroteHeader  bool
+	bytesWritten int64
+
+	// the first bodyLimit bytes of the response body are captured in body
+	bodyLimit int
+	body      []byte
 }
 
 func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
@@ -32,6 +40,21 @@ func (rw *responseWriter) Status() int {
 	return rw.status
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += int64(n)
+	if remaining := rw.bodyLimit - len(rw.body); remaining > 0 {
+		if remaining > n {
+			remaining = n
+		}
+		rw.body = append(rw.body, b[:remaining]...)
+	}
+	return n, err
+}
+
 func (rw *responseWriter) WriteHeader(code int) {
 	if rw.wroteHeader {
 		return
@@ -42,154 +65,179 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.wroteHeader = true
 }
 
-// LoggingMiddleware logs the incoming HTTP request & its duration.
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
+// Flush implements http.Flusher, it is a no-op if the wrapped writer doesn't support flushing.
+func (rw *responseWriter) Flush() {
+	flusher, ok := rw.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	flusher.Flush()
+}
 
-					method := ""
-					url := ""
-					if r != nil {
-						method = r.Method
-						url = r.URL.EscapedPath()
-					}
+// Hijack implements http.Hijacker, it returns http.ErrNotSupported if the wrapped writer can't be hijacked.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err == nil && !rw.wroteHeader {
+		// the handler writes the response itself, usually to upgrade the connection (e.g. websockets)
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, brw, err
+}
 
-					log.Error(fmt.Sprintf("http request panic: %s %s", method, url),
-						"err", err,
-						"trace", string(debug.Stack()),
-					)
-				}
-			}()
-			start := time.Now()
-			wrapped := wrapResponseWriter(w)
-			next.ServeHTTP(wrapped, r)
-			log.Info(fmt.Sprintf("http: %s %s %d", r.Method, r.URL.EscapedPath(), wrapped.status),
-				"status", wrapped.status,
-				"method", r.Method,
-				"path", r.URL.EscapedPath(),
-				"duration", fmt.Sprintf("%f", time.Since(start).Seconds()),
-			)
-		},
-	)
+// ReadFrom implements io.ReaderFrom, so that e.g. sendfile can be used by the wrapped writer.
+func (rw *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if rw.bodyLimit > 0 {
+		// go through Write, which captures the body
+		return io.Copy(writerOnly{rw}, src)
+	}
+
+	var n int64
+	var err error
+	if readerFrom, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{rw.ResponseWriter}, src)
+	}
+	rw.bytesWritten += n
+	return n, err
 }
 
-// LoggingMiddlewareSlog logs the incoming HTTP request & its duration.
-func LoggingMiddlewareSlog(logger *slog.Logger, next http.Handler) http.Handler {
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// writerOnly hides any io.ReaderFrom implementation of the writer, to prevent io.Copy from recursing.
+type writerOnly struct {
+	io.Writer
+}
+
+// Middleware logs the incoming HTTP request & its duration with the given logger. It is the core of all the
+// LoggingMiddleware* variants, which are thin wrappers adapting a specific logging library.
+//
+// Every request gets an ID (the incoming X-Request-ID header if present, a random one otherwise), which is logged
+// as httpRequestID, echoed in the X-Request-ID response header and available via RequestIDFromContext. Likewise the
+// client IP (see ClientIP) is logged as clientIP and available via ClientIPFromContext. If the request has a trace
+// context (traceparent header or a span, see Options.SpanFromContext), it is logged as trace_id and span_id.
+func Middleware(logger Logger, next http.Handler, opts Options) http.Handler {
+	o := opts.prepare(next)
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
+			start := time.Now()
+			httpRequestID := requestID(r)
+			clientIP := ClientIP(r, o.TrustedProxies)
+			ctx := ContextWithRequestID(r.Context(), httpRequestID)
+			r = r.WithContext(context.WithValue(ctx, clientIPContextKey, clientIP))
+			w.Header().Set(RequestIDHeader, httpRequestID)
+
+			wrapped := wrapResponseWriter(w)
+
+			var rpcMethod, rpcID string
+			if o.LogJSONRPC {
+				rpcMethod, rpcID = peekJSONRPC(r, o.JSONRPCPeekBytes)
+			}
 
-					method := ""
-					url := ""
-					if r != nil {
-						method = r.Method
-						url = r.URL.EscapedPath()
-					}
+			if o.StartSpan != nil {
+				var endSpan func(status int)
+				r, endSpan = o.StartSpan(r)
+				defer func() { endSpan(wrapped.status) }()
+			}
+			var trace *TraceContext
+			if tc, ok := o.traceContext(r); ok {
+				trace = &tc
+				r = r.WithContext(ContextWithTraceContext(r.Context(), tc))
+			}
 
-					logger.Error(fmt.Sprintf("http request panic: %s %s", method, url),
+			route := o.route(r)
+			var requestBody []byte
+			if route != nil && route.LogBody {
+				requestBody, _ = peekBody(r, route.maxBodyBytes())
+				wrapped.bodyLimit = route.maxBodyBytes()
+			}
+			defer func() {
+				if err := recover(); err != nil {
+					wrapped.WriteHeader(http.StatusInternalServerError)
+					logger.Log(r.Context(), slog.LevelError, fmt.Sprintf("http request panic: %s %s", r.Method, r.URL.EscapedPath()),
 						"err", err,
+						"httpRequestID", httpRequestID,
+						"clientIP", clientIP,
 						"trace", string(debug.Stack()),
 						"method", r.Method,
+						"path", r.URL.EscapedPath(),
 					)
 				}
 			}()
-			start := time.Now()
-			wrapped := wrapResponseWriter(w)
+
 			next.ServeHTTP(wrapped, r)
-			logger.Info(fmt.Sprintf("http: %s %s %d", r.Method, r.URL.EscapedPath(), wrapped.status),
-				"status", wrapped.status,
-				"method", r.Method,
-				"path", r.URL.EscapedPath(),
-				"duration", fmt.Sprintf("%f", time.Since(start).Seconds()),
-				"durationUs", fmt.Sprint(time.Since(start).Microseconds()),
-			)
+
+			duration := time.Since(start)
+			if o.skip(r, wrapped.status) || !o.shouldLog(wrapped.status, duration) {
+				return
+			}
+
+			msg, fields := o.format(&AccessLogEntry{
+				Request:       r,
+				Start:         start,
+				Duration:      duration,
+				Status:        wrapped.status,
+				ResponseBytes: wrapped.bytesWritten,
+				RequestID:     httpRequestID,
+				ClientIP:      clientIP,
+				Trace:         trace,
+				RPCMethod:     rpcMethod,
+				RPCID:         rpcID,
+				RequestBody:   requestBody,
+				ResponseBody:  wrapped.body,
+				Slow:          o.isSlow(duration),
+				Handler:       o.handlerName,
+			})
+			if route != nil {
+				fields = append(fields, route.Fields...)
+			}
+			logger.Log(r.Context(), o.level(route, wrapped.status, duration), msg, fields...)
 		},
 	)
 }
 
+// LoggingMiddleware logs the incoming HTTP request & its duration using the go-ethereum root logger.
+func LoggingMiddleware(next http.Handler, opts ...Option) http.Handler {
+	return Middleware(GethLogger(nil), next, applyOptions(opts))
+}
+
+// LoggingMiddlewareSlog logs the incoming HTTP request & its duration.
+func LoggingMiddlewareSlog(logger *slog.Logger, next http.Handler, opts ...Option) http.Handler {
+	return Middleware(logger, next, applyOptions(opts))
+}
+
 // LoggingMiddlewareLogrus logs the incoming HTTP request & its duration.
-func LoggingMiddlewareLogrus(logger *logrus.Entry, next http.Handler) http.Handler {
-	return http.HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-
-					method := ""
-					url := ""
-					if r != nil {
-						method = r.Method
-						url = r.URL.EscapedPath()
-					}
-
-					logger.WithFields(logrus.Fields{
-						"err":    err,
-						"trace":  string(debug.Stack()),
-						"method": r.Method,
-					}).Error(fmt.Sprintf("http request panic: %s %s", method, url))
-				}
-			}()
-			start := time.Now()
-			wrapped := wrapResponseWriter(w)
-			next.ServeHTTP(wrapped, r)
-			logger.WithFields(logrus.Fields{
-				"status":   wrapped.status,
-				"method":   r.Method,
-				"path":     r.URL.EscapedPath(),
-				"duration": fmt.Sprintf("%f", time.Since(start).Seconds()),
-			}).Info(fmt.Sprintf("http: %s %s %d", r.Method, r.URL.EscapedPath(), wrapped.status))
-		},
-	)
+func LoggingMiddlewareLogrus(logger *logrus.Entry, next http.Handler, opts ...Option) http.Handler {
+	return Middleware(LogrusLogger(logger), next, applyOptions(opts))
 }
 
-// LoggingMiddlewareZap logs the incoming HTTP request & its duration.
-func LoggingMiddlewareZap(logger *zap.Logger, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Generate request ID (`base64` to shorten its string representation)
-		_uuid := [16]byte(uuid.New())
-		httpRequestID := base64.RawStdEncoding.EncodeToString(_uuid[:])
-
-		l := logger.With(
-			zap.String("httpRequestID", httpRequestID),
-			zap.String("logType", "activity"),
-		)
-		r = logutils.RequestWithZap(r, l)
-
-		// Handle panics
-		defer func() {
-			if msg := recover(); msg != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				var method, url string
-				if r != nil {
-					method = r.Method
-					url = r.URL.EscapedPath()
-				}
-				l.Error("HTTP request handler panicked",
-					zap.Any("error", msg),
-					zap.String("method", method),
-					zap.String("url", url),
-				)
-			}
-		}()
-
-		start := time.Now()
-		wrapped := wrapResponseWriter(w)
-		next.ServeHTTP(w, r)
-
-		// Passing request stats both in-message (for the human reader)
-		// as well as inside the structured log (for the machine parser)
-		logger.Info(fmt.Sprintf("%s: %s %s %d", r.URL.Scheme, r.Method, r.URL.EscapedPath(), wrapped.status),
-			zap.Int("durationMs", int(time.Since(start).Milliseconds())),
-			zap.Int("status", wrapped.status),
-			zap.String("httpRequestID", httpRequestID),
-			zap.String("logType", "access"),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.EscapedPath()),
-		)
-	})
+// LoggingMiddlewareZap logs the incoming HTTP request & its duration. A logger carrying the request ID is attached
+// to the request context, see logutils.ZapFromRequest.
+func LoggingMiddlewareZap(logger *zap.Logger, next http.Handler, opts ...Option) http.Handler {
+	o := applyOptions(opts)
+	o.handlerName = handlerName(next)
+	return Middleware(ZapLogger(logger.With(zap.String("logType", "access"))), http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			l := logger.With(
+				zap.String("httpRequestID", RequestIDFromRequest(r)),
+				zap.String("logType", "activity"),
+			)
+			next.ServeHTTP(w, logutils.RequestWithZap(r, l))
+		},
+	), o)
 }
@@ -0,0 +1,214 @@
+package httplogger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/logutils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type logEntry struct {
+	level slog.Level
+	msg   string
+	args  map[string]any
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) Log(_ context.Context, level slog.Level, msg string, args ...any) {
+	e := logEntry{level: level, msg: msg, args: make(map[string]any)}
+	for i := 0; i+1 < len(args); i += 2 {
+		e.args[args[i].(string)] = args[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+}
+
+func (l *testLogger) Entries() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), l.entries...)
+}
+
+func serve(h http.Handler, path string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	t.Run("implicit status", func(t *testing.T) {
+		l := &testLogger{}
+		serve(Middleware(l, ok, Options{}), "/foo")
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelInfo, l.entries[0].level)
+		require.Equal(t, "http: GET /foo 200", l.entries[0].msg)
+		require.Equal(t, http.StatusOK, l.entries[0].args["status"])
+		require.Equal(t, int64(2), l.entries[0].args["responseBytes"])
+	})
+
+	t.Run("skip paths", func(t *testing.T) {
+		l := &testLogger{}
+		h := Middleware(l, ok, applyOptions([]Option{WithSkipPaths("/livez")}))
+		serve(h, "/livez")
+		serve(h, "/foo")
+		require.Len(t, l.entries, 1)
+		require.Equal(t, "/foo", l.entries[0].args["path"])
+	})
+
+	t.Run("quiet paths", func(t *testing.T) {
+		l := &testLogger{}
+		serve(Middleware(l, ok, Options{QuietPaths: HealthCheckPaths}), "/livez")
+		require.Empty(t, l.entries)
+		serve(Middleware(l, notFound, Options{QuietPaths: HealthCheckPaths}), "/readyz")
+		require.Len(t, l.entries, 1)
+	})
+
+	t.Run("level by status", func(t *testing.T) {
+		l := &testLogger{}
+		serve(Middleware(l, notFound, Options{LevelForStatus: StatusLevel}), "/foo")
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelWarn, l.entries[0].level)
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		l := &testLogger{}
+		h := Middleware(l, ok, applyOptions([]Option{WithSampling(0.5, 0)}))
+		for i := 0; i < 1000; i++ {
+			serve(h, "/foo")
+		}
+		require.InDelta(t, 500, len(l.entries), 150)
+
+		// failed requests are never sampled out
+		l = &testLogger{}
+		h = Middleware(l, notFound, applyOptions([]Option{WithSampling(0.001, 0)}))
+		for i := 0; i < 100; i++ {
+			serve(h, "/foo")
+		}
+		require.Len(t, l.entries, 100)
+
+		// nor are slow requests
+		l = &testLogger{}
+		h = Middleware(l, ok, applyOptions([]Option{WithSampling(0.001, time.Nanosecond)}))
+		for i := 0; i < 100; i++ {
+			serve(h, "/foo")
+		}
+		require.Len(t, l.entries, 100)
+		l = &testLogger{}
+		h = Middleware(l, ok, applyOptions([]Option{WithSampling(0.001, 0), WithSlowRequestThreshold(time.Nanosecond)}))
+		for i := 0; i < 100; i++ {
+			serve(h, "/foo")
+		}
+		require.Len(t, l.entries, 100)
+	})
+
+	t.Run("slow request", func(t *testing.T) {
+		l := &testLogger{}
+		h := Middleware(l, ok, applyOptions([]Option{WithSlowRequestThreshold(time.Nanosecond)}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/foo?a=1", strings.NewReader("abc")))
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelWarn, l.entries[0].level)
+		require.Contains(t, l.entries[0].args["handler"], "TestMiddleware")
+		require.Equal(t, "a=1", l.entries[0].args["query"])
+		require.Equal(t, int64(3), l.entries[0].args["contentLength"])
+
+		// higher levels are kept
+		l = &testLogger{}
+		serve(Middleware(l, notFound, applyOptions([]Option{WithSlowRequestThreshold(time.Nanosecond), WithLevelForStatus(func(int) slog.Level {
+			return slog.LevelError
+		})})), "/foo")
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelError, l.entries[0].level)
+
+		l = &testLogger{}
+		serve(Middleware(l, ok, applyOptions([]Option{WithSlowRequestThreshold(time.Hour)})), "/foo")
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelInfo, l.entries[0].level)
+		require.NotContains(t, l.entries[0].args, "handler")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		l := &testLogger{}
+		rr := serve(Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}), Options{}), "/foo")
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Len(t, l.entries, 1)
+		require.Equal(t, slog.LevelError, l.entries[0].level)
+		require.Equal(t, "boom", l.entries[0].args["err"])
+	})
+}
+
+func TestLoggingMiddlewareZap(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	var activity *zap.Logger
+	h := LoggingMiddlewareZap(zap.New(core), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		activity = logutils.ZapFromRequest(r)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rr := serve(h, "/foo")
+	require.Equal(t, http.StatusTeapot, rr.Code)
+	require.NotNil(t, activity)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	require.Equal(t, int64(http.StatusTeapot), fields["status"])
+	require.Equal(t, "access", fields["logType"])
+	require.NotEmpty(t, fields["httpRequestID"])
+}
+
+func TestLoggingMiddlewareSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	serve(LoggingMiddlewareSlog(logger, http.NotFoundHandler()), "/foo")
+	require.Contains(t, buf.String(), `"status":404`)
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := Middleware(&testLogger{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromRequest(r)
+	}), Options{})
+
+	rr := serve(h, "/foo")
+	require.NotEmpty(t, seen)
+	require.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+
+	// valid incoming IDs are honored
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	require.Equal(t, "abc-123", seen)
+	require.Equal(t, "abc-123", rr.Header().Get(RequestIDHeader))
+
+	// invalid ones are replaced
+	req.Header.Set(RequestIDHeader, "abc\n123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotEqual(t, "abc\n123", seen)
+	require.NotEmpty(t, seen)
+}
@@ -0,0 +1,82 @@
+package httplogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// defaultJSONRPCPeekBytes is enough for the method and id, which are usually sent before the params
+const defaultJSONRPCPeekBytes = 4096
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// peekJSONRPC reads up to limit bytes of a JSON-RPC request body and extracts the method and id from them. The
+// request body is restored, so the handler sees the complete body. For batch requests the method is "batch" and
+// the id is empty. Returns empty strings if the request isn't a JSON-RPC request or the fields could not be found
+// within the limit.
+func peekJSONRPC(r *http.Request, limit int) (method, id string) {
+	if r.Method != http.MethodPost || r.Body == nil || r.Body == http.NoBody {
+		return "", ""
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return "", ""
+	}
+	if limit <= 0 {
+		limit = defaultJSONRPCPeekBytes
+	}
+
+	peeked, err := peekBody(r, limit)
+	if err != nil {
+		return "", ""
+	}
+	return parseJSONRPC(peeked)
+}
+
+// peekBody reads up to limit bytes of the request body and restores it, so the handler sees the complete body.
+func peekBody(r *http.Request, limit int) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	peeked, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(peeked), r.Body), r.Body}
+	return peeked, err
+}
+
+// parseJSONRPC extracts the method and id from a (possibly truncated) JSON-RPC request.
+func parseJSONRPC(data []byte) (method, id string) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return "", ""
+	}
+	if tok == json.Delim('[') {
+		return "batch", ""
+	}
+	if tok != json.Delim('{') {
+		return "", ""
+	}
+
+	for dec.More() && (method == "" || id == "") {
+		key, err := dec.Token()
+		if err != nil {
+			return method, id
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return method, id
+		}
+		switch key {
+		case "method":
+			_ = json.Unmarshal(value, &method)
+		case "id":
+			id = string(value)
+		}
+	}
+	return method, id
+}
@@ -0,0 +1,49 @@
+package httplogger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONRPC(t *testing.T) {
+	tests := []struct {
+		body   string
+		method string
+		id     string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[]}`, "eth_sendBundle", "1"},
+		{`{"method":"eth_call","params":[{"to":"0x00"}],"id":"abc"}`, "eth_call", `"abc"`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[{"txs":["0x`, "eth_sendBundle", "1"},
+		{`[{"method":"eth_call"},{"method":"eth_chainId"}]`, "batch", ""},
+		{`not json`, "", ""},
+	}
+	for _, tt := range tests {
+		method, id := parseJSONRPC([]byte(tt.body))
+		require.Equal(t, tt.method, method, tt.body)
+		require.Equal(t, tt.id, id, tt.body)
+	}
+}
+
+func TestMiddlewareJSONRPC(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":7,"method":"eth_sendBundle","params":["` + strings.Repeat("a", 100) + `"]}`
+	var received string
+	l := &testLogger{}
+	h := Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+	}), Options{LogJSONRPC: true, JSONRPCPeekBytes: 64})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, body, received)
+	require.Len(t, l.entries, 1)
+	require.Equal(t, "eth_sendBundle", l.entries[0].args["rpcMethod"])
+	require.Equal(t, "7", l.entries[0].args["rpcID"])
+}
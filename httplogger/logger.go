@@ -0,0 +1,103 @@
+package httplogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is the minimal interface the middleware needs from a logging library. Args are key-value pairs, like in
+// slog. *slog.Logger implements it directly, use GethLogger, LogrusLogger or ZapLogger for other libraries.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// GethLogger adapts a go-ethereum logger. If logger is nil, the root logger is used.
+func GethLogger(logger log.Logger) Logger {
+	return gethLogger{logger}
+}
+
+type gethLogger struct {
+	logger log.Logger
+}
+
+func (l gethLogger) Log(_ context.Context, level slog.Level, msg string, args ...any) {
+	logger := l.logger
+	if logger == nil {
+		logger = log.Root()
+	}
+
+	switch {
+	case level >= slog.LevelError:
+		logger.Error(msg, args...)
+	case level >= slog.LevelWarn:
+		logger.Warn(msg, args...)
+	case level >= slog.LevelInfo:
+		logger.Info(msg, args...)
+	default:
+		logger.Debug(msg, args...)
+	}
+}
+
+// LogrusLogger adapts a logrus logger.
+func LogrusLogger(logger *logrus.Entry) Logger {
+	return logrusLogger{logger}
+}
+
+type logrusLogger struct {
+	logger *logrus.Entry
+}
+
+func (l logrusLogger) Log(_ context.Context, level slog.Level, msg string, args ...any) {
+	fields := make(logrus.Fields, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[fmt.Sprint(args[i])] = args[i+1]
+	}
+
+	var logrusLevel logrus.Level
+	switch {
+	case level >= slog.LevelError:
+		logrusLevel = logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		logrusLevel = logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		logrusLevel = logrus.InfoLevel
+	default:
+		logrusLevel = logrus.DebugLevel
+	}
+	l.logger.WithFields(fields).Log(logrusLevel, msg)
+}
+
+// ZapLogger adapts a zap logger.
+func ZapLogger(logger *zap.Logger) Logger {
+	return zapLogger{logger}
+}
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+func (l zapLogger) Log(_ context.Context, level slog.Level, msg string, args ...any) {
+	fields := make([]zap.Field, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		fields = append(fields, zap.Any(fmt.Sprint(args[i]), args[i+1]))
+	}
+
+	var zapLevel zapcore.Level
+	switch {
+	case level >= slog.LevelError:
+		zapLevel = zap.ErrorLevel
+	case level >= slog.LevelWarn:
+		zapLevel = zap.WarnLevel
+	case level >= slog.LevelInfo:
+		zapLevel = zap.InfoLevel
+	default:
+		zapLevel = zap.DebugLevel
+	}
+	l.logger.Log(zapLevel, msg, fields...)
+}
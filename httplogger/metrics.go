@@ -0,0 +1,138 @@
+package httplogger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// we use unknown label for methods that are not standard HTTP methods because otherwise
+	// users can create arbitrary number of metrics
+	unknownMethodLabel = "unknown"
+	// paths of unmatched requests (404), of the requests to other paths than the routes and paths that can't be used
+	// as a label value are replaced with unknown label too, so that arbitrary paths don't create new time series
+	unknownPathLabel = "unknown"
+
+	// incremented when request comes in
+	httpRequestCountLabel = `goutils_http_request_count{method="%s",path="%s",status="%s",server_name="%s"}`
+	// total duration of the request
+	httpRequestDurationLabel = `goutils_http_request_duration_milliseconds{method="%s",path="%s",status="%s",server_name="%s"}`
+	// size of the response body
+	httpResponseSizeLabel = `goutils_http_response_size_bytes{method="%s",path="%s",status="%s",server_name="%s"}`
+)
+
+var (
+	knownMethods = map[string]struct{}{
+		http.MethodGet: {}, http.MethodHead: {}, http.MethodPost: {}, http.MethodPut: {}, http.MethodPatch: {},
+		http.MethodDelete: {}, http.MethodConnect: {}, http.MethodOptions: {}, http.MethodTrace: {},
+	}
+
+	// path segments that look like identifiers: numbers, hex strings and UUIDs
+	idSegmentRegexp = regexp.MustCompile(`^([0-9]+|(0x)?[0-9a-fA-F]{16,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+)
+
+// MetricsOptions configures MetricsMiddleware.
+type MetricsOptions struct {
+	// ServerName is used as the server_name label
+	ServerName string
+
+	// Routes are the normalized paths (see NormalizePath) used as the path label, the other requests are labeled
+	// "unknown". E.g. "/v1/blocks/:id" for the requests to "/v1/blocks/123". Not used if NormalizePath is set.
+	Routes []string
+
+	// NormalizePath maps the request to the path label, or to "" for the "unknown" label. It must return a small,
+	// bounded set of values, as every distinct value creates new time series. It's not called for the requests
+	// answered with 404, labeled "unknown".
+	NormalizePath func(r *http.Request) string
+}
+
+// MetricsMiddleware records the request count, the request duration and the response size labeled by method,
+// normalized path and status class (2xx, 4xx, ...). Metrics are registered in the default VictoriaMetrics set.
+func MetricsMiddleware(next http.Handler, opts MetricsOptions) http.Handler {
+	normalizePath := opts.NormalizePath
+	if normalizePath == nil {
+		routes := make(map[string]struct{}, len(opts.Routes))
+		for _, route := range opts.Routes {
+			routes[route] = struct{}{}
+		}
+		normalizePath = func(r *http.Request) string {
+			path := NormalizePath(r)
+			if _, found := routes[path]; !found {
+				return ""
+			}
+			return path
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := wrapResponseWriter(w)
+		defer func() {
+			status := wrapped.status
+			if status == 0 {
+				// net/http responds with 200 if the handler doesn't write anything
+				status = http.StatusOK
+			}
+			if err := recover(); err != nil {
+				status = http.StatusInternalServerError
+				defer panic(err)
+			}
+
+			method := r.Method
+			if _, found := knownMethods[method]; !found {
+				method = unknownMethodLabel
+			}
+			path := unknownPathLabel
+			if status != http.StatusNotFound {
+				path = normalizePath(r)
+				if path == "" || !validLabelValue(path) {
+					path = unknownPathLabel
+				}
+			}
+			class := statusClass(status)
+
+			metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, method, path, class, opts.ServerName)).Inc()
+			metrics.GetOrCreateHistogram(fmt.Sprintf(httpRequestDurationLabel, method, path, class, opts.ServerName)).
+				Update(float64(time.Since(start).Milliseconds()))
+			metrics.GetOrCreateHistogram(fmt.Sprintf(httpResponseSizeLabel, method, path, class, opts.ServerName)).
+				Update(float64(wrapped.bytesWritten))
+		}()
+
+		next.ServeHTTP(wrapped, r)
+	})
+}
+
+// NormalizePath replaces path segments that look like identifiers (numbers, hex strings, UUIDs) with ":id", e.g.
+// "/blocks/123" becomes "/blocks/:id".
+func NormalizePath(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if idSegmentRegexp.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// validLabelValue reports whether the value can be put into the metric name without escaping
+func validLabelValue(value string) bool {
+	for _, c := range value {
+		if c == '"' || c == '\\' || !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
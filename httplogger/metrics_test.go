@@ -0,0 +1,97 @@
+package httplogger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/":                     "/",
+		"/v1/blocks/123":        "/v1/blocks/:id",
+		"/v1/blocks/123/header": "/v1/blocks/:id/header",
+		"/tx/0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925": "/tx/:id",
+		"/bundles/f81d4fae-7dec-11d0-a765-00a0c91e6bf6":                          "/bundles/:id",
+		"/livez": "/livez",
+	} {
+		require.Equal(t, want, NormalizePath(httptest.NewRequest(http.MethodGet, path, nil)), path)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	h := MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}), MetricsOptions{ServerName: "test", Routes: []string{"/blocks/:id"}})
+
+	counter := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "/blocks/:id", "2xx", "test"))
+	size := metrics.GetOrCreateHistogram(fmt.Sprintf(httpResponseSizeLabel, "GET", "/blocks/:id", "2xx", "test"))
+	countBefore := counter.Get()
+	var sizeBefore float64
+	size.VisitNonZeroBuckets(func(_ string, count uint64) { sizeBefore += float64(count) })
+
+	serve(h, "/blocks/1")
+	serve(h, "/blocks/2")
+
+	require.Equal(t, countBefore+2, counter.Get())
+	var sizeAfter float64
+	size.VisitNonZeroBuckets(func(_ string, count uint64) { sizeAfter += float64(count) })
+	require.Equal(t, sizeBefore+2, sizeAfter)
+}
+
+func TestMetricsMiddleware_UnknownPath(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			// catch-all handlers answer arbitrary paths with other statuses than 404
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	h := MetricsMiddleware(mux, MetricsOptions{ServerName: "test_unknown_path", Routes: []string{"/", "/blocks/:id"}})
+
+	unknown := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "unknown", "4xx", "test_unknown_path"))
+	unknownOK := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "unknown", "2xx", "test_unknown_path"))
+	// the handler never writes, net/http responds with 200
+	blocks := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "/blocks/:id", "2xx", "test_unknown_path"))
+	root := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "/", "2xx", "test_unknown_path"))
+
+	serve(h, "/random/path")
+	serve(h, "/foo%22bar")
+	serve(h, `/blocks/foo%22bar%5C`)
+	serve(h, "/blocks/latest")
+	serve(h, "/blocks/1")
+	serve(h, "/")
+
+	require.Equal(t, uint64(2), unknown.Get())
+	require.Equal(t, uint64(2), unknownOK.Get())
+	require.Equal(t, uint64(1), blocks.Get())
+	require.Equal(t, uint64(1), root.Get())
+}
+
+func TestMetricsMiddleware_NormalizePath(t *testing.T) {
+	h := MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), MetricsOptions{
+		ServerName: "test_normalize_path",
+		NormalizePath: func(r *http.Request) string {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				return "/api"
+			}
+			return ""
+		},
+	})
+
+	api := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "/api", "2xx", "test_normalize_path"))
+	unknown := metrics.GetOrCreateCounter(fmt.Sprintf(httpRequestCountLabel, "GET", "unknown", "2xx", "test_normalize_path"))
+
+	serve(h, "/api/v1")
+	serve(h, "/api/v2")
+	serve(h, "/other")
+
+	require.Equal(t, uint64(2), api.Get())
+	require.Equal(t, uint64(1), unknown.Get())
+}
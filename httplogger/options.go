@@ -0,0 +1,241 @@
+package httplogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// Options configures the logging middleware. The zero value logs every request at Info level.
+type Options struct {
+	// SkipPaths are request paths (exact match) that are never logged, except for panics
+	SkipPaths []string
+	// QuietPaths are request paths (exact match) that are only logged if the request failed (status code >= 400),
+	// e.g. health checks and metrics endpoints that otherwise dominate the log volume
+	QuietPaths []string
+
+	// SampleRate is the fraction (between 0 and 1) of successful requests that are logged. Failed requests
+	// (status code >= 400, panics) are always logged. 0 disables sampling, i.e. every request is logged.
+	SampleRate float64
+	// SampleAlwaysAbove makes requests that took longer than this always logged, regardless of sampling
+	SampleAlwaysAbove time.Duration
+
+	// SlowRequestThreshold makes requests that took longer than this logged at (at least) Warn level, with
+	// additional fields describing the request (handler, query, contentLength). Slow requests are never sampled out.
+	SlowRequestThreshold time.Duration
+
+	// LevelForStatus maps the response status code to the level of the access log line. If nil, Info is used
+	// for all requests. See StatusLevel for a mapping that escalates failed requests.
+	LevelForStatus func(status int) slog.Level
+
+	// LogJSONRPC makes the middleware peek at the body of application/json POST requests to log the JSON-RPC
+	// method and id (rpcMethod, rpcID fields). At most JSONRPCPeekBytes (4096 by default) are read ahead.
+	LogJSONRPC       bool
+	JSONRPCPeekBytes int
+
+	// Formatter turns the finished request into the log message and fields. If nil, DefaultFormat is used. See also
+	// ECSFormat, CommonLogFormat and CombinedLogFormat.
+	Formatter Formatter
+
+	// Routes override the options above for requests with matching path prefixes, see RouteOptions
+	Routes []RouteOptions
+
+	// StartSpan optionally starts a server span for the request (e.g. with OpenTelemetry). It returns the request
+	// carrying the span in its context and a function that ends the span, which is called with the response status.
+	StartSpan func(r *http.Request) (*http.Request, func(status int))
+	// SpanFromContext returns the trace context of the current span (e.g. from OpenTelemetry). If it returns false,
+	// or is nil, the incoming traceparent header is used. The trace context is logged as trace_id and span_id.
+	SpanFromContext func(ctx context.Context) (TraceContext, bool)
+
+	// TrustedProxies are the addresses of reverse proxies / load balancers whose client IP headers are trusted,
+	// see ClientIP. If empty, the remote address of the connection is logged as clientIP.
+	TrustedProxies []netip.Prefix
+
+	handlerName string
+	skipPaths   map[string]struct{}
+	quietPaths  map[string]struct{}
+}
+
+// HealthCheckPaths are the usual Kubernetes probe and metrics endpoints, to be used with WithSkipPaths or
+// WithQuietPaths.
+var HealthCheckPaths = []string{"/livez", "/readyz", "/healthz", "/metrics"}
+
+// Option allows to fine-tune the behaviour of the logging middleware.
+type Option = func(*Options)
+
+// WithSampling makes the middleware log only a fraction (rate, between 0 and 1) of successful requests. Failed
+// requests (status code >= 400, panics) are always logged, and so are requests that took longer than alwaysAbove
+// (0 disables this). Without this option every request is logged.
+func WithSampling(rate float64, alwaysAbove time.Duration) Option {
+	return func(o *Options) {
+		o.SampleRate = rate
+		o.SampleAlwaysAbove = alwaysAbove
+	}
+}
+
+// WithSlowRequestThreshold makes the middleware log requests that took longer than threshold at Warn level, with
+// additional fields describing the request (handler, query, contentLength). Slow requests are never sampled out.
+func WithSlowRequestThreshold(threshold time.Duration) Option {
+	return func(o *Options) {
+		o.SlowRequestThreshold = threshold
+	}
+}
+
+// WithSkipPaths makes the middleware skip logging for the given request paths.
+func WithSkipPaths(paths ...string) Option {
+	return func(o *Options) {
+		o.SkipPaths = append(o.SkipPaths, paths...)
+	}
+}
+
+// WithQuietPaths makes the middleware log requests to the given paths only if they failed (status code >= 400).
+func WithQuietPaths(paths ...string) Option {
+	return func(o *Options) {
+		o.QuietPaths = append(o.QuietPaths, paths...)
+	}
+}
+
+// WithLevelForStatus sets the function mapping the response status code to the log level.
+func WithLevelForStatus(fn func(status int) slog.Level) Option {
+	return func(o *Options) {
+		o.LevelForStatus = fn
+	}
+}
+
+// WithJSONRPC makes the middleware log the JSON-RPC method and id of requests.
+func WithJSONRPC() Option {
+	return func(o *Options) {
+		o.LogJSONRPC = true
+	}
+}
+
+// WithFormatter sets the access log format.
+func WithFormatter(formatter Formatter) Option {
+	return func(o *Options) {
+		o.Formatter = formatter
+	}
+}
+
+// WithTracing integrates a tracing library, see Options.StartSpan and Options.SpanFromContext. Either may be nil.
+func WithTracing(startSpan func(r *http.Request) (*http.Request, func(status int)), spanFromContext func(ctx context.Context) (TraceContext, bool)) Option {
+	return func(o *Options) {
+		o.StartSpan = startSpan
+		o.SpanFromContext = spanFromContext
+	}
+}
+
+// WithTrustedProxies sets the proxies whose client IP headers are trusted, see ParseTrustedProxies.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(o *Options) {
+		o.TrustedProxies = append(o.TrustedProxies, prefixes...)
+	}
+}
+
+// StatusLevel logs server errors (5xx) at Error level, client errors (4xx) at Warn level and everything else at
+// Info level.
+func StatusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func applyOptions(opts []Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// prepare returns a copy of the options with the derived fields filled in.
+func (o Options) prepare(next http.Handler) *Options {
+	if o.handlerName == "" {
+		o.handlerName = handlerName(next)
+	}
+	o.skipPaths = pathSet(o.SkipPaths)
+	o.quietPaths = pathSet(o.QuietPaths)
+	o.Routes = sortRoutes(o.Routes)
+	return &o
+}
+
+func pathSet(paths []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		set[path] = struct{}{}
+	}
+	return set
+}
+
+// skip decides whether the access log line for a finished request is omitted because of its path.
+func (o *Options) skip(r *http.Request, status int) bool {
+	if _, found := o.skipPaths[r.URL.Path]; found {
+		return true
+	}
+	if _, found := o.quietPaths[r.URL.Path]; found {
+		return status < http.StatusBadRequest
+	}
+	return false
+}
+
+// shouldLog decides whether the access log line for a finished request is written.
+func (o *Options) shouldLog(status int, duration time.Duration) bool {
+	if o.SampleRate <= 0 || o.SampleRate >= 1 {
+		return true
+	}
+	if status >= http.StatusBadRequest {
+		return true
+	}
+	if o.SampleAlwaysAbove > 0 && duration >= o.SampleAlwaysAbove {
+		return true
+	}
+	if o.isSlow(duration) {
+		return true
+	}
+	return rand.Float64() < o.SampleRate //nolint:gosec
+}
+
+func (o *Options) level(route *RouteOptions, status int, duration time.Duration) slog.Level {
+	level := slog.LevelInfo
+	switch {
+	case route != nil && route.LevelForStatus != nil:
+		level = route.LevelForStatus(status)
+	case o.LevelForStatus != nil:
+		level = o.LevelForStatus(status)
+	}
+	if o.isSlow(duration) && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+	return level
+}
+
+func (o *Options) format(e *AccessLogEntry) (string, []any) {
+	if o.Formatter == nil {
+		return DefaultFormat(e)
+	}
+	return o.Formatter(e)
+}
+
+func (o *Options) isSlow(duration time.Duration) bool {
+	return o.SlowRequestThreshold > 0 && duration >= o.SlowRequestThreshold
+}
+
+// handlerName returns the function name for http.HandlerFunc handlers and the type name for others.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
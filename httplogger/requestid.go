@@ -0,0 +1,62 @@
+package httplogger
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header an incoming request ID is read from, and the generated one is echoed in.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of incoming request IDs that are honored
+const maxRequestIDLength = 128
+
+type contextKey string
+
+const requestIDContextKey contextKey = "requestID"
+
+// ContextWithRequestID returns a copy of parent context carrying the request ID.
+func ContextWithRequestID(parent context.Context, requestID string) context.Context {
+	return context.WithValue(parent, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext retrieves the request ID set by the logging middleware, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if id, found := ctx.Value(requestIDContextKey).(string); found {
+		return id
+	}
+	return ""
+}
+
+// RequestIDFromRequest retrieves the request ID set by the logging middleware from the request's context.
+func RequestIDFromRequest(r *http.Request) string {
+	return RequestIDFromContext(r.Context())
+}
+
+// requestID returns the incoming request ID if it is valid, otherwise a newly generated one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); isValidRequestID(id) {
+		return id
+	}
+
+	// Generate request ID (`base64` to shorten its string representation)
+	_uuid := [16]byte(uuid.New())
+	return base64.RawStdEncoding.EncodeToString(_uuid[:])
+}
+
+// isValidRequestID only accepts reasonably short IDs of printable ASCII characters, so that clients can't inject
+// arbitrary content into logs and response headers.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package httplogger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseWriterFlush(t *testing.T) {
+	l := &testLogger{}
+	h := Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		flusher.Flush()
+	}), Options{})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+	require.True(t, rr.Flushed)
+	require.Len(t, l.entries, 1)
+	require.Equal(t, http.StatusOK, l.entries[0].args["status"])
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	l := &testLogger{}
+	srv := httptest.NewServer(Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\nhello")
+		_ = brw.Flush()
+	}), Options{}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+	require.NoError(t, err)
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+
+	// the handler finishes asynchronously, as the connection was hijacked
+	require.Eventually(t, func() bool { return len(l.Entries()) == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusSwitchingProtocols, l.Entries()[0].args["status"])
+}
+
+func TestResponseWriterHijackNotSupported(t *testing.T) {
+	rw := wrapResponseWriter(httptest.NewRecorder())
+	_, _, err := rw.Hijack()
+	require.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rw := wrapResponseWriter(rr)
+	n, err := io.Copy(rw, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	require.Equal(t, int64(11), n)
+	require.Equal(t, int64(11), rw.bytesWritten)
+	require.Equal(t, http.StatusOK, rw.Status())
+	require.Equal(t, "hello world", rr.Body.String())
+}
@@ -0,0 +1,75 @@
+package httplogger
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// defaultMaxBodyBytes is the number of request and response body bytes logged if body logging is enabled
+const defaultMaxBodyBytes = 4096
+
+// RouteOptions overrides the logging behaviour for all request paths starting with PathPrefix. If several
+// prefixes match, the longest one wins.
+type RouteOptions struct {
+	PathPrefix string
+
+	// LevelForStatus replaces Options.LevelForStatus for this route, see also ConstantLevel
+	LevelForStatus func(status int) slog.Level
+
+	// Fields are static key-value pairs added to every access log line of this route
+	Fields []any
+
+	// LogBody enables logging of the request and response bodies (requestBody, responseBody fields), truncated to
+	// MaxBodyBytes (4096 by default)
+	LogBody      bool
+	MaxBodyBytes int
+}
+
+// WithRoute adds per-route overrides, e.g.
+//
+//	httplogger.WithRoute(httplogger.RouteOptions{
+//		PathPrefix:     "/internal/",
+//		LevelForStatus: httplogger.ConstantLevel(slog.LevelDebug),
+//		LogBody:        true,
+//	})
+func WithRoute(route RouteOptions) Option {
+	return func(o *Options) {
+		o.Routes = append(o.Routes, route)
+	}
+}
+
+// ConstantLevel returns a LevelForStatus function that always returns level.
+func ConstantLevel(level slog.Level) func(status int) slog.Level {
+	return func(int) slog.Level {
+		return level
+	}
+}
+
+// sortRoutes returns a copy of the routes with the longest prefixes first.
+func sortRoutes(routes []RouteOptions) []RouteOptions {
+	sorted := make([]RouteOptions, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].PathPrefix) > len(sorted[j].PathPrefix)
+	})
+	return sorted
+}
+
+// route returns the overrides for the request, or nil if there are none.
+func (o *Options) route(r *http.Request) *RouteOptions {
+	for i := range o.Routes {
+		if strings.HasPrefix(r.URL.Path, o.Routes[i].PathPrefix) {
+			return &o.Routes[i]
+		}
+	}
+	return nil
+}
+
+func (route *RouteOptions) maxBodyBytes() int {
+	if route.MaxBodyBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return route.MaxBodyBytes
+}
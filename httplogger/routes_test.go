@@ -0,0 +1,45 @@
+package httplogger
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteOverrides(t *testing.T) {
+	l := &testLogger{}
+	h := Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("echo: "), b...))
+	}), applyOptions([]Option{
+		WithRoute(RouteOptions{PathPrefix: "/internal/", LevelForStatus: ConstantLevel(slog.LevelDebug), LogBody: true, MaxBodyBytes: 8}),
+		WithRoute(RouteOptions{PathPrefix: "/internal/admin/", Fields: []any{"route", "admin"}}),
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/internal/foo", strings.NewReader("hello world")))
+	require.Equal(t, "echo: hello world", rr.Body.String())
+
+	serve(h, "/internal/admin/bar")
+	serve(h, "/public")
+
+	entries := l.Entries()
+	require.Len(t, entries, 3)
+
+	require.Equal(t, slog.LevelDebug, entries[0].level)
+	require.Equal(t, "hello wo", entries[0].args["requestBody"])
+	require.Equal(t, "echo: he", entries[0].args["responseBody"])
+
+	// the longest prefix wins
+	require.Equal(t, slog.LevelInfo, entries[1].level)
+	require.Equal(t, "admin", entries[1].args["route"])
+	require.NotContains(t, entries[1].args, "requestBody")
+
+	require.Equal(t, slog.LevelInfo, entries[2].level)
+	require.NotContains(t, entries[2].args, "route")
+}
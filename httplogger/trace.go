@@ -0,0 +1,81 @@
+package httplogger
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context header (https://www.w3.org/TR/trace-context/)
+const TraceparentHeader = "traceparent"
+
+const traceContextContextKey contextKey = "traceContext"
+
+// TraceContext identifies the trace and span a request belongs to.
+type TraceContext struct {
+	TraceID string // 32 lowercase hex characters
+	SpanID  string // 16 lowercase hex characters
+	Sampled bool
+}
+
+// ParseTraceparent parses a W3C traceparent header value, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(value string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return TraceContext{}, false
+	}
+	if !isLowerHex(version) || !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return TraceContext{}, false
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return TraceContext{}, false
+	}
+	if traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return TraceContext{}, false
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+	return TraceContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Sampled: flagBits[0]&0x01 == 1,
+	}, true
+}
+
+// ContextWithTraceContext returns a copy of parent context carrying the trace context.
+func ContextWithTraceContext(parent context.Context, tc TraceContext) context.Context {
+	return context.WithValue(parent, traceContextContextKey, tc)
+}
+
+// TraceContextFromContext retrieves the trace context set by the logging middleware.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, found := ctx.Value(traceContextContextKey).(TraceContext)
+	return tc, found
+}
+
+// traceContext returns the trace context of the request: the current span as reported by the SpanFromContext hook
+// if there is one, otherwise the incoming traceparent header.
+func (o *Options) traceContext(r *http.Request) (TraceContext, bool) {
+	if o.SpanFromContext != nil {
+		if tc, ok := o.SpanFromContext(r.Context()); ok {
+			return tc, true
+		}
+	}
+	return ParseTraceparent(r.Header.Get(TraceparentHeader))
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package httplogger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	require.Equal(t, TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, tc)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, ok := ParseTraceparent(invalid)
+		require.False(t, ok, invalid)
+	}
+}
+
+func TestMiddlewareTracing(t *testing.T) {
+	l := &testLogger{}
+	var seen TraceContext
+	h := Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = TraceContextFromContext(r.Context())
+	}), Options{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", seen.TraceID)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", l.Entries()[0].args["trace_id"])
+	require.Equal(t, "00f067aa0ba902b7", l.Entries()[0].args["span_id"])
+
+	// a server span started by the tracing hook takes precedence
+	type spanKey struct{}
+	var endedWith int
+	l = &testLogger{}
+	h = Middleware(l, http.NotFoundHandler(), applyOptions([]Option{WithTracing(
+		func(r *http.Request) (*http.Request, func(status int)) {
+			ctx := context.WithValue(r.Context(), spanKey{}, TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "1111111111111111"})
+			return r.WithContext(ctx), func(status int) { endedWith = status }
+		},
+		func(ctx context.Context) (TraceContext, bool) {
+			tc, ok := ctx.Value(spanKey{}).(TraceContext)
+			return tc, ok
+		},
+	)}))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, http.StatusNotFound, endedWith)
+	require.Equal(t, "1111111111111111", l.Entries()[0].args["span_id"])
+}
@@ -0,0 +1,84 @@
+// Package httputil provides HTTP client helpers.
+package httputil
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersKey struct{}
+
+// CtxWithHeaders returns a context carrying the headers, in addition to the headers already carried by ctx. The
+// headers are set on the outgoing requests made with the context by rpcclient and HeaderTransport.
+func CtxWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string, len(headers))
+	for key, value := range HeadersFromCtx(ctx) {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	for key, value := range headers {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromCtx returns the headers carried by the context, keyed by canonical header key. The map must not be
+// modified.
+func HeadersFromCtx(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// HeaderTransportOpts configures which context headers HeaderTransport propagates.
+type HeaderTransportOpts struct {
+	// Allow only propagates these headers if not empty
+	Allow []string
+	// Deny never propagates these headers, e.g. Authorization
+	Deny []string
+}
+
+// HeaderTransport is a http.RoundTripper setting the headers of the request context (see CtxWithHeaders) on the
+// outgoing requests.
+type HeaderTransport struct {
+	base  http.RoundTripper
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewHeaderTransport wraps the transport, http.DefaultTransport if nil.
+func NewHeaderTransport(base http.RoundTripper, opts HeaderTransportOpts) *HeaderTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &HeaderTransport{base: base, deny: make(map[string]bool)}
+	if len(opts.Allow) > 0 {
+		t.allow = make(map[string]bool)
+		for _, key := range opts.Allow {
+			t.allow[http.CanonicalHeaderKey(key)] = true
+		}
+	}
+	for _, key := range opts.Deny {
+		t.deny[http.CanonicalHeaderKey(key)] = true
+	}
+	return t
+}
+
+func (t *HeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := HeadersFromCtx(req.Context())
+	if len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	for key, value := range headers {
+		if t.deny[key] || (t.allow != nil && !t.allow[key]) {
+			continue
+		}
+		if key == "Host" {
+			req.Host = value
+		} else {
+			req.Header.Set(key, value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,46 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderTransport(t *testing.T) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHeaderTransport(nil, HeaderTransportOpts{Deny: []string{"authorization"}})}
+
+	ctx := CtxWithHeaders(context.Background(), map[string]string{"x-request-id": "1", "Authorization": "secret"})
+	ctx = CtxWithHeaders(ctx, map[string]string{"X-Origin": "test"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	header := <-received
+	require.Equal(t, "1", header.Get("X-Request-Id"))
+	require.Equal(t, "test", header.Get("X-Origin"))
+	require.Empty(t, header.Get("Authorization"))
+	// the original request is unchanged
+	require.Empty(t, req.Header.Get("X-Request-Id"))
+
+	client = &http.Client{Transport: NewHeaderTransport(nil, HeaderTransportOpts{Allow: []string{"X-Origin"}})}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	header = <-received
+	require.Empty(t, header.Get("X-Request-Id"))
+	require.Equal(t, "test", header.Get("X-Origin"))
+}
@@ -0,0 +1,274 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/flashbots/go-utils/retry"
+)
+
+const (
+	// incremented when a request is retried / hedged, and when the budget prevents a retry or hedge
+	retriesCounter         = `goutils_httputil_retries_total{transport="%s"}`
+	hedgesCounter          = `goutils_httputil_hedges_total{transport="%s"}`
+	budgetExhaustedCounter = `goutils_httputil_budget_exhausted_total{transport="%s"}`
+
+	defaultBudgetMax = 10
+)
+
+// RetryTransportOpts configures a RetryTransport.
+type RetryTransportOpts struct {
+	// Name of the transport in the metrics
+	Name string
+	// RetryOptions configure the attempts and backoff, see the retry package
+	RetryOptions []retry.Option
+	// BudgetRatio limits the retries and hedges to this fraction of the requests (e.g. 0.1), so that they don't
+	// amplify an outage of the upstream, unlimited if 0. Up to BudgetMax (10 by default) retries are allowed in bursts.
+	BudgetRatio float64
+	BudgetMax   float64
+	// HedgeAfter sends a second request if the first one didn't complete after the duration, the first successful
+	// response is used and the other request canceled. Hedging is disabled if 0.
+	HedgeAfter time.Duration
+	// HedgeURL is the endpoint of the hedged requests (its scheme and host replace the ones of the request), the same
+	// endpoint if nil
+	HedgeURL *url.URL
+}
+
+// RetryTransport is a http.RoundTripper retrying requests failing with network errors or HTTP 429, 502, 503 and 504,
+// and optionally hedging slow requests. Requests with a body are only retried and hedged if their GetBody is set, as
+// by http.NewRequest for in-memory bodies. The response of the last attempt is returned if all attempts failed.
+type RetryTransport struct {
+	base   http.RoundTripper
+	opts   RetryTransportOpts
+	budget *budget
+}
+
+// NewRetryTransport wraps the transport, http.DefaultTransport if nil.
+func NewRetryTransport(base http.RoundTripper, opts RetryTransportOpts) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.BudgetMax <= 0 {
+		opts.BudgetMax = defaultBudgetMax
+	}
+	t := &RetryTransport{base: base, opts: opts}
+	if opts.BudgetRatio > 0 {
+		t.budget = &budget{ratio: opts.BudgetRatio, max: opts.BudgetMax, tokens: opts.BudgetMax}
+	}
+	return t
+}
+
+// statusError is a response with a retryable status
+type statusError struct {
+	resp *http.Response
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("http status %d", e.resp.StatusCode)
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// the body can't be sent again
+		return t.base.RoundTrip(req)
+	}
+	if t.budget != nil {
+		t.budget.deposit()
+	}
+
+	opts := append([]retry.Option{
+		retry.WithRetryIf(func(err error) bool {
+			return isRetryable(err) && t.withdraw()
+		}),
+		retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			metrics.GetOrCreateCounter(fmt.Sprintf(retriesCounter, t.opts.Name)).Inc()
+		}),
+	}, t.opts.RetryOptions...)
+
+	// the response with a retryable status is only drained once the next attempt starts
+	var previous *http.Response
+	resp, err := retry.DoValue(req.Context(), func(ctx context.Context) (*http.Response, error) {
+		if previous != nil {
+			drain(previous)
+			previous = nil
+		}
+		resp, err := t.attempt(req)
+		if err == nil && retry.IsRetryableHTTP(resp.StatusCode, nil) {
+			previous = resp
+			return nil, &statusError{resp: resp}
+		}
+		return resp, err
+	}, opts...)
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			// the context is done during the backoff, the body of the response can't be read anymore
+			drain(statusErr.resp)
+			return nil, ctxErr
+		}
+		return statusErr.resp, nil
+	}
+	return resp, err
+}
+
+// withdraw takes a token of the budget for a retry or hedge, and reports whether there was one
+func (t *RetryTransport) withdraw() bool {
+	if t.budget == nil || t.budget.withdraw() {
+		return true
+	}
+	metrics.GetOrCreateCounter(fmt.Sprintf(budgetExhaustedCounter, t.opts.Name)).Inc()
+	return false
+}
+
+type attemptResult struct {
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// attempt sends the request, and a hedged request if it is slow
+func (t *RetryTransport) attempt(req *http.Request) (*http.Response, error) {
+	results := make(chan attemptResult, 2)
+	send := func(target *url.URL) error {
+		ctx, cancel := context.WithCancel(req.Context())
+		r, err := cloneRequest(ctx, req, target)
+		if err != nil {
+			cancel()
+			return err
+		}
+		go func() {
+			resp, err := t.base.RoundTrip(r)
+			results <- attemptResult{resp: resp, err: err, cancel: cancel}
+		}()
+		return nil
+	}
+	if err := send(nil); err != nil {
+		return nil, err
+	}
+	pending := 1
+
+	var hedgeC <-chan time.Time
+	if t.opts.HedgeAfter > 0 {
+		timer := time.NewTimer(t.opts.HedgeAfter)
+		defer timer.Stop()
+		hedgeC = timer.C
+	}
+
+	for {
+		select {
+		case <-hedgeC:
+			hedgeC = nil
+			if !t.withdraw() {
+				continue
+			}
+			if err := send(t.opts.HedgeURL); err == nil {
+				pending++
+				metrics.GetOrCreateCounter(fmt.Sprintf(hedgesCounter, t.opts.Name)).Inc()
+			}
+		case res := <-results:
+			pending--
+			failed := res.err != nil || retry.IsRetryableHTTP(res.resp.StatusCode, nil)
+			if failed && pending > 0 {
+				// wait for the other request
+				res.cancel()
+				drain(res.resp)
+				continue
+			}
+			if pending > 0 {
+				// cancel the slower request
+				go func() {
+					other := <-results
+					other.cancel()
+					drain(other.resp)
+				}()
+			}
+			if res.err != nil {
+				res.cancel()
+				return nil, res.err
+			}
+			res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: res.cancel}
+			return res.resp, nil
+		}
+	}
+}
+
+// cloneRequest returns a copy of the request with a new body, sent to the target endpoint if not nil
+func cloneRequest(ctx context.Context, req *http.Request, target *url.URL) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	if target != nil {
+		r.URL.Scheme = target.Scheme
+		r.URL.Host = target.Host
+		r.Host = ""
+	}
+	return r, nil
+}
+
+// cancelBody cancels the context of the request when the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func drain(resp *http.Response) {
+	if resp != nil {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+	}
+}
+
+// isRetryable returns true for network errors and retryable statuses
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return true
+	}
+	return retry.IsRetryableHTTP(0, err)
+}
+
+// budget is a token bucket refilled by requests, retries and hedges taking a token each
+type budget struct {
+	mu     sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+func (b *budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,126 @@
+package httputil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, "payload", string(body))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryTransportOpts{
+		Name:         "test_retry",
+		RetryOptions: []retry.Option{retry.WithBackoff(time.Millisecond, 0)},
+	})}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "ok", string(body))
+	require.Equal(t, int32(3), calls.Load())
+
+	// the last response is returned when the attempts are exhausted
+	calls.Store(-10)
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, int32(-7), calls.Load())
+}
+
+func TestRetryTransportBudget(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryTransportOpts{
+		Name:         "test_budget",
+		RetryOptions: []retry.Option{retry.WithBackoff(time.Millisecond, 0), retry.WithMaxAttempts(5)},
+		BudgetRatio:  0.1,
+		BudgetMax:    2,
+	})}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	// 3 requests and 2 retries (+ 0.3 tokens)
+	require.Equal(t, int32(5), calls.Load())
+}
+
+func TestRetryTransportHedging(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer fast.Close()
+
+	hedgeURL, err := url.Parse(fast.URL)
+	require.NoError(t, err)
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryTransportOpts{
+		Name:       "test_hedge",
+		HedgeAfter: 10 * time.Millisecond,
+		HedgeURL:   hedgeURL,
+	})}
+
+	start := time.Now()
+	resp, err := client.Get(slow.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "fast", string(body))
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryTransportContextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryTransportOpts{
+		Name:         "test_context_done",
+		RetryOptions: []retry.Option{retry.WithBackoff(time.Hour, 0)},
+	})}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	// the context is done during the backoff
+	start := time.Now()
+	_, err = client.Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,177 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/flashbots/go-utils/rpcclient"
+)
+
+var (
+	ErrIncompatibleID     = errors.New("id is not an integer, as required by rpcclient")
+	ErrIncompatibleParams = errors.New("params are not an array")
+)
+
+// ToRPCRequest converts the request to a rpcclient request, the id must be an integer.
+func ToRPCRequest(req JSONRPCRequest) (*rpcclient.RPCRequest, error) {
+	id, err := intID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	version := req.Version
+	if version == "" {
+		version = "2.0"
+	}
+	var params any
+	if req.Params != nil {
+		params = req.Params
+	}
+	return &rpcclient.RPCRequest{
+		Method:  req.Method,
+		Params:  params,
+		ID:      id,
+		JSONRPC: version,
+	}, nil
+}
+
+// FromRPCRequest converts a rpcclient request, whose params must be an array (or nil).
+func FromRPCRequest(req *rpcclient.RPCRequest) (JSONRPCRequest, error) {
+	res := JSONRPCRequest{
+		ID:      req.ID,
+		Method:  req.Method,
+		Version: req.JSONRPC,
+	}
+	if req.Params == nil {
+		return res, nil
+	}
+	raw, err := json.Marshal(req.Params)
+	if err != nil {
+		return JSONRPCRequest{}, err
+	}
+	if raw = bytes.TrimSpace(raw); len(raw) == 0 || raw[0] != '[' {
+		return JSONRPCRequest{}, ErrIncompatibleParams
+	}
+	if err := json.Unmarshal(raw, &res.Params); err != nil {
+		return JSONRPCRequest{}, err
+	}
+	return res, nil
+}
+
+// ToRPCResponse converts the response to a rpcclient response, decoding the result as rpcclient does (numbers as
+// json.Number). The id must be an integer.
+func ToRPCResponse(res *JSONRPCResponse) (*rpcclient.RPCResponse, error) {
+	id, err := intID(res.ID)
+	if err != nil {
+		return nil, err
+	}
+	rpcRes := &rpcclient.RPCResponse{
+		JSONRPC: res.Version,
+		ID:      id,
+	}
+	if res.Error != nil {
+		rpcRes.Error = &rpcclient.RPCError{
+			Code:    res.Error.Code,
+			Message: res.Error.Message,
+			Data:    res.Error.Data,
+		}
+	}
+	if len(res.Result) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(res.Result))
+		dec.UseNumber()
+		if err := dec.Decode(&rpcRes.Result); err != nil {
+			return nil, err
+		}
+	}
+	return rpcRes, nil
+}
+
+// FromRPCResponse converts a rpcclient response.
+func FromRPCResponse(res *rpcclient.RPCResponse) (*JSONRPCResponse, error) {
+	jsonRes := &JSONRPCResponse{
+		ID:      res.ID,
+		Version: res.JSONRPC,
+	}
+	if res.Error != nil {
+		jsonRes.Error = &JSONRPCError{
+			Code:    res.Error.Code,
+			Message: res.Error.Message,
+			Data:    res.Error.Data,
+		}
+	}
+	if res.Result != nil {
+		result, err := json.Marshal(res.Result)
+		if err != nil {
+			return nil, err
+		}
+		jsonRes.Result = result
+	}
+	return jsonRes, nil
+}
+
+// SendJSONRPCRequestWithClient is SendJSONRPCRequestWithContext sending the request with the rpcclient client, to
+// use its signing and error handling (e.g. of broken Flashbots error responses) with the types of this package.
+func SendJSONRPCRequestWithClient(ctx context.Context, client rpcclient.RPCClient, req JSONRPCRequest) (*JSONRPCResponse, error) {
+	rpcReq, err := ToRPCRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rpcRes, err := client.CallRaw(ctx, rpcReq)
+	if err != nil {
+		return nil, err
+	}
+	return FromRPCResponse(rpcRes)
+}
+
+// intID converts the id to an int, as used by rpcclient
+func intID(id interface{}) (int, error) {
+	switch v := id.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		if int64(int(v)) != v {
+			return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+		}
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		return int(v), nil
+	case uint64:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+		}
+		return int(v), nil
+	case uint:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+		}
+		return int(v), nil
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt || v < math.MinInt {
+			return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+		}
+		return int(v), nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+		}
+		return intID(i)
+	default:
+		return 0, fmt.Errorf("%w: %v", ErrIncompatibleID, v)
+	}
+}
@@ -0,0 +1,51 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCClientConversions(t *testing.T) {
+	req := *NewJSONRPCRequest(uint64(7), "eth_call", map[string]interface{}{"to": "0x1"})
+	rpcReq, err := ToRPCRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, 7, rpcReq.ID)
+	require.Equal(t, "2.0", rpcReq.JSONRPC)
+
+	converted, err := FromRPCRequest(rpcReq)
+	require.NoError(t, err)
+	require.Equal(t, 7, converted.ID)
+	require.Equal(t, []interface{}{map[string]interface{}{"to": "0x1"}}, converted.Params)
+
+	_, err = ToRPCRequest(*NewJSONRPCRequest("abc", "eth_call", nil))
+	require.ErrorIs(t, err, ErrIncompatibleID)
+	_, err = FromRPCRequest(rpcclient.NewRequestWithObjectParam(1, "eth_call", map[string]string{"to": "0x1"}))
+	require.ErrorIs(t, err, ErrIncompatibleParams)
+
+	res := NewJSONRPCResponse(float64(3), json.RawMessage(`{"gas":21000}`))
+	rpcRes, err := ToRPCResponse(res)
+	require.NoError(t, err)
+	require.Equal(t, 3, rpcRes.ID)
+	require.Equal(t, map[string]interface{}{"gas": json.Number("21000")}, rpcRes.Result)
+
+	back, err := FromRPCResponse(rpcRes)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"gas":21000}`, string(back.Result))
+
+	rpcRes, err = ToRPCResponse(NewJSONRPCErrorResponse(1, ErrInvalidParams, "invalid"))
+	require.NoError(t, err)
+	require.Equal(t, &rpcclient.RPCError{Code: ErrInvalidParams, Message: "invalid"}, rpcRes.Error)
+}
+
+func TestSendJSONRPCRequestWithClient(t *testing.T) {
+	addr := setupMockServer()
+
+	res, err := SendJSONRPCRequestWithClient(context.Background(), rpcclient.NewClient(addr), *NewJSONRPCRequest(1, "eth_call", "0xabc"))
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+	require.Equal(t, `"0x12345"`, string(res.Result))
+}
@@ -0,0 +1,216 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errBlockNotFound = errors.New("block not found")
+
+// MockChain is an in-memory chain state answering common Ethereum methods (eth_chainId, net_version,
+// eth_blockNumber, eth_getBlockByNumber, eth_getBlockByHash and eth_call) of MockJSONRPCServer, see UseChain.
+// Blocks have no transactions.
+type MockChain struct {
+	mu          sync.RWMutex
+	chainID     *big.Int
+	headers     []*types.Header
+	callResults map[common.Address][]byte
+	onBlock     []func(header *types.Header)
+}
+
+// NewMockChain returns a chain with the genesis block
+func NewMockChain(chainID int64) *MockChain {
+	genesis := &types.Header{
+		ParentHash:  common.Hash{},
+		UncleHash:   types.EmptyUncleHash,
+		Root:        types.EmptyRootHash,
+		TxHash:      types.EmptyTxsHash,
+		ReceiptHash: types.EmptyReceiptsHash,
+		Difficulty:  big.NewInt(0),
+		Number:      big.NewInt(0),
+		GasLimit:    30_000_000,
+		Time:        1_700_000_000,
+		Extra:       []byte{},
+		BaseFee:     big.NewInt(1_000_000_000),
+	}
+	return &MockChain{
+		chainID:     big.NewInt(chainID),
+		headers:     []*types.Header{genesis},
+		callResults: make(map[common.Address][]byte),
+	}
+}
+
+// AddBlock appends an empty block 12 seconds after the head, and pushes it to the newHeads subscriptions of the
+// servers using the chain
+func (c *MockChain) AddBlock() *types.Header {
+	c.mu.Lock()
+	head := c.headers[len(c.headers)-1]
+	header := types.CopyHeader(head)
+	header.ParentHash = head.Hash()
+	header.Number = new(big.Int).Add(head.Number, big.NewInt(1))
+	header.Time = head.Time + 12
+	c.headers = append(c.headers, header)
+	onBlock := c.onBlock
+	c.mu.Unlock()
+
+	for _, fn := range onBlock {
+		fn(header)
+	}
+	return header
+}
+
+// AddBlocks appends n empty blocks and returns the new head
+func (c *MockChain) AddBlocks(n int) *types.Header {
+	head := c.Head()
+	for i := 0; i < n; i++ {
+		head = c.AddBlock()
+	}
+	return head
+}
+
+// Head returns the latest block header
+func (c *MockChain) Head() *types.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[len(c.headers)-1]
+}
+
+// HeaderByNumber returns the header of the block, or nil if it doesn't exist
+func (c *MockChain) HeaderByNumber(number uint64) *types.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+// SetCallResult sets the result returned by eth_call for calls to the address, "0x" is returned for other addresses
+func (c *MockChain) SetCallResult(to common.Address, result []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callResults[to] = result
+}
+
+// UseChain answers the Ethereum methods of the chain, replacing their handlers
+func (s *MockJSONRPCServer) UseChain(chain *MockChain) {
+	s.SetHandler("eth_chainId", func(req *JSONRPCRequest) (interface{}, error) {
+		return (*hexutil.Big)(chain.chainID), nil
+	})
+	s.SetHandler("net_version", func(req *JSONRPCRequest) (interface{}, error) {
+		return chain.chainID.String(), nil
+	})
+	s.SetHandler("eth_blockNumber", func(req *JSONRPCRequest) (interface{}, error) {
+		return hexutil.Uint64(chain.Head().Number.Uint64()), nil
+	})
+	s.SetHandler("eth_getBlockByNumber", func(req *JSONRPCRequest) (interface{}, error) {
+		var blockNumber string
+		if len(req.Params) > 0 {
+			blockNumber, _ = req.Params[0].(string)
+		}
+		header, err := chain.headerByTag(blockNumber)
+		if errors.Is(err, errBlockNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, &JSONRPCError{Code: ErrInvalidParams, Message: err.Error()}
+		}
+		return marshalMockBlock(header)
+	})
+	s.SetHandler("eth_getBlockByHash", func(req *JSONRPCRequest) (interface{}, error) {
+		var hash string
+		if len(req.Params) > 0 {
+			hash, _ = req.Params[0].(string)
+		}
+		header := chain.headerByHash(common.HexToHash(hash))
+		if header == nil {
+			return nil, nil
+		}
+		return marshalMockBlock(header)
+	})
+	s.SetHandler("eth_call", func(req *JSONRPCRequest) (interface{}, error) {
+		var call struct {
+			To *common.Address `json:"to"`
+		}
+		if len(req.Params) > 0 {
+			// the params are decoded as map, re-encode them to parse the call object
+			raw, err := json.Marshal(req.Params[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(raw, &call); err != nil {
+				return nil, &JSONRPCError{Code: ErrInvalidParams, Message: err.Error()}
+			}
+		}
+		var result []byte
+		if call.To != nil {
+			chain.mu.RLock()
+			result = chain.callResults[*call.To]
+			chain.mu.RUnlock()
+		}
+		return hexutil.Bytes(result), nil
+	})
+
+	chain.mu.Lock()
+	chain.onBlock = append(chain.onBlock, func(header *types.Header) {
+		s.Notify("newHeads", header)
+	})
+	chain.mu.Unlock()
+}
+
+// headerByTag returns the header of the block number or tag (latest, earliest, ...)
+func (c *MockChain) headerByTag(tag string) (*types.Header, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return c.Head(), nil
+	case "earliest":
+		return c.HeaderByNumber(0), nil
+	}
+	if !strings.HasPrefix(tag, "0x") {
+		return nil, fmt.Errorf("invalid block number %q", tag)
+	}
+	number, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return nil, err
+	}
+	header := c.HeaderByNumber(number)
+	if header == nil {
+		return nil, errBlockNotFound
+	}
+	return header, nil
+}
+
+func (c *MockChain) headerByHash(hash common.Hash) *types.Header {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+// marshalMockBlock returns the JSON-RPC representation of the empty block of the header
+func marshalMockBlock(header *types.Header) (map[string]interface{}, error) {
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	block := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	block["size"] = hexutil.Uint64(len(raw))
+	return block, nil
+}
@@ -0,0 +1,69 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockChain(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	defer srv.Close()
+	chain := NewMockChain(1)
+	srv.UseChain(chain)
+	head := chain.AddBlocks(3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, srv.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), chainID.Int64())
+	blockNumber, err := client.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), blockNumber)
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, head.Hash(), header.Hash())
+	block, err := client.BlockByNumber(ctx, big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, head.ParentHash, block.Hash())
+	block, err = client.BlockByHash(ctx, head.ParentHash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), block.NumberU64())
+	_, err = client.HeaderByNumber(ctx, big.NewInt(10))
+	require.ErrorIs(t, err, ethereum.NotFound)
+
+	to := common.HexToAddress("0x1")
+	chain.SetCallResult(to, []byte{1, 2, 3})
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &to}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, result)
+
+	// new blocks are pushed to websocket subscriptions
+	wsClient, err := ethclient.DialContext(ctx, srv.WSURL)
+	require.NoError(t, err)
+	defer wsClient.Close()
+	headers := make(chan *types.Header, 1)
+	sub, err := wsClient.SubscribeNewHead(ctx, headers)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	head = chain.AddBlock()
+	select {
+	case received := <-headers:
+		require.Equal(t, head.Hash(), received.Hash())
+	case <-ctx.Done():
+		t.Fatal("no header received")
+	}
+}
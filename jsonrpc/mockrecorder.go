@@ -0,0 +1,141 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MockRequest is a JSON-RPC request received by MockJSONRPCServer
+type MockRequest struct {
+	ID     interface{}
+	Method string
+	Params []interface{}
+	// Header of the HTTP request
+	Header http.Header
+	// Body is the raw body of the HTTP request, containing all requests of a batch
+	Body []byte
+	// Signer is the verified signer of the request, see MockJSONRPCServer.VerifySignature
+	Signer common.Address
+}
+
+func (s *MockJSONRPCServer) recordRequests(jsonReqs []*JSONRPCRequest, req *http.Request, body []byte, signer common.Address) {
+	s.requestsLock.Lock()
+	defer s.requestsLock.Unlock()
+	for _, jsonReq := range jsonReqs {
+		s.requests = append(s.requests, MockRequest{
+			ID:     jsonReq.ID,
+			Method: jsonReq.Method,
+			Params: jsonReq.Params,
+			Header: req.Header.Clone(),
+			Body:   body,
+			Signer: signer,
+		})
+	}
+}
+
+// Requests returns the received requests, in order
+func (s *MockJSONRPCServer) Requests() []MockRequest {
+	s.requestsLock.Lock()
+	defer s.requestsLock.Unlock()
+	return append([]MockRequest(nil), s.requests...)
+}
+
+// RequestsFor returns the received requests for the method, in order
+func (s *MockJSONRPCServer) RequestsFor(method string) []MockRequest {
+	var requests []MockRequest
+	for _, request := range s.Requests() {
+		if request.Method == method {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// ResetRequests forgets the received requests
+func (s *MockJSONRPCServer) ResetRequests() {
+	s.requestsLock.Lock()
+	defer s.requestsLock.Unlock()
+	s.requests = nil
+}
+
+// MockCallExpectation matches the requests received by MockJSONRPCServer, see ExpectCall
+type MockCallExpectation struct {
+	server    *MockJSONRPCServer
+	method    string
+	params    []interface{}
+	hasParams bool
+}
+
+// ExpectCall returns an expectation on the calls of the method, e.g.
+//
+//	err := server.ExpectCall("eth_sendBundle").WithParams(bundle).Times(1)
+func (s *MockJSONRPCServer) ExpectCall(method string) *MockCallExpectation {
+	return &MockCallExpectation{server: s, method: method}
+}
+
+// WithParams only matches the calls with the params, compared by their JSON encoding
+func (e *MockCallExpectation) WithParams(params ...interface{}) *MockCallExpectation {
+	e.params = params
+	e.hasParams = true
+	return e
+}
+
+// Calls returns the matching requests
+func (e *MockCallExpectation) Calls() ([]MockRequest, error) {
+	var expected []byte
+	if e.hasParams {
+		var err error
+		if expected, err = json.Marshal(e.params); err != nil {
+			return nil, err
+		}
+	}
+
+	var calls []MockRequest
+	for _, request := range e.server.RequestsFor(e.method) {
+		if e.hasParams {
+			actual, err := json.Marshal(request.Params)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(expected, actual) {
+				continue
+			}
+		}
+		calls = append(calls, request)
+	}
+	return calls, nil
+}
+
+// Times returns an error unless the method was called exactly n times
+func (e *MockCallExpectation) Times(n int) error {
+	calls, err := e.Calls()
+	if err != nil {
+		return err
+	}
+	if len(calls) != n {
+		return fmt.Errorf("expected %d calls of %s%s, got %d", n, e.method, e.describeParams(), len(calls))
+	}
+	return nil
+}
+
+// Once returns an error unless the method was called exactly once
+func (e *MockCallExpectation) Once() error {
+	return e.Times(1)
+}
+
+// Never returns an error if the method was called
+func (e *MockCallExpectation) Never() error {
+	return e.Times(0)
+}
+
+func (e *MockCallExpectation) describeParams() string {
+	if !e.hasParams {
+		return ""
+	}
+	params, _ := json.Marshal(e.params)
+	return fmt.Sprintf(" with params %s", params)
+}
@@ -1,85 +1,361 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/gorilla/websocket"
 )
 
+// MockFault configures the faults injected by MockJSONRPCServer for a method, to test the retry and timeout logic of
+// clients. For batches, the faults of all methods apply to the whole HTTP response.
+type MockFault struct {
+	// Latency delays the response
+	Latency time.Duration
+	// ErrorRate is the probability (0 to 1) of responding with a JSON-RPC internal error instead of calling the handler
+	ErrorRate float64
+	// Malformed responds with an invalid JSON body
+	Malformed bool
+	// StatusCode overrides the HTTP status of the response
+	StatusCode int
+}
+
+// MockResponse can be returned by handlers to control the HTTP response, e.g. to simulate misbehaving servers. For
+// batches, it applies to the whole HTTP response.
+type MockResponse struct {
+	// StatusCode overrides the HTTP status of the response
+	StatusCode int
+	// Header is added to the headers of the response
+	Header http.Header
+	// Body replaces the body of the response, e.g. `{"error":"text"}`
+	Body []byte
+	// Result is the JSON-RPC result, if Body is not set
+	Result interface{}
+}
+
+// ErrMockFault is the error returned for the requests failed according to MockFault.ErrorRate
+var ErrMockFault = &JSONRPCError{Code: ErrInternal, Message: "injected fault"}
+
 type MockJSONRPCServer struct {
-	Handlers       map[string]func(req *JSONRPCRequest) (interface{}, error)
-	RequestCounter sync.Map
-	server         *httptest.Server
-	URL            string
+	Handlers map[string]func(req *JSONRPCRequest) (interface{}, error)
+	// SignedHandlers are called with the signer of the request, they take precedence over Handlers
+	SignedHandlers map[string]func(req *JSONRPCRequest, signer common.Address) (interface{}, error)
+	// VerifySignature requires a valid X-Flashbots-Signature header on HTTP requests, the signer is passed to
+	// SignedHandlers (websocket requests are not signed)
+	VerifySignature bool
+	RequestCounter  sync.Map
+	server          *httptest.Server
+	URL             string
+	// WSURL is the websocket URL of the server, serving JSON-RPC and eth_subscribe subscriptions (see Notify)
+	WSURL string
+
+	faultsLock sync.Mutex
+	faults     map[string]MockFault
+	rand       *rand.Rand
+
+	requestsLock sync.Mutex
+	requests     []MockRequest
+
+	subscriptionsLock  sync.Mutex
+	subscriptions      map[string]*mockSubscription
+	lastSubscriptionID uint64
 }
 
 func NewMockJSONRPCServer() *MockJSONRPCServer {
 	s := &MockJSONRPCServer{
-		Handlers: make(map[string]func(req *JSONRPCRequest) (interface{}, error)),
+		Handlers:       make(map[string]func(req *JSONRPCRequest) (interface{}, error)),
+		SignedHandlers: make(map[string]func(req *JSONRPCRequest, signer common.Address) (interface{}, error)),
+		faults:         make(map[string]MockFault),
+		rand:           rand.New(rand.NewSource(1)), //nolint:gosec
+
+		subscriptions: make(map[string]*mockSubscription),
 	}
 	s.server = httptest.NewServer(http.HandlerFunc(s.handleHTTPRequest))
 	s.URL = s.server.URL
+	s.WSURL = "ws" + strings.TrimPrefix(s.server.URL, "http")
 	return s
 }
 
+// Close shuts down the server, closing the websocket connections
+func (s *MockJSONRPCServer) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
 func (s *MockJSONRPCServer) SetHandler(method string, handler func(req *JSONRPCRequest) (interface{}, error)) {
 	s.Handlers[method] = handler
 }
 
+// SetSignedHandler sets the handler of the method, called with the signer of the request, see VerifySignature
+func (s *MockJSONRPCServer) SetSignedHandler(method string, handler func(req *JSONRPCRequest, signer common.Address) (interface{}, error)) {
+	s.SignedHandlers[method] = handler
+}
+
+// SetFault injects the faults into the responses for the method, replacing the previous ones
+func (s *MockJSONRPCServer) SetFault(method string, fault MockFault) {
+	s.faultsLock.Lock()
+	defer s.faultsLock.Unlock()
+	s.faults[method] = fault
+}
+
+// ClearFaults removes all injected faults
+func (s *MockJSONRPCServer) ClearFaults() {
+	s.faultsLock.Lock()
+	defer s.faultsLock.Unlock()
+	s.faults = make(map[string]MockFault)
+}
+
+// SetSeed seeds the random source deciding which requests fail according to MockFault.ErrorRate, the seed is 1 by
+// default so a sequence of requests always fails the same way
+func (s *MockJSONRPCServer) SetSeed(seed int64) {
+	s.faultsLock.Lock()
+	defer s.faultsLock.Unlock()
+	s.rand = rand.New(rand.NewSource(seed)) //nolint:gosec
+}
+
 func (s *MockJSONRPCServer) handleHTTPRequest(w http.ResponseWriter, req *http.Request) {
+	if websocket.IsWebSocketUpgrade(req) {
+		s.handleWebsocket(w, req)
+		return
+	}
 	defer req.Body.Close()
 
 	w.Header().Set("Content-Type", "application/json")
 	testHeader := req.Header.Get("Test")
 	w.Header().Set("Test", testHeader)
 
-	returnError := func(id interface{}, err error) {
-		res := JSONRPCResponse{
-			ID:    id,
-			Error: errorPayload(err),
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		_ = json.NewEncoder(w).Encode(errorResponse(0, fmt.Errorf("failed to read request body: %v", err)))
+		return
+	}
+
+	var signer common.Address
+	if s.VerifySignature {
+		signer, err = signature.Verify(req.Header.Get(signature.HTTPHeader), body)
+		if err != nil {
+			_ = json.NewEncoder(w).Encode(NewJSONRPCErrorResponse(nil, ErrInvalidRequest, err.Error()))
+			return
 		}
+	}
 
-		if err := json.NewEncoder(w).Encode(res); err != nil {
+	statusCode, header, res := s.handleBody(req, body, signer)
+	if statusCode == 0 {
+		return
+	}
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(res)
+}
+
+// handleBody handles the JSON-RPC request or batch and returns the HTTP status, additional headers and body of the
+// response, or a zero status if the request was aborted
+func (s *MockJSONRPCServer) handleBody(req *http.Request, body []byte, signer common.Address) (statusCode int, header http.Header, res []byte) {
+	var out bytes.Buffer
+	writeResponse := func(res interface{}) {
+		if err := json.NewEncoder(&out).Encode(res); err != nil {
 			log.Error("error writing response", "err", err, "data", res)
 		}
 	}
 
-	// Parse JSON RPC
-	jsonReq := new(JSONRPCRequest)
-	if err := json.NewDecoder(req.Body).Decode(jsonReq); err != nil {
-		returnError(0, fmt.Errorf("failed to parse request body: %v", err))
-		return
+	var err error
+	// Parse JSON RPC, or a batch
+	var entries []mockBatchEntry
+	trimmed := bytes.TrimSpace(body)
+	isBatch := len(trimmed) > 0 && trimmed[0] == '['
+	if isBatch {
+		entries, err = parseMockBatch(trimmed)
+	} else {
+		jsonReq := new(JSONRPCRequest)
+		err = json.Unmarshal(trimmed, jsonReq)
+		entries = append(entries, mockBatchEntry{req: jsonReq})
+	}
+	if err != nil {
+		writeResponse(errorResponse(0, fmt.Errorf("failed to parse request body: %v", err)))
+		return http.StatusOK, nil, out.Bytes()
+	}
+	if isBatch && len(entries) == 0 {
+		writeResponse(NewJSONRPCErrorResponse(nil, ErrInvalidRequest, "empty batch"))
+		return http.StatusOK, nil, out.Bytes()
+	}
+
+	jsonReqs := make([]*JSONRPCRequest, 0, len(entries))
+	for _, entry := range entries {
+		if entry.req != nil {
+			jsonReqs = append(jsonReqs, entry.req)
+		}
+	}
+	s.recordRequests(jsonReqs, req, body, signer)
+
+	var latency time.Duration
+	statusCode = http.StatusOK
+	malformed := false
+	for i, entry := range entries {
+		if entry.req == nil {
+			continue
+		}
+		entries[i].fault = s.fault(entry.req.Method)
+		if entries[i].fault.Latency > latency {
+			latency = entries[i].fault.Latency
+		}
+		if entries[i].fault.StatusCode != 0 {
+			statusCode = entries[i].fault.StatusCode
+		}
+		malformed = malformed || entries[i].fault.Malformed
+	}
+
+	var rawBody []byte
+	responses := make([]*JSONRPCResponse, 0, len(entries))
+	for _, entry := range entries {
+		var res *JSONRPCResponse
+		var mockRes *MockResponse
+		switch {
+		case entry.req == nil:
+			res = NewJSONRPCErrorResponse(nil, ErrInvalidRequest, "invalid request")
+		case entry.fault.ErrorRate > 0 && s.randFloat() < entry.fault.ErrorRate:
+			s.IncrementRequestCounter(entry.req.Method)
+			res = errorResponse(entry.req.ID, ErrMockFault)
+		default:
+			res, mockRes = s.handleRequest(entry.req, signer)
+		}
+		if mockRes != nil {
+			if mockRes.StatusCode != 0 {
+				statusCode = mockRes.StatusCode
+			}
+			for key, values := range mockRes.Header {
+				if header == nil {
+					header = make(http.Header)
+				}
+				header[key] = values
+			}
+			if mockRes.Body != nil {
+				rawBody = mockRes.Body
+			}
+		}
+		if !entry.notification {
+			responses = append(responses, res)
+		}
+	}
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-req.Context().Done():
+			return 0, nil, nil
+		}
+	}
+	if rawBody != nil {
+		return statusCode, header, rawBody
+	}
+	if isBatch && len(responses) == 0 && !malformed {
+		// nothing is returned for a batch of notifications
+		if statusCode == http.StatusOK {
+			statusCode = http.StatusNoContent
+		}
+		return statusCode, header, nil
 	}
+	if malformed {
+		return statusCode, header, []byte(`{"jsonrpc":"2.0","id":`)
+	}
+	if isBatch {
+		writeResponse(responses)
+	} else {
+		writeResponse(responses[0])
+	}
+	return statusCode, header, out.Bytes()
+}
+
+type mockBatchEntry struct {
+	// req is nil for invalid entries
+	req *JSONRPCRequest
+	// notification is true for requests without id, which get no response
+	notification bool
+	fault        MockFault
+}
 
+// parseMockBatch parses the entries of a batch, as per the spec invalid entries don't fail the whole batch
+func parseMockBatch(body []byte) ([]mockBatchEntry, error) {
+	var rawReqs []json.RawMessage
+	if err := json.Unmarshal(body, &rawReqs); err != nil {
+		return nil, err
+	}
+
+	entries := make([]mockBatchEntry, 0, len(rawReqs))
+	for _, rawReq := range rawReqs {
+		var id struct {
+			ID json.RawMessage `json:"id"`
+		}
+		jsonReq := new(JSONRPCRequest)
+		if err := json.Unmarshal(rawReq, jsonReq); err != nil || jsonReq.Method == "" {
+			entries = append(entries, mockBatchEntry{})
+			continue
+		}
+		_ = json.Unmarshal(rawReq, &id)
+		entries = append(entries, mockBatchEntry{req: jsonReq, notification: id.ID == nil})
+	}
+	return entries, nil
+}
+
+func (s *MockJSONRPCServer) fault(method string) MockFault {
+	s.faultsLock.Lock()
+	defer s.faultsLock.Unlock()
+	return s.faults[method]
+}
+
+func (s *MockJSONRPCServer) randFloat() float64 {
+	s.faultsLock.Lock()
+	defer s.faultsLock.Unlock()
+	return s.rand.Float64()
+}
+
+func (s *MockJSONRPCServer) handleRequest(jsonReq *JSONRPCRequest, signer common.Address) (*JSONRPCResponse, *MockResponse) {
 	jsonRPCHandler, found := s.Handlers[jsonReq.Method]
+	if signedHandler, ok := s.SignedHandlers[jsonReq.Method]; ok {
+		jsonRPCHandler = func(req *JSONRPCRequest) (interface{}, error) {
+			return signedHandler(req, signer)
+		}
+		found = true
+	}
 	if !found {
-		returnError(jsonReq.ID, fmt.Errorf("no RPC method handler implemented for %s", jsonReq.Method))
-		return
+		return errorResponse(jsonReq.ID, fmt.Errorf("no RPC method handler implemented for %s", jsonReq.Method)), nil
 	}
 
 	s.IncrementRequestCounter(jsonReq.Method)
 
 	rawRes, err := jsonRPCHandler(jsonReq)
+	mockRes, isMockRes := rawRes.(*MockResponse)
+	if isMockRes {
+		rawRes = mockRes.Result
+	}
 	if err != nil {
-		returnError(jsonReq.ID, err)
-		return
+		return errorResponse(jsonReq.ID, err), mockRes
 	}
 
-	w.WriteHeader(http.StatusOK)
 	resBytes, err := json.Marshal(rawRes)
 	if err != nil {
 		log.Error("error marshalling rawRes", "err", err, "data", rawRes)
-		return
+		return errorResponse(jsonReq.ID, err), mockRes
 	}
+	return NewJSONRPCResponse(jsonReq.ID, resBytes), mockRes
+}
 
-	res := NewJSONRPCResponse(jsonReq.ID, resBytes)
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		log.Error("error writing response 2", "err", err, "data", rawRes)
-		return
+func errorResponse(id interface{}, err error) *JSONRPCResponse {
+	return &JSONRPCResponse{
+		ID:    id,
+		Error: errorPayload(err),
 	}
 }
 
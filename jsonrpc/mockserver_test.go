@@ -1,8 +1,16 @@
 package jsonrpc
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,3 +60,169 @@ func TestMockJSONRPCServer_IncrementRequestCounter(t *testing.T) {
 		})
 	}
 }
+
+func TestMockJSONRPCServer_Faults(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	srv.Handlers["eth_call"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return "0x12345", nil
+	}
+	req := NewJSONRPCRequest(1, "eth_call", "0xabc")
+
+	// latency
+	srv.SetFault("eth_call", MockFault{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := SendJSONRPCRequestWithContext(ctx, *req, srv.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// malformed response, with status override
+	srv.SetFault("eth_call", MockFault{Malformed: true, StatusCode: http.StatusBadGateway})
+	_, err = SendJSONRPCRequest(*req, srv.URL)
+	assert.Error(t, err)
+
+	// error rate, deterministic for the seed
+	countErrors := func() int {
+		errors := 0
+		for i := 0; i < 100; i++ {
+			res, err := SendJSONRPCRequest(*req, srv.URL)
+			assert.Nil(t, err, err)
+			if res.Error != nil {
+				assert.Equal(t, ErrInternal, res.Error.Code)
+				errors++
+			}
+		}
+		return errors
+	}
+	srv.SetFault("eth_call", MockFault{ErrorRate: 0.5})
+	errors := countErrors()
+	assert.Greater(t, errors, 20)
+	assert.Less(t, errors, 80)
+	srv.SetSeed(1)
+	assert.Equal(t, errors, countErrors())
+
+	srv.ClearFaults()
+	assert.Equal(t, 0, countErrors())
+}
+
+func TestMockJSONRPCServer_ExpectCall(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	srv.Handlers["eth_call"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return "0x12345", nil
+	}
+
+	_, err := SendJSONRPCRequest(*NewJSONRPCRequest(1, "eth_call", "0xabc"), srv.URL, WithHeader("Test", "a"))
+	assert.Nil(t, err, err)
+	_, err = SendJSONRPCBatch([]JSONRPCRequest{
+		*NewJSONRPCRequest(2, "eth_call", map[string]interface{}{"to": "0xdef", "gas": 21000}),
+		*NewJSONRPCRequest(3, "eth_blockNumber", nil),
+	}, srv.URL)
+	assert.Nil(t, err, err)
+
+	requests := srv.Requests()
+	assert.Len(t, requests, 3)
+	assert.Equal(t, "eth_call", requests[0].Method)
+	assert.Equal(t, "a", requests[0].Header.Get("Test"))
+	assert.Equal(t, "eth_blockNumber", requests[2].Method)
+	assert.Equal(t, requests[1].Body, requests[2].Body)
+
+	assert.NoError(t, srv.ExpectCall("eth_call").Times(2))
+	assert.NoError(t, srv.ExpectCall("eth_call").WithParams("0xabc").Once())
+	assert.NoError(t, srv.ExpectCall("eth_call").WithParams(map[string]interface{}{"gas": 21000, "to": "0xdef"}).Once())
+	assert.EqualError(t, srv.ExpectCall("eth_call").WithParams("0x123").Once(), `expected 1 calls of eth_call with params ["0x123"], got 0`)
+	assert.NoError(t, srv.ExpectCall("eth_sendBundle").Never())
+
+	srv.ResetRequests()
+	assert.Empty(t, srv.Requests())
+}
+
+func TestMockJSONRPCServer_Batch(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	srv.Handlers["eth_call"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return "0x12345", nil
+	}
+
+	client := rpcclient.NewClient(srv.URL)
+	res, err := client.CallBatch(context.Background(), rpcclient.RPCRequests{
+		rpcclient.NewRequest("eth_call", "0xabc"),
+		rpcclient.NewRequest("unknown"),
+	})
+	assert.Nil(t, err, err)
+	assert.Len(t, res, 2)
+	result, err := res.GetByID(0).GetString()
+	assert.Nil(t, err, err)
+	assert.Equal(t, "0x12345", result)
+	assert.NotNil(t, res.GetByID(1).Error)
+
+	post := func(body string) (int, string) {
+		httpRes, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		assert.Nil(t, err, err)
+		defer httpRes.Body.Close()
+		resBody, _ := io.ReadAll(httpRes.Body)
+		return httpRes.StatusCode, strings.TrimSpace(string(resBody))
+	}
+
+	// empty batch
+	_, body := post(`[]`)
+	assert.Equal(t, `{"id":null,"error":{"code":-32600,"message":"empty batch"},"jsonrpc":"2.0"}`, body)
+	// invalid entries and notifications
+	_, body = post(`[1, {"jsonrpc":"2.0","method":"eth_call","params":[]}, {"jsonrpc":"2.0","id":7,"method":"eth_call","params":[]}]`)
+	assert.Equal(t, `[{"id":null,"error":{"code":-32600,"message":"invalid request"},"jsonrpc":"2.0"},{"id":7,"result":"0x12345","jsonrpc":"2.0"}]`, body)
+	status, body := post(`[{"jsonrpc":"2.0","method":"eth_call","params":[]}]`)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Empty(t, body)
+}
+
+func TestMockJSONRPCServer_VerifySignature(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	srv.VerifySignature = true
+	srv.SetSignedHandler("eth_sendBundle", func(req *JSONRPCRequest, signer common.Address) (interface{}, error) {
+		return signer.Hex(), nil
+	})
+
+	signer, err := signature.NewRandomSigner()
+	assert.Nil(t, err, err)
+	req := NewJSONRPCRequest(1, "eth_sendBundle", "0xabc")
+
+	reply := new(string)
+	err = SendJSONRPCRequestAndParseResult(*req, srv.URL, reply, WithSigner(signer))
+	assert.Nil(t, err, err)
+	assert.Equal(t, signer.Address().Hex(), *reply)
+	assert.Equal(t, signer.Address(), srv.Requests()[0].Signer)
+
+	// unsigned requests are rejected
+	err = SendJSONRPCRequestAndParseResult(*req, srv.URL, reply)
+	assert.ErrorContains(t, err, signature.ErrNoSignature.Error())
+	assert.Len(t, srv.Requests(), 1)
+}
+
+func TestMockJSONRPCServer_MockResponse(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	srv.Handlers["eth_sendBundle"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return &MockResponse{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":"bundle rejected"}`)}, nil
+	}
+	srv.Handlers["eth_call"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return &MockResponse{Header: http.Header{"X-Test": {"a"}}, Result: "0x12345"}, nil
+	}
+	srv.Handlers["eth_unavailable"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return &MockResponse{StatusCode: http.StatusServiceUnavailable, Body: []byte("service unavailable")}, nil
+	}
+
+	client := rpcclient.NewClient(srv.URL)
+	res, err := client.Call(context.Background(), "eth_sendBundle")
+	assert.Nil(t, err, err)
+	assert.Equal(t, rpcclient.FlashbotsBrokenErrorResponseCode, res.Error.Code)
+	assert.Equal(t, "bundle rejected", res.Error.Message)
+
+	res, err = client.Call(context.Background(), "eth_call")
+	assert.Nil(t, err, err)
+	assert.Equal(t, "0x12345", res.Result)
+	httpRes, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[]}`))
+	assert.Nil(t, err, err)
+	httpRes.Body.Close()
+	assert.Equal(t, "a", httpRes.Header.Get("X-Test"))
+
+	_, err = client.Call(context.Background(), "eth_unavailable")
+	var httpErr *rpcclient.HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.Code)
+}
@@ -0,0 +1,178 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+)
+
+var mockUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// mockWSConn is a websocket connection of MockJSONRPCServer
+type mockWSConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *mockWSConn) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// mockSubscription is an eth_subscribe subscription of a websocket connection
+type mockSubscription struct {
+	id    string
+	topic string
+	conn  *mockWSConn
+}
+
+type mockSubscriptionNotification struct {
+	Version string                     `json:"jsonrpc"`
+	Method  string                     `json:"method"`
+	Params  mockSubscriptionResultJSON `json:"params"`
+}
+
+type mockSubscriptionResultJSON struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// handleWebsocket serves JSON-RPC over websocket, each message being a request or a batch. eth_subscribe and
+// eth_unsubscribe are handled by the server, see Notify.
+func (s *MockJSONRPCServer) handleWebsocket(w http.ResponseWriter, req *http.Request) {
+	conn, err := mockUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		log.Error("error upgrading websocket", "err", err)
+		return
+	}
+	wsConn := &mockWSConn{conn: conn}
+	defer func() {
+		s.unsubscribeConn(wsConn)
+		conn.Close()
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var res []byte
+		jsonReq := new(JSONRPCRequest)
+		if err := json.Unmarshal(msg, jsonReq); err == nil && (jsonReq.Method == "eth_subscribe" || jsonReq.Method == "eth_unsubscribe") {
+			s.recordRequests([]*JSONRPCRequest{jsonReq}, req, msg, common.Address{})
+			res, err = json.Marshal(s.handleSubscription(wsConn, jsonReq))
+			if err != nil {
+				log.Error("error marshalling response", "err", err)
+				continue
+			}
+		} else {
+			statusCode, _, body := s.handleBody(req, msg, common.Address{})
+			if statusCode == 0 {
+				return
+			}
+			res = bytes.TrimSpace(body)
+		}
+		if len(res) == 0 {
+			continue
+		}
+		if err := wsConn.write(res); err != nil {
+			return
+		}
+	}
+}
+
+func (s *MockJSONRPCServer) handleSubscription(conn *mockWSConn, jsonReq *JSONRPCRequest) *JSONRPCResponse {
+	s.IncrementRequestCounter(jsonReq.Method)
+	if len(jsonReq.Params) == 0 {
+		return NewJSONRPCErrorResponse(jsonReq.ID, ErrInvalidParams, "missing params")
+	}
+
+	s.subscriptionsLock.Lock()
+	defer s.subscriptionsLock.Unlock()
+
+	switch jsonReq.Method {
+	case "eth_subscribe":
+		topic, ok := jsonReq.Params[0].(string)
+		if !ok {
+			return NewJSONRPCErrorResponse(jsonReq.ID, ErrInvalidParams, "invalid subscription topic")
+		}
+		s.lastSubscriptionID++
+		id := fmt.Sprintf("0x%x", s.lastSubscriptionID)
+		s.subscriptions[id] = &mockSubscription{id: id, topic: topic, conn: conn}
+		res, _ := json.Marshal(id)
+		return NewJSONRPCResponse(jsonReq.ID, res)
+	default: // eth_unsubscribe
+		id, _ := jsonReq.Params[0].(string)
+		sub, found := s.subscriptions[id]
+		found = found && sub.conn == conn
+		if found {
+			delete(s.subscriptions, id)
+		}
+		res, _ := json.Marshal(found)
+		return NewJSONRPCResponse(jsonReq.ID, res)
+	}
+}
+
+func (s *MockJSONRPCServer) unsubscribeConn(conn *mockWSConn) {
+	s.subscriptionsLock.Lock()
+	defer s.subscriptionsLock.Unlock()
+	for id, sub := range s.subscriptions {
+		if sub.conn == conn {
+			delete(s.subscriptions, id)
+		}
+	}
+}
+
+// Notify pushes the result as eth_subscription notification to all websocket subscriptions of the topic (the first
+// param of eth_subscribe, e.g. "newHeads"), and returns the number of notified subscriptions
+func (s *MockJSONRPCServer) Notify(topic string, result interface{}) int {
+	s.subscriptionsLock.Lock()
+	var subs []*mockSubscription
+	for _, sub := range s.subscriptions {
+		if sub.topic == topic {
+			subs = append(subs, sub)
+		}
+	}
+	s.subscriptionsLock.Unlock()
+
+	notified := 0
+	for _, sub := range subs {
+		msg, err := json.Marshal(mockSubscriptionNotification{
+			Version: "2.0",
+			Method:  "eth_subscription",
+			Params:  mockSubscriptionResultJSON{Subscription: sub.id, Result: result},
+		})
+		if err != nil {
+			log.Error("error marshalling notification", "err", err, "data", result)
+			return notified
+		}
+		if err := sub.conn.write(msg); err != nil {
+			continue
+		}
+		notified++
+	}
+	return notified
+}
+
+// Subscriptions returns the number of websocket subscriptions of the topic
+func (s *MockJSONRPCServer) Subscriptions(topic string) int {
+	s.subscriptionsLock.Lock()
+	defer s.subscriptionsLock.Unlock()
+	count := 0
+	for _, sub := range s.subscriptions {
+		if sub.topic == topic {
+			count++
+		}
+	}
+	return count
+}
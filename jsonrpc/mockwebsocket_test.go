@@ -0,0 +1,48 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockJSONRPCServer_Websocket(t *testing.T) {
+	srv := NewMockJSONRPCServer()
+	defer srv.Close()
+	srv.Handlers["eth_chainId"] = func(req *JSONRPCRequest) (interface{}, error) {
+		return "0x1", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, srv.WSURL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), chainID.Int64())
+
+	headers := make(chan *types.Header, 1)
+	sub, err := client.SubscribeNewHead(ctx, headers)
+	require.NoError(t, err)
+	require.Equal(t, 1, srv.Subscriptions("newHeads"))
+
+	header := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0)}
+	require.Equal(t, 1, srv.Notify("newHeads", header))
+	select {
+	case received := <-headers:
+		require.Equal(t, header.Hash(), received.Hash())
+	case <-ctx.Done():
+		t.Fatal("no header received")
+	}
+
+	sub.Unsubscribe()
+	require.Eventually(t, func() bool { return srv.Subscriptions("newHeads") == 0 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, 0, srv.Notify("newHeads", header))
+}
@@ -0,0 +1,105 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/signature"
+)
+
+type requestConfig struct {
+	client *http.Client
+	header http.Header
+	signer *signature.Signer
+	retry  RetryOptions
+	// checkStatus fails the requests with retryable HTTP statuses, with WithRetry
+	checkStatus bool
+}
+
+// RequestOption customizes the HTTP requests of the Send* functions
+type RequestOption func(*requestConfig)
+
+// WithHTTPClient sends the requests with the client instead of http.DefaultClient, e.g. for timeouts or pinned TLS certificates
+func WithHTTPClient(client *http.Client) RequestOption {
+	return func(c *requestConfig) {
+		c.client = client
+	}
+}
+
+// WithHeader adds the header to the requests
+func WithHeader(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		if c.header == nil {
+			c.header = make(http.Header)
+		}
+		c.header.Add(key, value)
+	}
+}
+
+// WithHeaders adds the headers to the requests
+func WithHeaders(header http.Header) RequestOption {
+	return func(c *requestConfig) {
+		for key, values := range header {
+			for _, value := range values {
+				WithHeader(key, value)(c)
+			}
+		}
+	}
+}
+
+// WithSigner signs the request bodies with the signer, setting the X-Flashbots-Signature header
+func WithSigner(signer *signature.Signer) RequestOption {
+	return func(c *requestConfig) {
+		c.signer = signer
+	}
+}
+
+// RetryOptions configures the timeout and retries of the Send* functions, see WithRetry
+type RetryOptions struct {
+	// Timeout of each attempt, none by default (see the context and http.Client)
+	Timeout time.Duration
+	// Retries is the number of retries after the first attempt, on network errors, timeouts of attempts, and HTTP
+	// 429, 502, 503 and 504 responses
+	Retries int
+	// Backoff is the delay before the first retry, doubled at each retry
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, uncapped if 0
+	MaxBackoff time.Duration
+}
+
+// WithRetry retries the requests according to the options
+func WithRetry(opts RetryOptions) RequestOption {
+	return func(c *requestConfig) {
+		c.retry = opts
+		c.checkStatus = true
+	}
+}
+
+// WithTimeout times out each attempt of the requests after the duration
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.retry.Timeout = timeout
+	}
+}
+
+// HTTPStatusError is returned with WithRetry when the last attempt failed with a retryable HTTP status
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (err *HTTPStatusError) Error() string {
+	return fmt.Sprintf("http status %d", err.StatusCode)
+}
+
+// isRetryable returns true for network errors and timeouts of attempts (the overall context being checked by the
+// caller), and retryable HTTP statuses
+func isRetryable(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return retry.IsRetryableHTTP(statusErr.StatusCode, nil)
+	}
+	return retry.IsRetryableHTTP(0, err)
+}
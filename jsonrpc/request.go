@@ -1,13 +1,24 @@
 // Package jsonrpc is a minimal JSON-RPC implementation
+//
+// New client code should use rpcclient, the full-featured client. Existing code can migrate incrementally with the
+// conversions between both packages (ToRPCRequest, FromRPCResponse, ...), e.g. by replacing SendJSONRPCRequest with
+// SendJSONRPCRequestWithClient first.
 package jsonrpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/signature"
 )
 
+var ErrInvalidBatchResponse = errors.New("invalid batch response")
+
 type JSONRPCRequest struct {
 	ID      interface{}   `json:"id"`
 	Method  string        `json:"method"`
@@ -24,20 +35,21 @@ func NewJSONRPCRequest(id interface{}, method string, args interface{}) *JSONRPC
 	}
 }
 
-// SendJSONRPCRequest sends the request to URL and returns the general JsonRpcResponse, or an error (note: not the JSONRPCError)
-func SendJSONRPCRequest(req JSONRPCRequest, url string) (res *JSONRPCResponse, err error) {
-	buf, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
+// SendJSONRPCRequest sends the request to URL and returns the general JsonRpcResponse, or an error (note: not the JSONRPCError).
+// It doesn't time out, see SendJSONRPCRequestWithContext.
+func SendJSONRPCRequest(req JSONRPCRequest, url string, opts ...RequestOption) (res *JSONRPCResponse, err error) {
+	return SendJSONRPCRequestWithContext(context.Background(), req, url, opts...)
+}
 
-	rawResp, err := http.Post(url, "application/json", bytes.NewBuffer(buf))
+// SendJSONRPCRequestWithContext is SendJSONRPCRequest, aborting the request when the context is done
+func SendJSONRPCRequestWithContext(ctx context.Context, req JSONRPCRequest, url string, opts ...RequestOption) (res *JSONRPCResponse, err error) {
+	body, err := post(ctx, url, req, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	res = new(JSONRPCResponse)
-	if err := json.NewDecoder(rawResp.Body).Decode(res); err != nil {
+	if err := json.Unmarshal(body, res); err != nil {
 		return nil, err
 	}
 
@@ -45,15 +57,25 @@ func SendJSONRPCRequest(req JSONRPCRequest, url string) (res *JSONRPCResponse, e
 }
 
 // SendNewJSONRPCRequest constructs a request and sends it to the URL
-func SendNewJSONRPCRequest(id interface{}, method string, args interface{}, url string) (res *JSONRPCResponse, err error) {
+func SendNewJSONRPCRequest(id interface{}, method string, args interface{}, url string, opts ...RequestOption) (res *JSONRPCResponse, err error) {
+	return SendNewJSONRPCRequestWithContext(context.Background(), id, method, args, url, opts...)
+}
+
+// SendNewJSONRPCRequestWithContext is SendNewJSONRPCRequest, aborting the request when the context is done
+func SendNewJSONRPCRequestWithContext(ctx context.Context, id interface{}, method string, args interface{}, url string, opts ...RequestOption) (res *JSONRPCResponse, err error) {
 	req := NewJSONRPCRequest(id, method, args)
-	return SendJSONRPCRequest(*req, url)
+	return SendJSONRPCRequestWithContext(ctx, *req, url, opts...)
 }
 
 // SendJSONRPCRequestAndParseResult sends the request and decodes the response into the reply interface. If the JSON-RPC response
 // contains an Error property, the it's returned as this function's error.
-func SendJSONRPCRequestAndParseResult(req JSONRPCRequest, url string, reply interface{}) (err error) {
-	res, err := SendJSONRPCRequest(req, url)
+func SendJSONRPCRequestAndParseResult(req JSONRPCRequest, url string, reply interface{}, opts ...RequestOption) (err error) {
+	return SendJSONRPCRequestAndParseResultWithContext(context.Background(), req, url, reply, opts...)
+}
+
+// SendJSONRPCRequestAndParseResultWithContext is SendJSONRPCRequestAndParseResult, aborting the request when the context is done
+func SendJSONRPCRequestAndParseResultWithContext(ctx context.Context, req JSONRPCRequest, url string, reply interface{}, opts ...RequestOption) (err error) {
+	res, err := SendJSONRPCRequestWithContext(ctx, req, url, opts...)
 	if err != nil {
 		return err
 	}
@@ -68,3 +90,127 @@ func SendJSONRPCRequestAndParseResult(req JSONRPCRequest, url string, reply inte
 
 	return json.Unmarshal(res.Result, reply)
 }
+
+// SendJSONRPCBatch sends the requests as a batch to URL and returns the responses in the order of the requests, matched by
+// id. The response of a request is nil if the server didn't return one (e.g. for notifications).
+func SendJSONRPCBatch(reqs []JSONRPCRequest, url string, opts ...RequestOption) (res []*JSONRPCResponse, err error) {
+	return SendJSONRPCBatchWithContext(context.Background(), reqs, url, opts...)
+}
+
+// SendJSONRPCBatchWithContext is SendJSONRPCBatch, aborting the request when the context is done
+func SendJSONRPCBatchWithContext(ctx context.Context, reqs []JSONRPCRequest, url string, opts ...RequestOption) (res []*JSONRPCResponse, err error) {
+	body, err := post(ctx, url, reqs, opts)
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchResponse(reqs, body)
+}
+
+// post sends the JSON encoded payload and returns the response body, retrying according to the options
+func post(ctx context.Context, url string, payload interface{}, opts []RequestOption) ([]byte, error) {
+	cfg := &requestConfig{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var signatureHeader string
+	if cfg.signer != nil {
+		if signatureHeader, err = cfg.signer.Create(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	return retry.DoValue(ctx, func(ctx context.Context) ([]byte, error) {
+		return postOnce(ctx, cfg, url, buf, signatureHeader)
+	},
+		retry.WithMaxAttempts(cfg.retry.Retries+1),
+		retry.WithBackoff(cfg.retry.Backoff, cfg.retry.MaxBackoff),
+		retry.WithJitter(0),
+		retry.WithRetryIf(isRetryable),
+	)
+}
+
+func postOnce(ctx context.Context, cfg *requestConfig, url string, buf []byte, signatureHeader string) ([]byte, error) {
+	if cfg.retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.retry.Timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range cfg.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if signatureHeader != "" {
+		httpReq.Header.Set(signature.HTTPHeader, signatureHeader)
+	}
+
+	rawResp, err := cfg.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer rawResp.Body.Close()
+
+	body, err := io.ReadAll(rawResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.checkStatus && retry.IsRetryableHTTP(rawResp.StatusCode, nil) {
+		return body, &HTTPStatusError{StatusCode: rawResp.StatusCode}
+	}
+	return body, nil
+}
+
+func parseBatchResponse(reqs []JSONRPCRequest, body []byte) ([]*JSONRPCResponse, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		// the whole batch was rejected, e.g. with a parse error
+		single := new(JSONRPCResponse)
+		if err := json.Unmarshal(body, single); err != nil {
+			return nil, err
+		}
+		if single.Error != nil {
+			return nil, single.Error
+		}
+		return nil, ErrInvalidBatchResponse
+	}
+
+	var responses []*JSONRPCResponse
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // keeps large integer ids intact
+	if err := dec.Decode(&responses); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*JSONRPCResponse, len(responses))
+	for _, response := range responses {
+		if response == nil {
+			continue
+		}
+		id, err := json.Marshal(response.ID)
+		if err != nil {
+			return nil, err
+		}
+		byID[string(id)] = response
+	}
+
+	res := make([]*JSONRPCResponse, len(reqs))
+	for i, req := range reqs {
+		id, err := json.Marshal(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = byID[string(id)]
+	}
+	return res, nil
+}
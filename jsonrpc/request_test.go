@@ -1,9 +1,17 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/signature"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,3 +56,103 @@ func TestSendJSONRPCRequestAndParseResult(t *testing.T) {
 	err = SendJSONRPCRequestAndParseResult(*req2, addr, res2)
 	assert.NotNil(t, err, err)
 }
+
+func TestSendJSONRPCBatch(t *testing.T) {
+	addr := setupMockServer()
+
+	reqs := []JSONRPCRequest{
+		*NewJSONRPCRequest(1, "eth_call", "0xabc"),
+		*NewJSONRPCRequest("two", "unknown", "foo"),
+		*NewJSONRPCRequest(3, "eth_call", "0xdef"),
+	}
+	res, err := SendJSONRPCBatch(reqs, addr)
+	assert.Nil(t, err, err)
+	assert.Len(t, res, 3)
+
+	reply := new(string)
+	err = json.Unmarshal(res[0].Result, reply)
+	assert.Nil(t, err, err)
+	assert.Equal(t, "0x12345", *reply)
+	assert.NotNil(t, res[1].Error)
+	assert.Nil(t, res[2].Error)
+
+	// large ids are matched exactly, regardless of the order
+	res, err = parseBatchResponse([]JSONRPCRequest{{ID: uint64(1 << 63)}, {ID: uint64(1<<63 + 1)}},
+		[]byte(`[{"id":9223372036854775809,"result":"b"},{"id":9223372036854775808,"result":"a"}]`))
+	assert.Nil(t, err, err)
+	assert.Equal(t, `"a"`, string(res[0].Result))
+	assert.Equal(t, `"b"`, string(res[1].Result))
+
+	// the batch is rejected as a whole
+	_, err = parseBatchResponse(reqs, []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`))
+	assert.EqualError(t, err, "empty batch")
+}
+
+func TestSendJSONRPCRequestWithContext(t *testing.T) {
+	server := NewMockJSONRPCServer()
+	server.Handlers["eth_call"] = func(req *JSONRPCRequest) (interface{}, error) {
+		time.Sleep(time.Second)
+		return "0x12345", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := SendJSONRPCRequestWithContext(ctx, *NewJSONRPCRequest(1, "eth_call", "0xabc"), server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSendJSONRPCRequestOptions(t *testing.T) {
+	signer, err := signature.NewRandomSigner()
+	assert.Nil(t, err, err)
+
+	var header http.Header
+	var signerAddress common.Address
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		header = r.Header
+		signerAddress, _ = signature.Verify(r.Header.Get(signature.HTTPHeader), body)
+		_ = json.NewEncoder(w).Encode(NewJSONRPCResponse(1, json.RawMessage(`"ok"`)))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: time.Second}
+	reply := new(string)
+	err = SendJSONRPCRequestAndParseResult(*NewJSONRPCRequest(1, "eth_call", "0xabc"), server.URL, reply,
+		WithHTTPClient(client), WithHeader("Authorization", "Bearer token"), WithHeaders(http.Header{"X-Test": {"a", "b"}}), WithSigner(signer))
+	assert.Nil(t, err, err)
+	assert.Equal(t, "ok", *reply)
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, []string{"a", "b"}, header.Values("X-Test"))
+	assert.Equal(t, signer.Address(), signerAddress)
+}
+
+func TestSendJSONRPCRequestRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch attempts.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			time.Sleep(100 * time.Millisecond) // attempt times out
+		}
+		_ = json.NewEncoder(w).Encode(NewJSONRPCResponse(1, json.RawMessage(`"ok"`)))
+	}))
+	defer server.Close()
+
+	reply := new(string)
+	retry := RetryOptions{Timeout: 50 * time.Millisecond, Retries: 2, Backoff: time.Millisecond}
+	err := SendJSONRPCRequestAndParseResult(*NewJSONRPCRequest(1, "eth_call", "0xabc"), server.URL, reply, WithRetry(retry))
+	assert.Nil(t, err, err)
+	assert.Equal(t, "ok", *reply)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// retries exhausted
+	attempts.Store(0)
+	retry.Retries = 0
+	_, err = SendJSONRPCRequest(*NewJSONRPCRequest(1, "eth_call", "0xabc"), server.URL, WithRetry(retry))
+	var statusErr *HTTPStatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+}
@@ -0,0 +1,206 @@
+package logutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Alert describes a log entry that triggered an alert.
+type Alert struct {
+	Time    time.Time
+	Level   zapcore.Level
+	Logger  string
+	Message string
+	Fields  map[string]any
+	Stack   string
+
+	// Suppressed is the number of similar alerts that were throttled since the previous one was sent
+	Suppressed int
+}
+
+// AlertHook is invoked for log entries at or above AlertConfig.MinLevel, e.g. to send them to an error tracker
+// (see SentryHook) or a pager.
+type AlertHook interface {
+	Alert(ctx context.Context, alert Alert) error
+}
+
+// AlertHookFunc adapts a function to AlertHook.
+type AlertHookFunc func(ctx context.Context, alert Alert) error
+
+func (f AlertHookFunc) Alert(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// AlertConfig configures when alerts are raised.
+type AlertConfig struct {
+	// MinLevel is the lowest level that triggers alerts, Error by default (and if set below Warn)
+	MinLevel zapcore.Level
+	// Throttle is the minimum interval between alerts with the same level and message, 1 minute by default
+	Throttle time.Duration
+	// Timeout limits the duration of a hook invocation, 10 seconds by default
+	Timeout time.Duration
+}
+
+// alertThrottle keeps track of sent alerts, it is shared between the cores derived from each other.
+type alertThrottle struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	sent      map[samplingKey]*alertState
+	nextSweep time.Time
+}
+
+type alertState struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// allow returns whether the alert should be sent, and the number of alerts suppressed since the last one.
+func (t *alertThrottle) allow(level zapcore.Level, msg string) (bool, int) {
+	now := t.now()
+	key := samplingKey{level: int(level), msg: msg}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// forget the alerts one interval after their throttling expired, the number of suppressed alerts of a message
+	// that doesn't repeat until then is not reported
+	if !now.Before(t.nextSweep) {
+		for k, state := range t.sent {
+			if now.Sub(state.lastSent) >= 2*t.interval {
+				delete(t.sent, k)
+			}
+		}
+		t.nextSweep = now.Add(t.interval)
+	}
+
+	state, found := t.sent[key]
+	if !found {
+		t.sent[key] = &alertState{lastSent: now}
+		return true, 0
+	}
+	if now.Sub(state.lastSent) < t.interval {
+		state.suppressed++
+		return false, 0
+	}
+	suppressed := state.suppressed
+	state.lastSent = now
+	state.suppressed = 0
+	return true, suppressed
+}
+
+// alertCore is a zapcore.Core invoking an alert hook, it is meant to be used with zapcore.NewTee.
+type alertCore struct {
+	hook     AlertHook
+	cfg      AlertConfig
+	throttle *alertThrottle
+	fields   []zapcore.Field
+	wg       *sync.WaitGroup
+}
+
+// NewAlertCore returns a zapcore.Core that invokes the hook for entries at or above cfg.MinLevel, use it with
+// zapcore.NewTee. Error entries are alerted asynchronously, more severe ones (DPanic, Panic, Fatal) synchronously,
+// so the alert is sent before the process exits.
+func NewAlertCore(hook AlertHook, cfg AlertConfig) zapcore.Core {
+	if cfg.MinLevel < zapcore.WarnLevel {
+		cfg.MinLevel = zapcore.ErrorLevel
+	}
+	if cfg.Throttle <= 0 {
+		cfg.Throttle = time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &alertCore{
+		hook: hook,
+		cfg:  cfg,
+		throttle: &alertThrottle{
+			interval: cfg.Throttle,
+			now:      time.Now,
+			sent:     make(map[samplingKey]*alertState),
+		},
+		wg: new(sync.WaitGroup),
+	}
+}
+
+func (c *alertCore) Enabled(level zapcore.Level) bool {
+	return level >= c.cfg.MinLevel
+}
+
+func (c *alertCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+	clone := *c
+	clone.fields = combined
+	return &clone
+}
+
+func (c *alertCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *alertCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	allowed, suppressed := c.throttle.allow(entry.Level, entry.Message)
+	if !allowed {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	alert := Alert{
+		Time:       entry.Time,
+		Level:      entry.Level,
+		Logger:     entry.LoggerName,
+		Message:    entry.Message,
+		Fields:     enc.Fields,
+		Stack:      entry.Stack,
+		Suppressed: suppressed,
+	}
+
+	if entry.Level > zapcore.ErrorLevel {
+		return c.send(alert)
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		if err := c.send(alert); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to send alert: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (c *alertCore) send(alert Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+	return c.hook.Alert(ctx, alert)
+}
+
+// Sync waits for pending alerts to be sent.
+func (c *alertCore) Sync() error {
+	c.wg.Wait()
+	return nil
+}
+
+// LogAlertHook makes the logger invoke the hook for entries at or above cfg.MinLevel, see NewAlertCore.
+func LogAlertHook(hook AlertHook, cfg AlertConfig) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.alertHook = hook
+		lc.alertConfig = cfg
+	}
+}
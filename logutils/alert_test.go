@@ -0,0 +1,125 @@
+package logutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAlertCore(t *testing.T) {
+	var mu sync.Mutex
+	var alerts []Alert
+	hook := AlertHookFunc(func(_ context.Context, alert Alert) error {
+		mu.Lock()
+		defer mu.Unlock()
+		alerts = append(alerts, alert)
+		return nil
+	})
+
+	core := NewAlertCore(hook, AlertConfig{Throttle: time.Minute})
+	now := time.Now()
+	core.(*alertCore).throttle.now = func() time.Time { return now }
+	logger := zap.New(core).With(zap.String("component", "blocksub"))
+
+	logger.Warn("not alerted")
+	for i := 0; i < 3; i++ {
+		logger.Error("websocket failed", zap.Int("attempt", i))
+	}
+	now = now.Add(time.Minute)
+	logger.Error("websocket failed", zap.Int("attempt", 3))
+	require.NoError(t, logger.Sync())
+
+	require.Len(t, alerts, 2)
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Fields["attempt"].(int64) < alerts[j].Fields["attempt"].(int64) })
+	require.Equal(t, zapcore.ErrorLevel, alerts[0].Level)
+	require.Equal(t, "blocksub", alerts[0].Fields["component"])
+	require.Equal(t, 0, alerts[0].Suppressed)
+	require.Equal(t, 2, alerts[1].Suppressed)
+}
+
+func TestSentryHook(t *testing.T) {
+	_, err := NewSentryHook("https://sentry.io/1", SentryOptions{})
+	require.ErrorIs(t, err, ErrInvalidSentryDSN)
+
+	var event map[string]any
+	var auth, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&event)
+	}))
+	defer srv.Close()
+
+	hook, err := NewSentryHook("http://publickey@"+srv.Listener.Addr().String()+"/42", SentryOptions{Environment: "prod"})
+	require.NoError(t, err)
+	err = hook.Alert(context.Background(), Alert{
+		Time:    time.Now(),
+		Level:   zapcore.FatalLevel,
+		Message: "builder crashed",
+		Fields:  map[string]any{"slot": 1},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/42/store/", path)
+	require.Contains(t, auth, "sentry_key=publickey")
+	require.Equal(t, "fatal", event["level"])
+	require.Equal(t, "builder crashed", event["message"])
+	require.Equal(t, "prod", event["environment"])
+	require.Equal(t, map[string]any{"slot": float64(1)}, event["extra"])
+}
+
+func TestAlertThrottleSweep(t *testing.T) {
+	now := time.Now()
+	throttle := &alertThrottle{interval: time.Minute, now: func() time.Time { return now }, sent: make(map[samplingKey]*alertState)}
+
+	for i := 0; i < 100; i++ {
+		allowed, _ := throttle.allow(zapcore.ErrorLevel, fmt.Sprintf("failed %d", i))
+		require.True(t, allowed)
+	}
+	allowed, _ := throttle.allow(zapcore.ErrorLevel, "failed 0")
+	require.False(t, allowed)
+	require.Len(t, throttle.sent, 100)
+
+	// the suppressed alerts are still reported after the throttling expired
+	now = now.Add(time.Minute)
+	allowed, suppressed := throttle.allow(zapcore.ErrorLevel, "failed 0")
+	require.True(t, allowed)
+	require.Equal(t, 1, suppressed)
+
+	now = now.Add(time.Minute)
+	throttle.allow(zapcore.ErrorLevel, "failed 0")
+	require.Len(t, throttle.sent, 1)
+}
+
+func TestAlertHookSlog(t *testing.T) {
+	alerts := make(chan Alert, 10)
+	hook := AlertHookFunc(func(_ context.Context, alert Alert) error {
+		alerts <- alert
+		return nil
+	})
+	logger, err := GetSlogLogger(LogAlertHook(hook, AlertConfig{}), LogOutput(new(discard)))
+	require.NoError(t, err)
+
+	logger.Warn("not alerted")
+	logger.With("component", "blocksub").Error("websocket failed", "attempt", 1)
+
+	select {
+	case alert := <-alerts:
+		require.Equal(t, "websocket failed", alert.Message)
+		require.Equal(t, zapcore.ErrorLevel, alert.Level)
+		require.Equal(t, "blocksub", alert.Fields["component"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the alert")
+	}
+	require.Empty(t, alerts)
+}
@@ -0,0 +1,162 @@
+package logutils
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"go.uber.org/zap/zapcore"
+)
+
+// incremented when an entry is dropped because the buffer of an AsyncWriter is full
+const droppedEntriesLabel = `goutils_log_dropped_entries_count{writer="%s"}`
+
+// AsyncConfig configures AsyncWriter.
+type AsyncConfig struct {
+	// Name is used as the writer label of the dropped entries metric, "default" if empty
+	Name string
+	// BufferSize is the number of entries that can be buffered before new ones are dropped, 4096 by default
+	BufferSize int
+	// FlushTimeout limits how long Sync waits for the buffered entries to be written, 5 seconds by default
+	FlushTimeout time.Duration
+}
+
+// AsyncWriter writes to the underlying writer in a background goroutine, so that writing never blocks. When the
+// buffer is full, entries are dropped and counted (see Dropped) instead of backpressuring the caller. Sync waits
+// for the buffered entries to be written, so FlushZap on shutdown doesn't lose them.
+type AsyncWriter struct {
+	w            io.Writer
+	flushTimeout time.Duration
+	dropped      atomic.Uint64
+	droppedCount *metrics.Counter
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan asyncEntry
+	done    chan struct{}
+}
+
+// asyncEntry is either data to write or a flush request.
+type asyncEntry struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// NewAsyncWriter creates an AsyncWriter writing to w, call Close to stop it.
+func NewAsyncWriter(w io.Writer, cfg AsyncConfig) *AsyncWriter {
+	if cfg.Name == "" {
+		cfg.Name = "default"
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 4096
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = 5 * time.Second
+	}
+	aw := &AsyncWriter{
+		w:            w,
+		flushTimeout: cfg.FlushTimeout,
+		droppedCount: metrics.GetOrCreateCounter(fmt.Sprintf(droppedEntriesLabel, cfg.Name)),
+		entries:      make(chan asyncEntry, cfg.BufferSize),
+		done:         make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+	for entry := range aw.entries {
+		if entry.flushed != nil {
+			close(entry.flushed)
+			continue
+		}
+		_, _ = aw.w.Write(entry.data)
+	}
+}
+
+// Write buffers a copy of p, or drops it if the buffer is full. It never returns an error, except if the writer
+// is closed, in which case p is written synchronously.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+
+	if aw.closed {
+		return aw.w.Write(p)
+	}
+	// the caller (e.g. the zap encoder) may reuse p
+	data := make([]byte, len(p))
+	copy(data, p)
+	select {
+	case aw.entries <- asyncEntry{data: data}:
+	default:
+		aw.dropped.Add(1)
+		aw.droppedCount.Inc()
+	}
+	return len(p), nil
+}
+
+// Sync waits for the entries buffered so far to be written (up to the flush timeout) and syncs the underlying
+// writer if it supports it.
+func (aw *AsyncWriter) Sync() error {
+	aw.mu.RLock()
+	closed := aw.closed
+	if !closed {
+		flushed := make(chan struct{})
+		timer := time.NewTimer(aw.flushTimeout)
+		defer timer.Stop()
+		select {
+		case aw.entries <- asyncEntry{flushed: flushed}:
+			select {
+			case <-flushed:
+			case <-timer.C:
+				aw.mu.RUnlock()
+				return fmt.Errorf("timeout flushing the log buffer after %s", aw.flushTimeout)
+			}
+		case <-timer.C:
+			aw.mu.RUnlock()
+			return fmt.Errorf("timeout flushing the log buffer after %s", aw.flushTimeout)
+		}
+	}
+	aw.mu.RUnlock()
+
+	if syncer, ok := aw.w.(zapcore.WriteSyncer); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Close writes the buffered entries and stops the background goroutine. Later writes are synchronous.
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if aw.closed {
+		aw.mu.Unlock()
+		return nil
+	}
+	aw.closed = true
+	close(aw.entries)
+	aw.mu.Unlock()
+
+	<-aw.done
+	if syncer, ok := aw.w.(zapcore.WriteSyncer); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+// LogAsync makes the logger write its output (stderr or LogOutput) through an AsyncWriter. FlushZap (or
+// logger.Sync) waits for the buffered entries to be written. It applies to GetZapLogger only; for slog, pass an
+// AsyncWriter to LogOutput and sync it on shutdown.
+func LogAsync(cfg AsyncConfig) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.async = &cfg
+	}
+}
@@ -0,0 +1,74 @@
+package logutils
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks writes until released.
+type blockingWriter struct {
+	release chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	aw := NewAsyncWriter(w, AsyncConfig{Name: "test", BufferSize: 2})
+	defer aw.Close()
+
+	// the first entry is picked up by the background goroutine, which blocks, the next two are buffered
+	_, _ = aw.Write([]byte("first\n"))
+	require.Eventually(t, func() bool { return len(aw.entries) == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < 5; i++ {
+		_, err := aw.Write([]byte("next\n"))
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(3), aw.Dropped())
+
+	close(w.release)
+	require.NoError(t, aw.Sync())
+	require.Equal(t, "first\nnext\nnext\n", w.String())
+}
+
+func TestLogAsync(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := GetZapLogger(LogOutput(&buf), LogAsync(AsyncConfig{}))
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		logger.Info("hello")
+	}
+	FlushZap(logger)
+	require.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 100)
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	var buf bytes.Buffer
+	aw := NewAsyncWriter(&buf, AsyncConfig{})
+	_, err := aw.Write([]byte("buffered\n"))
+	require.NoError(t, err)
+	require.NoError(t, aw.Close())
+	_, err = aw.Write([]byte("direct\n"))
+	require.NoError(t, err)
+	require.Equal(t, "buffered\ndirect\n", buf.String())
+	require.Zero(t, aw.Dropped())
+}
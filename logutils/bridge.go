@@ -0,0 +1,261 @@
+package logutils
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogFromZap returns a *slog.Logger that writes to the zap logger, e.g. to pass a zap logger where a slog logger
+// is required.
+func SlogFromZap(logger *zap.Logger) *slog.Logger {
+	return slog.New(NewZapHandler(logger.Core()))
+}
+
+// ZapFromSlog returns a *zap.Logger that writes to the slog logger.
+func ZapFromSlog(logger *slog.Logger) *zap.Logger {
+	return zap.New(NewSlogCore(logger.Handler()))
+}
+
+// slogLevel converts a zap level to a slog level: Debug, Info, Warn and Error map to their slog counterparts,
+// DPanic, Panic and Fatal to levels above Error.
+func slogLevel(level zapcore.Level) slog.Level {
+	return slog.Level(int(level) * 4)
+}
+
+// zapLevel converts a slog level to the closest zap level at or below it.
+func zapLevel(level slog.Level) zapcore.Level {
+	l := int(level) / 4
+	if int(level)%4 != 0 && level < 0 {
+		l-- // round towards negative infinity
+	}
+	if l < int(zapcore.DebugLevel) {
+		return zapcore.DebugLevel
+	}
+	if l > int(zapcore.FatalLevel) {
+		return zapcore.FatalLevel
+	}
+	return zapcore.Level(l)
+}
+
+// zapHandler is a slog.Handler writing to a zap core.
+type zapHandler struct {
+	core zapcore.Core
+}
+
+// NewZapHandler returns a slog.Handler that writes to the zap core.
+func NewZapHandler(core zapcore.Core) slog.Handler {
+	return &zapHandler{core: core}
+}
+
+func (h *zapHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *zapHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(record.PC, frame.File, frame.Line, true)
+		entry.Caller.Function = frame.Function
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if field, ok := zapField(attr); ok {
+			fields = append(fields, field)
+		}
+		return true
+	})
+	if len(FieldsFromContext(ctx)) > 0 {
+		// expanded by the context core, if any
+		fields = append(fields, Context(ctx))
+	}
+
+	if checked := h.core.Check(entry, nil); checked != nil {
+		checked.Write(fields...)
+	}
+	return nil
+}
+
+func (h *zapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		if field, ok := zapField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	return &zapHandler{core: h.core.With(fields)}
+}
+
+func (h *zapHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	// zap namespaces apply to all fields added later, like slog groups
+	return &zapHandler{core: h.core.With([]zapcore.Field{zap.Namespace(name)})}
+}
+
+// zapField converts a slog attribute, returns false for attributes that should be ignored.
+func zapField(attr slog.Attr) (zapcore.Field, bool) {
+	value := attr.Value.Resolve()
+	if attr.Key == "" && value.Kind() != slog.KindGroup {
+		return zapcore.Field{}, false
+	}
+
+	switch value.Kind() {
+	case slog.KindString:
+		return zap.String(attr.Key, value.String()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, value.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, value.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, value.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(attr.Key, value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, value.Duration()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, value.Time()), true
+	case slog.KindGroup:
+		group := value.Group()
+		if len(group) == 0 {
+			return zapcore.Field{}, false
+		}
+		if attr.Key == "" {
+			// inline the attributes of groups without key
+			return zap.Inline(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				return addAttrs(enc, group)
+			})), true
+		}
+		return zap.Object(attr.Key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			return addAttrs(enc, group)
+		})), true
+	default:
+		if err, ok := value.Any().(error); ok {
+			return zap.NamedError(attr.Key, err), true
+		}
+		return zap.Any(attr.Key, value.Any()), true
+	}
+}
+
+func addAttrs(enc zapcore.ObjectEncoder, attrs []slog.Attr) error {
+	for _, attr := range attrs {
+		if field, ok := zapField(attr); ok {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// slogCore is a zapcore.Core writing to a slog handler.
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewSlogCore returns a zapcore.Core that writes to the slog handler.
+func NewSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{handler: handler}
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	handler := c.handler
+	for {
+		attrs, group, rest := slogAttrs(fields)
+		handler = handler.WithAttrs(attrs)
+		if group == "" {
+			return &slogCore{handler: handler}
+		}
+		// zap namespaces apply to all fields added later, like slog groups
+		handler = handler.WithGroup(group)
+		fields = rest
+	}
+}
+
+func (c *slogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *slogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, entry.Caller.PC)
+	record.AddAttrs(slogAttrsNested(fields)...)
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// slogAttrs converts the fields up to the first namespace, and returns the name of that namespace and the
+// fields following it.
+func slogAttrs(fields []zapcore.Field) ([]slog.Attr, string, []zapcore.Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for i, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			return attrs, field.Key, fields[i+1:]
+		}
+		attrs = append(attrs, slogAttr(field)...)
+	}
+	return attrs, "", nil
+}
+
+// slogAttrsNested converts the fields, the fields following a namespace become a group.
+func slogAttrsNested(fields []zapcore.Field) []slog.Attr {
+	attrs, group, rest := slogAttrs(fields)
+	if group != "" {
+		nested := slogAttrsNested(rest)
+		args := make([]any, len(nested))
+		for i, attr := range nested {
+			args[i] = attr
+		}
+		attrs = append(attrs, slog.Group(group, args...))
+	}
+	return attrs
+}
+
+// slogAttr converts a field by letting it encode itself, which supports all field types.
+func slogAttr(field zapcore.Field) []slog.Attr {
+	switch field.Type {
+	case zapcore.StringType:
+		return []slog.Attr{slog.String(field.Key, field.String)}
+	case zapcore.BoolType:
+		return []slog.Attr{slog.Bool(field.Key, field.Integer == 1)}
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return []slog.Attr{slog.Int64(field.Key, field.Integer)}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return []slog.Attr{slog.Any(field.Key, err)}
+		}
+	case zapcore.SkipType:
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	keys := make([]string, 0, len(enc.Fields))
+	for key := range enc.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, enc.Fields[key]))
+	}
+	return attrs
+}
@@ -0,0 +1,76 @@
+package logutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogFromZap(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := SlogFromZap(zap.New(core)).With("service", "builder")
+
+	logger.Debug("not logged")
+	logger.Warn("slot missed", "slot", 123, "err", errors.New("timeout"), slog.Group("block", "number", 7))
+	logger.WithGroup("req").Info("done", "took", time.Second)
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	require.Equal(t, "builder", fields["service"])
+	require.Equal(t, int64(123), fields["slot"])
+	require.Equal(t, "timeout", fields["err"])
+	require.Equal(t, map[string]any{"number": int64(7)}, fields["block"])
+	require.Equal(t, map[string]any{"took": time.Second}, entries[1].ContextMap()["req"])
+}
+
+func TestZapFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := ZapFromSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logger.Debug("not logged")
+	logger.With(zap.String("service", "builder")).Error("failed",
+		zap.Int("slot", 123),
+		zap.Error(errors.New("timeout")),
+		zap.Namespace("block"),
+		zap.Uint64("number", 7),
+	)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "ERROR", out["level"])
+	require.Equal(t, "failed", out["msg"])
+	require.Equal(t, "builder", out["service"])
+	require.Equal(t, float64(123), out["slot"])
+	require.Equal(t, "timeout", out["error"])
+	require.Equal(t, map[string]any{"number": float64(7)}, out["block"])
+}
+
+func TestLevelConversion(t *testing.T) {
+	for _, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.FatalLevel} {
+		require.Equal(t, level, zapLevel(slogLevel(level)))
+	}
+	require.Equal(t, slog.LevelWarn, slogLevel(zapcore.WarnLevel))
+	require.Equal(t, zapcore.DebugLevel, zapLevel(slog.Level(-8)))
+	require.Equal(t, zapcore.DebugLevel, zapLevel(slog.Level(-2)))
+	require.Equal(t, zapcore.InfoLevel, zapLevel(slog.Level(2)))
+}
+
+func TestZapFromSlogWithNamespace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := ZapFromSlog(slog.New(slog.NewJSONHandler(&buf, nil)))
+	logger.With(zap.Namespace("req"), zap.String("id", "abc")).Info("done", zap.Int("status", 200))
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, map[string]any{"id": "abc", "status": float64(200)}, out["req"])
+}
@@ -0,0 +1,146 @@
+package logutils
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const fieldsContextKey contextKey = "fields"
+
+// ContextWithFields returns a copy of parent context with the fields added to the ones already stored in it. The
+// context core and handler (see LogContextFields) append them to every entry logged with the context, e.g.
+//
+//	ctx = logutils.ContextWithFields(ctx, zap.String("requestID", id), zap.String("signer", signer.Hex()))
+//	logger.Info("bundle received", logutils.Context(ctx)) // zap
+//	slogLogger.InfoContext(ctx, "bundle received")        // slog
+func ContextWithFields(parent context.Context, fields ...zap.Field) context.Context {
+	existing := FieldsFromContext(parent)
+	combined := make([]zap.Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(parent, fieldsContextKey, combined)
+}
+
+// ContextWithAttrs is the slog equivalent of ContextWithFields.
+func ContextWithAttrs(parent context.Context, attrs ...slog.Attr) context.Context {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		if field, ok := zapField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	return ContextWithFields(parent, fields...)
+}
+
+// FieldsFromContext returns the fields stored in the context.
+func FieldsFromContext(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsContextKey).([]zap.Field)
+	return fields
+}
+
+// contextFields is the value of the field returned by Context.
+type contextFields struct {
+	ctx context.Context
+}
+
+// Context returns a field standing for the fields stored in the context, which are expanded by the context core
+// (see NewContextCore). Without it, the field is ignored.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Type: zapcore.SkipType, Interface: contextFields{ctx: ctx}}
+}
+
+// expandContextFields replaces the fields returned by Context with the fields stored in their context.
+func expandContextFields(fields []zapcore.Field) []zapcore.Field {
+	found := false
+	for _, field := range fields {
+		if _, ok := field.Interface.(contextFields); ok && field.Type == zapcore.SkipType {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fields
+	}
+
+	expanded := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		if cf, ok := field.Interface.(contextFields); ok && field.Type == zapcore.SkipType {
+			expanded = append(expanded, FieldsFromContext(cf.ctx)...)
+		} else {
+			expanded = append(expanded, field)
+		}
+	}
+	return expanded
+}
+
+// contextCore is a zapcore.Core expanding the fields returned by Context.
+type contextCore struct {
+	zapcore.Core
+}
+
+// NewContextCore wraps core, expanding the fields returned by Context into the fields stored in their context.
+func NewContextCore(core zapcore.Core) zapcore.Core {
+	return &contextCore{Core: core}
+}
+
+func (c *contextCore) With(fields []zapcore.Field) zapcore.Core {
+	return &contextCore{Core: c.Core.With(expandContextFields(fields))}
+}
+
+func (c *contextCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *contextCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, expandContextFields(fields))
+}
+
+// contextHandler is a slog.Handler adding the fields stored in the context of records.
+type contextHandler struct {
+	handler slog.Handler
+}
+
+// NewContextHandler wraps handler, adding the fields stored in the context (see ContextWithFields) to the records
+// logged with it, e.g. with slog.Logger.InfoContext.
+func NewContextHandler(handler slog.Handler) slog.Handler {
+	return &contextHandler{handler: handler}
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := FieldsFromContext(ctx); len(fields) > 0 {
+		record = record.Clone()
+		for _, field := range fields {
+			record.AddAttrs(slogAttr(field)...)
+		}
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{handler: h.handler.WithGroup(name)}
+}
+
+// LogContextFields makes the logger add the fields stored in the context (see ContextWithFields) to every entry
+// logged with the context: slog records logged with it and zap entries with the Context field.
+func LogContextFields() LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.contextFields = true
+	}
+}
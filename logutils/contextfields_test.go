@@ -0,0 +1,47 @@
+package logutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestContextFields(t *testing.T) {
+	ctx := ContextWithFields(context.Background(), zap.String("requestID", "abc"))
+	ctx = ContextWithAttrs(ctx, slog.String("signer", "0x01"), slog.String("password", "hunter2"))
+	require.Len(t, FieldsFromContext(ctx), 3)
+
+	var buf bytes.Buffer
+	logger, err := GetZapLogger(LogOutput(&buf), LogContextFields(), LogRedaction(DefaultRedactor()))
+	require.NoError(t, err)
+	logger.Info("bundle received", Context(ctx), zap.Int("txs", 2))
+	SlogFromZap(logger).InfoContext(ctx, "bundle simulated")
+
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		require.Equal(t, "abc", entry["requestID"])
+		require.Equal(t, "0x01", entry["signer"])
+		require.Equal(t, Redacted, entry["password"])
+	}
+
+	buf.Reset()
+	slogLogger, err := GetSlogLogger(LogOutput(&buf), LogContextFields())
+	require.NoError(t, err)
+	slogLogger.InfoContext(ctx, "bundle received")
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "abc", entry["requestID"])
+
+	// without the context core, the field is ignored
+	buf.Reset()
+	logger, err = GetZapLogger(LogOutput(&buf))
+	require.NoError(t, err)
+	logger.Info("bundle received", Context(ctx))
+	require.NotContains(t, buf.String(), "requestID")
+}
@@ -1,13 +1,43 @@
 package logutils
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Log formats, see LogFormat
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
 type loggerConfig struct {
-	devMode bool
-	level   string
+	devMode  bool
+	level    string
+	format   string
+	output   io.Writer
+	redactor *Redactor
+	sampling *SamplingConfig
+
+	filename string
+	rotation RotationConfig
+	file     *RotatingFile
+
+	serviceInfo *ServiceInfo
+
+	moduleLevels string
+	ringBuffer   *RingBuffer
+
+	alertHook   AlertHook
+	alertConfig AlertConfig
+
+	async *AsyncConfig
+
+	contextFields bool
 }
 
 // LogConfigOption allows to fine-tune the configuration of the logger.
@@ -27,6 +57,54 @@ func LogLevel(level string) LogConfigOption {
 	}
 }
 
+// LogFormat sets the output format, FormatJSON or FormatText. By default, the development mode logs text and the
+// production mode JSON.
+func LogFormat(format string) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.format = format
+	}
+}
+
+// LogOutput makes the logger write to w instead of stderr.
+func LogOutput(w io.Writer) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.output = w
+	}
+}
+
+// LogFile makes the logger write to a rotating file in addition to the regular output (stderr), see RotatingFile.
+// The file stays open for the lifetime of the logger, use LogRotatingFile to close it.
+func LogFile(filename string, rotation RotationConfig) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.filename = filename
+		lc.rotation = rotation
+	}
+}
+
+// LogRotatingFile is like LogFile with a file opened by the caller, who closes it once the logger is not used
+// anymore (e.g. after FlushZap on shutdown).
+func LogRotatingFile(file *RotatingFile) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.file = file
+	}
+}
+
+// openFile returns the file set with LogRotatingFile or opens the one set with LogFile, nil if there is none. The
+// returned function closes the file if it was opened here.
+func (lc *loggerConfig) openFile() (*RotatingFile, func(), error) {
+	if lc.file != nil {
+		return lc.file, func() {}, nil
+	}
+	if lc.filename == "" {
+		return nil, func() {}, nil
+	}
+	file, err := NewRotatingFile(lc.filename, lc.rotation)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return file, func() { _ = file.Close() }, nil
+}
+
 // GetZapLogger returns a logger created according to the provided options. In
 // case if anything goes wrong (for example if the log-level string can not be
 // parsed) it will return a logger (with configuration that is closest possible
@@ -50,10 +128,83 @@ func GetZapLogger(options ...LogConfigOption) (*zap.Logger, error) {
 
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
+	var formatErr error
+	switch cfg.format {
+	case "":
+	case FormatJSON:
+		config.Encoding = "json"
+	case FormatText:
+		config.Encoding = "console"
+	default:
+		formatErr = fmt.Errorf("unknown log format %q", cfg.format)
+	}
+	newEncoder := func() zapcore.Encoder {
+		if config.Encoding == "console" {
+			return zapcore.NewConsoleEncoder(config.EncoderConfig)
+		}
+		return zapcore.NewJSONEncoder(config.EncoderConfig)
+	}
+
+	var buildOptions []zap.Option
+	if cfg.async != nil {
+		var output io.Writer = os.Stderr
+		if cfg.output != nil {
+			output = cfg.output
+		}
+		asyncWriter := NewAsyncWriter(output, *cfg.async)
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewCore(newEncoder(), asyncWriter, config.Level)
+		}))
+	} else if cfg.output != nil {
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewCore(newEncoder(), zapcore.AddSync(cfg.output), config.Level)
+		}))
+	}
+	// on fileErr, the logger writes to the regular outputs only
+	file, closeFile, fileErr := cfg.openFile()
+	if file != nil {
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, zapcore.NewCore(newEncoder(), file, config.Level))
+		}))
+	}
+	if cfg.ringBuffer != nil {
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, cfg.ringBuffer.Core(config.Level))
+		}))
+	}
+	if cfg.alertHook != nil {
+		alertCore := NewAlertCore(cfg.alertHook, cfg.alertConfig)
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, alertCore)
+		}))
+	}
+	if cfg.sampling != nil {
+		config.Sampling = nil
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewSamplingCore(core, *cfg.sampling)
+		}))
+	}
+	if cfg.serviceInfo != nil {
+		buildOptions = append(buildOptions, zap.Fields(cfg.serviceInfo.ZapFields()...))
+	}
+	// redaction must wrap sampling, as sampling summaries contain the original messages
+	if cfg.redactor != nil {
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewRedactingCore(core, cfg.redactor)
+		}))
+	}
+	// the context fields are expanded before redaction
+	if cfg.contextFields {
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewContextCore(core)
+		}))
+	}
+
 	// Test the logger build as per the configuration we have so far
 	// (we want to know if anything is wrong as early as possible)
-	basicLogger, err := config.Build()
+	basicLogger, err := config.Build(buildOptions...)
 	if err != nil {
+		closeFile()
 		return zap.L(), err // Return global logger for MustGetZapLogger sake
 	}
 
@@ -64,13 +215,27 @@ func GetZapLogger(options ...LogConfigOption) (*zap.Logger, error) {
 	}
 	config.Level = level
 
+	if cfg.moduleLevels != "" {
+		moduleLevels, err := ParseModuleLevels(cfg.moduleLevels)
+		if err != nil {
+			return basicLogger, err
+		}
+		config.Level = zap.NewAtomicLevelAt(moduleLevels.MinLevel())
+		buildOptions = append(buildOptions, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewModuleLevelCore(core, moduleLevels)
+		}))
+	}
+
 	// Build the final config of the logger
-	finalLogger, err := config.Build()
+	finalLogger, err := config.Build(buildOptions...)
 	if err != nil {
 		return basicLogger, err
 	}
 
-	return finalLogger, nil
+	if formatErr != nil {
+		return finalLogger, formatErr
+	}
+	return finalLogger, fileErr
 }
 
 // MustGetZapLogger is guaranteed to return a logger with configuration as close
@@ -0,0 +1,183 @@
+package logutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// iso8601TimeFormat matches the timestamps of zapcore.ISO8601TimeEncoder
+const iso8601TimeFormat = "2006-01-02T15:04:05.000Z0700"
+
+// ParseSlogLevel parses zap level names (debug, info, warn, error, dpanic, panic, fatal) as well as slog level
+// names (e.g. "WARN" or "DEBUG+2").
+func ParseSlogLevel(level string) (slog.Level, error) {
+	var zapLvl zapcore.Level
+	if err := zapLvl.UnmarshalText([]byte(level)); err == nil {
+		return slogLevel(zapLvl), nil
+	}
+	var slogLvl slog.Level
+	if err := slogLvl.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+	return slogLvl, nil
+}
+
+// GetSlogLogger returns a logger created according to the provided options, with the same defaults as
+// GetZapLogger: JSON output (text in development mode) to stderr, ISO8601 timestamps and info level. In case if
+// anything goes wrong (for example if the log-level string can not be parsed) it will return a logger (with
+// configuration that is closest possible to the desired one) and an error.
+func GetSlogLogger(options ...LogConfigOption) (*slog.Logger, error) {
+	cfg := &loggerConfig{
+		devMode: false,
+		level:   "info",
+	}
+
+	for _, o := range options {
+		o(cfg)
+	}
+
+	var errs []error
+
+	level, err := ParseSlogLevel(cfg.level)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	var moduleLevels *ModuleLevels
+	if cfg.moduleLevels != "" {
+		levels, err := ParseModuleLevels(cfg.moduleLevels)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			moduleLevels = &levels
+			level = slogLevel(levels.MinLevel())
+		}
+	}
+
+	var output io.Writer = os.Stderr
+	if cfg.output != nil {
+		output = cfg.output
+	}
+	if file, _, err := cfg.openFile(); err != nil {
+		errs = append(errs, err) // log to the regular output only
+	} else if file != nil {
+		output = io.MultiWriter(output, file)
+	}
+
+	handlerOptions := &slog.HandlerOptions{
+		AddSource: cfg.devMode,
+		Level:     level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey && attr.Value.Kind() == slog.KindTime {
+				return slog.String(slog.TimeKey, attr.Value.Time().Format(iso8601TimeFormat))
+			}
+			return attr
+		},
+	}
+
+	format := cfg.format
+	if format == "" {
+		format = FormatJSON
+		if cfg.devMode {
+			format = FormatText
+		}
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatText:
+		handler = slog.NewTextHandler(output, handlerOptions)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	default:
+		errs = append(errs, fmt.Errorf("unknown log format %q", cfg.format))
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	}
+
+	// the ring buffer and the alert hook are zap cores, the entries are bridged to them
+	if cfg.ringBuffer != nil {
+		handler = &teeHandler{handlers: []slog.Handler{handler, NewZapHandler(cfg.ringBuffer.Core(zapLevel(level)))}}
+	}
+	if cfg.alertHook != nil {
+		handler = &teeHandler{handlers: []slog.Handler{handler, NewZapHandler(NewAlertCore(cfg.alertHook, cfg.alertConfig))}}
+	}
+	if moduleLevels != nil {
+		handler = NewModuleLevelHandler(handler, *moduleLevels)
+	}
+	if cfg.sampling != nil {
+		handler = NewSamplingHandler(handler, *cfg.sampling)
+	}
+	// redaction must wrap sampling, as sampling summaries contain the original messages
+	if cfg.redactor != nil {
+		handler = NewRedactingHandler(handler, cfg.redactor)
+	}
+	// the context fields are added before redaction
+	if cfg.contextFields {
+		handler = NewContextHandler(handler)
+	}
+
+	logger := slog.New(handler)
+	if cfg.serviceInfo != nil {
+		logger = logger.With(cfg.serviceInfo.SlogArgs()...)
+	}
+
+	return logger, errors.Join(errs...)
+}
+
+// MustGetSlogLogger is guaranteed to return a logger with configuration as close
+// as possible to the desired one. Any errors encountered in the process will be
+// logged as warnings with the resulting logger.
+func MustGetSlogLogger(options ...LogConfigOption) *slog.Logger {
+	l, err := GetSlogLogger(options...)
+	if err != nil {
+		l.Warn("Error while building the logger", "err", err)
+	}
+	return l
+}
+
+// teeHandler passes the records to all the handlers enabled for their level.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
@@ -0,0 +1,54 @@
+package logutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlogLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"info":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"error":   slog.LevelError,
+		"WARN":    slog.LevelWarn,
+		"DEBUG+2": slog.LevelDebug + 2,
+	} {
+		level, err := ParseSlogLevel(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, level, in)
+	}
+
+	_, err := ParseSlogLevel("verbose")
+	require.Error(t, err)
+}
+
+func TestGetSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := GetSlogLogger(LogOutput(&buf), LogLevel("warn"), WithServiceInfo(ServiceInfo{Name: "builder"}))
+	require.NoError(t, err)
+
+	logger.Info("not logged")
+	logger.Warn("logged", "slot", 1)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "logged", out["msg"])
+	require.Equal(t, "builder", out["service"])
+	require.Regexp(t, regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}`), out["time"])
+
+	buf.Reset()
+	logger, err = GetSlogLogger(LogOutput(&buf), LogDevMode(true))
+	require.NoError(t, err)
+	logger.Info("hello")
+	require.Contains(t, buf.String(), "msg=hello")
+
+	logger, err = GetSlogLogger(LogOutput(&buf), LogLevel("verbose"))
+	require.Error(t, err)
+	require.NotNil(t, logger)
+}
@@ -0,0 +1,141 @@
+// Package logtest provides loggers capturing the log entries of tests for assertions.
+package logtest
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-utils/logutils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Entry is a log entry captured by a test logger.
+type Entry struct {
+	Level   zapcore.Level
+	Message string
+	Fields  map[string]any
+}
+
+// Logs captures the entries of a test logger and provides assertions on them.
+type Logs struct {
+	t        testing.TB
+	observed *observer.ObservedLogs
+}
+
+// NewLogger returns a zap logger that captures all entries (at any level) for assertions and also writes them to
+// the test output.
+func NewLogger(t testing.TB) (*zap.Logger, *Logs) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	output := zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel)).Core()
+	return zap.New(zapcore.NewTee(core, output)), &Logs{t: t, observed: observed}
+}
+
+// NewSlogLogger is the slog equivalent of NewLogger.
+func NewSlogLogger(t testing.TB) (*slog.Logger, *Logs) {
+	logger, logs := NewLogger(t)
+	return logutils.SlogFromZap(logger), logs
+}
+
+// All returns all captured entries.
+func (l *Logs) All() []Entry {
+	observed := l.observed.All()
+	entries := make([]Entry, len(observed))
+	for i, e := range observed {
+		entries[i] = Entry{Level: e.Level, Message: e.Message, Fields: e.ContextMap()}
+	}
+	return entries
+}
+
+// ByLevel returns the captured entries with the given level.
+func (l *Logs) ByLevel(level zapcore.Level) []Entry {
+	var entries []Entry
+	for _, e := range l.All() {
+		if e.Level == level {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// WithMessage returns the captured entries whose message contains substr.
+func (l *Logs) WithMessage(substr string) []Entry {
+	var entries []Entry
+	for _, e := range l.All() {
+		if strings.Contains(e.Message, substr) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// WithField returns the captured entries that have the field with a value equal to value (ignoring differences of
+// numeric types, e.g. int and int64).
+func (l *Logs) WithField(key string, value any) []Entry {
+	var entries []Entry
+	for _, e := range l.All() {
+		if v, found := e.Fields[key]; found && fieldEquals(v, value) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Reset discards all captured entries.
+func (l *Logs) Reset() {
+	l.observed.TakeAll()
+}
+
+// AssertLogged asserts that an entry with the level and a message containing substr was logged, and returns it.
+func (l *Logs) AssertLogged(level zapcore.Level, substr string) Entry {
+	l.t.Helper()
+	for _, e := range l.WithMessage(substr) {
+		if e.Level == level {
+			return e
+		}
+	}
+	l.t.Errorf("no %s entry with message containing %q was logged, entries: %s", level, substr, l.summary())
+	return Entry{}
+}
+
+// AssertNotLogged asserts that no entry at or above the level was logged.
+func (l *Logs) AssertNotLogged(level zapcore.Level) {
+	l.t.Helper()
+	for _, e := range l.All() {
+		if e.Level >= level {
+			l.t.Errorf("unexpected %s entry was logged: %q", e.Level, e.Message)
+		}
+	}
+}
+
+// AssertField asserts that an entry with a message containing substr has the field with a value equal to value.
+func (l *Logs) AssertField(substr, key string, value any) {
+	l.t.Helper()
+	for _, e := range l.WithMessage(substr) {
+		if v, found := e.Fields[key]; found && fieldEquals(v, value) {
+			return
+		}
+	}
+	l.t.Errorf("no entry with message containing %q has field %s=%v, entries: %s", substr, key, value, l.summary())
+}
+
+func (l *Logs) summary() string {
+	var lines []string
+	for _, e := range l.All() {
+		lines = append(lines, fmt.Sprintf("[%s] %s %v", e.Level, e.Message, e.Fields))
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
+func fieldEquals(actual, expected any) bool {
+	if assert.ObjectsAreEqualValues(expected, actual) {
+		return true
+	}
+	// e.g. errors are captured as strings
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
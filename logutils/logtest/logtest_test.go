@@ -0,0 +1,53 @@
+package logtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogger(t *testing.T) {
+	logger, logs := NewLogger(t)
+	logger.Debug("polling", zap.Uint64("block", 100))
+	logger.Warn("websocket reconnect", zap.Error(errors.New("EOF")), zap.Int("attempt", 3))
+
+	require.Len(t, logs.All(), 2)
+	require.Len(t, logs.ByLevel(zapcore.WarnLevel), 1)
+	require.Len(t, logs.WithField("block", 100), 1)
+	entry := logs.AssertLogged(zapcore.WarnLevel, "reconnect")
+	require.Equal(t, int64(3), entry.Fields["attempt"])
+	logs.AssertField("reconnect", "error", "EOF")
+	logs.AssertNotLogged(zapcore.ErrorLevel)
+
+	logs.Reset()
+	require.Empty(t, logs.All())
+}
+
+func TestSlogLogger(t *testing.T) {
+	logger, logs := NewSlogLogger(t)
+	logger.Error("request failed", "status", 500)
+	logs.AssertLogged(zapcore.ErrorLevel, "failed")
+	logs.AssertField("failed", "status", 500)
+}
+
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(string, ...any) {
+	tb.failed = true
+}
+
+func TestLoggerFailures(t *testing.T) {
+	_, logs := NewLogger(t)
+	tb := &recordingTB{TB: t}
+	logs.t = tb
+	logs.AssertLogged(zapcore.InfoLevel, "missing")
+	require.True(t, tb.failed)
+}
@@ -0,0 +1,164 @@
+package logutils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleKey is the slog attribute naming the module of a logger, e.g. logger.With(logutils.ModuleKey, "blocksub").
+// For zap, the logger name is used (logger.Named("blocksub")).
+const ModuleKey = "module"
+
+// ModuleLevels configures the log level per module. Modules are matched by prefix: the level of "blocksub" also
+// applies to "blocksub.beacon" (zap joins nested logger names with dots), unless there is a more specific entry.
+type ModuleLevels struct {
+	Default zapcore.Level
+	Modules map[string]zapcore.Level
+}
+
+// ParseModuleLevels parses a comma separated list of module=level pairs, an entry without module sets the default
+// level, e.g. "info,blocksub=debug,rpcserver=warn". The default level is info if not set.
+func ParseModuleLevels(spec string) (ModuleLevels, error) {
+	levels := ModuleLevels{
+		Default: zapcore.InfoLevel,
+		Modules: make(map[string]zapcore.Level),
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		module, levelStr, found := strings.Cut(entry, "=")
+		if !found {
+			module, levelStr = "", entry
+		}
+		module = strings.TrimSpace(module)
+
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(levelStr))); err != nil {
+			return levels, fmt.Errorf("invalid log level in %q: %w", entry, err)
+		}
+		if module == "" {
+			levels.Default = level
+		} else {
+			levels.Modules[module] = level
+		}
+	}
+	return levels, nil
+}
+
+// Level returns the level of the module, the one of the longest matching prefix.
+func (m ModuleLevels) Level(module string) zapcore.Level {
+	for module != "" {
+		if level, found := m.Modules[module]; found {
+			return level
+		}
+		i := strings.LastIndex(module, ".")
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	return m.Default
+}
+
+// MinLevel returns the lowest of all configured levels.
+func (m ModuleLevels) MinLevel() zapcore.Level {
+	level := m.Default
+	for _, l := range m.Modules {
+		if l < level {
+			level = l
+		}
+	}
+	return level
+}
+
+// LogModuleLevels sets the level per module, parsed by ParseModuleLevels. It replaces LogLevel.
+func LogModuleLevels(spec string) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.moduleLevels = spec
+	}
+}
+
+// moduleLevelCore is a zapcore.Core filtering entries by the level of their logger name.
+type moduleLevelCore struct {
+	zapcore.Core
+	levels ModuleLevels
+}
+
+// NewModuleLevelCore wraps core, filtering entries by the level configured for their logger name. The wrapped
+// core must be enabled for levels.MinLevel().
+func NewModuleLevelCore(core zapcore.Core, levels ModuleLevels) zapcore.Core {
+	return &moduleLevelCore{Core: core, levels: levels}
+}
+
+func (c *moduleLevelCore) Enabled(level zapcore.Level) bool {
+	return level >= c.levels.MinLevel() && c.Core.Enabled(level)
+}
+
+func (c *moduleLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleLevelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *moduleLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.levels.Level(entry.LoggerName) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// moduleLevelHandler is a slog.Handler filtering records by the level of their module.
+type moduleLevelHandler struct {
+	handler slog.Handler
+	levels  ModuleLevels
+	module  string
+}
+
+// NewModuleLevelHandler wraps handler, filtering records by the level configured for their module (see
+// ModuleKey). The wrapped handler must be enabled for levels.MinLevel().
+func NewModuleLevelHandler(handler slog.Handler, levels ModuleLevels) slog.Handler {
+	return &moduleLevelHandler{handler: handler, levels: levels}
+}
+
+func (h *moduleLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// the module may still be set on the record, so the minimum level applies if there is none yet
+	minLevel := h.levels.MinLevel()
+	if h.module != "" {
+		minLevel = h.levels.Level(h.module)
+	}
+	return level >= slogLevel(minLevel) && h.handler.Enabled(ctx, level)
+}
+
+func (h *moduleLevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	module := h.module
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == ModuleKey {
+			module = attr.Value.String()
+			return false
+		}
+		return true
+	})
+	if record.Level < slogLevel(h.levels.Level(module)) {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *moduleLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	module := h.module
+	for _, attr := range attrs {
+		if attr.Key == ModuleKey {
+			module = attr.Value.String()
+		}
+	}
+	return &moduleLevelHandler{handler: h.handler.WithAttrs(attrs), levels: h.levels, module: module}
+}
+
+func (h *moduleLevelHandler) WithGroup(name string) slog.Handler {
+	return &moduleLevelHandler{handler: h.handler.WithGroup(name), levels: h.levels, module: h.module}
+}
@@ -0,0 +1,66 @@
+package logutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseModuleLevels(t *testing.T) {
+	levels, err := ParseModuleLevels("warn, blocksub=debug,rpcserver=error")
+	require.NoError(t, err)
+	require.Equal(t, zapcore.WarnLevel, levels.Default)
+	require.Equal(t, zapcore.DebugLevel, levels.Level("blocksub"))
+	require.Equal(t, zapcore.DebugLevel, levels.Level("blocksub.beacon"))
+	require.Equal(t, zapcore.ErrorLevel, levels.Level("rpcserver"))
+	require.Equal(t, zapcore.WarnLevel, levels.Level("blocksubx"))
+	require.Equal(t, zapcore.WarnLevel, levels.Level(""))
+	require.Equal(t, zapcore.DebugLevel, levels.MinLevel())
+
+	levels, err = ParseModuleLevels("blocksub=debug")
+	require.NoError(t, err)
+	require.Equal(t, zapcore.InfoLevel, levels.Default)
+
+	_, err = ParseModuleLevels("blocksub=loud")
+	require.Error(t, err)
+}
+
+func TestModuleLevelCore(t *testing.T) {
+	levels, err := ParseModuleLevels("info,blocksub=debug")
+	require.NoError(t, err)
+	core, logs := observer.New(zapcore.DebugLevel)
+	filtered := zap.New(NewModuleLevelCore(core, levels))
+
+	filtered.Debug("root debug")
+	filtered.Named("blocksub").Debug("blocksub debug")
+	filtered.Named("blocksub").Named("ws").Debug("blocksub ws debug")
+	filtered.Named("rpcserver").Debug("rpcserver debug")
+	filtered.Named("rpcserver").Info("rpcserver info")
+
+	var messages []string
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	require.Equal(t, []string{"blocksub debug", "blocksub ws debug", "rpcserver info"}, messages)
+}
+
+func TestModuleLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := GetSlogLogger(LogOutput(&buf), LogModuleLevels("warn,blocksub=debug"))
+	require.NoError(t, err)
+
+	logger.Info("root info")
+	logger.With(ModuleKey, "blocksub").Debug("blocksub debug")
+	logger.Debug("inline debug", ModuleKey, "blocksub")
+	logger.With(ModuleKey, "rpcserver").Info("rpcserver info")
+
+	out := buf.String()
+	require.Equal(t, 2, strings.Count(out, "\n"), out)
+	require.Contains(t, out, "blocksub debug")
+	require.Contains(t, out, "inline debug")
+}
@@ -0,0 +1,219 @@
+package logutils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces redacted values.
+const Redacted = "[REDACTED]"
+
+var (
+	// DefaultRedactionPatterns match secrets that must never end up in logs.
+	DefaultRedactionPatterns = []*regexp.Regexp{
+		// PEM encoded private keys
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		// JWTs
+		regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		// bearer tokens, e.g. from an Authorization header
+		regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`),
+		// ECDSA signatures (65 bytes)
+		regexp.MustCompile(`0x[0-9a-fA-F]{130}\b`),
+		// private keys and other secrets assigned to a telling name, e.g. "private_key=0x..."
+		regexp.MustCompile(`(?i)(private[_-]?key|priv[_-]?key|secret|password|passwd)(["']?\s*[:=]\s*["']?)[^\s"',;&]+`),
+	}
+
+	// DefaultSensitiveKeys are field names (compared case-insensitively, ignoring "_" and "-") whose values are
+	// always redacted.
+	DefaultSensitiveKeys = []string{
+		"privatekey", "privkey", "secret", "password", "passwd", "authorization", "signature", "mnemonic", "seed",
+	}
+)
+
+// Redactor removes secrets from log messages and field values.
+type Redactor struct {
+	// Patterns are replaced by Redacted wherever they match
+	Patterns []*regexp.Regexp
+	// SensitiveKeys are field names whose values are always replaced by Redacted
+	SensitiveKeys []string
+
+	sensitiveKeys map[string]struct{}
+}
+
+// NewRedactor creates a Redactor with the given patterns and sensitive keys.
+func NewRedactor(patterns []*regexp.Regexp, sensitiveKeys []string) *Redactor {
+	r := &Redactor{
+		Patterns:      patterns,
+		SensitiveKeys: sensitiveKeys,
+		sensitiveKeys: make(map[string]struct{}, len(sensitiveKeys)),
+	}
+	for _, key := range sensitiveKeys {
+		r.sensitiveKeys[normalizeKey(key)] = struct{}{}
+	}
+	return r
+}
+
+// DefaultRedactor creates a Redactor with DefaultRedactionPatterns and DefaultSensitiveKeys.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(DefaultRedactionPatterns, DefaultSensitiveKeys)
+}
+
+// Redact returns s with all matches of the patterns replaced.
+func (r *Redactor) Redact(s string) string {
+	for _, pattern := range r.Patterns {
+		if pattern.NumSubexp() >= 2 {
+			// keep the name of the secret, only redact its value
+			s = pattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+		} else {
+			s = pattern.ReplaceAllLiteralString(s, Redacted)
+		}
+	}
+	return s
+}
+
+// IsSensitiveKey reports whether values of fields with the given name are always redacted.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	_, found := r.sensitiveKeys[normalizeKey(key)]
+	return found
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// redactField returns the field with its value redacted, if needed.
+func (r *Redactor) redactField(f zapcore.Field) zapcore.Field {
+	if r.IsSensitiveKey(f.Key) {
+		return zap.String(f.Key, Redacted)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.Redact(f.String)
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			if redacted := r.Redact(string(b)); redacted != string(b) {
+				return zap.String(f.Key, redacted)
+			}
+		}
+	case zapcore.ErrorType, zapcore.StringerType, zapcore.ReflectType:
+		// only replace the field (changing its type) if it contains a secret
+		if f.Interface != nil {
+			s := fmt.Sprint(f.Interface)
+			if redacted := r.Redact(s); redacted != s {
+				return zap.String(f.Key, redacted)
+			}
+		}
+	}
+	return f
+}
+
+func (r *Redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.redactField(f)
+	}
+	return redacted
+}
+
+// redactingCore is a zapcore.Core that redacts messages and fields before passing them on.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewRedactingCore wraps core, redacting secrets from messages and field values.
+func NewRedactingCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	return &redactingCore{Core: core, redactor: redactor}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.redactFields(fields)), redactor: c.redactor}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.Redact(entry.Message)
+	entry.Stack = c.redactor.Redact(entry.Stack)
+	return c.Core.Write(entry, c.redactor.redactFields(fields))
+}
+
+// LogRedaction makes the logger redact secrets from messages and field values, see DefaultRedactor.
+func LogRedaction(redactor *Redactor) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.redactor = redactor
+	}
+}
+
+// redactingHandler is a slog.Handler that redacts messages and attributes before passing them on.
+type redactingHandler struct {
+	handler  slog.Handler
+	redactor *Redactor
+}
+
+// NewRedactingHandler wraps handler, redacting secrets from messages and attribute values.
+func NewRedactingHandler(handler slog.Handler, redactor *Redactor) slog.Handler {
+	return &redactingHandler{handler: handler, redactor: redactor}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+	return &redactingHandler{handler: h.handler.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: h.handler.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	if h.redactor.IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, a := range group {
+			redacted[i] = h.redactAttr(a)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		s := fmt.Sprint(value.Any())
+		if redacted := h.redactor.Redact(s); redacted != s {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,156 @@
+package logutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RingEntry is a log entry kept by a RingBuffer.
+type RingEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Logger  string         `json:"logger,omitempty"`
+	Message string         `json:"msg"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// RingBuffer keeps the most recent log entries in memory, so they can be inspected (see ServeHTTP) even if the
+// log pipeline lags. Attach it to a logger with LogRingBuffer or Core. It is safe for concurrent use.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []RingEntry
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a ring buffer keeping the last size entries.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBuffer{entries: make([]RingEntry, size)}
+}
+
+func (rb *RingBuffer) add(entry RingEntry) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	rb.entries[rb.next] = entry
+	rb.next++
+	if rb.next == len(rb.entries) {
+		rb.next = 0
+		rb.full = true
+	}
+}
+
+// Entries returns the kept entries, oldest first.
+func (rb *RingBuffer) Entries() []RingEntry {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if !rb.full {
+		return append([]RingEntry(nil), rb.entries[:rb.next]...)
+	}
+	entries := make([]RingEntry, 0, len(rb.entries))
+	entries = append(entries, rb.entries[rb.next:]...)
+	return append(entries, rb.entries[:rb.next]...)
+}
+
+// ServeHTTP dumps the kept entries as JSON array, oldest first. The query parameters "level" (minimum level, e.g.
+// "warn") and "limit" (number of most recent entries) filter the output.
+func (rb *RingBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	minLevel := zapcore.DebugLevel
+	if levelStr := r.URL.Query().Get("level"); levelStr != "" {
+		if err := minLevel.UnmarshalText([]byte(levelStr)); err != nil {
+			http.Error(w, "invalid level", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries := make([]RingEntry, 0)
+	for _, entry := range rb.Entries() {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(entry.Level)); err == nil && level < minLevel {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+// Core returns a zapcore.Core that adds the entries at or above the level to the ring buffer, use it with
+// zapcore.NewTee.
+func (rb *RingBuffer) Core(level zapcore.LevelEnabler) zapcore.Core {
+	return &ringCore{LevelEnabler: level, rb: rb}
+}
+
+// LogRingBuffer makes the logger keep the most recent entries (at any enabled level) in the ring buffer.
+func LogRingBuffer(rb *RingBuffer) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.ringBuffer = rb
+	}
+}
+
+type ringCore struct {
+	zapcore.LevelEnabler
+	rb     *RingBuffer
+	fields []zapcore.Field
+}
+
+func (c *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+	return &ringCore{LevelEnabler: c.LevelEnabler, rb: c.rb, fields: combined}
+}
+
+func (c *ringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	ringEntry := RingEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+	}
+	if len(enc.Fields) > 0 {
+		ringEntry.Fields = enc.Fields
+	}
+	c.rb.add(ringEntry)
+	return nil
+}
+
+func (c *ringCore) Sync() error {
+	return nil
+}
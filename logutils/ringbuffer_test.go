@@ -0,0 +1,70 @@
+package logutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRingBuffer(t *testing.T) {
+	rb := NewRingBuffer(3)
+	logger, err := GetZapLogger(LogRingBuffer(rb), LogOutput(new(discard)))
+	require.NoError(t, err)
+
+	logger = logger.With(zap.String("component", "blocksub"))
+	for i := 0; i < 5; i++ {
+		logger.Info("new block", zap.Int("number", i))
+	}
+	logger.Warn("reconnecting")
+	logger.Debug("not enabled")
+
+	entries := rb.Entries()
+	require.Len(t, entries, 3)
+	require.Equal(t, int64(3), entries[0].Fields["number"])
+	require.Equal(t, "blocksub", entries[0].Fields["component"])
+	require.Equal(t, "reconnecting", entries[2].Message)
+
+	rr := httptest.NewRecorder()
+	rb.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/logs?level=warn", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var dumped []RingEntry
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &dumped))
+	require.Len(t, dumped, 1)
+	require.Equal(t, "warn", dumped[0].Level)
+
+	rr = httptest.NewRecorder()
+	rb.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/logs?limit=2", nil))
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &dumped))
+	require.Len(t, dumped, 2)
+	require.Equal(t, "new block", dumped[0].Message)
+
+	rr = httptest.NewRecorder()
+	rb.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/logs?level=loud", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+func TestRingBufferSlog(t *testing.T) {
+	rb := NewRingBuffer(3)
+	logger, err := GetSlogLogger(LogRingBuffer(rb), LogOutput(new(discard)))
+	require.NoError(t, err)
+
+	logger = logger.With("component", "blocksub")
+	logger.Info("new block", "number", 1)
+	logger.Warn("reconnecting")
+	logger.Debug("not enabled")
+
+	entries := rb.Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "new block", entries[0].Message)
+	require.Equal(t, int64(1), entries[0].Fields["number"])
+	require.Equal(t, "blocksub", entries[0].Fields["component"])
+	require.Equal(t, "warn", entries[1].Level)
+}
@@ -0,0 +1,299 @@
+package logutils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+
+	defaultMaxSize = 100 * 1024 * 1024
+)
+
+// RotationConfig configures the rotation of a log file.
+type RotationConfig struct {
+	// MaxSize is the size in bytes after which the file is rotated, 100 MiB by default
+	MaxSize int64
+	// RotateInterval rotates the file after it has been written to for this long, 0 disables time based rotation
+	RotateInterval time.Duration
+
+	// MaxAge removes backups older than this, 0 keeps them regardless of their age
+	MaxAge time.Duration
+	// MaxBackups is the number of backups to keep, 0 keeps all of them
+	MaxBackups int
+	// Compress gzips the backups
+	Compress bool
+}
+
+// RotatingFile is an io.Writer appending to a file, which is rotated according to RotationConfig. Backups are
+// named after the file with the rotation time inserted before the extension, e.g. "builder-2024-06-01T12-30-00.000.log".
+// It is safe for concurrent use.
+type RotatingFile struct {
+	filename string
+	cfg      RotationConfig
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// one cleanup of backups runs at a time in the background, the rotations during a cleanup run it again
+	cleanupMu      sync.Mutex
+	cleanupRunning bool
+	cleanupPending bool
+	cleanupWG      sync.WaitGroup
+}
+
+// NewRotatingFile opens (or creates) the file for appending.
+func NewRotatingFile(filename string, cfg RotationConfig) (*RotatingFile, error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxSize
+	}
+	f := &RotatingFile{filename: filename, cfg: cfg}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if it would grow beyond MaxSize.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	needsRotation := f.size > 0 && f.size+int64(len(p)) > f.cfg.MaxSize
+	if f.cfg.RotateInterval > 0 && time.Since(f.openedAt) >= f.cfg.RotateInterval {
+		needsRotation = needsRotation || f.size > 0
+	}
+	if needsRotation {
+		if err := f.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Sync commits the file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file, it waits for a running cleanup of backups.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+
+	f.cleanupWG.Wait()
+	return err
+}
+
+// Rotate rotates the file immediately, e.g. on SIGHUP.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotateLocked()
+}
+
+func (f *RotatingFile) rotateLocked() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	if _, err := os.Stat(f.filename); err == nil {
+		if err := os.Rename(f.filename, f.backupName(time.Now())); err != nil {
+			return err
+		}
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.startCleanup()
+	return nil
+}
+
+// startCleanup runs the cleanup in the background, or once more after the running one.
+func (f *RotatingFile) startCleanup() {
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	if f.cleanupRunning {
+		f.cleanupPending = true
+		return
+	}
+	f.cleanupRunning = true
+	f.cleanupWG.Add(1)
+	go func() {
+		defer f.cleanupWG.Done()
+		for {
+			if err := f.cleanup(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to clean up log file backups of %s: %v\n", f.filename, err)
+			}
+
+			f.cleanupMu.Lock()
+			if !f.cleanupPending {
+				f.cleanupRunning = false
+				f.cleanupMu.Unlock()
+				return
+			}
+			f.cleanupPending = false
+			f.cleanupMu.Unlock()
+		}
+	}()
+}
+
+// backupName returns the name of a new backup rotated at t. If a backup of the same millisecond exists, the time is
+// advanced to the next free millisecond.
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.filename)
+	prefix := strings.TrimSuffix(f.filename, ext)
+	for {
+		name := fmt.Sprintf("%s-%s%s", prefix, t.Format(backupTimeFormat), ext)
+		if !fileExists(name) && !fileExists(name+compressSuffix) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backups returns the backups of the file, newest first.
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir := filepath.Dir(f.filename)
+	ext := filepath.Ext(f.filename)
+	prefix := strings.TrimSuffix(filepath.Base(f.filename), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, entry.Name()), time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// cleanup removes backups according to MaxAge and MaxBackups and compresses the remaining ones.
+func (f *RotatingFile) cleanup() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, backup := range backups {
+		expired := f.cfg.MaxAge > 0 && time.Since(backup.time) > f.cfg.MaxAge
+		if expired || (f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups) {
+			errs = append(errs, os.Remove(backup.path))
+			continue
+		}
+		if f.cfg.Compress && !strings.HasSuffix(backup.path, compressSuffix) {
+			errs = append(errs, compressFile(backup.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
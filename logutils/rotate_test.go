@@ -0,0 +1,91 @@
+package logutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "builder.log")
+	f, err := NewRotatingFile(filename, RotationConfig{MaxSize: 10, MaxBackups: 2, Compress: true})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err = f.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond) // distinct backup names
+	}
+	require.NoError(t, f.Close())
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "12345678\n", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var backups []string
+	for _, entry := range entries {
+		if entry.Name() != "builder.log" {
+			backups = append(backups, entry.Name())
+		}
+	}
+	require.Len(t, backups, 2)
+	for _, name := range backups {
+		require.True(t, strings.HasPrefix(name, "builder-"), name)
+		require.True(t, strings.HasSuffix(name, ".log.gz"), name)
+	}
+}
+
+func TestGetZapLoggerWithFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "service.log")
+	logger, err := GetZapLogger(LogFile(filename, RotationConfig{}))
+	require.NoError(t, err)
+	logger.Info("hello file")
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"msg":"hello file"`)
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "builder.log")
+	f, err := NewRotatingFile(filename, RotationConfig{Compress: true})
+	require.NoError(t, err)
+
+	// the rotations within the same millisecond don't overwrite each other's backups
+	for i := 0; i < 10; i++ {
+		_, err = f.Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Rotate())
+	}
+	require.NoError(t, f.Close())
+
+	backups, err := f.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 10)
+	for _, backup := range backups {
+		require.True(t, strings.HasSuffix(backup.path, ".log.gz"), backup.path)
+	}
+}
+
+func TestGetZapLoggerWithRotatingFile(t *testing.T) {
+	file, err := NewRotatingFile(filepath.Join(t.TempDir(), "service.log"), RotationConfig{})
+	require.NoError(t, err)
+	logger, err := GetZapLogger(LogRotatingFile(file), LogOutput(new(discard)))
+	require.NoError(t, err)
+	logger.Info("hello file")
+	FlushZap(logger)
+	require.NoError(t, file.Close())
+
+	content, err := os.ReadFile(file.filename)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"msg":"hello file"`)
+}
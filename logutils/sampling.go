@@ -0,0 +1,191 @@
+package logutils
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SuppressedMessage is the message of the summary entries emitted for suppressed log lines.
+const SuppressedMessage = "suppressed similar messages"
+
+// SamplingConfig configures the sampling of repetitive log lines. Lines are considered similar if they have the
+// same level and message. Of the similar lines within Interval, the first First are logged and after that every
+// Thereafter-th. At the end of the interval a summary with the number of suppressed lines is logged.
+//
+// Summaries are emitted lazily, with the next log line after the interval ended.
+type SamplingConfig struct {
+	Interval   time.Duration // 1 second by default
+	First      int           // 10 by default
+	Thereafter int           // 0 means that all lines after First are suppressed
+}
+
+// DefaultSamplingConfig logs the first 10 similar lines per second and suppresses the rest.
+var DefaultSamplingConfig = SamplingConfig{
+	Interval: time.Second,
+	First:    10,
+}
+
+type samplingKey struct {
+	level int
+	msg   string
+}
+
+type samplingWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// samplingSummary describes the suppressed lines of an expired window.
+type samplingSummary struct {
+	key        samplingKey
+	suppressed int
+}
+
+// sampler keeps track of similar log lines, it is shared between the loggers derived from each other.
+type sampler struct {
+	cfg SamplingConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	windows   map[samplingKey]*samplingWindow
+	nextSweep time.Time
+}
+
+func newSampler(cfg SamplingConfig) *sampler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSamplingConfig.Interval
+	}
+	if cfg.First <= 0 {
+		cfg.First = DefaultSamplingConfig.First
+	}
+	return &sampler{
+		cfg:     cfg,
+		now:     time.Now,
+		windows: make(map[samplingKey]*samplingWindow),
+	}
+}
+
+// sample decides whether the line is logged and returns the summaries of expired windows that should be logged.
+func (s *sampler) sample(level int, msg string) (bool, []samplingSummary) {
+	now := s.now()
+	key := samplingKey{level: level, msg: msg}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []samplingSummary
+	if !now.Before(s.nextSweep) {
+		for k, w := range s.windows {
+			if now.Sub(w.start) < s.cfg.Interval {
+				continue
+			}
+			if w.suppressed > 0 {
+				summaries = append(summaries, samplingSummary{key: k, suppressed: w.suppressed})
+			}
+			delete(s.windows, k)
+		}
+		s.nextSweep = now.Add(s.cfg.Interval)
+	}
+
+	w, found := s.windows[key]
+	if !found {
+		w = &samplingWindow{start: now}
+		s.windows[key] = w
+	}
+	w.count++
+
+	if w.count <= s.cfg.First || (s.cfg.Thereafter > 0 && (w.count-s.cfg.First)%s.cfg.Thereafter == 0) {
+		return true, summaries
+	}
+	w.suppressed++
+	return false, summaries
+}
+
+// samplingCore is a zapcore.Core that samples repetitive log lines.
+type samplingCore struct {
+	zapcore.Core
+	sampler *sampler
+}
+
+// NewSamplingCore wraps core, sampling repetitive log lines according to cfg.
+func NewSamplingCore(core zapcore.Core, cfg SamplingConfig) zapcore.Core {
+	return &samplingCore{Core: core, sampler: newSampler(cfg)}
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+func (c *samplingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+
+	allowed, summaries := c.sampler.sample(int(entry.Level), entry.Message)
+	for _, summary := range summaries {
+		_ = c.Core.Write(zapcore.Entry{
+			Level:      zapcore.Level(summary.key.level),
+			Time:       entry.Time,
+			LoggerName: entry.LoggerName,
+			Message:    SuppressedMessage,
+		}, []zapcore.Field{
+			zap.String("suppressedMsg", summary.key.msg),
+			zap.Int("suppressed", summary.suppressed),
+		})
+	}
+
+	if !allowed {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+// LogSampling makes the logger sample repetitive log lines according to cfg. It replaces zap's built-in sampling.
+func LogSampling(cfg SamplingConfig) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.sampling = &cfg
+	}
+}
+
+// samplingHandler is a slog.Handler that samples repetitive log lines.
+type samplingHandler struct {
+	handler slog.Handler
+	sampler *sampler
+}
+
+// NewSamplingHandler wraps handler, sampling repetitive log lines according to cfg.
+func NewSamplingHandler(handler slog.Handler, cfg SamplingConfig) slog.Handler {
+	return &samplingHandler{handler: handler, sampler: newSampler(cfg)}
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	allowed, summaries := h.sampler.sample(int(record.Level), record.Message)
+	for _, summary := range summaries {
+		r := slog.NewRecord(record.Time, slog.Level(summary.key.level), SuppressedMessage, 0)
+		r.AddAttrs(slog.String("suppressedMsg", summary.key.msg), slog.Int("suppressed", summary.suppressed))
+		_ = h.handler.Handle(ctx, r)
+	}
+
+	if !allowed {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{handler: h.handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{handler: h.handler.WithGroup(name), sampler: h.sampler}
+}
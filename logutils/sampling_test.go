@@ -0,0 +1,37 @@
+package logutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	sc := NewSamplingCore(core, SamplingConfig{Interval: time.Minute, First: 2, Thereafter: 3})
+	now := time.Now()
+	sc.(*samplingCore).sampler.now = func() time.Time { return now }
+	logger := zap.New(sc).With(zap.String("component", "blocksub"))
+
+	for i := 0; i < 10; i++ {
+		logger.Warn("reconnecting")
+	}
+	logger.Info("other")
+	// lines 1, 2, 5, 8 are logged
+	require.Equal(t, 4, logs.FilterMessage("reconnecting").Len())
+	require.Equal(t, 1, logs.FilterMessage("other").Len())
+
+	// the summary is logged with the first line after the interval
+	now = now.Add(time.Minute)
+	logger.Warn("reconnecting")
+	summaries := logs.FilterMessage(SuppressedMessage).All()
+	require.Len(t, summaries, 1)
+	require.Equal(t, zapcore.WarnLevel, summaries[0].Level)
+	require.Equal(t, "reconnecting", summaries[0].ContextMap()["suppressedMsg"])
+	require.Equal(t, int64(6), summaries[0].ContextMap()["suppressed"])
+	require.Equal(t, 5, logs.FilterMessage("reconnecting").Len())
+}
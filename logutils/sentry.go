@@ -0,0 +1,151 @@
+package logutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrInvalidSentryDSN = errors.New("invalid Sentry DSN")
+
+// SentryOptions configures SentryHook.
+type SentryOptions struct {
+	Environment string
+	Release     string
+	ServerName  string // hostname by default
+	HTTPClient  *http.Client
+}
+
+// SentryHook is an AlertHook sending alerts as events to Sentry (https://develop.sentry.dev/sdk/store/).
+type SentryHook struct {
+	storeURL  string
+	publicKey string
+	opts      SentryOptions
+}
+
+// NewSentryHook creates a hook sending events to the project of the DSN, e.g.
+// "https://<key>@o0.ingest.sentry.io/<project>".
+func NewSentryHook(dsn string, opts SentryOptions) (*SentryHook, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSentryDSN, err)
+	}
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, ErrInvalidSentryDSN
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	projectID := path[i+1:]
+	if projectID == "" {
+		return nil, ErrInvalidSentryDSN
+	}
+	prefix := ""
+	if i >= 0 {
+		prefix = "/" + path[:i]
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.ServerName == "" {
+		opts.ServerName, _ = os.Hostname()
+	}
+	return &SentryHook{
+		storeURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID),
+		publicKey: u.User.Username(),
+		opts:      opts,
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID     string         `json:"event_id"`
+	Timestamp   string         `json:"timestamp"`
+	Level       string         `json:"level"`
+	Logger      string         `json:"logger,omitempty"`
+	Platform    string         `json:"platform"`
+	Message     string         `json:"message"`
+	Environment string         `json:"environment,omitempty"`
+	Release     string         `json:"release,omitempty"`
+	ServerName  string         `json:"server_name,omitempty"`
+	Extra       map[string]any `json:"extra,omitempty"`
+}
+
+func sentryLevel(level zapcore.Level) string {
+	switch {
+	case level >= zapcore.DPanicLevel:
+		return "fatal"
+	case level >= zapcore.ErrorLevel:
+		return "error"
+	case level >= zapcore.WarnLevel:
+		return "warning"
+	case level >= zapcore.InfoLevel:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// Alert implements AlertHook.
+func (h *SentryHook) Alert(ctx context.Context, alert Alert) error {
+	eventID := make([]byte, 16)
+	if _, err := rand.Read(eventID); err != nil {
+		return err
+	}
+
+	extra := make(map[string]any, len(alert.Fields)+2)
+	for k, v := range alert.Fields {
+		extra[k] = v
+	}
+	if alert.Stack != "" {
+		extra["stacktrace"] = alert.Stack
+	}
+	if alert.Suppressed > 0 {
+		extra["suppressed"] = alert.Suppressed
+	}
+
+	body, err := json.Marshal(sentryEvent{
+		EventID:     hex.EncodeToString(eventID),
+		Timestamp:   alert.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Level:       sentryLevel(alert.Level),
+		Logger:      alert.Logger,
+		Platform:    "go",
+		Message:     alert.Message,
+		Environment: h.opts.Environment,
+		Release:     h.opts.Release,
+		ServerName:  h.opts.ServerName,
+		Extra:       extra,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=go-utils/1.0, sentry_key=%s", h.publicKey))
+
+	res, err := h.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("sentry: unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package logutils
+
+import (
+	"os"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// Field names of the service metadata, they are the same across all services for log correlation.
+const (
+	ServiceNameField        = "service"
+	ServiceVersionField     = "serviceVersion"
+	ServiceCommitField      = "gitCommit"
+	ServiceEnvironmentField = "environment"
+	ServiceInstanceIDField  = "instanceID"
+)
+
+// ServiceInfo describes the running service instance. Empty fields are not logged.
+type ServiceInfo struct {
+	Name        string
+	Version     string
+	Commit      string
+	Environment string
+	InstanceID  string
+}
+
+// ServiceInfoFromEnv fills in the service info from the environment variables SERVICE_NAME, SERVICE_VERSION,
+// GIT_COMMIT, ENVIRONMENT and INSTANCE_ID. The name falls back to the given default, the version and commit to the
+// build info of the binary and the instance ID to the hostname (the pod name in Kubernetes).
+func ServiceInfoFromEnv(defaultName string) ServiceInfo {
+	info := ServiceInfo{
+		Name:        os.Getenv("SERVICE_NAME"),
+		Version:     os.Getenv("SERVICE_VERSION"),
+		Commit:      os.Getenv("GIT_COMMIT"),
+		Environment: os.Getenv("ENVIRONMENT"),
+		InstanceID:  os.Getenv("INSTANCE_ID"),
+	}
+	if info.Name == "" {
+		info.Name = defaultName
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			if info.Commit == "" && setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	if info.InstanceID == "" {
+		info.InstanceID, _ = os.Hostname()
+	}
+	return info
+}
+
+// ZapFields returns the non-empty service info fields.
+func (info ServiceInfo) ZapFields() []zap.Field {
+	var fields []zap.Field
+	for _, kv := range info.keyValues() {
+		fields = append(fields, zap.String(kv[0], kv[1]))
+	}
+	return fields
+}
+
+// SlogArgs returns the non-empty service info fields as key-value pairs for slog.Logger.With.
+func (info ServiceInfo) SlogArgs() []any {
+	var args []any
+	for _, kv := range info.keyValues() {
+		args = append(args, kv[0], kv[1])
+	}
+	return args
+}
+
+func (info ServiceInfo) keyValues() [][2]string {
+	var kvs [][2]string
+	for _, kv := range [][2]string{
+		{ServiceNameField, info.Name},
+		{ServiceVersionField, info.Version},
+		{ServiceCommitField, info.Commit},
+		{ServiceEnvironmentField, info.Environment},
+		{ServiceInstanceIDField, info.InstanceID},
+	} {
+		if kv[1] != "" {
+			kvs = append(kvs, kv)
+		}
+	}
+	return kvs
+}
+
+// WithServiceInfo stamps every log entry with the service metadata, see ServiceInfoFromEnv.
+func WithServiceInfo(info ServiceInfo) LogConfigOption {
+	return func(lc *loggerConfig) {
+		lc.serviceInfo = &info
+	}
+}
@@ -0,0 +1,24 @@
+package logutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceInfoFromEnv(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "")
+	t.Setenv("SERVICE_VERSION", "v1.2.3")
+	t.Setenv("GIT_COMMIT", "abcdef")
+	t.Setenv("ENVIRONMENT", "staging")
+	t.Setenv("INSTANCE_ID", "")
+
+	info := ServiceInfoFromEnv("builder")
+	require.Equal(t, "builder", info.Name)
+	require.Equal(t, "v1.2.3", info.Version)
+	require.Equal(t, "abcdef", info.Commit)
+	require.Equal(t, "staging", info.Environment)
+	require.NotEmpty(t, info.InstanceID) // hostname
+
+	require.Equal(t, []any{"service", "builder", "environment", "staging"}, ServiceInfo{Name: "builder", Environment: "staging"}.SlogArgs())
+}
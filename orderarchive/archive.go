@@ -0,0 +1,211 @@
+// Package orderarchive records the received orderflow (rpctypes orders) to pluggable sinks, in batches and with
+// bounded memory.
+package orderarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/rpctypes"
+	"github.com/google/uuid"
+)
+
+const (
+	// number of records waiting to be written
+	queuedGauge = `goutils_orderarchive_queued{archive="%s"}`
+	// incremented for each written record, each record dropped because the queue is full or the sink failed, and
+	// each failed write
+	archivedCounter   = `goutils_orderarchive_archived_total{archive="%s"}`
+	droppedCounter    = `goutils_orderarchive_dropped_total{archive="%s"}`
+	sinkErrorsCounter = `goutils_orderarchive_sink_errors_total{archive="%s"}`
+
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10_000
+	defaultWriteTimeout  = 10 * time.Second
+)
+
+var (
+	ErrQueueFull = errors.New("archive queue is full")
+	ErrClosed    = errors.New("archive is closed")
+)
+
+// Record is an archived order.
+type Record struct {
+	Method     string         `json:"method"`
+	ReceivedAt time.Time      `json:"receivedAt"`
+	Signer     common.Address `json:"signer"`
+	UniqueKey  uuid.UUID      `json:"uniqueKey"`
+	Order      rpctypes.Order `json:"order"`
+}
+
+// Sink stores batches of records.
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// Config configures an Archive.
+type Config struct {
+	// Name of the archive in the metrics
+	Name string
+	// BatchSize is the maximum number of records per write, 100 by default
+	BatchSize int
+	// FlushInterval is the maximum delay before a record is written, 1 second by default
+	FlushInterval time.Duration
+	// QueueSize is the maximum number of records waiting to be written, 10,000 by default
+	QueueSize int
+	// Block makes Add wait for room in the queue instead of dropping the record with ErrQueueFull
+	Block bool
+	// WriteTimeout of each attempt to write a batch, 10 seconds by default
+	WriteTimeout time.Duration
+	// RetryOptions of the writes (3 attempts by default, see the retry package), the batch is dropped if they fail
+	RetryOptions []retry.Option
+	// OnError is called with the errors of the writes, can be nil
+	OnError func(err error)
+}
+
+// Archive queues the records and writes them to the sink in the background.
+type Archive struct {
+	cfg  Config
+	sink Sink
+
+	// mu guards closed, Add holds it for reading while enqueueing
+	mu        sync.RWMutex
+	closed    bool
+	queue     chan Record
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New starts an archive writing to the sink.
+func New(sink Sink, cfg Config) *Archive {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaultWriteTimeout
+	}
+	a := &Archive{
+		cfg:   cfg,
+		sink:  sink,
+		queue: make(chan Record, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Add queues the order received from the signer with the method (e.g. eth_sendBundle).
+func (a *Archive) Add(ctx context.Context, method string, signer common.Address, order rpctypes.Order) error {
+	record := Record{
+		Method:     method,
+		ReceivedAt: time.Now().UTC(),
+		Signer:     signer,
+		UniqueKey:  order.UniqueKey(),
+		Order:      order,
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrClosed
+	}
+	if a.cfg.Block {
+		select {
+		case a.queue <- record:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		select {
+		case a.queue <- record:
+		default:
+			metrics.GetOrCreateCounter(fmt.Sprintf(droppedCounter, a.cfg.Name)).Inc()
+			return ErrQueueFull
+		}
+	}
+	metrics.GetOrCreateGauge(fmt.Sprintf(queuedGauge, a.cfg.Name), nil).Set(float64(len(a.queue)))
+	return nil
+}
+
+// Close writes the queued records and closes the sink.
+func (a *Archive) Close() error {
+	a.closeOnce.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.queue)
+		a.mu.Unlock()
+		<-a.done
+		a.closeErr = a.sink.Close()
+	})
+	return a.closeErr
+}
+
+func (a *Archive) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, a.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		a.write(batch)
+		batch = make([]Record, 0, a.cfg.BatchSize)
+		metrics.GetOrCreateGauge(fmt.Sprintf(queuedGauge, a.cfg.Name), nil).Set(float64(len(a.queue)))
+	}
+
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= a.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (a *Archive) write(batch []Record) {
+	opts := append([]retry.Option{
+		retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			metrics.GetOrCreateCounter(fmt.Sprintf(sinkErrorsCounter, a.cfg.Name)).Inc()
+		}),
+	}, a.cfg.RetryOptions...)
+	err := retry.Do(context.Background(), func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, a.cfg.WriteTimeout)
+		defer cancel()
+		return a.sink.Write(ctx, batch)
+	}, opts...)
+	if err != nil {
+		metrics.GetOrCreateCounter(fmt.Sprintf(sinkErrorsCounter, a.cfg.Name)).Inc()
+		metrics.GetOrCreateCounter(fmt.Sprintf(droppedCounter, a.cfg.Name)).Add(len(batch))
+		if a.cfg.OnError != nil {
+			a.cfg.OnError(fmt.Errorf("writing %d records: %w", len(batch), err))
+		}
+		return
+	}
+	metrics.GetOrCreateCounter(fmt.Sprintf(archivedCounter, a.cfg.Name)).Add(len(batch))
+}
@@ -0,0 +1,269 @@
+package orderarchive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/rpctypes"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]Record
+	err     error
+	closed  bool
+}
+
+func (s *memorySink) Write(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, records)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memorySink) records() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, batch := range s.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+func testOrder(i int) *rpctypes.EthSendRawTransactionArgs {
+	tx := rpctypes.EthSendRawTransactionArgs(hexutil.Bytes{byte(i)})
+	return &tx
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("batches", func(t *testing.T) {
+		sink := &memorySink{}
+		a := New(sink, Config{Name: "test-batches", BatchSize: 2, FlushInterval: time.Hour})
+		for i := 0; i < 5; i++ {
+			require.NoError(t, a.Add(ctx, "eth_sendRawTransaction", common.Address{1}, testOrder(i)))
+		}
+		require.Eventually(t, func() bool { return sink.records() == 4 }, time.Second, time.Millisecond)
+
+		// the remaining record is written on close
+		require.NoError(t, a.Close())
+		require.Equal(t, 5, sink.records())
+		require.Len(t, sink.batches, 3)
+		require.True(t, sink.closed)
+		require.Equal(t, testOrder(0).UniqueKey(), sink.batches[0][0].UniqueKey)
+		require.ErrorIs(t, a.Add(ctx, "eth_sendRawTransaction", common.Address{}, testOrder(0)), ErrClosed)
+	})
+
+	t.Run("flush interval", func(t *testing.T) {
+		sink := &memorySink{}
+		a := New(sink, Config{Name: "test-interval", FlushInterval: 10 * time.Millisecond})
+		defer a.Close()
+		require.NoError(t, a.Add(ctx, "eth_sendRawTransaction", common.Address{}, testOrder(0)))
+		require.Eventually(t, func() bool { return sink.records() == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		block := make(chan struct{})
+		sink := &blockingSink{block: block}
+		a := New(sink, Config{Name: "test-full", BatchSize: 1, QueueSize: 1})
+		// the first record is blocked in the sink, the second fills the queue
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(0)))
+		require.Eventually(t, func() bool { return len(a.queue) == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(1)))
+		require.ErrorIs(t, a.Add(ctx, "m", common.Address{}, testOrder(2)), ErrQueueFull)
+		close(block)
+		require.NoError(t, a.Close())
+	})
+
+	t.Run("blocking", func(t *testing.T) {
+		block := make(chan struct{})
+		sink := &blockingSink{block: block}
+		a := New(sink, Config{Name: "test-block", BatchSize: 1, QueueSize: 1, Block: true})
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(0)))
+		require.Eventually(t, func() bool { return len(a.queue) == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(1)))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, a.Add(timeoutCtx, "m", common.Address{}, testOrder(2)), context.DeadlineExceeded)
+		close(block)
+		require.NoError(t, a.Close())
+	})
+
+	t.Run("sink error", func(t *testing.T) {
+		sinkErr := errors.New("unavailable")
+		sink := &memorySink{err: sinkErr}
+		errs := make(chan error, 1)
+		a := New(sink, Config{
+			Name:         "test-error",
+			RetryOptions: []retry.Option{retry.WithMaxAttempts(2), retry.WithBackoff(time.Millisecond, time.Millisecond)},
+			OnError:      func(err error) { errs <- err },
+		})
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(0)))
+		require.NoError(t, a.Close())
+		require.ErrorIs(t, <-errs, sinkErr)
+	})
+
+	t.Run("write timeout", func(t *testing.T) {
+		// never unblocked, the writes time out
+		sink := &blockingSink{block: make(chan struct{})}
+		errs := make(chan error, 1)
+		a := New(sink, Config{
+			Name:         "test-timeout",
+			WriteTimeout: 10 * time.Millisecond,
+			RetryOptions: []retry.Option{retry.WithMaxAttempts(2), retry.WithBackoff(time.Millisecond, time.Millisecond)},
+			OnError:      func(err error) { errs <- err },
+		})
+		require.NoError(t, a.Add(ctx, "m", common.Address{}, testOrder(0)))
+		require.NoError(t, a.Close())
+		require.ErrorIs(t, <-errs, context.DeadlineExceeded)
+	})
+}
+
+type blockingSink struct {
+	block chan struct{}
+}
+
+func (s *blockingSink) Write(ctx context.Context, records []Record) error {
+	select {
+	case <-s.block:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
+
+func TestFileSink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+
+	record := Record{Method: "eth_sendRawTransaction", Signer: common.Address{1}, Order: testOrder(1), UniqueKey: testOrder(1).UniqueKey()}
+	line, err := encodeJSONL([]Record{record})
+	require.NoError(t, err)
+
+	sink, err := NewFileSink(FileConfig{Path: path, MaxBytes: int64(2 * len(line))})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(ctx, []Record{record}))
+	require.NoError(t, sink.Write(ctx, []Record{record}))
+	// exceeds MaxBytes, rotated
+	require.NoError(t, sink.Write(ctx, []Record{record}))
+	require.NoError(t, sink.Close())
+
+	files, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	var decoded struct {
+		Method string          `json:"method"`
+		Signer common.Address  `json:"signer"`
+		Order  json.RawMessage `json:"order"`
+	}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &decoded))
+	require.Equal(t, "eth_sendRawTransaction", decoded.Method)
+	require.Equal(t, common.Address{1}, decoded.Signer)
+	require.Equal(t, `"0x01"`, string(decoded.Order))
+	require.False(t, scanner.Scan())
+	rotated, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(rotated), "\n"))
+
+	t.Run("age", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "orders.jsonl")
+		sink, err := NewFileSink(FileConfig{Path: path, MaxAge: time.Minute})
+		require.NoError(t, err)
+		now := time.Now()
+		sink.now = func() time.Time { return now }
+		sink.openedAt = now
+
+		require.NoError(t, sink.Write(ctx, []Record{record}))
+		now = now.Add(time.Minute)
+		require.NoError(t, sink.Write(ctx, []Record{record}))
+		require.NoError(t, sink.Close())
+
+		files, err := filepath.Glob(path + "*")
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+	})
+}
+
+func TestS3Sink(t *testing.T) {
+	var key string
+	var body []byte
+	sink := NewS3Sink(ObjectPutterFunc(func(ctx context.Context, k string, b []byte) error {
+		key, body = k, b
+		return nil
+	}), "orders/")
+	sink.now = func() time.Time { return time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC) }
+
+	require.NoError(t, sink.Write(context.Background(), []Record{{Method: "a", Order: testOrder(1)}, {Method: "b", Order: testOrder(2)}}))
+	require.True(t, strings.HasPrefix(key, "orders/2024/01/02/15/20240102T150405.000Z-"), key)
+	require.True(t, strings.HasSuffix(key, ".jsonl"))
+	require.Equal(t, 2, strings.Count(string(body), "\n"))
+}
+
+func TestClickHouseSink(t *testing.T) {
+	var query, user string
+	var rows []clickHouseRow
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		user = r.Header.Get("X-ClickHouse-User")
+		body, _ := io.ReadAll(r.Body)
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var row clickHouseRow
+			require.NoError(t, json.Unmarshal([]byte(line), &row))
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewClickHouseSink(ClickHouseConfig{URL: server.URL, Database: "archive", Table: "orders", User: "writer"})
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), []Record{{Method: "eth_sendRawTransaction", Order: testOrder(1), UniqueKey: testOrder(1).UniqueKey()}}))
+
+	require.Equal(t, "INSERT INTO archive.orders FORMAT JSONEachRow", query)
+	require.Equal(t, "writer", user)
+	require.Len(t, rows, 1)
+	require.Equal(t, `"0x01"`, rows[0].Order)
+	require.Equal(t, testOrder(1).UniqueKey().String(), rows[0].UniqueKey)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "table doesn't exist", http.StatusNotFound)
+	})
+	require.ErrorContains(t, sink.Write(context.Background(), []Record{{Order: testOrder(1)}}), "table doesn't exist")
+}
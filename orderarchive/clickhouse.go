@@ -0,0 +1,104 @@
+package orderarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClickHouseConfig configures a ClickHouseSink.
+type ClickHouseConfig struct {
+	// URL of the ClickHouse HTTP interface, e.g. http://localhost:8123
+	URL      string
+	Database string
+	// Table with the columns method, received_at (DateTime64), signer, unique_key (UUID) and order (String, the JSON
+	// encoded order)
+	Table    string
+	User     string
+	Password string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// ClickHouseSink inserts the records with the ClickHouse HTTP interface, in the JSONEachRow format.
+type ClickHouseSink struct {
+	cfg       ClickHouseConfig
+	insertURL string
+}
+
+type clickHouseRow struct {
+	Method     string `json:"method"`
+	ReceivedAt string `json:"received_at"`
+	Signer     string `json:"signer"`
+	UniqueKey  string `json:"unique_key"`
+	Order      string `json:"order"`
+}
+
+func NewClickHouseSink(cfg ClickHouseConfig) (*ClickHouseSink, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	table := cfg.Table
+	if cfg.Database != "" {
+		table = cfg.Database + "." + table
+	}
+	query := u.Query()
+	query.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", table))
+	query.Set("date_time_input_format", "best_effort")
+	u.RawQuery = query.Encode()
+	return &ClickHouseSink{cfg: cfg, insertURL: u.String()}, nil
+}
+
+func (s *ClickHouseSink) Write(ctx context.Context, records []Record) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range records {
+		order, err := json.Marshal(record.Order)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(clickHouseRow{
+			Method:     record.Method,
+			ReceivedAt: record.ReceivedAt.UTC().Format(time.RFC3339Nano),
+			Signer:     record.Signer.Hex(),
+			UniqueKey:  record.UniqueKey.String(),
+			Order:      string(order),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.insertURL, &buf)
+	if err != nil {
+		return err
+	}
+	if s.cfg.User != "" {
+		req.Header.Set("X-ClickHouse-User", s.cfg.User)
+		req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+	}
+	res, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("clickhouse insert failed with status %d: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+func (s *ClickHouseSink) Close() error {
+	return nil
+}
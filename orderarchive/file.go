@@ -0,0 +1,128 @@
+package orderarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoPath = errors.New("no file path")
+
+// FileConfig configures a FileSink.
+type FileConfig struct {
+	// Path of the file the records are appended to, rotated files get a timestamp suffix (e.g. orders.jsonl.20240102T150405Z)
+	Path string
+	// MaxBytes rotates the file when it reaches the size, not rotated by size if 0
+	MaxBytes int64
+	// MaxAge rotates the file when it was opened for longer, not rotated by age if 0
+	MaxAge time.Duration
+}
+
+// FileSink appends the records as JSON lines to a file, with rotation by size and age.
+type FileSink struct {
+	cfg FileConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewFileSink opens (or creates) the file.
+func NewFileSink(cfg FileConfig) (*FileSink, error) {
+	if cfg.Path == "" {
+		return nil, ErrNoPath
+	}
+	s := &FileSink{cfg: cfg, now: time.Now}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, records []Record) error {
+	buf, err := encodeJSONL(records)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	if s.shouldRotate(int64(len(buf))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(buf)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) shouldRotate(size int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.cfg.MaxBytes > 0 && s.size+size > s.cfg.MaxBytes {
+		return true
+	}
+	return s.cfg.MaxAge > 0 && s.now().Sub(s.openedAt) >= s.cfg.MaxAge
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	name := s.cfg.Path + "." + s.now().UTC().Format("20060102T150405.000000000Z")
+	if err := os.Rename(s.cfg.Path, name); err != nil {
+		return fmt.Errorf("rotating %s: %w", s.cfg.Path, err)
+	}
+	return s.open()
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	s.openedAt = s.now()
+	return nil
+}
+
+func encodeJSONL(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
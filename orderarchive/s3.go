@@ -0,0 +1,48 @@
+package orderarchive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ObjectPutter uploads an object, e.g. an adapter of the PutObject of the AWS SDK S3 client.
+type ObjectPutter interface {
+	PutObject(ctx context.Context, key string, body []byte) error
+}
+
+// ObjectPutterFunc adapts a function to an ObjectPutter.
+type ObjectPutterFunc func(ctx context.Context, key string, body []byte) error
+
+func (f ObjectPutterFunc) PutObject(ctx context.Context, key string, body []byte) error {
+	return f(ctx, key, body)
+}
+
+// S3Sink uploads each batch as a JSON lines object, keyed by
+// <prefix><yyyy>/<mm>/<dd>/<hh>/<timestamp>-<random>.jsonl
+type S3Sink struct {
+	client ObjectPutter
+	prefix string
+	now    func() time.Time
+}
+
+// NewS3Sink uploads the batches with the client, under the key prefix (e.g. "orders/").
+func NewS3Sink(client ObjectPutter, prefix string) *S3Sink {
+	return &S3Sink{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *S3Sink) Write(ctx context.Context, records []Record) error {
+	buf, err := encodeJSONL(records)
+	if err != nil {
+		return err
+	}
+	now := s.now().UTC()
+	key := fmt.Sprintf("%s%s/%s-%s.jsonl", s.prefix, now.Format("2006/01/02/15"), now.Format("20060102T150405.000Z"), uuid.NewString())
+	return s.client.PutObject(ctx, key, buf)
+}
+
+func (s *S3Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package orderflowproxy implements the core of orderflow proxies: it verifies the X-Flashbots-Signature of incoming
+// JSON-RPC requests, and forwards them to upstreams with the signer as metadata, optionally re-signed with the
+// identity of the proxy.
+package orderflowproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/rpcserver"
+	"github.com/flashbots/go-utils/signature"
+)
+
+// SignerHeader carries the verified signer of the request to the upstreams
+const SignerHeader = "X-Flashbots-Signer"
+
+const (
+	// incremented for each request, each request rejected (e.g. invalid signature), and each failed upstream request
+	requestsCounter       = `goutils_orderflowproxy_requests_total{proxy="%s"}`
+	rejectedCounter       = `goutils_orderflowproxy_rejected_total{proxy="%s"}`
+	upstreamErrorsCounter = `goutils_orderflowproxy_upstream_errors_total{proxy="%s",upstream="%s"}`
+
+	defaultTimeout = 10 * time.Second
+)
+
+var ErrNoUpstreams = errors.New("no upstreams configured")
+
+// Config configures a Proxy.
+type Config struct {
+	// Name of the proxy in the metrics
+	Name string
+	// Upstreams receive the requests, the response of the first one is returned to the client while the others are
+	// sent in the background
+	Upstreams []string
+	// RequireSignature rejects unsigned requests, signatures are verified whenever present
+	RequireSignature bool
+	// Signer re-signs the forwarded requests with the identity of the proxy, if not nil
+	Signer *signature.Signer
+	// Timeout of the upstream requests, 10 seconds by default
+	Timeout time.Duration
+	// MaxRequestBodySizeBytes is rpcserver.DefaultMaxRequestBodySizeBytes by default
+	MaxRequestBodySizeBytes int64
+	// HTTPClient of the upstream requests
+	HTTPClient *http.Client
+	// Log, can be nil
+	Log *slog.Logger
+}
+
+// Proxy is the http.Handler of the proxy.
+type Proxy struct {
+	cfg       Config
+	upstreams []rpcclient.Upstream
+}
+
+type proxyRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type proxyResponse struct {
+	JSONRPC string              `json:"jsonrpc"`
+	ID      json.RawMessage     `json:"id"`
+	Result  json.RawMessage     `json:"result,omitempty"`
+	Error   *rpcclient.RPCError `json:"error,omitempty"`
+}
+
+// New creates a proxy to the upstreams.
+func New(cfg Config) (*Proxy, error) {
+	if len(cfg.Upstreams) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxRequestBodySizeBytes <= 0 {
+		cfg.MaxRequestBodySizeBytes = int64(rpcserver.DefaultMaxRequestBodySizeBytes)
+	}
+
+	upstreams, err := rpcclient.NewUpstreams(cfg.Upstreams, &rpcclient.RPCClientOpts{
+		HTTPClient:         cfg.HTTPClient,
+		Signer:             cfg.Signer,
+		AllowUnknownFields: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Proxy{cfg: cfg, upstreams: upstreams}, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(requestsCounter, p.cfg.Name)).Inc()
+
+	if r.Method != http.MethodPost {
+		p.reject()
+		http.Error(w, "only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, p.cfg.MaxRequestBodySizeBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		p.writeError(w, nil, rpcserver.CodeInvalidRequest, fmt.Sprintf("request body is too big, max size: %d", p.cfg.MaxRequestBodySizeBytes))
+		return
+	}
+
+	signatureHeader := r.Header.Get(signature.HTTPHeader)
+	var signer common.Address
+	if signatureHeader != "" || p.cfg.RequireSignature {
+		signer, err = signature.Verify(signatureHeader, body)
+		if err != nil {
+			p.writeError(w, nil, rpcserver.CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		p.writeError(w, nil, rpcserver.CodeInvalidRequest, "batch requests are not supported")
+		return
+	}
+	var req proxyRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		p.writeError(w, nil, rpcserver.CodeParseError, err.Error())
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		p.writeError(w, req.ID, rpcserver.CodeInvalidRequest, "invalid request")
+		return
+	}
+
+	p.writeResponse(w, p.forward(r.Context(), &req, signer))
+}
+
+// forward sends the request to the upstreams and returns the response of the first one
+func (p *Proxy) forward(ctx context.Context, req *proxyRequest, signer common.Address) *proxyResponse {
+	rpcReq := &rpcclient.RPCRequest{
+		Method:  req.Method,
+		ID:      1,
+		JSONRPC: "2.0",
+	}
+	if len(req.Params) > 0 {
+		rpcReq.Params = req.Params
+	}
+	headers := make(map[string]string)
+	if signer != (common.Address{}) {
+		headers[SignerHeader] = signer.Hex()
+	}
+
+	rpcRes, err := rpcclient.Forward(httputil.CtxWithHeaders(ctx, headers), p.upstreams, rpcReq, rpcclient.ForwardOpts{
+		Timeout: p.cfg.Timeout,
+		OnError: func(u rpcclient.Upstream, err error) {
+			metrics.GetOrCreateCounter(fmt.Sprintf(upstreamErrorsCounter, p.cfg.Name, u.Host)).Inc()
+			if p.cfg.Log != nil {
+				p.cfg.Log.Error("upstream request failed", slog.String("upstream", u.Host), slog.String("method", req.Method), slog.Any("error", err))
+			}
+		},
+	})
+	res := &proxyResponse{JSONRPC: "2.0", ID: req.ID}
+	if err != nil && (rpcRes == nil || rpcRes.Error == nil) {
+		res.Error = &rpcclient.RPCError{Code: rpcserver.CodeInternalError, Message: "upstream request failed"}
+		return res
+	}
+	if rpcRes.Error != nil {
+		res.Error = rpcRes.Error
+		return res
+	}
+	result, err := json.Marshal(rpcRes.Result)
+	if err != nil {
+		res.Error = &rpcclient.RPCError{Code: rpcserver.CodeInternalError, Message: err.Error()}
+		return res
+	}
+	res.Result = result
+	return res
+}
+
+func (p *Proxy) reject() {
+	metrics.GetOrCreateCounter(fmt.Sprintf(rejectedCounter, p.cfg.Name)).Inc()
+}
+
+func (p *Proxy) writeError(w http.ResponseWriter, id json.RawMessage, code int, msg string) {
+	p.reject()
+	p.writeResponse(w, &proxyResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &rpcclient.RPCError{Code: code, Message: msg},
+	})
+}
+
+func (p *Proxy) writeResponse(w http.ResponseWriter, res *proxyResponse) {
+	if res.ID == nil {
+		res.ID = json.RawMessage("null")
+	}
+	if res.Error == nil && res.Result == nil {
+		res.Result = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil && p.cfg.Log != nil {
+		p.cfg.Log.Error("failed to write response", slog.Any("error", err))
+	}
+}
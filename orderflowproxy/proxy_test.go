@@ -0,0 +1,101 @@
+package orderflowproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/jsonrpc"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy(t *testing.T) {
+	primary := jsonrpc.NewMockJSONRPCServer()
+	defer primary.Close()
+	primary.VerifySignature = true
+	secondary := jsonrpc.NewMockJSONRPCServer()
+	defer secondary.Close()
+
+	proxySigner, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+	primary.SetSignedHandler("eth_sendBundle", func(req *jsonrpc.JSONRPCRequest, signer common.Address) (interface{}, error) {
+		require.Equal(t, proxySigner.Address(), signer)
+		return map[string]string{"bundleHash": "0x01"}, nil
+	})
+	secondary.SetHandler("eth_sendBundle", func(req *jsonrpc.JSONRPCRequest) (interface{}, error) {
+		return nil, nil
+	})
+
+	proxy, err := New(Config{
+		Name:             "test",
+		Upstreams:        []string{primary.URL, secondary.URL},
+		RequireSignature: true,
+		Signer:           proxySigner,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	clientSigner, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+	client := rpcclient.NewClientWithOpts(server.URL, &rpcclient.RPCClientOpts{Signer: clientSigner})
+
+	var res struct {
+		BundleHash string `json:"bundleHash"`
+	}
+	require.NoError(t, client.CallFor(context.Background(), &res, "eth_sendBundle", map[string]any{"txs": []string{"0x01"}}))
+	require.Equal(t, "0x01", res.BundleHash)
+
+	requests := primary.RequestsFor("eth_sendBundle")
+	require.Len(t, requests, 1)
+	require.Equal(t, clientSigner.Address().Hex(), requests[0].Header.Get(SignerHeader))
+	require.Equal(t, []interface{}{map[string]interface{}{"txs": []interface{}{"0x01"}}}, requests[0].Params)
+
+	require.Eventually(t, func() bool {
+		return len(secondary.RequestsFor("eth_sendBundle")) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestProxyRejects(t *testing.T) {
+	upstream := jsonrpc.NewMockJSONRPCServer()
+	defer upstream.Close()
+	proxy, err := New(Config{Name: "test_rejects", Upstreams: []string{upstream.URL}, RequireSignature: true})
+	require.NoError(t, err)
+
+	send := func(body string) map[string]any {
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+		var res map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	// unsigned
+	res := send(`{"jsonrpc":"2.0","id":"a","method":"eth_sendBundle","params":[]}`)
+	require.Equal(t, float64(-32600), res["error"].(map[string]any)["code"])
+	require.Empty(t, upstream.Requests())
+
+	_, err = New(Config{})
+	require.ErrorIs(t, err, ErrNoUpstreams)
+}
+
+func TestProxyForwardsErrors(t *testing.T) {
+	upstream := jsonrpc.NewMockJSONRPCServer()
+	defer upstream.Close()
+	upstream.SetHandler("eth_sendBundle", func(req *jsonrpc.JSONRPCRequest) (interface{}, error) {
+		return nil, &jsonrpc.JSONRPCError{Code: -32000, Message: "bundle too large"}
+	})
+	proxy, err := New(Config{Name: "test_errors", Upstreams: []string{upstream.URL}})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"jsonrpc":"2.0","id":"abc","method":"eth_sendBundle","params":[{}]}`)))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":"abc","error":{"code":-32000,"message":"bundle too large"}}`, rec.Body.String())
+}
@@ -0,0 +1,140 @@
+// Package ratelimit provides rate limiters keyed by arbitrary strings, e.g. signer addresses, IPs or origins.
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// incremented when a request is allowed / rejected by the limiter
+	allowedCounter  = `goutils_ratelimit_allowed_total{limiter="%s"}`
+	rejectedCounter = `goutils_ratelimit_rejected_total{limiter="%s"}`
+	// number of keys tracked by the limiter
+	keysGauge = `goutils_ratelimit_keys{limiter="%s"}`
+
+	defaultTTL = 10 * time.Minute
+)
+
+// Limiter limits the rate of events per key.
+type Limiter interface {
+	// Allow reports whether an event for the key may happen now, and records it if so
+	Allow(key string) bool
+}
+
+// keyedState holds the limiter state of the keys, evicting the keys idle for longer than the TTL.
+type keyedState[S any] struct {
+	name string
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry[S]
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type entry[S any] struct {
+	state    S
+	lastSeen time.Time
+}
+
+func newKeyedState[S any](name string, ttl time.Duration) *keyedState[S] {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	k := &keyedState[S]{
+		name:    name,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*entry[S]),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go k.run()
+	return k
+}
+
+// update calls fn with the state of the key (created if missing) under the lock and records the result.
+func (k *keyedState[S]) update(key string, fn func(state *S, now time.Time) bool) bool {
+	k.mu.Lock()
+	now := k.now()
+	e, ok := k.entries[key]
+	if !ok {
+		e = &entry[S]{}
+		k.entries[key] = e
+	}
+	e.lastSeen = now
+	allowed := fn(&e.state, now)
+	k.mu.Unlock()
+
+	if allowed {
+		metrics.GetOrCreateCounter(fmt.Sprintf(allowedCounter, k.name)).Inc()
+	} else {
+		metrics.GetOrCreateCounter(fmt.Sprintf(rejectedCounter, k.name)).Inc()
+	}
+	return allowed
+}
+
+func (k *keyedState[S]) run() {
+	defer close(k.done)
+	ticker := time.NewTicker(k.ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.stop:
+			return
+		case <-ticker.C:
+			k.evict()
+		}
+	}
+}
+
+// evict removes the keys idle for longer than the TTL.
+func (k *keyedState[S]) evict() {
+	k.mu.Lock()
+	now := k.now()
+	for key, e := range k.entries {
+		if now.Sub(e.lastSeen) > k.ttl {
+			delete(k.entries, key)
+		}
+	}
+	keys := len(k.entries)
+	k.mu.Unlock()
+
+	metrics.GetOrCreateGauge(fmt.Sprintf(keysGauge, k.name), nil).Set(float64(keys))
+}
+
+// Len returns the number of tracked keys.
+func (k *keyedState[S]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.entries)
+}
+
+// Close stops the eviction of idle keys.
+func (k *keyedState[S]) Close() {
+	k.closeOnce.Do(func() { close(k.stop) })
+	<-k.done
+}
+
+// Middleware rejects the requests exceeding the limit of their key with 429 Too Many Requests, e.g. keyed by
+// httplogger.ClientIP. Requests with an empty key are not limited.
+func Middleware(limiter Limiter, keyFunc func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := keyFunc(r); key != "" && !limiter.Allow(key) {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Add(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestTokenBucket(t *testing.T) {
+	l := NewTokenBucket(TokenBucketConfig{Name: "test", Rate: 2, Burst: 3, TTL: time.Minute})
+	defer l.Close()
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+	l.now = c.Now
+
+	for i := 0; i < 3; i++ {
+		require.True(t, l.Allow("a"))
+	}
+	require.False(t, l.Allow("a"))
+	require.True(t, l.Allow("b"), "keys are limited independently")
+
+	c.Add(500 * time.Millisecond)
+	require.True(t, l.Allow("a"))
+	require.False(t, l.Allow("a"))
+	c.Add(10 * time.Second)
+	require.True(t, l.AllowN("a", 3))
+	require.False(t, l.AllowN("a", 4))
+
+	c.Add(2 * time.Minute)
+	require.Equal(t, 2, l.Len())
+	l.evict()
+	require.Equal(t, 0, l.Len())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l := NewSlidingWindow(SlidingWindowConfig{Name: "test", Limit: 10, Window: time.Minute})
+	defer l.Close()
+	c := &clock{now: time.Unix(1_700_000_000, 0).Truncate(time.Minute)}
+	l.now = c.Now
+
+	for i := 0; i < 10; i++ {
+		require.True(t, l.Allow("a"))
+	}
+	require.False(t, l.Allow("a"))
+
+	// half way through the next window, half of the previous count is still in the window
+	c.Add(90 * time.Second)
+	for i := 0; i < 5; i++ {
+		require.True(t, l.Allow("a"))
+	}
+	require.False(t, l.Allow("a"))
+
+	// the count is reset after an idle window
+	c.Add(3 * time.Minute)
+	require.True(t, l.Allow("a"))
+}
+
+func TestMiddleware(t *testing.T) {
+	l := NewTokenBucket(TokenBucketConfig{Name: "middleware", Rate: 0, Burst: 1})
+	defer l.Close()
+	handler := Middleware(l, func(r *http.Request) string { return r.Header.Get("X-Key") })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, request("a"))
+	require.Equal(t, http.StatusTooManyRequests, request("a"))
+	require.Equal(t, http.StatusOK, request(""))
+	require.Equal(t, http.StatusOK, request(""))
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import "time"
+
+// SlidingWindowConfig configures SlidingWindow.
+type SlidingWindowConfig struct {
+	// Name is the limiter label of the metrics
+	Name string
+	// Limit is the number of events allowed per window
+	Limit int
+	// Window is the duration of the window, e.g. 1 minute for a per-minute limit
+	Window time.Duration
+	// TTL is the idle time after which keys are evicted, twice the window by default
+	TTL time.Duration
+}
+
+// SlidingWindow limits the number of events per key within a sliding window. The count of the window is
+// approximated by weighting the count of the previous fixed window, which needs constant memory per key. Call Close
+// to stop evicting idle keys.
+type SlidingWindow struct {
+	*keyedState[window]
+	limit  int
+	window time.Duration
+}
+
+type window struct {
+	start    time.Time
+	count    int
+	previous int
+}
+
+// NewSlidingWindow creates a sliding window limiter.
+func NewSlidingWindow(cfg SlidingWindowConfig) *SlidingWindow {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 2 * cfg.Window
+	}
+	return &SlidingWindow{
+		keyedState: newKeyedState[window](cfg.Name, ttl),
+		limit:      cfg.Limit,
+		window:     cfg.Window,
+	}
+}
+
+// Allow reports whether an event for the key may happen now, counting it if so.
+func (l *SlidingWindow) Allow(key string) bool {
+	return l.update(key, func(w *window, now time.Time) bool {
+		start := now.Truncate(l.window)
+		switch {
+		case w.start.Equal(start):
+		case w.start.Add(l.window).Equal(start):
+			w.previous, w.count = w.count, 0
+			w.start = start
+		default:
+			w.previous, w.count = 0, 0
+			w.start = start
+		}
+
+		elapsed := float64(now.Sub(start)) / float64(l.window)
+		estimate := float64(w.previous)*(1-elapsed) + float64(w.count)
+		if estimate+1 > float64(l.limit) {
+			return false
+		}
+		w.count++
+		return true
+	})
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// TokenBucketConfig configures TokenBucket.
+type TokenBucketConfig struct {
+	// Name is the limiter label of the metrics
+	Name string
+	// Rate is the number of tokens added per second
+	Rate float64
+	// Burst is the capacity of the buckets, at least 1
+	Burst int
+	// TTL is the idle time after which keys are evicted (their bucket being full again), 10 minutes by default
+	TTL time.Duration
+}
+
+// TokenBucket is a token bucket limiter per key: events consume tokens, which are refilled at a constant rate up
+// to the burst size. Call Close to stop evicting idle keys.
+type TokenBucket struct {
+	*keyedState[bucket]
+	rate  float64
+	burst float64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter.
+func NewTokenBucket(cfg TokenBucketConfig) *TokenBucket {
+	burst := cfg.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		keyedState: newKeyedState[bucket](cfg.Name, cfg.TTL),
+		rate:       cfg.Rate,
+		burst:      float64(burst),
+	}
+}
+
+// Allow reports whether an event for the key may happen now, consuming a token if so.
+func (l *TokenBucket) Allow(key string) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events for the key may happen now, consuming n tokens if so.
+func (l *TokenBucket) AllowN(key string, n int) bool {
+	return l.update(key, func(b *bucket, now time.Time) bool {
+		if b.last.IsZero() {
+			b.tokens = l.burst
+		} else {
+			b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		}
+		b.last = now
+
+		if b.tokens < float64(n) {
+			return false
+		}
+		b.tokens -= float64(n)
+		return true
+	})
+}
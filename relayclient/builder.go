@@ -0,0 +1,31 @@
+package relayclient
+
+import (
+	"context"
+)
+
+// SubmitOptions configures a block submission.
+type SubmitOptions struct {
+	// Cancellations allows the submission to replace a higher bid of the builder for the slot
+	Cancellations bool
+}
+
+// GetProposerDuties returns the registered validators proposing in the current and next epoch
+func (c *Client) GetProposerDuties(ctx context.Context) ([]*ProposerDuty, error) {
+	var res []*ProposerDuty
+	if _, err := c.get(ctx, "/relay/v1/builder/validators", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SubmitBlock submits the fork specific signed block submission (bid trace message, execution payload, signature and
+// blobs bundle). It is not retried.
+func (c *Client) SubmitBlock(ctx context.Context, submission any, opts SubmitOptions) error {
+	path := "/relay/v1/builder/blocks"
+	if opts.Cancellations {
+		path += "?cancellations=1"
+	}
+	_, err := c.post(ctx, path, submission, nil, false)
+	return err
+}
@@ -0,0 +1,170 @@
+// Package relayclient is a client for the MEV-Boost relay APIs: the builder-specs proposer API (validator
+// registrations, getHeader, getPayload), the relay Data API (bid traces) and the relay Builder API (validators,
+// block submissions).
+package relayclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/signature"
+)
+
+var ErrNoBid = errors.New("no bid available")
+
+// APIError is a non-2xx response of the relay.
+type APIError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("relay returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("relay returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client sends requests to a relay.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	header       http.Header
+	signer       *signature.Signer
+	retryOptions []retry.Option
+	// retryClient sends the retryable requests if WithRetry is set
+	retryClient *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with the client instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithHeader adds the header to the requests
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithSigner signs the request bodies, setting the X-Flashbots-Signature header
+func WithSigner(signer *signature.Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// WithRetry retries the requests failing with network errors or HTTP 429, 502, 503 and 504, see the retry package.
+// Block submissions are never retried, as they are only useful on time.
+func WithRetry(opts ...retry.Option) Option {
+	return func(c *Client) {
+		c.retryOptions = append([]retry.Option{}, opts...)
+	}
+}
+
+// New creates a client for the relay at the base URL, e.g. https://boost-relay.flashbots.net
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.retryClient = c.httpClient
+	if c.retryOptions != nil {
+		retryClient := *c.httpClient
+		retryClient.Transport = httputil.NewRetryTransport(c.httpClient.Transport, httputil.RetryTransportOpts{
+			Name:         "relayclient",
+			RetryOptions: c.retryOptions,
+		})
+		c.retryClient = &retryClient
+	}
+	return c
+}
+
+// get decodes the JSON response of the path into out, returning the status code
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) (int, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out, true)
+}
+
+// post sends the payload as JSON and decodes the JSON response into out (if not nil), returning the status code
+func (c *Client) post(ctx context.Context, path string, payload, out any, retryable bool) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	return c.do(ctx, http.MethodPost, path, body, out, retryable)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any, retryable bool) (int, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range c.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if c.signer != nil {
+			signatureHeader, err := c.signer.Create(body)
+			if err != nil {
+				return 0, err
+			}
+			req.Header.Set(signature.HTTPHeader, signatureHeader)
+		}
+	}
+
+	client := c.httpClient
+	if retryable {
+		client = c.retryClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(respBody, apiErr)
+		return resp.StatusCode, apiErr
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decoding response of %s: %w", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
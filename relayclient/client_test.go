@@ -0,0 +1,117 @@
+package relayclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+const testBidTrace = `{
+	"slot": "1000",
+	"parent_hash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+	"block_hash": "0x0000000000000000000000000000000000000000000000000000000000000002",
+	"builder_pubkey": "0xaa",
+	"proposer_pubkey": "0xbb",
+	"proposer_fee_recipient": "0x0000000000000000000000000000000000000003",
+	"gas_limit": "30000000",
+	"gas_used": "15000000",
+	"value": "123456789012345678901",
+	"num_tx": "100",
+	"block_number": "18000000",
+	"timestamp": "1700000000",
+	"timestamp_ms": "1700000000123"
+}`
+
+func TestDataAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/relay/v1/data/bidtraces/builder_blocks_received", r.URL.Path)
+		require.Equal(t, "1000", r.URL.Query().Get("slot"))
+		require.Equal(t, "0xaa", r.URL.Query().Get("builder_pubkey"))
+		require.False(t, r.URL.Query().Has("limit"))
+		_, _ = w.Write([]byte("[" + testBidTrace + "]"))
+	}))
+	defer server.Close()
+
+	traces, err := New(server.URL).GetReceivedBlocks(context.Background(), BidTraceFilter{Slot: 1000, BuilderPubkey: hexutil.Bytes{0xaa}})
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	trace := traces[0]
+	require.Equal(t, uint64(1000), trace.Slot)
+	require.Equal(t, common.HexToHash("0x2"), trace.BlockHash)
+	require.Equal(t, common.HexToAddress("0x3"), trace.ProposerFeeRecipient)
+	require.Equal(t, "123456789012345678901", trace.Value.ToInt().String())
+	require.Equal(t, int64(1700000000123), trace.TimestampMs)
+
+	// round trip
+	raw, err := json.Marshal(trace.BidTrace)
+	require.NoError(t, err)
+	var decoded BidTrace
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Equal(t, trace.BidTrace, decoded)
+}
+
+func TestSubmitBlock(t *testing.T) {
+	signer, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.Equal(t, "/relay/v1/builder/blocks", r.URL.Path)
+		require.Equal(t, "1", r.URL.Query().Get("cancellations"))
+		body, _ := io.ReadAll(r.Body)
+		recovered, err := signature.Verify(r.Header.Get(signature.HTTPHeader), body)
+		require.NoError(t, err)
+		require.Equal(t, signer.Address(), recovered)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"code":503,"message":"relay overloaded"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithSigner(signer), WithRetry(retry.WithBackoff(time.Millisecond, 0)))
+	err = client.SubmitBlock(context.Background(), map[string]any{"message": map[string]any{"slot": "1"}}, SubmitOptions{Cancellations: true})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	require.Equal(t, "relay overloaded", apiErr.Message)
+	// submissions are not retried
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetHeader(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		require.Equal(t, "/eth/v1/builder/header/1000/0x0000000000000000000000000000000000000000000000000000000000000001/0xbb", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithRetry(retry.WithBackoff(time.Millisecond, 0)))
+	_, err := client.GetHeader(context.Background(), 1000, common.HexToHash("0x1"), hexutil.Bytes{0xbb})
+	require.ErrorIs(t, err, ErrNoBid)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestWei(t *testing.T) {
+	value := NewWei(big.NewInt(42))
+	raw, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.Equal(t, `"42"`, string(raw))
+	require.Error(t, json.Unmarshal([]byte(`"0x2a"`), new(Wei)))
+}
@@ -0,0 +1,78 @@
+package relayclient
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BidTraceFilter filters the bid traces of the Data API, zero fields are ignored.
+type BidTraceFilter struct {
+	Slot           uint64
+	Cursor         uint64
+	Limit          uint64
+	BlockHash      common.Hash
+	BlockNumber    uint64
+	ProposerPubkey hexutil.Bytes
+	BuilderPubkey  hexutil.Bytes
+	// OrderBy is "value" or "-value", by slot by default
+	OrderBy string
+}
+
+func (f BidTraceFilter) query() url.Values {
+	query := make(url.Values)
+	setUint := func(key string, value uint64) {
+		if value != 0 {
+			query.Set(key, strconv.FormatUint(value, 10))
+		}
+	}
+	setUint("slot", f.Slot)
+	setUint("cursor", f.Cursor)
+	setUint("limit", f.Limit)
+	setUint("block_number", f.BlockNumber)
+	if f.BlockHash != (common.Hash{}) {
+		query.Set("block_hash", f.BlockHash.Hex())
+	}
+	if len(f.ProposerPubkey) > 0 {
+		query.Set("proposer_pubkey", f.ProposerPubkey.String())
+	}
+	if len(f.BuilderPubkey) > 0 {
+		query.Set("builder_pubkey", f.BuilderPubkey.String())
+	}
+	if f.OrderBy != "" {
+		query.Set("order_by", f.OrderBy)
+	}
+	return query
+}
+
+// GetDeliveredPayloads returns the traces of the payloads delivered to proposers
+func (c *Client) GetDeliveredPayloads(ctx context.Context, filter BidTraceFilter) ([]*BidTrace, error) {
+	var res []*BidTrace
+	if _, err := c.get(ctx, "/relay/v1/data/bidtraces/proposer_payload_delivered", filter.query(), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetReceivedBlocks returns the traces of the blocks submitted by builders, the filter needs one of slot, block hash,
+// block number or builder pubkey
+func (c *Client) GetReceivedBlocks(ctx context.Context, filter BidTraceFilter) ([]*ReceivedBidTrace, error) {
+	var res []*ReceivedBidTrace
+	if _, err := c.get(ctx, "/relay/v1/data/bidtraces/builder_blocks_received", filter.query(), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetValidatorRegistration returns the latest registration of the validator
+func (c *Client) GetValidatorRegistration(ctx context.Context, pubkey hexutil.Bytes) (*SignedValidatorRegistration, error) {
+	res := new(SignedValidatorRegistration)
+	query := url.Values{"pubkey": []string{pubkey.String()}}
+	if _, err := c.get(ctx, "/relay/v1/data/validator_registration", query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
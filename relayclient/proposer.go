@@ -0,0 +1,46 @@
+package relayclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Status checks that the relay is available
+func (c *Client) Status(ctx context.Context) error {
+	_, err := c.get(ctx, "/eth/v1/builder/status", nil, nil)
+	return err
+}
+
+// RegisterValidators submits the validator registrations
+func (c *Client) RegisterValidators(ctx context.Context, registrations []*SignedValidatorRegistration) error {
+	_, err := c.post(ctx, "/eth/v1/builder/validators", registrations, nil, true)
+	return err
+}
+
+// GetHeader returns the best bid for the slot, or ErrNoBid. The data is the fork specific signed builder bid.
+func (c *Client) GetHeader(ctx context.Context, slot uint64, parentHash common.Hash, proposerPubkey hexutil.Bytes) (*VersionedResponse, error) {
+	res := new(VersionedResponse)
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHash.Hex(), proposerPubkey.String())
+	statusCode, err := c.get(ctx, path, nil, res)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNoContent || len(res.Data) == 0 {
+		return nil, ErrNoBid
+	}
+	return res, nil
+}
+
+// GetPayload submits the fork specific signed blinded beacon block and returns the execution payload. It is not
+// retried, the relay revealing the payload only once.
+func (c *Client) GetPayload(ctx context.Context, signedBlindedBlock any) (*VersionedResponse, error) {
+	res := new(VersionedResponse)
+	if _, err := c.post(ctx, "/eth/v1/builder/blinded_blocks", signedBlindedBlock, res, false); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package relayclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Wei is an amount encoded as decimal JSON string, as in the relay APIs.
+type Wei big.Int
+
+// NewWei returns the amount as Wei
+func NewWei(amount *big.Int) *Wei {
+	return (*Wei)(new(big.Int).Set(amount))
+}
+
+// ToInt returns the amount as big.Int
+func (w *Wei) ToInt() *big.Int {
+	return (*big.Int)(w)
+}
+
+func (w *Wei) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*big.Int)(w).String())
+}
+
+func (w *Wei) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if _, ok := (*big.Int)(w).SetString(s, 10); !ok {
+		return fmt.Errorf("invalid wei amount %q", s)
+	}
+	return nil
+}
+
+// BidTrace is the trace of a bid, as returned by the Data API and submitted by builders.
+type BidTrace struct {
+	Slot                 uint64         `json:"slot,string"`
+	ParentHash           common.Hash    `json:"parent_hash"`
+	BlockHash            common.Hash    `json:"block_hash"`
+	BuilderPubkey        hexutil.Bytes  `json:"builder_pubkey"`
+	ProposerPubkey       hexutil.Bytes  `json:"proposer_pubkey"`
+	ProposerFeeRecipient common.Address `json:"proposer_fee_recipient"`
+	GasLimit             uint64         `json:"gas_limit,string"`
+	GasUsed              uint64         `json:"gas_used,string"`
+	Value                *Wei           `json:"value"`
+	NumTx                uint64         `json:"num_tx,string,omitempty"`
+	BlockNumber          uint64         `json:"block_number,string,omitempty"`
+}
+
+// ReceivedBidTrace is the trace of a block received by the relay.
+type ReceivedBidTrace struct {
+	BidTrace
+	Timestamp            int64 `json:"timestamp,string,omitempty"`
+	TimestampMs          int64 `json:"timestamp_ms,string,omitempty"`
+	OptimisticSubmission bool  `json:"optimistic_submission,omitempty"`
+}
+
+// ValidatorRegistration is the fee recipient and gas limit preferences of a validator.
+type ValidatorRegistration struct {
+	FeeRecipient common.Address `json:"fee_recipient"`
+	GasLimit     uint64         `json:"gas_limit,string"`
+	Timestamp    uint64         `json:"timestamp,string"`
+	Pubkey       hexutil.Bytes  `json:"pubkey"`
+}
+
+// SignedValidatorRegistration is a validator registration with its BLS signature.
+type SignedValidatorRegistration struct {
+	Message   *ValidatorRegistration `json:"message"`
+	Signature hexutil.Bytes          `json:"signature"`
+}
+
+// ProposerDuty is a validator proposing a slot of the current or next epoch, as returned by the Builder API.
+type ProposerDuty struct {
+	Slot           uint64                       `json:"slot,string"`
+	ValidatorIndex uint64                       `json:"validator_index,string"`
+	Entry          *SignedValidatorRegistration `json:"entry"`
+}
+
+// VersionedResponse is a fork versioned response (e.g. of getHeader), whose data depends on the fork.
+type VersionedResponse struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
@@ -0,0 +1,22 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// IsRetryableHTTP reports whether an HTTP request is worth retrying: if it failed with a network error or timeout
+// (err), or if the response has the status 429, 502, 503 or 504. The status is ignored if err is not nil.
+func IsRetryableHTTP(status int, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
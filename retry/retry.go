@@ -0,0 +1,165 @@
+// Package retry retries operations with exponential backoff and jitter, until they succeed, the maximum number of
+// attempts is reached, or the context is done.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts  = 3
+	DefaultInitialDelay = 100 * time.Millisecond
+	DefaultMaxDelay     = 10 * time.Second
+	DefaultMultiplier   = 2.0
+	DefaultJitter       = 0.2
+)
+
+type config struct {
+	maxAttempts  int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	multiplier   float64
+	jitter       float64
+	retryIf      func(err error) bool
+	onRetry      func(attempt int, err error, delay time.Duration)
+}
+
+// Option configures Do
+type Option func(*config)
+
+// WithMaxAttempts sets the maximum number of attempts including the first one, 0 retries until the context is done
+func WithMaxAttempts(attempts int) Option {
+	return func(c *config) {
+		c.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the maximum delay between retries (uncapped if 0)
+func WithBackoff(initial, maxDelay time.Duration) Option {
+	return func(c *config) {
+		c.initialDelay = initial
+		c.maxDelay = maxDelay
+	}
+}
+
+// WithMultiplier sets the factor by which the delay grows at each retry, 1 for a constant delay
+func WithMultiplier(multiplier float64) Option {
+	return func(c *config) {
+		c.multiplier = multiplier
+	}
+}
+
+// WithJitter randomizes each delay by +/- the fraction of it (e.g. 0.2), 0 disables jitter
+func WithJitter(fraction float64) Option {
+	return func(c *config) {
+		c.jitter = fraction
+	}
+}
+
+// WithRetryIf only retries the errors for which the predicate returns true, other errors are returned immediately
+func WithRetryIf(retryIf func(err error) bool) Option {
+	return func(c *config) {
+		c.retryIf = retryIf
+	}
+}
+
+// WithOnRetry calls the hook after each failed attempt that will be retried, with the attempt number (starting at 1),
+// its error and the delay before the next attempt
+func WithOnRetry(onRetry func(attempt int, err error, delay time.Duration)) Option {
+	return func(c *config) {
+		c.onRetry = onRetry
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps the error to stop retrying, Do returns the unwrapped error
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds and returns the error of the last attempt. It stops retrying when the maximum number
+// of attempts is reached, the error is permanent or not retryable, or the context is done (the error of the last
+// attempt is returned then, or the context error if there was no attempt).
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// DoValue is Do for functions returning a value
+func DoValue[T any](ctx context.Context, fn func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	cfg := &config{
+		maxAttempts:  DefaultMaxAttempts,
+		initialDelay: DefaultInitialDelay,
+		maxDelay:     DefaultMaxDelay,
+		multiplier:   DefaultMultiplier,
+		jitter:       DefaultJitter,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	delay := cfg.initialDelay
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return zero, permanent.err
+		}
+		if (cfg.maxAttempts > 0 && attempt >= cfg.maxAttempts) || ctx.Err() != nil || (cfg.retryIf != nil && !cfg.retryIf(err)) {
+			return zero, err
+		}
+
+		wait := cfg.jittered(delay)
+		if cfg.onRetry != nil {
+			cfg.onRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, err
+		case <-timer.C:
+		}
+		delay = cfg.next(delay)
+	}
+}
+
+// next returns the delay after the given one
+func (c *config) next(delay time.Duration) time.Duration {
+	next := time.Duration(float64(delay) * c.multiplier)
+	if next < 0 || (c.maxDelay > 0 && next > c.maxDelay) {
+		// negative on overflow
+		next = c.maxDelay
+	}
+	return next
+}
+
+func (c *config) jittered(delay time.Duration) time.Duration {
+	if c.jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	delta := c.jitter * float64(delay)
+	return time.Duration(float64(delay) - delta + rand.Float64()*2*delta) //nolint:gosec
+}
@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test error")
+
+func TestDo(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTest
+		}
+		return nil
+	}, WithBackoff(time.Millisecond, 0))
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestDoMaxAttempts(t *testing.T) {
+	var attempts []int
+	var delays []time.Duration
+	calls := 0
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTest
+	}, WithMaxAttempts(4), WithBackoff(time.Millisecond, 3*time.Millisecond), WithJitter(0),
+		WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			require.ErrorIs(t, err, errTest)
+			attempts = append(attempts, attempt)
+			delays = append(delays, delay)
+		}))
+	require.ErrorIs(t, err, errTest)
+	require.Equal(t, 4, calls)
+	require.Equal(t, []int{1, 2, 3}, attempts)
+	require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, delays)
+}
+
+func TestDoRetryIfAndPermanent(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTest
+	}, WithRetryIf(func(err error) bool { return !errors.Is(err, errTest) }))
+	require.ErrorIs(t, err, errTest)
+	require.Equal(t, 1, calls)
+
+	calls = 0
+	err = Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return Permanent(errTest)
+	})
+	require.Equal(t, errTest, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestDoContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Do(ctx, func(ctx context.Context) error {
+		t.Fatal("unexpected attempt")
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = Do(ctx, func(ctx context.Context) error {
+		return errTest
+	}, WithMaxAttempts(0), WithBackoff(time.Hour, 0))
+	require.ErrorIs(t, err, errTest)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoValue(t *testing.T) {
+	calls := 0
+	value, err := DoValue(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		if calls < 2 {
+			return 0, errTest
+		}
+		return 42, nil
+	}, WithBackoff(time.Millisecond, 0))
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+}
+
+func TestJitter(t *testing.T) {
+	cfg := &config{jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		delay := cfg.jittered(time.Second)
+		require.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		require.LessOrEqual(t, delay, 1500*time.Millisecond)
+	}
+}
+
+func TestIsRetryableHTTP(t *testing.T) {
+	require.True(t, IsRetryableHTTP(0, &net.OpError{Op: "dial", Err: errTest}))
+	require.True(t, IsRetryableHTTP(0, fmt.Errorf("call: %w", context.DeadlineExceeded)))
+	require.False(t, IsRetryableHTTP(0, context.Canceled))
+	require.False(t, IsRetryableHTTP(http.StatusServiceUnavailable, errTest))
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		require.True(t, IsRetryableHTTP(status, nil), status)
+	}
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError} {
+		require.False(t, IsRetryableHTTP(status, nil), status)
+	}
+}
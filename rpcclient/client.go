@@ -16,7 +16,10 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/flashbots/go-utils/retry"
 	"github.com/flashbots/go-utils/signature"
 )
 
@@ -255,14 +258,17 @@ type rpcClient struct {
 	allowUnknownFields          bool
 	defaultRequestID            int
 	signer                      *signature.Signer
+	signTimestamp               bool
 	rejectBrokenFlashbotsErrors bool
+	retryOptions                []retry.Option
 }
 
 // RPCClientOpts can be provided to NewClientWithOpts() to change configuration of RPCClient.
 //
 // HTTPClient: provide a custom http.Client (e.g. to set a proxy, or tls options)
 //
-// CustomHeaders: provide custom headers, e.g. to set BasicAuth
+// CustomHeaders: provide custom headers, e.g. to set BasicAuth. The headers of the request context (see
+// httputil.CtxWithHeaders) are set after them.
 //
 // AllowUnknownFields: allows the rpc response to contain fields that are not defined in the rpc response specification.
 type RPCClientOpts struct {
@@ -273,10 +279,16 @@ type RPCClientOpts struct {
 
 	// If Signer is set requset body will be signed and signature will be set in the X-Flashbots-Signature header
 	Signer *signature.Signer
+	// If true (with Signer) the X-Flashbots-Timestamp header is set to the current time and covered by the signature,
+	// as required by servers with replay protection
+	SignTimestamp bool
 	// if true client will return error when server responds with errors like {"error": "text"}
 	// otherwise this response will be converted to equivalent {"error": {"message": "text", "code": FlashbotsBrokenErrorResponseCode}}
 	// Bad errors are always rejected for batch requests
 	RejectBrokenFlashbotsErrors bool
+	// If RetryOptions is not nil, requests failing with network errors or HTTP 429, 502, 503 and 504 are retried
+	// (3 attempts with exponential backoff by default, see the retry package)
+	RetryOptions []retry.Option
 }
 
 // RPCResponses is of type []*RPCResponse.
@@ -357,7 +369,9 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 
 	rpcClient.defaultRequestID = opts.DefaultRequestID
 	rpcClient.signer = opts.Signer
+	rpcClient.signTimestamp = opts.SignTimestamp
 	rpcClient.rejectBrokenFlashbotsErrors = opts.RejectBrokenFlashbotsErrors
+	rpcClient.retryOptions = opts.RetryOptions
 
 	return rpcClient
 }
@@ -420,7 +434,13 @@ func (client *rpcClient) newRequest(ctx context.Context, req any) (*http.Request
 	request.Header.Set("Accept", "application/json")
 
 	if client.signer != nil {
-		signatureHeader, err := client.signer.Create(body)
+		payload := body
+		if client.signTimestamp {
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			request.Header.Set(signature.TimestampHTTPHeader, timestamp)
+			payload = signature.TimestampedBody(timestamp, body)
+		}
+		signatureHeader, err := client.signer.Create(payload)
 		if err != nil {
 			return nil, err
 		}
@@ -437,10 +457,31 @@ func (client *rpcClient) newRequest(ctx context.Context, req any) (*http.Request
 		}
 	}
 
+	// then the dynamic headers of the context, see httputil.CtxWithHeaders
+	for k, v := range httputil.HeadersFromCtx(ctx) {
+		if k == "Host" {
+			request.Host = v
+		} else {
+			request.Header.Set(k, v)
+		}
+	}
+
 	return request, nil
 }
 
 func (client *rpcClient) doCall(ctx context.Context, RPCRequest *RPCRequest) (*RPCResponse, error) {
+	if client.retryOptions == nil {
+		return client.doCallOnce(ctx, RPCRequest)
+	}
+	var rpcResponse *RPCResponse
+	err := retry.Do(ctx, func(ctx context.Context) (err error) {
+		rpcResponse, err = client.doCallOnce(ctx, RPCRequest)
+		return err
+	}, client.retryOpts()...)
+	return rpcResponse, err
+}
+
+func (client *rpcClient) doCallOnce(ctx context.Context, RPCRequest *RPCRequest) (*RPCResponse, error) {
 	httpRequest, err := client.newRequest(ctx, RPCRequest)
 	if err != nil {
 		return nil, fmt.Errorf("rpc call %v() on %v: %w", RPCRequest.Method, client.endpoint, err)
@@ -535,6 +576,32 @@ func (client *rpcClient) doCall(ctx context.Context, RPCRequest *RPCRequest) (*R
 }
 
 func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
+	if client.retryOptions == nil {
+		return client.doBatchCallOnce(ctx, rpcRequest)
+	}
+	var rpcResponses []*RPCResponse
+	err := retry.Do(ctx, func(ctx context.Context) (err error) {
+		rpcResponses, err = client.doBatchCallOnce(ctx, rpcRequest)
+		return err
+	}, client.retryOpts()...)
+	return rpcResponses, err
+}
+
+// retryOpts returns the retry options, retrying network errors and retryable HTTP statuses unless overridden
+func (client *rpcClient) retryOpts() []retry.Option {
+	return append([]retry.Option{retry.WithRetryIf(isRetryable)}, client.retryOptions...)
+}
+
+// isRetryable returns true for network errors and HTTP 429, 502, 503 and 504
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return retry.IsRetryableHTTP(httpErr.Code, nil)
+	}
+	return retry.IsRetryableHTTP(0, err)
+}
+
+func (client *rpcClient) doBatchCallOnce(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
 	httpRequest, err := client.newRequest(ctx, rpcRequest)
 	if err != nil {
 		return nil, fmt.Errorf("rpc batch call on %v: %w", client.endpoint, err)
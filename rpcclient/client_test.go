@@ -8,7 +8,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/flashbots/go-utils/retry"
 	"github.com/flashbots/go-utils/signature"
 	"github.com/stretchr/testify/assert"
 )
@@ -1141,3 +1144,49 @@ func BenchmarkJSONRPCClientWithSignatures(b *testing.B) {
 		}
 	}
 }
+
+func TestRetry(t *testing.T) {
+	check := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":0,"result":"ok"}`)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		RetryOptions: []retry.Option{retry.WithBackoff(time.Millisecond, 0)},
+	})
+	res, err := rpcClient.Call(context.Background(), "something")
+	check.Nil(err)
+	check.Equal("ok", res.Result)
+	check.Equal(3, calls)
+
+	// not retried without options
+	calls = 0
+	res, err = NewClient(server.URL).Call(context.Background(), "something")
+	check.Nil(res)
+	check.Error(err)
+	check.Equal(1, calls)
+}
+
+func TestContextHeaders(t *testing.T) {
+	check := assert.New(t)
+
+	responseBody = `{"result": null}`
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"X-Origin": "static"},
+	})
+	ctx := httputil.CtxWithHeaders(context.Background(), map[string]string{"X-Origin": "dynamic", "X-Request-Id": "1"})
+	_, err := rpcClient.Call(ctx, "add", 1, 2)
+	check.Nil(err)
+
+	req := (<-requestChan).request
+	check.Equal("dynamic", req.Header.Get("X-Origin"))
+	check.Equal("1", req.Header.Get("X-Request-Id"))
+}
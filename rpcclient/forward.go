@@ -0,0 +1,76 @@
+package rpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/flashbots/go-utils/httputil"
+)
+
+// ErrForwardDropped is passed to ForwardOpts.OnError for the background requests dropped by ForwardOpts.Go
+var ErrForwardDropped = errors.New("background request dropped")
+
+// Upstream is an endpoint the requests are forwarded to, see Forward.
+type Upstream struct {
+	// Host of the upstream URL, e.g. for metrics labels
+	Host   string
+	Client RPCClient
+}
+
+// NewUpstreams creates the clients of the upstream URLs with the options.
+func NewUpstreams(urls []string, opts *RPCClientOpts) ([]Upstream, error) {
+	upstreams := make([]Upstream, 0, len(urls))
+	for _, upstreamURL := range urls {
+		u, err := url.Parse(upstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upstream %q: %w", upstreamURL, err)
+		}
+		upstreams = append(upstreams, Upstream{Host: u.Host, Client: NewClientWithOpts(upstreamURL, opts)})
+	}
+	return upstreams, nil
+}
+
+// ForwardOpts configures Forward.
+type ForwardOpts struct {
+	// Timeout of each upstream request
+	Timeout time.Duration
+	// OnError is called for each failed upstream request, can be nil
+	OnError func(upstream Upstream, err error)
+	// Go runs the background requests, e.g. to limit their concurrency. It returns false if the request is dropped.
+	// If nil, each background request runs in a new goroutine.
+	Go func(fn func()) bool
+}
+
+// Forward sends the request to all the upstreams and returns the response of the first one. The requests to the
+// other upstreams are sent in the background and outlive ctx, only its headers (see httputil.CtxWithHeaders) are
+// passed on to them.
+func Forward(ctx context.Context, upstreams []Upstream, req *RPCRequest, opts ForwardOpts) (*RPCResponse, error) {
+	call := func(ctx context.Context, u Upstream) (*RPCResponse, error) {
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+		res, err := u.Client.CallRaw(ctx, req)
+		if err != nil && opts.OnError != nil {
+			opts.OnError(u, err)
+		}
+		return res, err
+	}
+
+	background := httputil.CtxWithHeaders(context.Background(), httputil.HeadersFromCtx(ctx))
+	for _, u := range upstreams[1:] {
+		u := u
+		fn := func() { _, _ = call(background, u) }
+		if opts.Go == nil {
+			go fn()
+		} else if !opts.Go(fn) && opts.OnError != nil {
+			opts.OnError(u, ErrForwardDropped)
+		}
+	}
+
+	return call(ctx, upstreams[0])
+}
@@ -0,0 +1,70 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForward(t *testing.T) {
+	check := assert.New(t)
+
+	headers := make(chan string, 2)
+	newUpstream := func(result int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers <- r.Header.Get("X-Test")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%d}`, result)
+		}))
+	}
+	first := newUpstream(1)
+	defer first.Close()
+	second := newUpstream(2)
+	defer second.Close()
+	failing := httptest.NewServer(http.NotFoundHandler())
+	failing.Close()
+
+	upstreams, err := NewUpstreams([]string{first.URL, second.URL, failing.URL}, nil)
+	check.Nil(err)
+
+	var mu sync.Mutex
+	failed := make(map[string]error)
+	onError := func(u Upstream, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[u.Host] = err
+	}
+
+	// the response of the first upstream is returned, the headers of the context are sent to all of them
+	ctx, cancel := context.WithCancel(httputil.CtxWithHeaders(context.Background(), map[string]string{"X-Test": "value"}))
+	res, err := Forward(ctx, upstreams, &RPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"}, ForwardOpts{Timeout: time.Second, OnError: onError})
+	cancel()
+	check.Nil(err)
+	check.Equal(json.Number("1"), res.Result)
+	check.Equal("value", <-headers)
+	check.Equal("value", <-headers)
+	check.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed[upstreams[2].Host] != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// the background requests dropped by Go are reported
+	res, err = Forward(context.Background(), upstreams[:2], &RPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"}, ForwardOpts{
+		OnError: onError,
+		Go:      func(fn func()) bool { return false },
+	})
+	check.Nil(err)
+	check.Equal(json.Number("1"), res.Result)
+	<-headers
+	mu.Lock()
+	check.ErrorIs(failed[upstreams[1].Host], ErrForwardDropped)
+	mu.Unlock()
+}
@@ -0,0 +1,38 @@
+package rpcserver
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const errSignerNotAllowed = "signer is not allowed"
+
+var ErrAllowedSignersWithoutSignature = errors.New("allowed signers need VerifyRequestSignatureFromHeader or ExtractUnverifiedRequestSignatureFromHeader")
+
+type signerSet map[common.Address]struct{}
+
+func newSignerSet(signers []common.Address) signerSet {
+	if signers == nil {
+		return nil
+	}
+	set := make(signerSet, len(signers))
+	for _, signer := range signers {
+		set[signer] = struct{}{}
+	}
+	return set
+}
+
+// signerAllowed reports whether the signer may call the method, according to the allowlist of the method or the
+// handler
+func (h *JSONRPCHandler) signerAllowed(method string, signer common.Address) bool {
+	allowed, ok := h.methodAllowedSigners[method]
+	if !ok {
+		allowed = h.allowedSigners
+	}
+	if allowed == nil {
+		return true
+	}
+	_, found := allowed[signer]
+	return found
+}
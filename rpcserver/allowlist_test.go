@@ -0,0 +1,52 @@
+package rpcserver
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedSigners(t *testing.T) {
+	allowed, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+	other, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+
+	handler, err := NewJSONRPCHandler(Methods{
+		"restricted": func(ctx context.Context) (int, error) { return 1, nil },
+		"open":       func(ctx context.Context) (int, error) { return 2, nil },
+	}, JSONRPCHandlerOpts{
+		VerifyRequestSignatureFromHeader: true,
+		AllowedSigners:                   []common.Address{allowed.Address()},
+		MethodOpts: map[string]MethodOpts{
+			"open": {AllowedSigners: []common.Address{allowed.Address(), other.Address()}},
+		},
+	})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	call := func(signer *signature.Signer, method string) *rpcclient.RPCResponse {
+		client := rpcclient.NewClientWithOpts(httpServer.URL, &rpcclient.RPCClientOpts{Signer: signer})
+		resp, err := client.Call(context.Background(), method)
+		require.NoError(t, err)
+		return resp
+	}
+
+	require.Nil(t, call(allowed, "restricted").Error)
+	require.Nil(t, call(allowed, "open").Error)
+	require.Nil(t, call(other, "open").Error)
+
+	resp := call(other, "restricted")
+	require.NotNil(t, resp.Error)
+	require.Equal(t, CodeInvalidRequest, resp.Error.Code)
+	require.Equal(t, errSignerNotAllowed, resp.Error.Message)
+
+	_, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{AllowedSigners: []common.Address{allowed.Address()}})
+	require.ErrorIs(t, err, ErrAllowedSignersWithoutSignature)
+}
@@ -0,0 +1,164 @@
+package rpcserver
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	errUnsupportedEncoding = "unsupported Content-Encoding"
+
+	defaultCompressionMinSizeBytes = 1024
+)
+
+// Codec is a content coding of the request and response bodies, e.g. GzipCodec or ZstdCodec.
+type Codec interface {
+	// Encoding is the name of the coding in the Content-Encoding and Accept-Encoding headers
+	Encoding() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// NewCodec returns a Codec from the functions creating the writers and readers.
+func NewCodec(encoding string, newWriter func(w io.Writer) (io.WriteCloser, error), newReader func(r io.Reader) (io.ReadCloser, error)) Codec {
+	return funcCodec{encoding: encoding, newWriter: newWriter, newReader: newReader}
+}
+
+type funcCodec struct {
+	encoding  string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+func (c funcCodec) Encoding() string                              { return c.encoding }
+func (c funcCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return c.newWriter(w) }
+func (c funcCodec) NewReader(r io.Reader) (io.ReadCloser, error)  { return c.newReader(r) }
+
+// GzipCodec is the gzip Codec, reusing the writers.
+var GzipCodec Codec = gzipCodec{}
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+type gzipCodec struct{}
+
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+func (w pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	gzipWriters.Put(w.Writer)
+	return err
+}
+
+func (gzipCodec) Encoding() string { return "gzip" }
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	gw := gzipWriters.Get().(*gzip.Writer) //nolint:forcetypeassert
+	gw.Reset(w)
+	return pooledGzipWriter{gw}, nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// ZstdCodec is the zstd Codec, reusing the writers.
+var ZstdCodec Codec = zstdCodec{}
+
+var zstdWriters = sync.Pool{
+	New: func() any {
+		// only fails with invalid options
+		zw, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return zw
+	},
+}
+
+type zstdCodec struct{}
+
+type pooledZstdWriter struct {
+	*zstd.Encoder
+}
+
+func (w pooledZstdWriter) Close() error {
+	err := w.Encoder.Close()
+	zstdWriters.Put(w.Encoder)
+	return err
+}
+
+func (zstdCodec) Encoding() string { return "zstd" }
+
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	zw := zstdWriters.Get().(*zstd.Encoder) //nolint:forcetypeassert
+	zw.Reset(w)
+	return pooledZstdWriter{zw}, nil
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	// the decompressed size is limited by the handler, a single goroutine decodes the request synchronously
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// requestCodec returns the codec of the request body, nil if not encoded. ok is false for unsupported encodings.
+func (h *JSONRPCHandler) requestCodec(r *http.Request) (codec Codec, ok bool) {
+	encoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+	if encoding == "" || strings.EqualFold(encoding, "identity") {
+		return nil, true
+	}
+	for _, codec := range h.Compression {
+		if strings.EqualFold(codec.Encoding(), encoding) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// responseCodec returns the preferred codec accepted by the client, nil if none
+func (h *JSONRPCHandler) responseCodec(r *http.Request) Codec {
+	if len(h.Compression) == 0 {
+		return nil
+	}
+	accepted := parseAcceptEncoding(r.Header.Get("Accept-Encoding"))
+	for _, codec := range h.Compression {
+		q, ok := accepted[strings.ToLower(codec.Encoding())]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return codec
+		}
+	}
+	return nil
+}
+
+// parseAcceptEncoding returns the q-values of the codings of the Accept-Encoding header
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if name, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.TrimSpace(name) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[coding] = q
+	}
+	return accepted
+}
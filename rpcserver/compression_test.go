@@ -0,0 +1,174 @@
+package rpcserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+var deflateCodec = NewCodec("deflate",
+	func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.DefaultCompression) },
+	func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+)
+
+func TestCompression(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"repeat": func(ctx context.Context, s string, n int) (string, error) {
+			return strings.Repeat(s, n), nil
+		},
+	}, JSONRPCHandlerOpts{Compression: []Codec{deflateCodec, GzipCodec}})
+	require.NoError(t, err)
+
+	call := func(body []byte, header http.Header) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		require.NoError(t, err)
+		request.Header = header
+		request.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, request)
+		return rr
+	}
+	largeRequest := []byte(`{"jsonrpc":"2.0","id":1,"method":"repeat","params":["a",2000]}`)
+	largeResponse := `{"jsonrpc":"2.0","id":1,"result":"` + strings.Repeat("a", 2000) + `"}`
+
+	t.Run("gzip response", func(t *testing.T) {
+		rr := call(largeRequest, http.Header{"Accept-Encoding": {"gzip, deflate;q=0"}})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+		gr, err := gzip.NewReader(rr.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		require.JSONEq(t, largeResponse, string(body))
+	})
+
+	t.Run("preferred codec", func(t *testing.T) {
+		rr := call(largeRequest, http.Header{"Accept-Encoding": {"gzip, deflate"}})
+		require.Equal(t, "deflate", rr.Header().Get("Content-Encoding"))
+		body, err := io.ReadAll(flate.NewReader(rr.Body))
+		require.NoError(t, err)
+		require.JSONEq(t, largeResponse, string(body))
+	})
+
+	t.Run("small response", func(t *testing.T) {
+		rr := call([]byte(`{"jsonrpc":"2.0","id":1,"method":"repeat","params":["a",2]}`), http.Header{"Accept-Encoding": {"gzip"}})
+		require.Empty(t, rr.Header().Get("Content-Encoding"))
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":"aa"}`, rr.Body.String())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		rr := call(largeRequest, http.Header{})
+		require.Empty(t, rr.Header().Get("Content-Encoding"))
+		require.JSONEq(t, largeResponse, rr.Body.String())
+	})
+
+	t.Run("gzip request", func(t *testing.T) {
+		var compressed bytes.Buffer
+		gw := gzip.NewWriter(&compressed)
+		_, err := gw.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"repeat","params":["b",3]}`))
+		require.NoError(t, err)
+		require.NoError(t, gw.Close())
+
+		rr := call(compressed.Bytes(), http.Header{"Content-Encoding": {"gzip"}})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":"bbb"}`, rr.Body.String())
+
+		rr = call([]byte("not gzip"), http.Header{"Content-Encoding": {"gzip"}})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), `"code":-32700`)
+	})
+
+	t.Run("unsupported request encoding", func(t *testing.T) {
+		rr := call(largeRequest, http.Header{"Content-Encoding": {"br"}})
+		require.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		// the http.Client asks for and decompresses gzip responses
+		httpServer := httptest.NewServer(handler)
+		defer httpServer.Close()
+		var result string
+		require.NoError(t, rpcclient.NewClient(httpServer.URL).CallFor(context.Background(), &result, "repeat", "c", 2000))
+		require.Equal(t, strings.Repeat("c", 2000), result)
+	})
+}
+
+func TestZstdCodec(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"repeat": func(ctx context.Context, s string, n int) (string, error) {
+			return strings.Repeat(s, n), nil
+		},
+	}, JSONRPCHandlerOpts{Compression: []Codec{ZstdCodec, GzipCodec}})
+	require.NoError(t, err)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	decoder, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer decoder.Close()
+
+	// the writers are reused
+	for i := 0; i < 3; i++ {
+		body := encoder.EncodeAll([]byte(`{"jsonrpc":"2.0","id":1,"method":"repeat","params":["z",2000]}`), nil)
+		request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Content-Encoding", "zstd")
+		request.Header.Set("Accept-Encoding", "gzip, zstd")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, request)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "zstd", rr.Header().Get("Content-Encoding"))
+		decompressed, err := decoder.DecodeAll(rr.Body.Bytes(), nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":"`+strings.Repeat("z", 2000)+`"}`, string(decompressed))
+	}
+
+	request, err := http.NewRequest(http.MethodPost, "/", strings.NewReader("not zstd"))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "zstd")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, request)
+	require.Contains(t, rr.Body.String(), `"code":-32700`)
+}
+
+func TestCompressionBodyLimit(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"echo": func(ctx context.Context, s string) (string, error) { return s, nil },
+	}, JSONRPCHandlerOpts{Compression: []Codec{GzipCodec}, MaxRequestBodySizeBytes: 1024})
+	require.NoError(t, err)
+
+	// small when compressed, too big when decompressed
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":["` + strings.Repeat("a", 4096) + `"]}`))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.Less(t, compressed.Len(), 1024)
+
+	request, err := http.NewRequest(http.MethodPost, "/", &compressed)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, request)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"request body is too big, max size: 1024"}}`, rr.Body.String())
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	require.Equal(t, map[string]float64{"gzip": 1, "zstd": 0.5, "br": 0}, parseAcceptEncoding("gzip, ZSTD;q=0.5 ,br;q=0"))
+	require.Empty(t, parseAcceptEncoding(""))
+}
@@ -0,0 +1,38 @@
+package rpcserver
+
+const errTooManyConcurrentRequests = "too many concurrent requests"
+
+// acquire reserves a slot for a method call, it returns false if MaxConcurrentRequests is reached
+func (h *JSONRPCHandler) acquire() bool {
+	if h.inFlight != nil {
+		select {
+		case h.inFlight <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	setInFlight(h.ServerName, h.inFlightCount.Add(1))
+	return true
+}
+
+// release frees the slot of a finished method call
+func (h *JSONRPCHandler) release() {
+	setInFlight(h.ServerName, h.inFlightCount.Add(-1))
+	if h.inFlight != nil {
+		<-h.inFlight
+	}
+}
+
+// goLimited runs fn in a new goroutine holding a slot, e.g. for the background requests of the fallback. It returns
+// false if no slot is free, fn not being run.
+func (h *JSONRPCHandler) goLimited(fn func()) bool {
+	if !h.acquire() {
+		incConcurrencyLimited(fallbackMethodLabel, h.ServerName)
+		return false
+	}
+	go func() {
+		defer h.release()
+		fn()
+	}()
+	return true
+}
@@ -0,0 +1,61 @@
+package rpcserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/retry"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	handler, err := NewJSONRPCHandler(Methods{
+		"block": func(ctx context.Context) error {
+			started <- struct{}{}
+			<-unblock
+			return nil
+		},
+		"fast": func(ctx context.Context) (int, error) {
+			return 1, nil
+		},
+	}, JSONRPCHandlerOpts{MaxConcurrentRequests: 1})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := rpcclient.NewClient(httpServer.URL).Call(context.Background(), "block")
+		done <- err
+	}()
+	<-started
+
+	res, err := http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"fast","params":[]}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	// rpcclient retries it until the slot is free
+	retried := make(chan int64)
+	go func() {
+		client := rpcclient.NewClientWithOpts(httpServer.URL, &rpcclient.RPCClientOpts{
+			RetryOptions: []retry.Option{retry.WithMaxAttempts(0), retry.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+				if attempt == 1 {
+					close(unblock)
+				}
+			})},
+		})
+		var result int64
+		require.NoError(t, client.CallFor(context.Background(), &result, "fast"))
+		retried <- result
+	}()
+	require.Equal(t, int64(1), <-retried)
+	require.NoError(t, <-done)
+}
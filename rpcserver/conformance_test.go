@@ -0,0 +1,11 @@
+package rpcserver_test
+
+import (
+	"testing"
+
+	"github.com/flashbots/go-utils/rpcserver/rpctest"
+)
+
+func TestConformance(t *testing.T) {
+	rpctest.Run(t, rpctest.Config{Notifications: true})
+}
@@ -0,0 +1,186 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/cache"
+	"github.com/flashbots/go-utils/rpctypes"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDedupTTL  = time.Minute
+	defaultDedupSize = 100_000
+)
+
+var (
+	ErrDedupNoMethods          = errors.New("deduplicated methods must be set")
+	ErrDedupMethodWithoutOrder = errors.New("deduplicated method must have an rpctypes.Order as first argument")
+	ErrDedupCancellationMethod = errors.New("cancellation methods can't be deduplicated")
+)
+
+var (
+	orderType        = reflect.TypeOf((*rpctypes.Order)(nil)).Elem()
+	cancelBundleType = reflect.TypeOf(rpctypes.EthCancelBundleArgs{})
+)
+
+// Dedup responds to the repeated submissions of an order (e.g. rpctypes.EthSendBundleArgs, MevSendBundleArgs or
+// EthSendRawTransactionArgs) with the response of the first one, without calling the method again. The orders are
+// identified by their method, signer and UniqueKey, only the successful responses are cached.
+//
+// The cancellations (eth_cancelBundle, mev_sendBundle without body) are never deduplicated, they remove the cached
+// responses of the orders with the same signer and replacement UUID, so that a cancelled order can be sent again.
+type Dedup struct {
+	// TTL of the cached responses, 1 minute by default
+	TTL time.Duration
+	// Size is the maximum number of cached responses, 100k by default
+	Size int
+	// Methods deduplicated, their first argument (after the context) must be an rpctypes.Order. Required.
+	Methods []string
+}
+
+type dedupKey struct {
+	method string
+	signer common.Address
+	key    uuid.UUID
+}
+
+type replacementKey struct {
+	signer          common.Address
+	replacementUUID string
+}
+
+type deduplicator struct {
+	methods map[string]bool
+	cache   *cache.Cache[dedupKey, json.RawMessage]
+
+	// keys of the cached orders by replacement UUID, removed on cancellation
+	replacementsMu sync.Mutex
+	replacements   *cache.Cache[replacementKey, []dedupKey]
+}
+
+func newDeduplicator(opts Dedup, methods map[string]methodHandler, serverName string) (*deduplicator, error) {
+	if len(opts.Methods) == 0 {
+		return nil, ErrDedupNoMethods
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultDedupTTL
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultDedupSize
+	}
+
+	d := &deduplicator{
+		methods: make(map[string]bool),
+		cache:   cache.New[dedupKey, json.RawMessage](cache.Config{Name: "rpcserver_dedup_" + serverName, Size: opts.Size, TTL: opts.TTL}),
+		replacements: cache.New[replacementKey, []dedupKey](cache.Config{
+			Name: "rpcserver_dedup_replacements_" + serverName, Size: opts.Size, TTL: opts.TTL,
+		}),
+	}
+	for _, name := range opts.Methods {
+		method, ok := methods[name]
+		if !ok || !hasOrderArg(method) {
+			return nil, fmt.Errorf("%w: %s", ErrDedupMethodWithoutOrder, name)
+		}
+		if arg := method.in[1]; arg == cancelBundleType || arg == reflect.PointerTo(cancelBundleType) {
+			return nil, fmt.Errorf("%w: %s", ErrDedupCancellationMethod, name)
+		}
+		d.methods[name] = true
+	}
+	return d, nil
+}
+
+func hasOrderArg(method methodHandler) bool {
+	if len(method.in) < 2 {
+		return false
+	}
+	arg := method.in[1]
+	return arg.Implements(orderType) || reflect.PointerTo(arg).Implements(orderType)
+}
+
+// orderArg returns the order of the first argument, nil if it isn't an order
+func orderArg(args []reflect.Value) rpctypes.Order {
+	if len(args) == 0 {
+		return nil
+	}
+	arg := args[0]
+	if arg.Kind() != reflect.Pointer {
+		if !arg.CanAddr() {
+			return nil
+		}
+		// the decoded arguments are addressable
+		arg = arg.Addr()
+	}
+	if arg.IsNil() {
+		return nil
+	}
+	order, _ := arg.Interface().(rpctypes.Order)
+	return order
+}
+
+// replacementUUID returns the replacement UUID of the order, and if the order is a cancellation
+func replacementUUID(order rpctypes.Order) (string, bool) {
+	switch o := order.(type) {
+	case *rpctypes.EthCancelBundleArgs:
+		return o.ReplacementUUID, true
+	case *rpctypes.MevSendBundleArgs:
+		cancelled := len(o.Body) == 0 || (o.Metadata != nil && o.Metadata.Cancelled != nil && *o.Metadata.Cancelled)
+		return o.ReplacementUUID, cancelled
+	case *rpctypes.EthSendBundleArgs:
+		if o.ReplacementUUID != nil {
+			return *o.ReplacementUUID, false
+		}
+	}
+	return "", false
+}
+
+// key returns the key of the order of the request, false if the request isn't deduplicated. The cancellations remove
+// the cached orders they replace.
+func (d *deduplicator) key(method string, signer common.Address, args []reflect.Value) (dedupKey, bool) {
+	order := orderArg(args)
+	if order == nil {
+		return dedupKey{}, false
+	}
+	if id, cancel := replacementUUID(order); cancel {
+		d.cancel(replacementKey{signer: signer, replacementUUID: id})
+		return dedupKey{}, false
+	}
+	if !d.methods[method] {
+		return dedupKey{}, false
+	}
+	return dedupKey{method: method, signer: signer, key: order.UniqueKey()}, true
+}
+
+// set caches the response of the order
+func (d *deduplicator) set(key dedupKey, args []reflect.Value, response json.RawMessage) {
+	d.cache.Set(key, response)
+	id, _ := replacementUUID(orderArg(args))
+	if id == "" {
+		return
+	}
+	replacement := replacementKey{signer: key.signer, replacementUUID: id}
+	d.replacementsMu.Lock()
+	defer d.replacementsMu.Unlock()
+	keys, _ := d.replacements.Get(replacement)
+	d.replacements.Set(replacement, append(keys, key))
+}
+
+// cancel removes the cached orders with the replacement UUID
+func (d *deduplicator) cancel(replacement replacementKey) {
+	if replacement.replacementUUID == "" {
+		return
+	}
+	d.replacementsMu.Lock()
+	defer d.replacementsMu.Unlock()
+	keys, _ := d.replacements.Get(replacement)
+	for _, key := range keys {
+		d.cache.Delete(key)
+	}
+	d.replacements.Delete(replacement)
+}
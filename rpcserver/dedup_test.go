@@ -0,0 +1,122 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/rpctypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	txCalls, bundleCalls := 0, 0
+	handler, err := NewJSONRPCHandler(Methods{
+		"eth_sendRawTransaction": func(ctx context.Context, tx rpctypes.EthSendRawTransactionArgs) (int, error) {
+			txCalls++
+			if len(tx) == 0 {
+				return 0, errors.New("empty transaction")
+			}
+			return txCalls, nil
+		},
+		"eth_sendBundle": func(ctx context.Context, bundle *rpctypes.EthSendBundleArgs) (int, error) {
+			bundleCalls++
+			return bundleCalls, nil
+		},
+	}, JSONRPCHandlerOpts{Dedup: &Dedup{Methods: []string{"eth_sendRawTransaction", "eth_sendBundle"}}})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := rpcclient.NewClient(server.URL)
+
+	call := func(method string, params ...any) (int, error) {
+		var result int
+		err := client.CallFor(context.Background(), &result, method, params...)
+		return result, err
+	}
+
+	// the repeated transaction gets the response of the first submission
+	for i := 0; i < 2; i++ {
+		result, err := call("eth_sendRawTransaction", hexutil.Bytes{1})
+		require.NoError(t, err)
+		require.Equal(t, 1, result)
+	}
+	result, err := call("eth_sendRawTransaction", hexutil.Bytes{2})
+	require.NoError(t, err)
+	require.Equal(t, 2, result)
+
+	// the errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err = call("eth_sendRawTransaction", hexutil.Bytes{})
+		require.Error(t, err)
+	}
+	require.Equal(t, 4, txCalls)
+
+	bundle := rpctypes.EthSendBundleArgs{Txs: []hexutil.Bytes{{1}}, BlockNumber: 1}
+	for i := 0; i < 2; i++ {
+		result, err = call("eth_sendBundle", bundle)
+		require.NoError(t, err)
+		require.Equal(t, 1, result)
+	}
+	bundle.BlockNumber = 2
+	result, err = call("eth_sendBundle", bundle)
+	require.NoError(t, err)
+	require.Equal(t, 2, result)
+
+	_, err = NewJSONRPCHandler(Methods{
+		"add": func(ctx context.Context, a, b int) (int, error) { return a + b, nil },
+	}, JSONRPCHandlerOpts{Dedup: &Dedup{Methods: []string{"add"}}})
+	require.ErrorIs(t, err, ErrDedupMethodWithoutOrder)
+
+	_, err = NewJSONRPCHandler(Methods{
+		"eth_sendBundle": func(ctx context.Context, bundle rpctypes.EthSendBundleArgs) error { return nil },
+	}, JSONRPCHandlerOpts{Dedup: &Dedup{}})
+	require.ErrorIs(t, err, ErrDedupNoMethods)
+
+	_, err = NewJSONRPCHandler(Methods{
+		"eth_cancelBundle": func(ctx context.Context, cancel rpctypes.EthCancelBundleArgs) error { return nil },
+	}, JSONRPCHandlerOpts{Dedup: &Dedup{Methods: []string{"eth_cancelBundle"}}})
+	require.ErrorIs(t, err, ErrDedupCancellationMethod)
+}
+
+func TestDedupCancellation(t *testing.T) {
+	sendCalls, cancelCalls := 0, 0
+	handler, err := NewJSONRPCHandler(Methods{
+		"eth_sendBundle": func(ctx context.Context, bundle rpctypes.EthSendBundleArgs) (int, error) {
+			sendCalls++
+			return sendCalls, nil
+		},
+		"eth_cancelBundle": func(ctx context.Context, cancel rpctypes.EthCancelBundleArgs) (int, error) {
+			cancelCalls++
+			return cancelCalls, nil
+		},
+	}, JSONRPCHandlerOpts{Dedup: &Dedup{Methods: []string{"eth_sendBundle"}}})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := rpcclient.NewClient(server.URL)
+
+	call := func(method string, param any) int {
+		var result int
+		require.NoError(t, client.CallFor(context.Background(), &result, method, param))
+		return result
+	}
+
+	replacementUUID := "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+	bundle := rpctypes.EthSendBundleArgs{Txs: []hexutil.Bytes{{1}}, BlockNumber: 1, ReplacementUUID: &replacementUUID}
+	cancel := rpctypes.EthCancelBundleArgs{ReplacementUUID: replacementUUID}
+
+	require.Equal(t, 1, call("eth_sendBundle", bundle))
+	require.Equal(t, 1, call("eth_sendBundle", bundle))
+	require.Equal(t, 1, call("eth_cancelBundle", cancel))
+	// the resent bundle isn't responded from the cache after the cancellation
+	require.Equal(t, 2, call("eth_sendBundle", bundle))
+	require.Equal(t, 2, call("eth_sendBundle", bundle))
+	// the cancellations are never deduplicated
+	require.Equal(t, 2, call("eth_cancelBundle", cancel))
+	require.Equal(t, 3, call("eth_sendBundle", bundle))
+	require.Equal(t, 3, sendCalls)
+}
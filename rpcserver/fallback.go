@@ -0,0 +1,109 @@
+package rpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+)
+
+const (
+	// metrics label of the forwarded methods, not registered in the handler
+	fallbackMethodLabel = "fallback"
+
+	defaultFallbackTimeout = 10 * time.Second
+)
+
+var ErrFallbackWithoutUpstreams = errors.New("fallback has no upstreams")
+
+// Fallback forwards the requests of the methods that are not registered to upstreams, e.g. the methods of an
+// orderflow proxy served by the builders. The handler options of all methods (allowed signers, rate limit, ...)
+// apply to the forwarded requests.
+type Fallback struct {
+	// Upstreams receive the requests, the response of the first one is relayed to the client while the others are
+	// sent in the background
+	Upstreams []string
+	// Signer re-signs the forwarded requests in the X-Flashbots-Signature header, if not nil
+	Signer *signature.Signer
+	// Timeout of the upstream requests, 10 seconds by default
+	Timeout time.Duration
+	// HTTPClient of the upstream requests
+	HTTPClient *http.Client
+}
+
+type fallback struct {
+	timeout   time.Duration
+	upstreams []rpcclient.Upstream
+	log       *slog.Logger
+}
+
+func newFallback(opts Fallback, log *slog.Logger) (*fallback, error) {
+	if len(opts.Upstreams) == 0 {
+		return nil, ErrFallbackWithoutUpstreams
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultFallbackTimeout
+	}
+
+	upstreams, err := rpcclient.NewUpstreams(opts.Upstreams, &rpcclient.RPCClientOpts{
+		HTTPClient:         opts.HTTPClient,
+		Signer:             opts.Signer,
+		AllowUnknownFields: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fallback: %w", err)
+	}
+	return &fallback{timeout: opts.Timeout, upstreams: upstreams, log: log}, nil
+}
+
+// forward sends the request to the upstreams and returns the response of the first one, the background requests to
+// the others are run by spawn
+func (f *fallback) forward(ctx context.Context, req *jsonRPCRequest, serverName string, spawn func(fn func()) bool) handledRequest {
+	rpcReq := &rpcclient.RPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  req.Method,
+	}
+	if len(req.Params) > 0 {
+		rpcReq.Params = req.Params
+	}
+
+	rpcRes, err := rpcclient.Forward(ctx, f.upstreams, rpcReq, rpcclient.ForwardOpts{
+		Timeout: f.timeout,
+		Go:      spawn,
+		OnError: func(u rpcclient.Upstream, err error) {
+			incFallbackErrors(u.Host, serverName)
+			if f.log != nil {
+				f.log.Error("fallback upstream request failed", slog.String("upstream", u.Host), slog.String("method", req.Method),
+					slog.Any("error", err), slog.String("serverName", serverName))
+			}
+		},
+	})
+	if err != nil && (rpcRes == nil || rpcRes.Error == nil) {
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInternalError, "upstream request failed"), method: fallbackMethodLabel}
+	}
+	if rpcRes.Error != nil {
+		res := newJSONRPCError(req.ID, rpcRes.Error.Code, rpcRes.Error.Message)
+		if rpcRes.Error.Data != nil {
+			res.Error.Data = &rpcRes.Error.Data
+		}
+		return handledRequest{response: res, method: fallbackMethodLabel}
+	}
+
+	result, err := json.Marshal(rpcRes.Result)
+	if err != nil {
+		incInternalErrors(serverName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInternalError, err.Error()), method: fallbackMethodLabel}
+	}
+	rawResult := json.RawMessage(result)
+	return handledRequest{
+		response: jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: &rawResult},
+		method:   fallbackMethodLabel,
+	}
+}
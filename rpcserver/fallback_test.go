@@ -0,0 +1,104 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallback(t *testing.T) {
+	upstreamHandler, err := NewJSONRPCHandler(Methods{
+		"upstream_signer": func(ctx context.Context, value int) (common.Address, error) { return GetSigner(ctx), nil },
+		"upstream_fail":   func(ctx context.Context) (int, error) { return 0, errors.New("upstream error") },
+	}, JSONRPCHandlerOpts{VerifyRequestSignatureFromHeader: true})
+	require.NoError(t, err)
+	upstream := httptest.NewServer(upstreamHandler)
+	defer upstream.Close()
+
+	proxySigner, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+	handler, err := NewJSONRPCHandler(Methods{
+		"local": func(ctx context.Context) (int, error) { return 1, nil },
+	}, JSONRPCHandlerOpts{
+		Fallback: &Fallback{Upstreams: []string{upstream.URL}, Signer: proxySigner},
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := rpcclient.NewClient(server.URL)
+
+	var local int
+	require.NoError(t, client.CallFor(context.Background(), &local, "local"))
+	require.Equal(t, 1, local)
+
+	// forwarded with the params, re-signed by the proxy
+	var signer common.Address
+	require.NoError(t, client.CallFor(context.Background(), &signer, "upstream_signer", 1))
+	require.Equal(t, proxySigner.Address(), signer)
+
+	// the upstream errors are relayed
+	resp, err := client.Call(context.Background(), "upstream_fail")
+	require.NoError(t, err)
+	require.Equal(t, &rpcclient.RPCError{Code: CodeCustomError, Message: "upstream error"}, resp.Error)
+	resp, err = client.Call(context.Background(), "upstream_unknown")
+	require.NoError(t, err)
+	require.Equal(t, CodeMethodNotFound, resp.Error.Code)
+
+	upstream.Close()
+	resp, err = client.Call(context.Background(), "upstream_signer", 1)
+	require.NoError(t, err)
+	require.Equal(t, &rpcclient.RPCError{Code: CodeInternalError, Message: "upstream request failed"}, resp.Error)
+
+	_, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{Fallback: &Fallback{}})
+	require.ErrorIs(t, err, ErrFallbackWithoutUpstreams)
+}
+
+func TestFallbackConcurrencyLimit(t *testing.T) {
+	fastHandler, err := NewJSONRPCHandler(Methods{
+		"upstream_method": func(ctx context.Context) (int, error) { return 1, nil },
+	}, JSONRPCHandlerOpts{})
+	require.NoError(t, err)
+	fast := httptest.NewServer(fastHandler)
+	defer fast.Close()
+
+	var slowCalls atomic.Int32
+	unblock := make(chan struct{})
+	slowHandler, err := NewJSONRPCHandler(Methods{
+		"upstream_method": func(ctx context.Context) (int, error) {
+			slowCalls.Add(1)
+			<-unblock
+			return 1, nil
+		},
+	}, JSONRPCHandlerOpts{})
+	require.NoError(t, err)
+	slow := httptest.NewServer(slowHandler)
+	defer slow.Close()
+	defer close(unblock)
+
+	handler, err := NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{
+		MaxConcurrentRequests: 2,
+		Fallback:              &Fallback{Upstreams: []string{fast.URL, slow.URL, slow.URL}},
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := rpcclient.NewClient(server.URL)
+
+	// the request holds a slot, only one of the background requests gets the other one
+	var res int
+	require.NoError(t, client.CallFor(context.Background(), &res, "upstream_method"))
+	require.Equal(t, 1, res)
+	require.Eventually(t, func() bool { return slowCalls.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// the blocked background request still holds its slot
+	require.NoError(t, client.CallFor(context.Background(), &res, "upstream_method"))
+	require.Equal(t, int32(1), slowCalls.Load())
+}
@@ -9,29 +9,42 @@ package rpcserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"reflect"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/logutils"
 	"github.com/flashbots/go-utils/signature"
+	"github.com/gorilla/websocket"
 )
 
 var (
+	ErrMethodOptsForUnknownMethod = errors.New("method options for unknown method")
+
 	// this are the only errors that are returned as http errors with http error codes
 	errMethodNotAllowed = "only POST method is allowed"
 	errWrongContentType = "header Content-Type must be application/json"
 	errMarshalResponse  = "failed to marshal response"
 
+	// message of the responses of the methods that panicked
+	errInternal = "internal error"
+
 	CodeParseError     = -32700
 	CodeInvalidRequest = -32600
 	CodeMethodNotFound = -32601
 	CodeInvalidParams  = -32602
 	CodeInternalError  = -32603
 	CodeCustomError    = -32000
+	CodeLimitExceeded  = -32005
 
 	DefaultMaxRequestBodySizeBytes = 30 * 1024 * 1024 // 30mb
 )
@@ -47,10 +60,12 @@ type (
 )
 
 type jsonRPCRequest struct {
-	JSONRPC string            `json:"jsonrpc"`
-	ID      any               `json:"id"`
-	Method  string            `json:"method"`
-	Params  []json.RawMessage `json:"params"`
+	JSONRPC string `json:"jsonrpc"`
+	// RawID is nil if the id is omitted (notifications), ID is the decoded id
+	RawID  json.RawMessage `json:"id"`
+	ID     any             `json:"-"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
 }
 
 type jsonRPCResponse struct {
@@ -66,13 +81,53 @@ type jsonRPCError struct {
 	Data    *any   `json:"data,omitempty"`
 }
 
+func (e *jsonRPCError) Error() string {
+	return e.Message
+}
+
 type JSONRPCHandler struct {
 	JSONRPCHandlerOpts
 	methods map[string]methodHandler
+
+	allowedSigners       signerSet
+	methodAllowedSigners map[string]signerSet
+
+	// replay is set if ReplayProtection is
+	replay *replayGuard
+	// fallback is set if Fallback is
+	fallback *fallback
+	// dedup is set if Dedup is
+	dedup *deduplicator
+
+	// open websocket connections, closed by Server on shutdown
+	wsConnsMu sync.Mutex
+	wsConns   map[*wsConn]struct{}
+
+	// inFlight limits the concurrent method calls if MaxConcurrentRequests is set
+	inFlight      chan struct{}
+	inFlightCount atomic.Int64
+
+	subscriptions       map[string]methodHandler
+	subscribeMethod     string
+	unsubscribeMethod   string
+	notificationMethod  string
+	activeSubscriptions atomic.Int64
 }
 
 type Methods map[string]any
 
+// MethodOpts are the options of a single method, or of the subscription methods (e.g. eth_subscribe).
+type MethodOpts struct {
+	// RateLimit of the method, replaces JSONRPCHandlerOpts.RateLimit for the method
+	RateLimit *RateLimit
+	// AllowedSigners restricts the calls of the method to these signers, replaces JSONRPCHandlerOpts.AllowedSigners
+	// for the method
+	AllowedSigners []common.Address
+	// ParamNames are the names of the arguments (after the context), to accept named params: {"params": {...}}.
+	// Without names, named params are only accepted by methods with a single argument, decoded from the object.
+	ParamNames []string
+}
+
 type JSONRPCHandlerOpts struct {
 	// Logger, can be nil
 	Log *slog.Logger
@@ -94,6 +149,59 @@ type JSONRPCHandlerOpts struct {
 	ExtractOriginFromHeader bool
 	// GET response content
 	GetResponseContent []byte
+	// If true the methods are also served over websocket, to GET requests upgrading the connection. The headers of
+	// the upgrade request apply to all the requests of the connection (origin, priority, unverified signer), request
+	// signatures can't be verified and the upgrade is rejected if VerifyRequestSignatureFromHeader is set.
+	EnableWebSocket bool
+	// Checks the Origin header of the websocket upgrade requests, by default the origin host must be the request host
+	// (or the header not set)
+	WebSocketCheckOrigin func(r *http.Request) bool
+	// Subscriptions served over websocket with <namespace>_subscribe and <namespace>_unsubscribe, like eth_subscribe.
+	// The first param of subscribe is the name of the subscription in this map, see Notifier.
+	Subscriptions Subscriptions
+	// Namespace of the subscription methods, "eth" by default
+	SubscriptionNamespace string
+	// If set, replayed signed requests are rejected, needs VerifyRequestSignatureFromHeader
+	ReplayProtection *ReplayProtection
+	// If set, the repeated submissions of an order get the cached response of the first one
+	Dedup *Dedup
+	// If set, the requests of unknown methods are forwarded to the upstreams of the fallback
+	Fallback *Fallback
+	// AllowedSigners restricts the calls to these signers (from the X-Flashbots-Signature header), the requests of
+	// other signers are rejected with CodeInvalidRequest. Not restricted if nil. Needs
+	// VerifyRequestSignatureFromHeader (or ExtractUnverifiedRequestSignatureFromHeader if the signature was verified
+	// before). See also MethodOpts.AllowedSigners.
+	AllowedSigners []common.Address
+	// Rate limit of the methods without their own in MethodOpts, can be nil
+	RateLimit *RateLimit
+	// Options of the methods by name
+	MethodOpts map[string]MethodOpts
+	// Codecs of the request and response bodies, in order of preference (e.g. GzipCodec), no compression if empty.
+	// Requests are decompressed according to their Content-Encoding (415 Unsupported Media Type if not supported)
+	// and responses compressed according to the Accept-Encoding header.
+	Compression []Codec
+	// Responses smaller than this are not compressed, 1024 by default
+	CompressionMinSizeBytes int
+	// StartSpan starts a span for each request to trace them, e.g. with OpenTelemetry (see Span). The span is a child
+	// of the trace context propagated in the header, the returned context carrying the span is passed to the method.
+	// The traceparent header is also available with httplogger.TraceContextFromContext. Not traced if nil.
+	StartSpan func(ctx context.Context, name string, header http.Header) (context.Context, Span)
+	// If true each call is logged with Log: method, duration, signer, origin, error code and error. Successful calls
+	// are logged at Info level, failed ones at Warn level.
+	LogRequests bool
+	// LogSampleRate is the fraction (between 0 and 1) of successful calls that are logged, failed calls are always
+	// logged. 0 disables sampling, i.e. every call is logged.
+	LogSampleRate float64
+	// LogParams adds the params of the calls to the logs, redacted by LogRedactor if set (e.g.
+	// logutils.DefaultRedactor()) and truncated to 4kb
+	LogParams   bool
+	LogRedactor *logutils.Redactor
+	// MaxConcurrentRequests bounds the concurrent method calls. Requests above it are rejected with
+	// CodeLimitExceeded, over HTTP with the 429 Too Many Requests status. Not bounded if 0.
+	MaxConcurrentRequests int
+	// If true the notifications (requests without id) are handled in the background, after responding with 204 No
+	// Content, with a context that is not canceled when the request ends. Otherwise they are handled before.
+	AsyncNotifications bool
 }
 
 // NewJSONRPCHandler creates JSONRPC http.Handler from the map that maps method names to method functions
@@ -107,6 +215,13 @@ func NewJSONRPCHandler(methods Methods, opts JSONRPCHandlerOpts) (*JSONRPCHandle
 		opts.MaxRequestBodySizeBytes = int64(DefaultMaxRequestBodySizeBytes)
 	}
 
+	if opts.CompressionMinSizeBytes == 0 {
+		opts.CompressionMinSizeBytes = defaultCompressionMinSizeBytes
+	}
+	if opts.SubscriptionNamespace == "" {
+		opts.SubscriptionNamespace = "eth"
+	}
+
 	m := make(map[string]methodHandler)
 	for name, fn := range methods {
 		method, err := getMethodTypes(fn)
@@ -115,15 +230,88 @@ func NewJSONRPCHandler(methods Methods, opts JSONRPCHandlerOpts) (*JSONRPCHandle
 		}
 		m[name] = method
 	}
+	if opts.ReplayProtection != nil && !opts.VerifyRequestSignatureFromHeader {
+		return nil, ErrReplayProtectionWithoutSignature
+	}
+	hasSigner := opts.VerifyRequestSignatureFromHeader || opts.ExtractUnverifiedRequestSignatureFromHeader
+	if opts.AllowedSigners != nil && !hasSigner {
+		return nil, ErrAllowedSignersWithoutSignature
+	}
+	methodAllowedSigners := make(map[string]signerSet)
+	for name, methodOpts := range opts.MethodOpts {
+		method, ok := m[name]
+		// the subscription methods only take AllowedSigners and RateLimit
+		subscription := len(opts.Subscriptions) > 0 &&
+			(name == opts.SubscriptionNamespace+"_subscribe" || name == opts.SubscriptionNamespace+"_unsubscribe")
+		if !ok && !subscription {
+			return nil, fmt.Errorf("%w: %s", ErrMethodOptsForUnknownMethod, name)
+		}
+		if methodOpts.ParamNames != nil && (!ok || len(methodOpts.ParamNames) != len(method.in)-1) {
+			return nil, fmt.Errorf("%w: %s", ErrParamNamesMismatch, name)
+		}
+		if methodOpts.AllowedSigners != nil {
+			if !hasSigner {
+				return nil, fmt.Errorf("%w: %s", ErrAllowedSignersWithoutSignature, name)
+			}
+			methodAllowedSigners[name] = newSignerSet(methodOpts.AllowedSigners)
+		}
+	}
+	subs := make(map[string]methodHandler)
+	for name, fn := range opts.Subscriptions {
+		sub, err := getSubscriptionTypes(fn)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", name, err)
+		}
+		subs[name] = sub
+	}
+	var inFlight chan struct{}
+	if opts.MaxConcurrentRequests > 0 {
+		inFlight = make(chan struct{}, opts.MaxConcurrentRequests)
+	}
+	var replay *replayGuard
+	if opts.ReplayProtection != nil {
+		replay = newReplayGuard(*opts.ReplayProtection, opts.ServerName)
+	}
+	var fb *fallback
+	if opts.Fallback != nil {
+		var err error
+		if fb, err = newFallback(*opts.Fallback, opts.Log); err != nil {
+			return nil, err
+		}
+	}
+	var dedup *deduplicator
+	if opts.Dedup != nil {
+		var err error
+		if dedup, err = newDeduplicator(*opts.Dedup, m, opts.ServerName); err != nil {
+			return nil, err
+		}
+	}
 	return &JSONRPCHandler{
-		JSONRPCHandlerOpts: opts,
-		methods:            m,
+		JSONRPCHandlerOpts:   opts,
+		replay:               replay,
+		fallback:             fb,
+		dedup:                dedup,
+		wsConns:              make(map[*wsConn]struct{}),
+		inFlight:             inFlight,
+		allowedSigners:       newSignerSet(opts.AllowedSigners),
+		methodAllowedSigners: methodAllowedSigners,
+		methods:              m,
+		subscriptions:        subs,
+		subscribeMethod:      opts.SubscriptionNamespace + "_subscribe",
+		unsubscribeMethod:    opts.SubscriptionNamespace + "_unsubscribe",
+		notificationMethod:   opts.SubscriptionNamespace + "_subscription",
 	}, nil
 }
 
-func (h *JSONRPCHandler) writeJSONRPCResponse(w http.ResponseWriter, response jsonRPCResponse) {
+// writeJSONRPCResponse writes the response with the HTTP status, compressed with the codec if it's not nil
+func (h *JSONRPCHandler) writeJSONRPCResponse(w http.ResponseWriter, codec Codec, status int, response jsonRPCResponse) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if len(h.Compression) > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
 		if h.Log != nil {
 			h.Log.Error("failed to marshall response", slog.Any("error", err), slog.String("serverName", h.ServerName))
 		}
@@ -131,10 +319,33 @@ func (h *JSONRPCHandler) writeJSONRPCResponse(w http.ResponseWriter, response js
 		incInternalErrors(h.ServerName)
 		return
 	}
+	body = append(body, '\n')
+
+	if codec == nil || len(body) < h.CompressionMinSizeBytes {
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Encoding", codec.Encoding())
+	w.WriteHeader(status)
+	cw, err := codec.NewWriter(w)
+	if err == nil {
+		_, err = cw.Write(body)
+		if closeErr := cw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && h.Log != nil {
+		h.Log.Debug("failed to write compressed response", slog.Any("error", err), slog.String("serverName", h.ServerName))
+	}
+}
+
+func (h *JSONRPCHandler) writeJSONRPCError(w http.ResponseWriter, codec Codec, id any, code int, msg string) {
+	h.writeJSONRPCResponse(w, codec, http.StatusOK, newJSONRPCError(id, code, msg))
 }
 
-func (h *JSONRPCHandler) writeJSONRPCError(w http.ResponseWriter, id any, code int, msg string) {
-	res := jsonRPCResponse{
+func newJSONRPCError(id any, code int, msg string) jsonRPCResponse {
+	return jsonRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result:  nil,
@@ -144,15 +355,17 @@ func (h *JSONRPCHandler) writeJSONRPCError(w http.ResponseWriter, id any, code i
 			Data:    nil,
 		},
 	}
-	h.writeJSONRPCResponse(w, res)
 }
 
 func (h *JSONRPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.EnableWebSocket && websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
+
 	startAt := time.Now()
 	methodForMetrics := unknownMethodLabel
 
-	ctx := r.Context()
-
 	defer func() {
 		incRequestCount(methodForMetrics, h.ServerName)
 		incRequestDuration(methodForMetrics, time.Since(startAt).Milliseconds(), h.ServerName)
@@ -183,57 +396,160 @@ func (h *JSONRPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	requestCodec, ok := h.requestCodec(r)
+	if !ok && len(h.Compression) > 0 {
+		http.Error(w, errUnsupportedEncoding, http.StatusUnsupportedMediaType)
+		incIncorrectRequest(h.ServerName)
+		return
+	}
+	responseCodec := h.responseCodec(r)
+
 	r.Body = http.MaxBytesReader(w, r.Body, h.MaxRequestBodySizeBytes)
+	if requestCodec != nil {
+		// the limit applies to the decompressed body too
+		decompressed, err := requestCodec.NewReader(r.Body)
+		if err != nil {
+			h.writeJSONRPCError(w, responseCodec, nil, CodeParseError, err.Error())
+			incIncorrectRequest(h.ServerName)
+			return
+		}
+		defer decompressed.Close()
+		r.Body = http.MaxBytesReader(w, decompressed, h.MaxRequestBodySizeBytes)
+	}
+	ctx, span := h.startSpan(r.Context(), r.Header)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		span.RecordError(err)
+		span.End()
+		var maxBytesErr *http.MaxBytesError
+		if requestCodec != nil && !errors.As(err, &maxBytesErr) {
+			h.writeJSONRPCError(w, responseCodec, nil, CodeParseError, err.Error())
+			incIncorrectRequest(h.ServerName)
+			return
+		}
 		msg := fmt.Sprintf("request body is too big, max size: %d", h.MaxRequestBodySizeBytes)
-		h.writeJSONRPCError(w, nil, CodeInvalidRequest, msg)
+		h.writeJSONRPCError(w, responseCodec, nil, CodeInvalidRequest, msg)
 		incIncorrectRequest(h.ServerName)
 		return
 	}
 
-	if h.VerifyRequestSignatureFromHeader {
-		signatureHeader := r.Header.Get("x-flashbots-signature")
-		signer, err := signature.Verify(signatureHeader, body)
+	span.SetAttribute("rpc.jsonrpc.request_size", len(body))
+	span.AddEvent("body read")
+
+	res := h.handleRequest(ctx, r.Header, body, nil)
+	methodForMetrics = res.method
+	defer endSpan(span, res)
+	defer func() { h.logRequest(res, time.Since(startAt)) }()
+	if res.notification {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if res.stream != nil {
+		h.writeJSONRPCStreamResponse(w, responseCodec, res)
+		span.AddEvent("response written")
+		return
+	}
+	status := http.StatusOK
+	if res.concurrencyLimited {
+		// lets HTTP clients retry, e.g. rpcclient with RetryOptions
+		status = http.StatusTooManyRequests
+	}
+	h.writeJSONRPCResponse(w, responseCodec, status, res.response)
+	span.AddEvent("response written")
+}
+
+// handledRequest is the outcome of a request
+type handledRequest struct {
+	response jsonRPCResponse
+	// notification is true if the request has no id, the response must not be sent
+	notification bool
+	// method name for the metrics
+	method string
+	// notifier of the new subscription, to activate once the response is written
+	notifier *Notifier
+	// concurrencyLimited is true if the request was rejected by MaxConcurrentRequests
+	concurrencyLimited bool
+	// stream is set if the result is streamed, the response has no result then
+	stream JSONStreamer
+	// for the request logs
+	signer common.Address
+	origin string
+	params json.RawMessage
+}
+
+// handleRequest handles the body of a request independently of the transport, with the header of the HTTP request
+// (of the upgrade request for websockets, conn being the connection).
+func (h *JSONRPCHandler) handleRequest(ctx context.Context, header http.Header, body []byte, conn *wsConn) handledRequest {
+	span := spanFromContext(ctx)
+	if conn == nil && h.VerifyRequestSignatureFromHeader {
+		var (
+			signer common.Address
+			err    error
+		)
+		if h.replay != nil {
+			signer, err = h.replay.verify(header, body)
+		} else {
+			signatureHeader := header.Get("x-flashbots-signature")
+			signer, err = signature.Verify(signatureHeader, body)
+		}
 		if err != nil {
-			h.writeJSONRPCError(w, nil, CodeInvalidRequest, err.Error())
 			incIncorrectRequest(h.ServerName)
-			return
+			return handledRequest{response: newJSONRPCError(nil, CodeInvalidRequest, err.Error()), method: unknownMethodLabel}
 		}
 		ctx = context.WithValue(ctx, signerKey{}, signer)
+		span.AddEvent("signature verified")
 	}
 
 	// read request
 	var req jsonRPCRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		h.writeJSONRPCError(w, nil, CodeParseError, err.Error())
 		incIncorrectRequest(h.ServerName)
-		return
+		return handledRequest{response: newJSONRPCError(nil, CodeParseError, err.Error()), method: unknownMethodLabel}
+	}
+	if req.RawID != nil {
+		if err := json.Unmarshal(req.RawID, &req.ID); err != nil {
+			incIncorrectRequest(h.ServerName)
+			return handledRequest{response: newJSONRPCError(nil, CodeParseError, err.Error()), method: unknownMethodLabel}
+		}
+	}
+
+	span.SetAttribute("rpc.method", req.Method)
+	span.AddEvent("request parsed")
+
+	// requests without id are notifications, even the errors are not responded
+	notification := req.RawID == nil
+	finish := func(res handledRequest) handledRequest {
+		res.notification = notification
+		res.signer = GetSigner(ctx)
+		res.origin = GetOrigin(ctx)
+		res.params = req.Params
+		return res
+	}
+	errorResponse := func(code int, msg, method string) handledRequest {
+		return finish(handledRequest{response: newJSONRPCError(req.ID, code, msg), method: method})
 	}
 
 	if req.JSONRPC != "2.0" {
-		h.writeJSONRPCError(w, req.ID, CodeParseError, "invalid jsonrpc version")
 		incIncorrectRequest(h.ServerName)
-		return
+		return errorResponse(CodeParseError, "invalid jsonrpc version", unknownMethodLabel)
 	}
 	if req.ID != nil {
 		// id must be string or number
 		switch req.ID.(type) {
 		case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
 		default:
-			h.writeJSONRPCError(w, req.ID, CodeParseError, "invalid id type")
 			incIncorrectRequest(h.ServerName)
-			return
+			return errorResponse(CodeParseError, "invalid id type", unknownMethodLabel)
 		}
 	}
 
 	if h.ExtractPriorityFromHeader {
-		highPriority := r.Header.Get("high_prio") == "true"
+		highPriority := header.Get("high_prio") == "true"
 		ctx = context.WithValue(ctx, highPriorityKey{}, highPriority)
 	}
 
 	if h.ExtractUnverifiedRequestSignatureFromHeader {
-		signature := r.Header.Get("x-flashbots-signature")
+		signature := header.Get("x-flashbots-signature")
 		if split := strings.Split(signature, ":"); len(split) > 0 {
 			signer := common.HexToAddress(split[0])
 			ctx = context.WithValue(ctx, signerKey{}, signer)
@@ -241,52 +557,200 @@ func (h *JSONRPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if h.ExtractOriginFromHeader {
-		origin := r.Header.Get("x-flashbots-origin")
+		origin := header.Get("x-flashbots-origin")
 		if origin != "" {
 			if len(origin) > maxOriginIDLength {
-				h.writeJSONRPCError(w, req.ID, CodeInvalidRequest, "x-flashbots-origin header is too long")
 				incIncorrectRequest(h.ServerName)
-				return
+				return errorResponse(CodeInvalidRequest, "x-flashbots-origin header is too long", unknownMethodLabel)
 			}
 			ctx = context.WithValue(ctx, originKey{}, origin)
 		}
 	}
 
-	// get method
+	if signer := GetSigner(ctx); signer != (common.Address{}) {
+		span.SetAttribute("flashbots.signer", signer.Hex())
+	}
+	if origin := GetOrigin(ctx); origin != "" {
+		span.SetAttribute("flashbots.origin", origin)
+	}
+
+	// get method, the unknown methods are forwarded to the fallback if set
 	method, ok := h.methods[req.Method]
-	if !ok {
-		h.writeJSONRPCError(w, req.ID, CodeMethodNotFound, "method not found")
+	label := req.Method
+	subscription := len(h.subscriptions) > 0 && (req.Method == h.subscribeMethod || req.Method == h.unsubscribeMethod)
+	switch {
+	case subscription:
+		if conn == nil {
+			incIncorrectRequest(h.ServerName)
+			return errorResponse(CodeMethodNotFound, errSubscriptionsWebSocketOnly, unknownMethodLabel)
+		}
+	case !ok:
+		if h.fallback == nil {
+			incIncorrectRequest(h.ServerName)
+			return errorResponse(CodeMethodNotFound, "method not found", unknownMethodLabel)
+		}
+		label = fallbackMethodLabel
+	}
+
+	if !h.signerAllowed(req.Method, GetSigner(ctx)) {
 		incIncorrectRequest(h.ServerName)
-		return
+		return errorResponse(CodeInvalidRequest, errSignerNotAllowed, label)
+	}
+
+	if limit := h.rateLimit(req.Method); limit != nil && !limit.allow(ctx, req.Method) {
+		incRateLimited(label, h.ServerName)
+		return errorResponse(CodeLimitExceeded, "rate limit exceeded", label)
+	}
+
+	if subscription {
+		if !h.acquire() {
+			incConcurrencyLimited(label, h.ServerName)
+			res := errorResponse(CodeLimitExceeded, errTooManyConcurrentRequests, label)
+			res.concurrencyLimited = true
+			return res
+		}
+		defer h.release()
+		if req.Method == h.subscribeMethod {
+			return finish(h.subscribe(ctx, conn, req))
+		}
+		return finish(h.unsubscribe(conn, req))
+	}
+
+	var args []reflect.Value
+	if ok {
+		var err error
+		args, err = method.decodeArgs(req.Params, h.MethodOpts[req.Method].ParamNames)
+		var paramsErr paramsError
+		if errors.As(err, &paramsErr) {
+			incIncorrectRequest(h.ServerName)
+			return errorResponse(CodeInvalidParams, err.Error(), label)
+		} else if err != nil {
+			incRequestErrorCount(label, h.ServerName)
+			return errorResponse(CodeCustomError, err.Error(), label)
+		}
+	}
+
+	var orderKey dedupKey
+	deduplicated := false
+	if ok && h.dedup != nil {
+		if orderKey, deduplicated = h.dedup.key(req.Method, GetSigner(ctx), args); deduplicated {
+			if cached, hit := h.dedup.cache.Get(orderKey); hit {
+				incDeduplicated(req.Method, h.ServerName)
+				return finish(handledRequest{
+					response: jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: &cached},
+					method:   req.Method,
+				})
+			}
+		}
+	}
+
+	if !h.acquire() {
+		incConcurrencyLimited(label, h.ServerName)
+		res := errorResponse(CodeLimitExceeded, errTooManyConcurrentRequests, label)
+		res.concurrencyLimited = true
+		return res
 	}
-	methodForMetrics = req.Method
 
-	// call method
-	result, err := method.call(ctx, req.Params)
+	if !ok {
+		defer h.release()
+		res := h.fallback.forward(ctx, &req, h.ServerName, h.goLimited)
+		span.AddEvent("request forwarded")
+		return finish(res)
+	}
+
+	if notification && h.AsyncNotifications {
+		go func() {
+			defer h.release()
+			// runs outside of the HTTP handler, nothing else recovers its panics
+			defer func() {
+				if r := recover(); r != nil {
+					incRequestErrorCount(req.Method, h.ServerName)
+					h.methodPanicked(req.Method, &methodPanicError{value: r, stack: debug.Stack()})
+				}
+			}()
+			result, err := method.callArgs(detachedContext{ctx}, args)
+			if err != nil {
+				incRequestErrorCount(req.Method, h.ServerName)
+				h.methodPanicked(req.Method, err)
+			} else if stream := asJSONStreamer(result); stream != nil {
+				discardStream(stream)
+			}
+		}()
+		return finish(handledRequest{method: req.Method})
+	}
+
+	// call method, releasing the slot even if it panics
+	result, err := func() (any, error) {
+		defer h.release()
+		return method.callArgs(ctx, args)
+	}()
+	span.AddEvent("method called")
 	if err != nil {
-		h.writeJSONRPCError(w, req.ID, CodeCustomError, err.Error())
-		incRequestErrorCount(methodForMetrics, h.ServerName)
-		return
+		incRequestErrorCount(req.Method, h.ServerName)
+		if h.methodPanicked(req.Method, err) {
+			return errorResponse(CodeInternalError, errInternal, req.Method)
+		}
+		return errorResponse(CodeCustomError, err.Error(), req.Method)
+	}
+
+	if stream := asJSONStreamer(result); stream != nil {
+		if notification {
+			discardStream(stream)
+			return finish(handledRequest{method: req.Method})
+		}
+		return finish(handledRequest{
+			response: jsonRPCResponse{JSONRPC: "2.0", ID: req.ID},
+			stream:   stream,
+			method:   req.Method,
+		})
 	}
 
 	marshaledResult, err := json.Marshal(result)
 	if err != nil {
-		h.writeJSONRPCError(w, req.ID, CodeInternalError, err.Error())
 		incInternalErrors(h.ServerName)
-		return
+		return errorResponse(CodeInternalError, err.Error(), req.Method)
 	}
 
 	// write response
 	rawMessageResult := json.RawMessage(marshaledResult)
-	res := jsonRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result:  &rawMessageResult,
-		Error:   nil,
+	if deduplicated {
+		h.dedup.set(orderKey, args, rawMessageResult)
+	}
+	return finish(handledRequest{
+		response: jsonRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  &rawMessageResult,
+			Error:   nil,
+		},
+		method: req.Method,
+	})
+}
+
+// methodPanicked counts and logs the panic of a method call, it reports whether the error is a panic
+func (h *JSONRPCHandler) methodPanicked(method string, err error) bool {
+	var panicErr *methodPanicError
+	if !errors.As(err, &panicErr) {
+		return false
 	}
-	h.writeJSONRPCResponse(w, res)
+	incInternalErrors(h.ServerName)
+	if h.Log != nil {
+		h.Log.Error("method panicked", slog.String("method", method), slog.Any("panic", panicErr.value),
+			slog.String("stack", string(panicErr.stack)), slog.String("serverName", h.ServerName))
+	}
+	return true
+}
+
+// detachedContext keeps the values of the parent context without its cancellation, for the notifications handled
+// after the response
+type detachedContext struct {
+	context.Context
 }
 
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
 func GetHighPriority(ctx context.Context) bool {
 	value, ok := ctx.Value(highPriorityKey{}).(bool)
 	if !ok {
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/flashbots/go-utils/rpcclient"
 	"github.com/flashbots/go-utils/signature"
 	"github.com/stretchr/testify/require"
@@ -84,6 +87,133 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestHandler_NamedParams(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"struct": func(ctx context.Context, arg dummyStruct) (dummyStruct, error) {
+			return arg, nil
+		},
+		"sub": func(ctx context.Context, a, b int) (int, error) {
+			return a - b, nil
+		},
+	}, JSONRPCHandlerOpts{
+		MethodOpts: map[string]MethodOpts{"sub": {ParamNames: []string{"a", "b"}}},
+	})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		requestBody      string
+		expectedResponse string
+	}{
+		"single struct": {
+			requestBody:      `{"jsonrpc":"2.0","id":1,"method":"struct","params":{"field":1}}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":1,"result":{"field":1}}`,
+		},
+		"named": {
+			requestBody:      `{"jsonrpc":"2.0","id":1,"method":"sub","params":{"b":1,"a":3}}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":1,"result":2}`,
+		},
+		"positional": {
+			requestBody:      `{"jsonrpc":"2.0","id":1,"method":"sub","params":[3,1]}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":1,"result":2}`,
+		},
+		"unknown name": {
+			requestBody:      `{"jsonrpc":"2.0","id":1,"method":"sub","params":{"c":1}}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"unknown param: c"}}`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(testCase.requestBody)))
+			require.NoError(t, err)
+			request.Header.Add("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, request)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.JSONEq(t, testCase.expectedResponse, rr.Body.String())
+		})
+	}
+
+	_, err = NewJSONRPCHandler(Methods{
+		"sub": func(ctx context.Context, a, b int) (int, error) { return a - b, nil },
+	}, JSONRPCHandlerOpts{
+		MethodOpts: map[string]MethodOpts{"sub": {ParamNames: []string{"a"}}},
+	})
+	require.ErrorIs(t, err, ErrParamNamesMismatch)
+}
+
+func TestHandler_Notifications(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		called := make(chan int, 1)
+		handler, err := NewJSONRPCHandler(Methods{
+			"notify": func(ctx context.Context, arg int) error {
+				require.NoError(t, ctx.Err())
+				called <- arg
+				return nil
+			},
+		}, JSONRPCHandlerOpts{AsyncNotifications: async})
+		require.NoError(t, err)
+
+		call := func(body string) *httptest.ResponseRecorder {
+			request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			request.Header.Add("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, request)
+			return rr
+		}
+
+		rr := call(`{"jsonrpc":"2.0","method":"notify","params":[1]}`)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Empty(t, rr.Body.String())
+		require.Equal(t, 1, <-called)
+
+		// errors are not responded either
+		rr = call(`{"jsonrpc":"2.0","method":"not_found","params":[1]}`)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Empty(t, rr.Body.String())
+
+		// null id is not a notification
+		rr = call(`{"jsonrpc":"2.0","id":null,"method":"notify","params":[2]}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":null,"result":null}`, rr.Body.String())
+		require.Equal(t, 2, <-called)
+	}
+}
+
+// chanWriter sends each write to the channel
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestHandler_AsyncNotificationPanic(t *testing.T) {
+	logs := make(chanWriter, 1)
+	handler, err := NewJSONRPCHandler(Methods{
+		"panic": func(ctx context.Context) error { panic("x") },
+	}, JSONRPCHandlerOpts{
+		ServerName:         "async_panic",
+		AsyncNotifications: true,
+		Log:                slog.New(slog.NewTextHandler(logs, nil)),
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc":"2.0","method":"panic","params":[]}`)))
+	require.NoError(t, err)
+	request.Header.Add("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, request)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	// the panic of the detached call is recovered, counted and logged
+	require.Contains(t, <-logs, `msg="method panicked" method=panic panic=x`)
+	require.Equal(t, uint64(1), metrics.GetOrCreateCounter(fmt.Sprintf(internalErrorsCounter, "async_panic")).Get())
+	require.Equal(t, uint64(1), metrics.GetOrCreateCounter(fmt.Sprintf(errorCountLabel, "panic", "async_panic")).Get())
+}
+
 func TestJSONRPCServerWithClient(t *testing.T) {
 	handler := testHandler(JSONRPCHandlerOpts{})
 	httpServer := httptest.NewServer(handler)
@@ -0,0 +1,57 @@
+package rpcserver
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const maxLoggedParamsBytes = 4096
+
+// logRequest logs the call if LogRequests is set, see JSONRPCHandlerOpts.LogRequests
+func (h *JSONRPCHandler) logRequest(res handledRequest, duration time.Duration) {
+	if !h.LogRequests || h.Log == nil {
+		return
+	}
+	failed := res.response.Error != nil
+	if !failed && h.LogSampleRate > 0 && h.LogSampleRate < 1 && rand.Float64() >= h.LogSampleRate { //nolint:gosec
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("serverName", h.ServerName),
+		slog.String("method", res.method),
+		slog.Duration("duration", duration),
+		slog.Bool("notification", res.notification),
+	}
+	if res.signer != (common.Address{}) {
+		attrs = append(attrs, slog.String("signer", res.signer.Hex()))
+	}
+	if res.origin != "" {
+		attrs = append(attrs, slog.String("origin", res.origin))
+	}
+	if h.LogParams && len(res.params) > 0 {
+		params := string(res.params)
+		// redacted before truncating, not to leave a part of a secret
+		if h.LogRedactor != nil {
+			params = h.LogRedactor.Redact(params)
+		}
+		if len(params) > maxLoggedParamsBytes {
+			params = params[:maxLoggedParamsBytes] + "...(truncated)"
+		}
+		attrs = append(attrs, slog.String("params", params))
+	}
+
+	level := slog.LevelInfo
+	if failed {
+		level = slog.LevelWarn
+		attrs = append(attrs,
+			slog.Int("errorCode", res.response.Error.Code),
+			slog.String("error", res.response.Error.Message),
+		)
+	}
+	h.Log.LogAttrs(context.Background(), level, "JSON-RPC call", attrs...)
+}
@@ -0,0 +1,78 @@
+package rpcserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-utils/logutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRequests(t *testing.T) {
+	var logs bytes.Buffer
+	handler := testHandler(JSONRPCHandlerOpts{
+		Log:                     slog.New(slog.NewJSONHandler(&logs, nil)),
+		ServerName:              "test",
+		ExtractOriginFromHeader: true,
+		LogRequests:             true,
+		LogParams:               true,
+		LogRedactor:             logutils.DefaultRedactor(),
+	})
+
+	call := func(body string) map[string]any {
+		logs.Reset()
+		request, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Flashbots-Origin", "test-origin")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(logs.Bytes(), &entry), logs.String())
+		return entry
+	}
+
+	entry := call(`{"jsonrpc":"2.0","id":1,"method":"function","params":[1]}`)
+	require.Equal(t, "INFO", entry["level"])
+	require.Equal(t, "JSON-RPC call", entry["msg"])
+	require.Equal(t, "test", entry["serverName"])
+	require.Equal(t, "function", entry["method"])
+	require.Equal(t, "test-origin", entry["origin"])
+	require.Equal(t, "[1]", entry["params"])
+	require.Contains(t, entry, "duration")
+	require.NotContains(t, entry, "error")
+
+	entry = call(`{"jsonrpc":"2.0","id":1,"method":"function","params":[-1]}`)
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, float64(CodeCustomError), entry["errorCode"])
+	require.Equal(t, "custom error", entry["error"])
+
+	secret := `private_key=0x` + strings.Repeat("ab", 32)
+	entry = call(`{"jsonrpc":"2.0","id":1,"method":"function","params":["` + secret + `"]}`)
+	require.NotContains(t, entry["params"], strings.Repeat("ab", 32))
+	require.Contains(t, entry["params"], logutils.Redacted)
+}
+
+func TestLogRequestsSampling(t *testing.T) {
+	var logs bytes.Buffer
+	handler := testHandler(JSONRPCHandlerOpts{
+		Log:           slog.New(slog.NewJSONHandler(&logs, nil)),
+		LogRequests:   true,
+		LogSampleRate: 0.000001,
+	})
+
+	for _, arg := range []string{"1", "-1"} {
+		request, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"function","params":[`+arg+`]}`))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	// only the failed call is logged
+	require.Equal(t, 1, strings.Count(logs.String(), "\n"), logs.String())
+	require.Contains(t, logs.String(), "custom error")
+}
@@ -23,6 +23,26 @@ const (
 	errorCountLabel = `goutils_rpcserver_error_count{method="%s",server_name="%s"}`
 	// total duration of the request
 	requestDurationLabel = `goutils_rpcserver_request_duration_milliseconds{method="%s",server_name="%s"}`
+
+	// incremented when a request is rejected by the rate limit
+	rateLimitedCounter = `goutils_rpcserver_rate_limited_total{method="%s",server_name="%s"}`
+
+	// incremented when a request is rejected by MaxConcurrentRequests
+	concurrencyLimitedCounter = `goutils_rpcserver_concurrency_limited_total{method="%s",server_name="%s"}`
+	// number of method calls in progress
+	inFlightGauge = `goutils_rpcserver_in_flight_requests{server_name="%s"}`
+
+	// incremented when a signed request is rejected by the replay protection
+	replayRejectedCounter = `goutils_rpcserver_replay_rejected_total{reason="%s",server_name="%s"}`
+
+	// incremented when a request forwarded to a fallback upstream fails
+	fallbackErrorsCounter = `goutils_rpcserver_fallback_errors_total{upstream="%s",server_name="%s"}`
+
+	// incremented when a repeated order is responded from the dedup cache
+	deduplicatedCounter = `goutils_rpcserver_deduplicated_total{method="%s",server_name="%s"}`
+
+	// number of active websocket subscriptions
+	subscriptionsGauge = `goutils_rpcserver_subscriptions{server_name="%s"}`
 )
 
 func incRequestCount(method, serverName string) {
@@ -49,3 +69,38 @@ func incInternalErrors(serverName string) {
 	l := fmt.Sprintf(internalErrorsCounter, serverName)
 	metrics.GetOrCreateCounter(l).Inc()
 }
+
+func incRateLimited(method, serverName string) {
+	l := fmt.Sprintf(rateLimitedCounter, method, serverName)
+	metrics.GetOrCreateCounter(l).Inc()
+}
+
+func setActiveSubscriptions(serverName string, count int64) {
+	l := fmt.Sprintf(subscriptionsGauge, serverName)
+	metrics.GetOrCreateGauge(l, nil).Set(float64(count))
+}
+
+func incConcurrencyLimited(method, serverName string) {
+	l := fmt.Sprintf(concurrencyLimitedCounter, method, serverName)
+	metrics.GetOrCreateCounter(l).Inc()
+}
+
+func setInFlight(serverName string, count int64) {
+	l := fmt.Sprintf(inFlightGauge, serverName)
+	metrics.GetOrCreateGauge(l, nil).Set(float64(count))
+}
+
+func incReplayRejected(reason, serverName string) {
+	l := fmt.Sprintf(replayRejectedCounter, reason, serverName)
+	metrics.GetOrCreateCounter(l).Inc()
+}
+
+func incFallbackErrors(upstream, serverName string) {
+	l := fmt.Sprintf(fallbackErrorsCounter, upstream, serverName)
+	metrics.GetOrCreateCounter(l).Inc()
+}
+
+func incDeduplicated(method, serverName string) {
+	l := fmt.Sprintf(deduplicatedCounter, method, serverName)
+	metrics.GetOrCreateCounter(l).Inc()
+}
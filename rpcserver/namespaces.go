@@ -0,0 +1,163 @@
+package rpcserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoNamespaces       = errors.New("no namespaces")
+	ErrDuplicateNamespace = errors.New("duplicate namespace prefix")
+)
+
+// Namespace is a set of methods mounted under a prefix, with the options of its handler.
+type Namespace struct {
+	// Prefix of the method names, e.g. "eth_"
+	Prefix string
+	// Methods of the namespace, without the prefix
+	Methods Methods
+	// Opts of the handler of the namespace (signature verification, allowed signers, rate limit, ...), the
+	// MethodOpts are by method name without the prefix
+	Opts JSONRPCHandlerOpts
+}
+
+type namespaceHandler struct {
+	prefix  string
+	handler *JSONRPCHandler
+}
+
+// NamespacesHandler serves several namespaces, each request being handled by the handler of the namespace with the
+// longest prefix of its method. The requests other than POST (GET, websocket upgrades) are handled by the first
+// namespace.
+type NamespacesHandler struct {
+	first *JSONRPCHandler
+	// by decreasing prefix length
+	namespaces []namespaceHandler
+	// largest MaxRequestBodySizeBytes of the namespaces
+	maxBodySize int64
+}
+
+// NewNamespacesHandler creates a handler for each namespace.
+func NewNamespacesHandler(namespaces ...Namespace) (*NamespacesHandler, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespaces
+	}
+
+	nh := &NamespacesHandler{}
+	prefixes := make(map[string]bool)
+	for _, ns := range namespaces {
+		if prefixes[ns.Prefix] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateNamespace, ns.Prefix)
+		}
+		prefixes[ns.Prefix] = true
+
+		methods := make(Methods, len(ns.Methods))
+		for name, fn := range ns.Methods {
+			methods[ns.Prefix+name] = fn
+		}
+		opts := ns.Opts
+		if ns.Opts.MethodOpts != nil {
+			opts.MethodOpts = make(map[string]MethodOpts, len(ns.Opts.MethodOpts))
+			for name, methodOpts := range ns.Opts.MethodOpts {
+				opts.MethodOpts[ns.Prefix+name] = methodOpts
+			}
+		}
+		handler, err := NewJSONRPCHandler(methods, opts)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %q: %w", ns.Prefix, err)
+		}
+
+		if nh.first == nil {
+			nh.first = handler
+		}
+		if handler.MaxRequestBodySizeBytes > nh.maxBodySize {
+			nh.maxBodySize = handler.MaxRequestBodySizeBytes
+		}
+		nh.namespaces = append(nh.namespaces, namespaceHandler{prefix: ns.Prefix, handler: handler})
+	}
+	sort.SliceStable(nh.namespaces, func(i, j int) bool {
+		return len(nh.namespaces[i].prefix) > len(nh.namespaces[j].prefix)
+	})
+	return nh, nil
+}
+
+func (nh *NamespacesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		nh.first.ServeHTTP(w, r)
+		return
+	}
+
+	// the body is read up to the largest limit to find the method, the handler of the namespace reads it again with
+	// its own limit
+	raw, err := io.ReadAll(io.LimitReader(r.Body, nh.maxBodySize+1))
+	if err != nil {
+		nh.first.writeJSONRPCError(w, nh.first.responseCodec(r), nil, CodeInvalidRequest, err.Error())
+		incIncorrectRequest(nh.first.ServerName)
+		return
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(raw), r.Body), r.Body}
+
+	req, ok := nh.peekRequest(r, raw)
+	if !ok {
+		// the handler responds with the error
+		nh.first.ServeHTTP(w, r)
+		return
+	}
+	for _, ns := range nh.namespaces {
+		if strings.HasPrefix(req.Method, ns.prefix) {
+			ns.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	incIncorrectRequest(nh.first.ServerName)
+	if req.RawID == nil {
+		// notification
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	nh.first.writeJSONRPCError(w, nh.first.responseCodec(r), req.RawID, CodeMethodNotFound, "method not found")
+}
+
+// peekRequest decodes the request, decompressing it with the codec of a namespace if needed
+func (nh *NamespacesHandler) peekRequest(r *http.Request, raw []byte) (jsonRPCRequest, bool) {
+	var req jsonRPCRequest
+	body := raw
+	for _, ns := range nh.namespaces {
+		codec, ok := ns.handler.requestCodec(r)
+		if !ok {
+			continue
+		}
+		if codec != nil {
+			reader, err := codec.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return req, false
+			}
+			defer reader.Close()
+			if body, err = io.ReadAll(io.LimitReader(reader, nh.maxBodySize+1)); err != nil {
+				return req, false
+			}
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, false
+		}
+		return req, true
+	}
+	return req, false
+}
+
+// closeWebSockets closes the websocket connections of the namespaces, see Server
+func (nh *NamespacesHandler) closeWebSockets() {
+	for _, ns := range nh.namespaces {
+		ns.handler.closeWebSockets()
+	}
+}
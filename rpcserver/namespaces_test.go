@@ -0,0 +1,67 @@
+package rpcserver
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacesHandler(t *testing.T) {
+	admin, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+
+	handler, err := NewNamespacesHandler(
+		Namespace{
+			Prefix: "eth_",
+			Methods: Methods{
+				"add": func(ctx context.Context, a, b int) (int, error) { return a + b, nil },
+			},
+			Opts: JSONRPCHandlerOpts{
+				MethodOpts: map[string]MethodOpts{"add": {ParamNames: []string{"a", "b"}}},
+			},
+		},
+		Namespace{
+			Prefix: "admin_",
+			Methods: Methods{
+				"signer": func(ctx context.Context) (common.Address, error) { return GetSigner(ctx), nil },
+			},
+			Opts: JSONRPCHandlerOpts{
+				VerifyRequestSignatureFromHeader: true,
+				AllowedSigners:                   []common.Address{admin.Address()},
+			},
+		},
+	)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := rpcclient.NewClient(server.URL)
+
+	var sum int
+	require.NoError(t, client.CallFor(context.Background(), &sum, "eth_add", 1, 2))
+	require.Equal(t, 3, sum)
+
+	// the admin namespace requires a signature of the allowed signer
+	resp, err := client.Call(context.Background(), "admin_signer")
+	require.NoError(t, err)
+	require.Equal(t, "no signature provided", resp.Error.Message)
+	adminClient := rpcclient.NewClientWithOpts(server.URL, &rpcclient.RPCClientOpts{Signer: admin})
+	var signer common.Address
+	require.NoError(t, adminClient.CallFor(context.Background(), &signer, "admin_signer"))
+	require.Equal(t, admin.Address(), signer)
+
+	for _, method := range []string{"eth_unknown", "debug_trace", "signer"} {
+		resp, err = client.Call(context.Background(), method)
+		require.NoError(t, err)
+		require.Equal(t, CodeMethodNotFound, resp.Error.Code, method)
+	}
+
+	_, err = NewNamespacesHandler(Namespace{Prefix: "eth_"}, Namespace{Prefix: "eth_"})
+	require.ErrorIs(t, err, ErrDuplicateNamespace)
+	_, err = NewNamespacesHandler()
+	require.ErrorIs(t, err, ErrNoNamespaces)
+}
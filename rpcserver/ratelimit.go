@@ -0,0 +1,52 @@
+package rpcserver
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/ratelimit"
+)
+
+// RateLimitKey selects the key the requests are limited by.
+type RateLimitKey int
+
+const (
+	// RateLimitByMethod limits all the requests of a method together
+	RateLimitByMethod RateLimitKey = iota
+	// RateLimitBySigner limits the requests per signer (see GetSigner), requests without signer are not limited
+	RateLimitBySigner
+	// RateLimitByOrigin limits the requests per origin (see GetOrigin), requests without origin are not limited
+	RateLimitByOrigin
+)
+
+// RateLimit limits the rate of the requests, rejecting the requests above the limit with CodeLimitExceeded.
+type RateLimit struct {
+	// Limiter of the requests, e.g. ratelimit.NewTokenBucket
+	Limiter ratelimit.Limiter
+	// By selects the key of the limit
+	By RateLimitKey
+}
+
+// allow reports whether the request to the method is allowed by the rate limit
+func (l *RateLimit) allow(ctx context.Context, method string) bool {
+	var key string
+	switch l.By {
+	case RateLimitByMethod:
+		key = method
+	case RateLimitBySigner:
+		if signer := GetSigner(ctx); signer != (common.Address{}) {
+			key = signer.Hex()
+		}
+	case RateLimitByOrigin:
+		key = GetOrigin(ctx)
+	}
+	return key == "" || l.Limiter.Allow(key)
+}
+
+// rateLimit returns the rate limit of the method, nil if not limited
+func (h *JSONRPCHandler) rateLimit(method string) *RateLimit {
+	if opts, ok := h.MethodOpts[method]; ok && opts.RateLimit != nil {
+		return opts.RateLimit
+	}
+	return h.RateLimit
+}
@@ -0,0 +1,59 @@
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flashbots/go-utils/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	methodLimiter := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Name: "test-method", Rate: 0.001, Burst: 1})
+	defer methodLimiter.Close()
+	originLimiter := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Name: "test-origin", Rate: 0.001, Burst: 2})
+	defer originLimiter.Close()
+
+	handler, err := NewJSONRPCHandler(Methods{
+		"limited":   func(ctx context.Context) (int, error) { return 1, nil },
+		"perOrigin": func(ctx context.Context) (int, error) { return 2, nil },
+	}, JSONRPCHandlerOpts{
+		ExtractOriginFromHeader: true,
+		RateLimit:               &RateLimit{Limiter: methodLimiter, By: RateLimitByMethod},
+		MethodOpts: map[string]MethodOpts{
+			"perOrigin": {RateLimit: &RateLimit{Limiter: originLimiter, By: RateLimitByOrigin}},
+		},
+	})
+	require.NoError(t, err)
+
+	call := func(method, origin string) string {
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":[]}`)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if origin != "" {
+			req.Header.Set("X-Flashbots-Origin", origin)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr.Body.String()
+	}
+	limited := `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"rate limit exceeded"}}`
+
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":1}`, call("limited", ""))
+	require.JSONEq(t, limited, call("limited", ""))
+
+	// the method's own limit applies per origin, requests without origin aren't limited
+	for i := 0; i < 2; i++ {
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":2}`, call("perOrigin", "a"))
+	}
+	require.JSONEq(t, limited, call("perOrigin", "a"))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":2}`, call("perOrigin", "b"))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":2}`, call("perOrigin", ""))
+
+	_, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{MethodOpts: map[string]MethodOpts{"unknown": {}}})
+	require.ErrorIs(t, err, ErrMethodOptsForUnknownMethod)
+}
@@ -1,10 +1,15 @@
 package rpcserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
 )
 
 var (
@@ -14,8 +19,41 @@ var (
 	ErrTooManyReturnValues = errors.New("too many return values")
 
 	ErrTooMuchArguments = errors.New("too much arguments")
+
+	ErrInvalidParams           = errors.New("params must be an array or an object")
+	ErrNamedParamsNotSupported = errors.New("named params are not supported by the method")
+	ErrUnknownParam            = errors.New("unknown param")
+	ErrParamNamesMismatch      = errors.New("number of param names doesn't match the number of arguments")
 )
 
+// RawParams is the type of the only argument (after the context) of the methods receiving the params of the request
+// undecoded, an array or an object, e.g. for hot methods decoding them without reflection:
+//
+//	func SendRawTransaction(ctx context.Context, params rpcserver.RawParams) (common.Hash, error)
+type RawParams json.RawMessage
+
+var rawParamsType = reflect.TypeOf(RawParams(nil))
+
+// methodPanicError is returned by the calls of the functions that panicked, the panic being recovered
+type methodPanicError struct {
+	value any
+	stack []byte
+}
+
+func (e *methodPanicError) Error() string {
+	return fmt.Sprintf("method panicked: %v", e.value)
+}
+
+// callFunc calls the function, recovering its panic as a *methodPanicError
+func callFunc(fn any, args []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &methodPanicError{value: r, stack: debug.Stack()}
+		}
+	}()
+	return reflect.ValueOf(fn).Call(args), nil
+}
+
 type methodHandler struct {
 	in  []reflect.Type
 	out []reflect.Type
@@ -61,12 +99,58 @@ func (h methodHandler) call(ctx context.Context, params []json.RawMessage) (any,
 	if err != nil {
 		return nil, err
 	}
+	return h.callArgs(ctx, args)
+}
+
+// paramsError is an error of the structure of the params, as opposed to the errors decoding the arguments
+type paramsError struct {
+	error
+}
+
+func (e paramsError) Unwrap() error { return e.error }
+
+// decodeArgs decodes the params of a request into the arguments of the method (after the context), see
+// positionalParams. Positional params are decoded directly into the arguments, without splitting them first.
+func (h methodHandler) decodeArgs(raw json.RawMessage, names []string) ([]reflect.Value, error) {
+	in := h.in[1:]
+	if len(in) == 1 && in[0] == rawParamsType {
+		return []reflect.Value{reflect.ValueOf(RawParams(raw))}, nil
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		// the elements of the slice are pointers to the arguments, decoded in place by json.Unmarshal while the extra
+		// params are decoded into new elements
+		args := make([]reflect.Value, len(in))
+		ptrs := make([]any, len(in))
+		for i, argType := range in {
+			arg := reflect.New(argType)
+			args[i] = arg.Elem()
+			ptrs[i] = arg.Interface()
+		}
+		if err := json.Unmarshal(trimmed, &ptrs); err == nil && len(ptrs) <= len(in) {
+			return args, nil
+		}
+		// decoded again param by param for the error
+	}
 
+	params, err := positionalParams(raw, names, len(in))
+	if err != nil {
+		return nil, paramsError{err}
+	}
+	return extractArgumentsFromJSONparamsArray(in, params)
+}
+
+// callArgs calls the method with the decoded arguments (after the context)
+func (h methodHandler) callArgs(ctx context.Context, args []reflect.Value) (any, error) {
 	// prepend context.Context
 	args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
 
 	// call function
-	results := reflect.ValueOf(h.fn).Call(args)
+	results, err := callFunc(h.fn, args)
+	if err != nil {
+		return nil, err
+	}
 
 	// check error
 	var outError error
@@ -102,3 +186,53 @@ func extractArgumentsFromJSONparamsArray(in []reflect.Type, params []json.RawMes
 	}
 	return args, nil
 }
+
+// positionalParams converts the params of a request to positional params. Named params (an object) are mapped to the
+// arguments by their names, or decoded into the only argument if the method has no param names.
+func positionalParams(raw json.RawMessage, names []string, numArgs int) ([]json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '[':
+		var params []json.RawMessage
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	case '{':
+		if names == nil {
+			if numArgs != 1 {
+				return nil, ErrNamedParamsNotSupported
+			}
+			return []json.RawMessage{raw}, nil
+		}
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &named); err != nil {
+			return nil, err
+		}
+		params := make([]json.RawMessage, len(names))
+		for i, name := range names {
+			param, ok := named[name]
+			if !ok {
+				// missing params are zero values
+				param = json.RawMessage("null")
+			}
+			params[i] = param
+			delete(named, name)
+		}
+		if len(named) > 0 {
+			unknown := make([]string, 0, len(named))
+			for name := range named {
+				unknown = append(unknown, name)
+			}
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("%w: %s", ErrUnknownParam, strings.Join(unknown, ", "))
+		}
+		return params, nil
+	default:
+		return nil, ErrInvalidParams
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -221,3 +222,95 @@ func TestCall(t *testing.T) {
 		})
 	}
 }
+
+func TestPositionalParams(t *testing.T) {
+	testCases := map[string]struct {
+		params   string
+		names    []string
+		numArgs  int
+		expected []string
+		err      error
+	}{
+		"array":            {params: `[1, "a"]`, numArgs: 2, expected: []string{`1`, `"a"`}},
+		"null":             {params: `null`, numArgs: 2},
+		"missing":          {params: ``, numArgs: 2},
+		"single struct":    {params: `{"field": 1}`, numArgs: 1, expected: []string{`{"field": 1}`}},
+		"not supported":    {params: `{"a": 1}`, numArgs: 2, err: ErrNamedParamsNotSupported},
+		"named":            {params: `{"b": "x", "a": 1}`, names: []string{"a", "b"}, numArgs: 2, expected: []string{`1`, `"x"`}},
+		"named missing":    {params: `{"b": "x"}`, names: []string{"a", "b"}, numArgs: 2, expected: []string{`null`, `"x"`}},
+		"named unknown":    {params: `{"a": 1, "c": 2}`, names: []string{"a", "b"}, numArgs: 2, err: ErrUnknownParam},
+		"invalid":          {params: `"a"`, numArgs: 1, err: ErrInvalidParams},
+		"named single arg": {params: `{"arg": {"field": 1}}`, names: []string{"arg"}, numArgs: 1, expected: []string{`{"field": 1}`}},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			params, err := positionalParams(json.RawMessage(testCase.params), testCase.names, testCase.numArgs)
+			if testCase.err != nil {
+				require.ErrorIs(t, err, testCase.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, params, len(testCase.expected))
+			for i, param := range params {
+				require.Equal(t, testCase.expected[i], string(param))
+			}
+		})
+	}
+}
+
+func TestDecodeArgs(t *testing.T) {
+	method, err := getMethodTypes(func(ctx context.Context, a int, s *dummyStruct, b []string) error { return nil })
+	require.NoError(t, err)
+
+	args, err := method.decodeArgs(json.RawMessage(`[1, {"field": 2}, ["x"]]`), nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, args[0].Interface())
+	require.Equal(t, &dummyStruct{Field: 2}, args[1].Interface())
+	require.Equal(t, []string{"x"}, args[2].Interface())
+
+	// missing and null params are zero values
+	args, err = method.decodeArgs(json.RawMessage(`[1, null]`), nil)
+	require.NoError(t, err)
+	require.Nil(t, args[1].Interface())
+	require.Nil(t, args[2].Interface())
+
+	args, err = method.decodeArgs(json.RawMessage(`{"b": ["y"], "a": 3}`), []string{"a", "s", "b"})
+	require.NoError(t, err)
+	require.Equal(t, 3, args[0].Interface())
+	require.Equal(t, []string{"y"}, args[2].Interface())
+
+	_, err = method.decodeArgs(json.RawMessage(`[1, null, [], 4]`), nil)
+	require.ErrorIs(t, err, ErrTooMuchArguments)
+	_, err = method.decodeArgs(json.RawMessage(`["a"]`), nil)
+	require.EqualError(t, err, "json: cannot unmarshal string into Go value of type int")
+	_, err = method.decodeArgs(json.RawMessage(`"a"`), nil)
+	require.ErrorIs(t, err, ErrInvalidParams)
+
+	// raw params are passed undecoded
+	method, err = getMethodTypes(func(ctx context.Context, params RawParams) error { return nil })
+	require.NoError(t, err)
+	args, err = method.decodeArgs(json.RawMessage(`["0x01"]`), nil)
+	require.NoError(t, err)
+	require.Equal(t, RawParams(`["0x01"]`), args[0].Interface())
+}
+
+func BenchmarkDecodeArgs(b *testing.B) {
+	method, err := getMethodTypes(func(ctx context.Context, tx hexutil.Bytes) error { return nil })
+	require.NoError(b, err)
+	params := json.RawMessage(`["0x02f8730182012a8405f5e100850bdfd63e00825208940000000000000000000000000000000000000000880de0b6b3a764000080c001a0"]`)
+
+	b.Run("positional", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p, _ := positionalParams(params, nil, 1)
+			_, _ = extractArgumentsFromJSONparamsArray(method.in[1:], p)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = method.decodeArgs(params, nil)
+		}
+	})
+}
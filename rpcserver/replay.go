@@ -0,0 +1,132 @@
+package rpcserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/flashbots/go-utils/signature"
+)
+
+const (
+	defaultReplayMaxAge = 30 * time.Second
+	defaultReplaySize   = 100_000
+)
+
+var ErrReplayProtectionWithoutSignature = errors.New("replay protection needs VerifyRequestSignatureFromHeader")
+
+var (
+	errNoTimestamp      = errors.New("no x-flashbots-timestamp header provided")
+	errInvalidTimestamp = errors.New("invalid x-flashbots-timestamp header")
+	errStaleTimestamp   = errors.New("stale x-flashbots-timestamp header")
+	errReplayed         = errors.New("request already received")
+	errReplayFull       = errors.New("too many recent requests")
+)
+
+// ReplayProtection rejects the replays of signed requests. The requests must have a X-Flashbots-Timestamp header
+// covered by the signature (see signature.TimestampedBody and rpcclient.RPCClientOpts.SignTimestamp), within MaxAge
+// of the server time, and are rejected if the same signer already sent the same timestamp and body.
+type ReplayProtection struct {
+	// MaxAge is the maximum difference between the timestamp and the server time, 30s by default
+	MaxAge time.Duration
+	// Size is the maximum number of requests remembered (100k by default). The requests are remembered for 2*MaxAge,
+	// new requests are rejected while Size requests were received in that window, so it should be above 2*MaxAge
+	// times the expected request rate.
+	Size int
+}
+
+type replayGuard struct {
+	serverName string
+	maxAge     time.Duration
+	size       int
+	now        func() time.Time
+
+	mu sync.Mutex
+	// seen are the requests received within 2*MaxAge, queue is in the order they were received in and expire
+	seen  map[common.Hash]struct{}
+	queue []seenRequest
+}
+
+type seenRequest struct {
+	key       common.Hash
+	expiresAt time.Time
+}
+
+func newReplayGuard(opts ReplayProtection, serverName string) *replayGuard {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = defaultReplayMaxAge
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultReplaySize
+	}
+	return &replayGuard{
+		serverName: serverName,
+		maxAge:     opts.MaxAge,
+		size:       opts.Size,
+		now:        time.Now,
+		seen:       make(map[common.Hash]struct{}),
+	}
+}
+
+// verify verifies the signature of the body and the timestamp of the header, and records the request. It returns the
+// signer.
+func (g *replayGuard) verify(header http.Header, body []byte) (common.Address, error) {
+	timestamp := header.Get(signature.TimestampHTTPHeader)
+	if timestamp == "" {
+		return common.Address{}, g.reject(errNoTimestamp, "no_timestamp")
+	}
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return common.Address{}, g.reject(errInvalidTimestamp, "invalid_timestamp")
+	}
+
+	payload := signature.TimestampedBody(timestamp, body)
+	signer, err := signature.Verify(header.Get(signature.HTTPHeader), payload)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	age := g.now().Sub(time.UnixMilli(millis))
+	if age > g.maxAge || age < -g.maxAge {
+		return common.Address{}, g.reject(errStaleTimestamp, "stale")
+	}
+
+	key := crypto.Keccak256Hash(signer.Bytes(), payload)
+	if err := g.record(key); err != nil {
+		return common.Address{}, err
+	}
+	return signer, nil
+}
+
+// record remembers the request until its timestamp can't be accepted anymore. The requests are never forgotten
+// before, new ones are rejected if Size requests are remembered.
+func (g *replayGuard) record(key common.Hash) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for len(g.queue) > 0 && !now.Before(g.queue[0].expiresAt) {
+		delete(g.seen, g.queue[0].key)
+		g.queue = g.queue[1:]
+	}
+
+	if _, seen := g.seen[key]; seen {
+		return g.reject(errReplayed, "replayed")
+	}
+	if len(g.seen) >= g.size {
+		return g.reject(errReplayFull, "full")
+	}
+	g.seen[key] = struct{}{}
+	// the timestamps are accepted from MaxAge in the past to MaxAge in the future
+	g.queue = append(g.queue, seenRequest{key: key, expiresAt: now.Add(2 * g.maxAge)})
+	return nil
+}
+
+func (g *replayGuard) reject(err error, reason string) error {
+	incReplayRejected(reason, g.serverName)
+	return err
+}
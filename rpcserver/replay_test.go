@@ -0,0 +1,93 @@
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayProtection(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"function": func(ctx context.Context) (int, error) { return 1, nil },
+	}, JSONRPCHandlerOpts{
+		VerifyRequestSignatureFromHeader: true,
+		ReplayProtection:                 &ReplayProtection{MaxAge: time.Minute},
+	})
+	require.NoError(t, err)
+
+	signer, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	client := rpcclient.NewClientWithOpts(httpServer.URL, &rpcclient.RPCClientOpts{Signer: signer, SignTimestamp: true})
+	resp, err := client.Call(context.Background(), "function")
+	require.NoError(t, err)
+	require.Nil(t, resp.Error)
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"function","params":[]}`)
+	call := func(timestamp string, signedTimestamp string) string {
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if timestamp != "" {
+			req.Header.Set(signature.TimestampHTTPHeader, timestamp)
+		}
+		sig, err := signer.Create(signature.TimestampedBody(signedTimestamp, body))
+		require.NoError(t, err)
+		req.Header.Set(signature.HTTPHeader, sig)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+	rejected := func(msg string) string {
+		return `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"` + msg + `"}}`
+	}
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":1}`, call(now, now))
+	require.JSONEq(t, rejected("request already received"), call(now, now))
+
+	later := strconv.FormatInt(time.Now().UnixMilli()+1, 10)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":1}`, call(later, later))
+
+	// the timestamp must be covered by the signature
+	other := strconv.FormatInt(time.Now().UnixMilli()+2, 10)
+	require.Contains(t, call(other, now), "signing address mismatch")
+
+	require.JSONEq(t, rejected("no x-flashbots-timestamp header provided"), call("", now))
+	require.JSONEq(t, rejected("invalid x-flashbots-timestamp header"), call("now", "now"))
+	stale := strconv.FormatInt(time.Now().Add(-2*time.Minute).UnixMilli(), 10)
+	require.JSONEq(t, rejected("stale x-flashbots-timestamp header"), call(stale, stale))
+
+	_, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{ReplayProtection: &ReplayProtection{}})
+	require.ErrorIs(t, err, ErrReplayProtectionWithoutSignature)
+}
+
+func TestReplayGuardFull(t *testing.T) {
+	g := newReplayGuard(ReplayProtection{MaxAge: time.Minute, Size: 2}, "test")
+	now := time.Now()
+	g.now = func() time.Time { return now }
+
+	require.NoError(t, g.record(common.Hash{1}))
+	require.NoError(t, g.record(common.Hash{2}))
+	// the remembered requests are never evicted by new ones
+	require.ErrorIs(t, g.record(common.Hash{3}), errReplayFull)
+	require.ErrorIs(t, g.record(common.Hash{1}), errReplayed)
+
+	now = now.Add(2*time.Minute - time.Millisecond)
+	require.ErrorIs(t, g.record(common.Hash{1}), errReplayed)
+	now = now.Add(time.Millisecond)
+	require.NoError(t, g.record(common.Hash{3}))
+	require.NoError(t, g.record(common.Hash{1}))
+	require.ErrorIs(t, g.record(common.Hash{2}), errReplayFull)
+}
@@ -0,0 +1,363 @@
+// Package rpctest is a conformance test suite for the JSON-RPC protocol as implemented by rpcserver and rpcclient.
+//
+// Forks and alternative handlers or clients can run it to verify they stay compatible:
+//
+//	func TestConformance(t *testing.T) {
+//		rpctest.Run(t, rpctest.Config{NewHandler: newMyHandler})
+//	}
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/flashbots/go-utils/rpcserver"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+// ErrTest is returned by the "test_fail" method
+var ErrTest = errors.New("test error")
+
+// Struct is the argument and result of the "test_struct" method
+type Struct struct {
+	Field  int    `json:"field"`
+	String string `json:"string,omitempty"`
+}
+
+// Methods returns the methods the suite calls, served by the handler under test.
+func Methods() rpcserver.Methods {
+	return rpcserver.Methods{
+		"test_echo": func(ctx context.Context, arg int) (int, error) {
+			return arg, nil
+		},
+		"test_add": func(ctx context.Context, a, b int) (int, error) {
+			return a + b, nil
+		},
+		"test_struct": func(ctx context.Context, arg Struct) (Struct, error) {
+			return arg, nil
+		},
+		"test_noResult": func(ctx context.Context) error {
+			return nil
+		},
+		"test_fail": func(ctx context.Context) (int, error) {
+			return 0, ErrTest
+		},
+		"test_signer": func(ctx context.Context) (common.Address, error) {
+			return rpcserver.GetSigner(ctx), nil
+		},
+		"test_origin": func(ctx context.Context) (string, error) {
+			return rpcserver.GetOrigin(ctx), nil
+		},
+		"test_highPriority": func(ctx context.Context) (bool, error) {
+			return rpcserver.GetHighPriority(ctx), nil
+		},
+	}
+}
+
+// Config selects the implementations under test and the optional features they support.
+type Config struct {
+	// NewHandler creates the handler under test serving the methods, rpcserver.NewJSONRPCHandler by default
+	NewHandler func(methods rpcserver.Methods, opts rpcserver.JSONRPCHandlerOpts) (http.Handler, error)
+	// NewClient creates the client under test, rpcclient.NewClientWithOpts by default
+	NewClient func(endpoint string, opts *rpcclient.RPCClientOpts) rpcclient.RPCClient
+	// Batches is true if the handler supports batch requests, otherwise they must be rejected with an error
+	Batches bool
+	// Notifications is true if the handler doesn't respond to requests without id, otherwise they must get a response
+	// with a null id
+	Notifications bool
+}
+
+func (cfg *Config) setDefaults() {
+	if cfg.NewHandler == nil {
+		cfg.NewHandler = func(methods rpcserver.Methods, opts rpcserver.JSONRPCHandlerOpts) (http.Handler, error) {
+			return rpcserver.NewJSONRPCHandler(methods, opts)
+		}
+	}
+	if cfg.NewClient == nil {
+		cfg.NewClient = rpcclient.NewClientWithOpts
+	}
+}
+
+// Run runs the conformance suite as subtests of t.
+func Run(t *testing.T, cfg Config) {
+	t.Helper()
+	cfg.setDefaults()
+
+	t.Run("calls", func(t *testing.T) { testCalls(t, cfg) })
+	t.Run("ids", func(t *testing.T) { testIDs(t, cfg) })
+	t.Run("error codes", func(t *testing.T) { testErrorCodes(t, cfg) })
+	t.Run("http errors", func(t *testing.T) { testHTTPErrors(t, cfg) })
+	t.Run("size limit", func(t *testing.T) { testSizeLimit(t, cfg) })
+	t.Run("signatures", func(t *testing.T) { testSignatures(t, cfg) })
+	t.Run("headers", func(t *testing.T) { testHeaders(t, cfg) })
+	t.Run("batches", func(t *testing.T) { testBatches(t, cfg) })
+	t.Run("notifications", func(t *testing.T) { testNotifications(t, cfg) })
+}
+
+// Server is a handler under test served over HTTP.
+type Server struct {
+	*httptest.Server
+	cfg Config
+}
+
+// NewServer serves the Methods with the handler of the config, closed at the end of the test.
+func NewServer(t *testing.T, cfg Config, opts rpcserver.JSONRPCHandlerOpts) *Server {
+	t.Helper()
+	cfg.setDefaults()
+	handler, err := cfg.NewHandler(Methods(), opts)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &Server{Server: server, cfg: cfg}
+}
+
+// Client returns a client of the config for the server.
+func (s *Server) Client(opts *rpcclient.RPCClientOpts) rpcclient.RPCClient {
+	if opts == nil {
+		opts = &rpcclient.RPCClientOpts{}
+	}
+	return s.cfg.NewClient(s.URL, opts)
+}
+
+// Post sends the raw body as a JSON request and returns the status code and response body.
+func (s *Server) Post(t *testing.T, body string, header http.Header) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, resBody
+}
+
+// rawResponse keeps the id as sent by the server
+type rawResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func decodeResponse(t *testing.T, body []byte) rawResponse {
+	t.Helper()
+	var res rawResponse
+	require.NoError(t, json.Unmarshal(body, &res), string(body))
+	require.Equal(t, "2.0", res.JSONRPC)
+	return res
+}
+
+func testCalls(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	client := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{}).Client(nil)
+
+	var sum int
+	require.NoError(t, client.CallFor(ctx, &sum, "test_add", 2, 3))
+	require.Equal(t, 5, sum)
+
+	var st Struct
+	require.NoError(t, client.CallFor(ctx, &st, "test_struct", Struct{Field: 1, String: "a"}))
+	require.Equal(t, Struct{Field: 1, String: "a"}, st)
+
+	// missing trailing params are zero values
+	res, err := client.Call(ctx, "test_add", 1)
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+	n, err := res.GetInt()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	// methods without result return null
+	res, err = client.Call(ctx, "test_noResult")
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+	require.Nil(t, res.Result)
+}
+
+func testIDs(t *testing.T, cfg Config) {
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{})
+	for _, id := range []string{`1`, `0`, `"abc"`, `123456789`} {
+		_, body := server.Post(t, `{"jsonrpc":"2.0","id":`+id+`,"method":"test_echo","params":[7]}`, nil)
+		res := decodeResponse(t, body)
+		require.Equal(t, id, string(res.ID))
+		require.Equal(t, `7`, string(res.Result))
+	}
+
+	_, body := server.Post(t, `{"jsonrpc":"2.0","id":{"a":1},"method":"test_echo","params":[7]}`, nil)
+	res := decodeResponse(t, body)
+	require.NotNil(t, res.Error, "object ids are invalid")
+}
+
+func testErrorCodes(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{})
+	client := server.Client(nil)
+
+	res, err := client.Call(ctx, "test_fail")
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+	require.Equal(t, rpcserver.CodeCustomError, res.Error.Code)
+	require.Equal(t, ErrTest.Error(), res.Error.Message)
+
+	res, err = client.Call(ctx, "test_unknown")
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+	require.Equal(t, rpcserver.CodeMethodNotFound, res.Error.Code)
+
+	// invalid params are rejected, the code is not pinned yet
+	res, err = client.Call(ctx, "test_echo", "not a number")
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+	res, err = client.Call(ctx, "test_echo", 1, 2)
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+
+	_, body := server.Post(t, `{"jsonrpc":"2.0","id":1,"method":`, nil)
+	raw := decodeResponse(t, body)
+	require.NotNil(t, raw.Error)
+	require.Equal(t, rpcserver.CodeParseError, raw.Error.Code)
+	require.Equal(t, `null`, string(raw.ID))
+
+	_, body = server.Post(t, `{"jsonrpc":"1.0","id":1,"method":"test_echo","params":[1]}`, nil)
+	raw = decodeResponse(t, body)
+	require.NotNil(t, raw.Error, "only version 2.0 is supported")
+}
+
+func testHTTPErrors(t *testing.T, cfg Config) {
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{})
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = http.Post(server.URL, "text/plain", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":[1]}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
+func testSizeLimit(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	client := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{MaxRequestBodySizeBytes: 1024}).Client(nil)
+
+	var st Struct
+	require.NoError(t, client.CallFor(ctx, &st, "test_struct", Struct{String: strings.Repeat("a", 512)}))
+
+	res, err := client.Call(ctx, "test_struct", Struct{String: strings.Repeat("a", 1024)})
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+	require.Equal(t, rpcserver.CodeInvalidRequest, res.Error.Code)
+}
+
+func testSignatures(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{VerifyRequestSignatureFromHeader: true})
+
+	res, err := server.Client(nil).Call(ctx, "test_signer")
+	require.NoError(t, err)
+	require.NotNil(t, res.Error, "unsigned requests are rejected")
+	require.Equal(t, rpcserver.CodeInvalidRequest, res.Error.Code)
+
+	signer, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+	var address common.Address
+	require.NoError(t, server.Client(&rpcclient.RPCClientOpts{Signer: signer}).CallFor(ctx, &address, "test_signer"))
+	require.Equal(t, signer.Address(), address)
+
+	// the signature of another body is rejected
+	body := `{"jsonrpc":"2.0","id":1,"method":"test_signer","params":[]}`
+	header, err := signer.Create([]byte(body + " "))
+	require.NoError(t, err)
+	_, resBody := server.Post(t, body, http.Header{signature.HTTPHeader: {header}})
+	raw := decodeResponse(t, resBody)
+	require.NotNil(t, raw.Error)
+	require.Equal(t, rpcserver.CodeInvalidRequest, raw.Error.Code)
+}
+
+func testHeaders(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	client := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{
+		ExtractOriginFromHeader:   true,
+		ExtractPriorityFromHeader: true,
+	}).Client(&rpcclient.RPCClientOpts{
+		CustomHeaders: map[string]string{"X-Flashbots-Origin": "test-origin", "high_prio": "true"},
+	})
+
+	var origin string
+	require.NoError(t, client.CallFor(ctx, &origin, "test_origin"))
+	require.Equal(t, "test-origin", origin)
+
+	var highPriority bool
+	require.NoError(t, client.CallFor(ctx, &highPriority, "test_highPriority"))
+	require.True(t, highPriority)
+}
+
+func testBatches(t *testing.T, cfg Config) {
+	ctx := context.Background()
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{})
+	client := server.Client(nil)
+
+	responses, err := client.CallBatch(ctx, rpcclient.RPCRequests{
+		rpcclient.NewRequest("test_echo", 1),
+		rpcclient.NewRequest("test_fail"),
+		rpcclient.NewRequest("test_add", 1, 2),
+	})
+	if !cfg.Batches {
+		if err == nil {
+			require.True(t, responses.HasError(), "batches must be rejected")
+		}
+		return
+	}
+
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+	byID := responses.AsMap()
+	n, err := byID[0].GetInt()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	require.NotNil(t, byID[1].Error)
+	require.Equal(t, rpcserver.CodeCustomError, byID[1].Error.Code)
+	n, err = byID[2].GetInt()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	// an empty batch is an invalid request
+	_, body := server.Post(t, `[]`, nil)
+	raw := decodeResponse(t, body)
+	require.NotNil(t, raw.Error)
+	require.Equal(t, rpcserver.CodeInvalidRequest, raw.Error.Code)
+}
+
+func testNotifications(t *testing.T, cfg Config) {
+	server := NewServer(t, cfg, rpcserver.JSONRPCHandlerOpts{})
+
+	status, body := server.Post(t, `{"jsonrpc":"2.0","method":"test_echo","params":[1]}`, nil)
+	if cfg.Notifications {
+		require.Empty(t, bytes.TrimSpace(body))
+		require.Contains(t, []int{http.StatusOK, http.StatusNoContent}, status)
+		return
+	}
+	require.Equal(t, http.StatusOK, status)
+	res := decodeResponse(t, body)
+	require.Equal(t, `null`, string(res.ID))
+	require.Equal(t, `1`, string(res.Result))
+}
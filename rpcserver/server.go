@@ -0,0 +1,176 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/flashbots/go-utils/healthcheck"
+)
+
+const (
+	defaultGracePeriod       = 30 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+
+	drainingCheckName = "rpcserver_draining"
+)
+
+var ErrDraining = errors.New("server is draining")
+
+// ServerOpts configures a Server.
+type ServerOpts struct {
+	// ListenAddr is the address to listen on, e.g. ":8080"
+	ListenAddr string
+	// Health holds the checks served on /readyz and /livez, a check failing during the shutdown is added to it. A new
+	// registry is created if nil.
+	Health *healthcheck.Registry
+	// DrainDelay is how long /readyz fails before the listener is closed on shutdown, for the load balancers to stop
+	// routing requests to the server
+	DrainDelay time.Duration
+	// GracePeriod is the maximum time the in-flight requests are waited for on shutdown, 30s by default. The
+	// connections still open after it, including websockets, are closed.
+	GracePeriod time.Duration
+	// ReadHeaderTimeout of the HTTP server, 10s by default
+	ReadHeaderTimeout time.Duration
+}
+
+// webSocketCloser is implemented by JSONRPCHandler and NamespacesHandler
+type webSocketCloser interface {
+	closeWebSockets()
+}
+
+// Server serves a JSONRPCHandler (or NamespacesHandler) on "/" with the health checks on "/readyz" and "/livez", and drains the in-flight
+// requests on shutdown.
+type Server struct {
+	handler http.Handler
+	opts    ServerOpts
+	http    *http.Server
+
+	draining atomic.Bool
+	inFlight sync.WaitGroup
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewServer creates a server for the handler.
+func NewServer(handler http.Handler, opts ServerOpts) *Server {
+	if opts.Health == nil {
+		opts.Health = healthcheck.NewRegistry()
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = defaultGracePeriod
+	}
+	if opts.ReadHeaderTimeout <= 0 {
+		opts.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+
+	s := &Server{handler: handler, opts: opts}
+	opts.Health.Register(healthcheck.Check{Name: drainingCheckName, Check: func(ctx context.Context) error {
+		if s.draining.Load() {
+			return ErrDraining
+		}
+		return nil
+	}})
+
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", opts.Health.ReadyHandler())
+	mux.Handle("/livez", opts.Health.LiveHandler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Done()
+		handler.ServeHTTP(w, r)
+	})
+	s.http = &http.Server{
+		Addr:              opts.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+	}
+	return s
+}
+
+// ListenAndServe serves until ctx is done or the process receives SIGINT or SIGTERM, then shuts down (see Shutdown).
+// It returns nil once shut down.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.opts.ListenAddr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is ListenAndServe with the listener.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- s.http.Serve(ln)
+	}()
+
+	select {
+	case err := <-errC:
+		if errors.Is(err, http.ErrServerClosed) {
+			// Shutdown was called
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+	// restore the default behavior, a second signal terminates the process
+	stop()
+	return s.Shutdown(context.Background())
+}
+
+// Shutdown fails /readyz and waits for DrainDelay, then stops accepting connections and waits for the in-flight
+// requests, for at most GracePeriod or until ctx is done. The connections still open are then closed and the
+// context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		s.shutdownErr = s.shutdown(ctx)
+	})
+	return s.shutdownErr
+}
+
+func (s *Server) shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	if s.opts.DrainDelay > 0 {
+		timer := time.NewTimer(s.opts.DrainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.GracePeriod)
+	defer cancel()
+
+	// waits for the HTTP requests, then for the websocket connections that were hijacked from the server
+	err := s.http.Shutdown(ctx)
+	if err == nil {
+		done := make(chan struct{})
+		go func() {
+			s.inFlight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	_ = s.http.Close()
+	if closer, ok := s.handler.(webSocketCloser); ok {
+		closer.closeWebSockets()
+	}
+	return err
+}
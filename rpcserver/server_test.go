@@ -0,0 +1,84 @@
+package rpcserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/rpcclient"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestServer(t *testing.T, methods Methods, handlerOpts JSONRPCHandlerOpts, opts ServerOpts) (*Server, string, chan error) {
+	t.Helper()
+	handler, err := NewJSONRPCHandler(methods, handlerOpts)
+	require.NoError(t, err)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer(handler, opts)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- server.Serve(context.Background(), ln)
+	}()
+	return server, ln.Addr().String(), errC
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server, addr, serveErrC := startTestServer(t, Methods{
+		"slow": func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		},
+	}, JSONRPCHandlerOpts{}, ServerOpts{DrainDelay: 200 * time.Millisecond})
+
+	readyz := func() int {
+		resp, err := http.Get("http://" + addr + "/readyz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, readyz())
+
+	callErrC := make(chan error, 1)
+	go func() {
+		var result int
+		callErrC <- rpcclient.NewClient("http://"+addr).CallFor(context.Background(), &result, "slow")
+	}()
+	<-started
+
+	shutdownErrC := make(chan error, 1)
+	go func() {
+		shutdownErrC <- server.Shutdown(context.Background())
+	}()
+	// /readyz fails during the drain delay, before the listener is closed
+	require.Eventually(t, func() bool { return readyz() == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+
+	// the in-flight request completes
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-callErrC)
+	require.NoError(t, <-shutdownErrC)
+	require.NoError(t, <-serveErrC)
+
+	_, err := http.Get("http://" + addr + "/readyz")
+	require.Error(t, err)
+}
+
+func TestServer_ShutdownGracePeriod(t *testing.T) {
+	server, addr, _ := startTestServer(t, Methods{}, JSONRPCHandlerOpts{EnableWebSocket: true}, ServerOpts{GracePeriod: 100 * time.Millisecond})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the websocket connection is closed after the grace period
+	require.ErrorIs(t, server.Shutdown(context.Background()), context.DeadlineExceeded)
+	_, _, err = conn.ReadMessage()
+	require.Error(t, err)
+}
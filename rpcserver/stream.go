@@ -0,0 +1,110 @@
+package rpcserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+const streamBufferSize = 32 * 1024
+
+// JSONStreamer is implemented by the method results written directly to the response instead of being marshaled in
+// memory first, e.g. multi-megabyte simulation traces. Methods can also return an io.Reader of the raw JSON result,
+// closed once written if it's an io.Closer. Once the response started, an error of StreamJSON can't be reported to
+// the client: the response is left truncated.
+type JSONStreamer interface {
+	// StreamJSON writes the JSON encoding of the result
+	StreamJSON(w io.Writer) error
+}
+
+// JSONStreamerFunc is a JSONStreamer function.
+type JSONStreamerFunc func(w io.Writer) error
+
+func (f JSONStreamerFunc) StreamJSON(w io.Writer) error { return f(w) }
+
+type readerStreamer struct {
+	r io.Reader
+}
+
+func (s readerStreamer) StreamJSON(w io.Writer) error {
+	_, err := io.Copy(w, s.r)
+	if closeErr := s.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s readerStreamer) Close() error {
+	if closer, ok := s.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// asJSONStreamer returns the result as a JSONStreamer if it's streamed
+func asJSONStreamer(result any) JSONStreamer {
+	switch r := result.(type) {
+	case JSONStreamer:
+		return r
+	case io.Reader:
+		return readerStreamer{r: r}
+	}
+	return nil
+}
+
+// discardStream releases the result of a notification
+func discardStream(stream JSONStreamer) {
+	if closer, ok := stream.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// writeJSONRPCStream writes the response with the streamed result
+func writeJSONRPCStream(w io.Writer, id any, stream JSONStreamer) error {
+	marshaledID, err := json.Marshal(id)
+	if err != nil {
+		discardStream(stream)
+		return err
+	}
+	bw := bufio.NewWriterSize(w, streamBufferSize)
+	_, _ = bw.WriteString(`{"jsonrpc":"2.0","id":`)
+	_, _ = bw.Write(marshaledID)
+	_, _ = bw.WriteString(`,"result":`)
+	if err := stream.StreamJSON(bw); err != nil {
+		return err
+	}
+	_, _ = bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func (h *JSONRPCHandler) writeJSONRPCStreamResponse(w http.ResponseWriter, codec Codec, res handledRequest) {
+	w.Header().Set("Content-Type", "application/json")
+	if len(h.Compression) > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	// the size of the result is unknown, it's compressed whenever the client accepts it
+	var out io.Writer = w
+	var cw io.WriteCloser
+	if codec != nil {
+		var err error
+		if cw, err = codec.NewWriter(w); err == nil {
+			w.Header().Set("Content-Encoding", codec.Encoding())
+			out = cw
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+
+	err := writeJSONRPCStream(out, res.response.ID, res.stream)
+	if cw != nil {
+		if closeErr := cw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && h.Log != nil {
+		h.Log.Warn("failed to write streamed response", slog.Any("error", err), slog.String("method", res.method),
+			slog.String("serverName", h.ServerName))
+	}
+}
@@ -0,0 +1,89 @@
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStreamedResults(t *testing.T) {
+	reader := &closeRecorder{}
+	handler, err := NewJSONRPCHandler(Methods{
+		"reader": func(ctx context.Context) (io.Reader, error) {
+			reader.Reader = strings.NewReader(`{"trace":[1,2,3]}`)
+			return reader, nil
+		},
+		"streamer": func(ctx context.Context, n int) (JSONStreamer, error) {
+			return JSONStreamerFunc(func(w io.Writer) error {
+				enc := json.NewEncoder(w)
+				_, _ = io.WriteString(w, "[")
+				for i := 0; i < n; i++ {
+					if i > 0 {
+						_, _ = io.WriteString(w, ",")
+					}
+					if err := enc.Encode(i); err != nil {
+						return err
+					}
+				}
+				_, err := io.WriteString(w, "]")
+				return err
+			}), nil
+		},
+	}, JSONRPCHandlerOpts{Compression: []Codec{GzipCodec}})
+	require.NoError(t, err)
+
+	call := func(body string, acceptEncoding string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := call(`{"jsonrpc":"2.0","id":"a","method":"reader","params":[]}`, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":"a","result":{"trace":[1,2,3]}}`, rr.Body.String())
+	require.True(t, reader.closed)
+
+	rr = call(`{"jsonrpc":"2.0","id":1,"method":"streamer","params":[3]}`, "")
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":[0,1,2]}`, rr.Body.String())
+
+	// streamed results are compressed regardless of their size
+	rr = call(`{"jsonrpc":"2.0","id":1,"method":"streamer","params":[1000]}`, "gzip")
+	require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	decompressed, err := GzipCodec.NewReader(rr.Body)
+	require.NoError(t, err)
+	var res struct {
+		Result []int `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(decompressed).Decode(&res))
+	require.Len(t, res.Result, 1000)
+	require.Equal(t, 999, res.Result[999])
+
+	// the result of a notification is discarded
+	reader.closed = false
+	rr = call(`{"jsonrpc":"2.0","method":"reader","params":[]}`, "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.True(t, reader.closed)
+}
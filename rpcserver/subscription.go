@@ -0,0 +1,216 @@
+package rpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	ErrSubscriptionClosed = errors.New("subscription is closed")
+	ErrMustHaveNotifier   = errors.New("subscription function must have *Notifier as a second argument")
+	ErrMustReturnOnlyErr  = errors.New("subscription function must return only an error")
+
+	errSubscriptionsWebSocketOnly = "subscriptions are only supported over websocket"
+)
+
+// Subscriptions maps the subscription names to subscription functions like:
+// func Foo(context, *Notifier, int) error
+//
+// The function is called on subscribe with the params following the name and must return quickly, pushing the
+// notifications with the notifier in the background until the context is done (on unsubscribe or disconnect). If
+// it returns an error the subscription is rejected.
+type Subscriptions map[string]any
+
+// Notifier pushes the notifications of a subscription to the websocket client.
+type Notifier struct {
+	id     string
+	conn   *wsConn
+	method string
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	active  bool
+	closed  bool
+	pending []any
+}
+
+type subscriptionNotification struct {
+	JSONRPC string                   `json:"jsonrpc"`
+	Method  string                   `json:"method"`
+	Params  subscriptionNotifyParams `json:"params"`
+}
+
+type subscriptionNotifyParams struct {
+	Subscription string `json:"subscription"`
+	Result       any    `json:"result"`
+}
+
+// ID returns the subscription id returned to the client.
+func (n *Notifier) ID() string {
+	return n.id
+}
+
+// Notify sends the result to the client as a notification of the subscription. Results notified before the
+// subscription id is sent to the client are queued.
+func (n *Notifier) Notify(result any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return ErrSubscriptionClosed
+	}
+	if !n.active {
+		n.pending = append(n.pending, result)
+		return nil
+	}
+	return n.send(result)
+}
+
+func (n *Notifier) send(result any) error {
+	return n.conn.writeJSON(subscriptionNotification{
+		JSONRPC: "2.0",
+		Method:  n.method,
+		Params:  subscriptionNotifyParams{Subscription: n.id, Result: result},
+	})
+}
+
+// activate sends the queued notifications, once the subscription id was sent
+func (n *Notifier) activate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.active = true
+	for _, result := range n.pending {
+		if n.send(result) != nil {
+			break
+		}
+	}
+	n.pending = nil
+}
+
+func (n *Notifier) close() {
+	n.mu.Lock()
+	n.closed = true
+	n.pending = nil
+	n.mu.Unlock()
+	n.cancel()
+}
+
+func getSubscriptionTypes(fn any) (methodHandler, error) {
+	method, err := getMethodTypes(fn)
+	if err != nil {
+		return methodHandler{}, err
+	}
+	if len(method.in) < 2 || method.in[1] != reflect.TypeOf((*Notifier)(nil)) {
+		return methodHandler{}, ErrMustHaveNotifier
+	}
+	if len(method.out) != 1 {
+		return methodHandler{}, ErrMustReturnOnlyErr
+	}
+	return method, nil
+}
+
+func newSubscriptionID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hexutil.Encode(id[:])
+}
+
+func (h *JSONRPCHandler) subscribe(ctx context.Context, conn *wsConn, req jsonRPCRequest) handledRequest {
+	params, err := positionalParams(req.Params, nil, 0)
+	if err != nil {
+		incIncorrectRequest(h.ServerName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInvalidParams, err.Error()), method: req.Method}
+	}
+	var name string
+	if len(params) == 0 || json.Unmarshal(params[0], &name) != nil {
+		incIncorrectRequest(h.ServerName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInvalidParams, "first param must be the subscription name"), method: req.Method}
+	}
+	sub, ok := h.subscriptions[name]
+	if !ok {
+		incIncorrectRequest(h.ServerName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInvalidParams, "unknown subscription: "+name), method: req.Method}
+	}
+	args, err := extractArgumentsFromJSONparamsArray(sub.in[2:], params[1:])
+	if err != nil {
+		incIncorrectRequest(h.ServerName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInvalidParams, err.Error()), method: req.Method}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	n := &Notifier{
+		id:     newSubscriptionID(),
+		conn:   conn,
+		method: h.notificationMethod,
+		cancel: cancel,
+	}
+	args = append([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(n)}, args...)
+	results, err := callFunc(sub.fn, args)
+	if err != nil {
+		cancel()
+		incRequestErrorCount(req.Method, h.ServerName)
+		h.methodPanicked(req.Method, err)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInternalError, errInternal), method: req.Method}
+	}
+	if errVal := results[0]; !errVal.IsNil() {
+		cancel()
+		incRequestErrorCount(req.Method, h.ServerName)
+		msg := errVal.Interface().(error).Error() //nolint:forcetypeassert
+		return handledRequest{response: newJSONRPCError(req.ID, CodeCustomError, msg), method: req.Method}
+	}
+
+	conn.subscriptionsMu.Lock()
+	conn.subscriptions[n.id] = n
+	conn.subscriptionsMu.Unlock()
+	setActiveSubscriptions(h.ServerName, h.activeSubscriptions.Add(1))
+
+	result, _ := json.Marshal(n.id)
+	rawResult := json.RawMessage(result)
+	return handledRequest{
+		response: jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: &rawResult},
+		method:   req.Method,
+		notifier: n,
+	}
+}
+
+func (h *JSONRPCHandler) unsubscribe(conn *wsConn, req jsonRPCRequest) handledRequest {
+	params, err := positionalParams(req.Params, nil, 0)
+	var id string
+	if err != nil || len(params) != 1 || json.Unmarshal(params[0], &id) != nil {
+		incIncorrectRequest(h.ServerName)
+		return handledRequest{response: newJSONRPCError(req.ID, CodeInvalidParams, "param must be the subscription id"), method: req.Method}
+	}
+
+	conn.subscriptionsMu.Lock()
+	n, found := conn.subscriptions[id]
+	delete(conn.subscriptions, id)
+	conn.subscriptionsMu.Unlock()
+	if found {
+		n.close()
+		setActiveSubscriptions(h.ServerName, h.activeSubscriptions.Add(-1))
+	}
+
+	result, _ := json.Marshal(found)
+	rawResult := json.RawMessage(result)
+	return handledRequest{response: jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: &rawResult}, method: req.Method}
+}
+
+// closeSubscriptions ends the subscriptions of the closed connection
+func (h *JSONRPCHandler) closeSubscriptions(conn *wsConn) {
+	conn.subscriptionsMu.Lock()
+	subs := conn.subscriptions
+	conn.subscriptions = make(map[string]*Notifier)
+	conn.subscriptionsMu.Unlock()
+
+	for _, n := range subs {
+		n.close()
+	}
+	if len(subs) > 0 {
+		setActiveSubscriptions(h.ServerName, h.activeSubscriptions.Add(-int64(len(subs))))
+	}
+}
@@ -0,0 +1,131 @@
+package rpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type testNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string `json:"subscription"`
+		Result       int    `json:"result"`
+	} `json:"params"`
+}
+
+func TestSubscriptions(t *testing.T) {
+	trigger := make(chan int)
+	stopped := make(chan struct{}, 1)
+	handler, err := NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{
+		EnableWebSocket: true,
+		Subscriptions: Subscriptions{
+			"numbers": func(ctx context.Context, n *Notifier, start int) error {
+				if start < 0 {
+					return errors.New("negative start") //nolint:goerr113
+				}
+				// queued until the subscription id is sent
+				require.NoError(t, n.Notify(start))
+				go func() {
+					defer func() { stopped <- struct{}{} }()
+					for {
+						select {
+						case <-ctx.Done():
+							require.ErrorIs(t, n.Notify(0), ErrSubscriptionClosed)
+							return
+						case i := <-trigger:
+							_ = n.Notify(i)
+						}
+					}
+				}()
+				return nil
+			},
+		},
+	})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	call := func(req string) json.RawMessage {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(req)))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		return msg
+	}
+	readNotification := func() testNotification {
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		var notification testNotification
+		require.NoError(t, json.Unmarshal(msg, &notification))
+		return notification
+	}
+
+	res := call(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["numbers",10]}`)
+	var subRes struct {
+		Result string `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(res, &subRes))
+	require.NotEmpty(t, subRes.Result)
+
+	notification := readNotification()
+	require.Equal(t, "eth_subscription", notification.Method)
+	require.Equal(t, subRes.Result, notification.Params.Subscription)
+	require.Equal(t, 10, notification.Params.Result)
+
+	trigger <- 11
+	require.Equal(t, 11, readNotification().Params.Result)
+
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"unknown subscription: unknown"}}`,
+		string(call(`{"jsonrpc":"2.0","id":2,"method":"eth_subscribe","params":["unknown"]}`)))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":3,"error":{"code":-32000,"message":"negative start"}}`,
+		string(call(`{"jsonrpc":"2.0","id":3,"method":"eth_subscribe","params":["numbers",-1]}`)))
+
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":4,"result":true}`,
+		string(call(`{"jsonrpc":"2.0","id":4,"method":"eth_unsubscribe","params":["`+subRes.Result+`"]}`)))
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not stopped on unsubscribe")
+	}
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":5,"result":false}`,
+		string(call(`{"jsonrpc":"2.0","id":5,"method":"eth_unsubscribe","params":["`+subRes.Result+`"]}`)))
+
+	// subscriptions end when the client disconnects
+	call(`{"jsonrpc":"2.0","id":6,"method":"eth_subscribe","params":["numbers",1]}`)
+	readNotification()
+	conn.Close()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("subscription not stopped on disconnect")
+	}
+
+	// subscriptions need websocket
+	resp, err := http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["numbers",1]}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var httpRes jsonRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&httpRes))
+	require.Equal(t, CodeMethodNotFound, httpRes.Error.Code)
+}
+
+func TestSubscriptionTypes(t *testing.T) {
+	_, err := getSubscriptionTypes(func(ctx context.Context, n *Notifier, arg int) error { return nil })
+	require.NoError(t, err)
+	_, err = getSubscriptionTypes(func(ctx context.Context, arg int) error { return nil })
+	require.ErrorIs(t, err, ErrMustHaveNotifier)
+	_, err = getSubscriptionTypes(func(ctx context.Context, n *Notifier) (int, error) { return 0, nil })
+	require.ErrorIs(t, err, ErrMustReturnOnlyErr)
+}
@@ -0,0 +1,82 @@
+package rpcserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/flashbots/go-utils/httplogger"
+)
+
+const spanName = "rpcserver.request"
+
+type spanKey struct{}
+
+// Span is the span of a request, see JSONRPCHandlerOpts.StartSpan. With OpenTelemetry:
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value any) {
+//		switch v := value.(type) {
+//		case int:
+//			s.Span.SetAttributes(attribute.Int(key, v))
+//		default:
+//			s.Span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+//		}
+//	}
+//	func (s otelSpan) AddEvent(name string)   { s.Span.AddEvent(name) }
+//	func (s otelSpan) RecordError(err error) { s.Span.RecordError(err); s.Span.SetStatus(codes.Error, err.Error()) }
+//	func (s otelSpan) End()                  { s.Span.End() }
+//
+//	opts.StartSpan = func(ctx context.Context, name string, header http.Header) (context.Context, rpcserver.Span) {
+//		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+//		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+//		return ctx, otelSpan{span}
+//	}
+type Span interface {
+	// SetAttribute sets an attribute, the values are strings or ints
+	SetAttribute(key string, value any)
+	// AddEvent records the end of a step of the request
+	AddEvent(name string)
+	RecordError(err error)
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value any) {}
+func (noopSpan) AddEvent(name string)               {}
+func (noopSpan) RecordError(err error)              {}
+func (noopSpan) End()                               {}
+
+// startSpan starts the span of a request if tracing is enabled, propagating the traceparent header into the context
+// (see httplogger.TraceContextFromContext)
+func (h *JSONRPCHandler) startSpan(ctx context.Context, header http.Header) (context.Context, Span) {
+	if h.StartSpan == nil {
+		return ctx, noopSpan{}
+	}
+	if _, found := httplogger.TraceContextFromContext(ctx); !found {
+		if tc, ok := httplogger.ParseTraceparent(header.Get(httplogger.TraceparentHeader)); ok {
+			ctx = httplogger.ContextWithTraceContext(ctx, tc)
+		}
+	}
+	ctx, span := h.StartSpan(ctx, spanName, header)
+	span.SetAttribute("rpc.system", "jsonrpc")
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// endSpan records the error of the response and ends the span
+func endSpan(span Span, res handledRequest) {
+	if res.response.Error != nil {
+		span.SetAttribute("rpc.jsonrpc.error_code", res.response.Error.Code)
+		span.SetAttribute("rpc.jsonrpc.error_message", res.response.Error.Message)
+		span.RecordError(res.response.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,121 @@
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/flashbots/go-utils/httplogger"
+	"github.com/flashbots/go-utils/signature"
+	"github.com/stretchr/testify/require"
+)
+
+type testSpan struct {
+	mu         sync.Mutex
+	attributes map[string]any
+	events     []string
+	errors     []error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+func (s *testSpan) AddEvent(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, name)
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, err)
+}
+
+func (s *testSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+func TestTracing(t *testing.T) {
+	var span *testSpan
+	handler := testHandler(JSONRPCHandlerOpts{
+		VerifyRequestSignatureFromHeader: true,
+		ExtractOriginFromHeader:          true,
+		StartSpan: func(ctx context.Context, name string, header http.Header) (context.Context, Span) {
+			require.Equal(t, spanName, name)
+			span = &testSpan{attributes: make(map[string]any)}
+			return ctx, span
+		},
+	})
+	signer, err := signature.NewRandomSigner()
+	require.NoError(t, err)
+
+	call := func(body string) {
+		header, err := signer.Create([]byte(body))
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(signature.HTTPHeader, header)
+		request.Header.Set("X-Flashbots-Origin", "test-origin")
+		request.Header.Set(httplogger.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, request)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"function","params":[1]}`
+	call(body)
+	require.True(t, span.ended)
+	require.Equal(t, map[string]any{
+		"rpc.system":               "jsonrpc",
+		"rpc.method":               "function",
+		"rpc.jsonrpc.request_size": len(body),
+		"flashbots.signer":         signer.Address().Hex(),
+		"flashbots.origin":         "test-origin",
+	}, span.attributes)
+	require.Equal(t, []string{"body read", "signature verified", "request parsed", "method called", "response written"}, span.events)
+	require.Empty(t, span.errors)
+
+	call(`{"jsonrpc":"2.0","id":1,"method":"function","params":[-1]}`)
+	require.True(t, span.ended)
+	require.Equal(t, CodeCustomError, span.attributes["rpc.jsonrpc.error_code"])
+	require.Len(t, span.errors, 1)
+	require.EqualError(t, span.errors[0], "custom error")
+}
+
+func TestTracingContext(t *testing.T) {
+	var traceContext httplogger.TraceContext
+	handler, err := NewJSONRPCHandler(Methods{
+		"trace": func(ctx context.Context) error {
+			var found bool
+			traceContext, found = httplogger.TraceContextFromContext(ctx)
+			require.True(t, found)
+			require.IsType(t, &testSpan{}, spanFromContext(ctx))
+			return nil
+		},
+	}, JSONRPCHandlerOpts{
+		StartSpan: func(ctx context.Context, name string, header http.Header) (context.Context, Span) {
+			return ctx, &testSpan{attributes: make(map[string]any)}
+		},
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"trace","params":[]}`)))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(httplogger.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, request)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":null}`, rr.Body.String())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceContext.TraceID)
+}
@@ -0,0 +1,160 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	errWebSocketSignature = "request signatures can't be verified over websocket"
+
+	// requests of a websocket connection handled concurrently, reading the next messages waits above
+	maxWebSocketConcurrentRequests = 16
+	wsWriteTimeout                 = 10 * time.Second
+)
+
+// wsConn is a websocket connection, writes are serialized by writeMu
+type wsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]*Notifier
+}
+
+func (c *wsConn) writeJSON(v any) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// writeStream writes the response with the streamed result as a single message
+func (c *wsConn) writeStream(id any, stream JSONStreamer) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		discardStream(stream)
+		return err
+	}
+	w, err := c.conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		discardStream(stream)
+		return err
+	}
+	err = writeJSONRPCStream(w, id, stream)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// serveWebSocket upgrades the connection and handles each message as a request, the responses are written in the
+// order the requests complete
+func (h *JSONRPCHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.VerifyRequestSignatureFromHeader {
+		http.Error(w, errWebSocketSignature, http.StatusBadRequest)
+		incIncorrectRequest(h.ServerName)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.WebSocketCheckOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded with an HTTP error
+		incIncorrectRequest(h.ServerName)
+		return
+	}
+	conn.SetReadLimit(h.MaxRequestBodySizeBytes)
+	c := &wsConn{conn: conn, subscriptions: make(map[string]*Notifier)}
+	h.wsConnsMu.Lock()
+	h.wsConns[c] = struct{}{}
+	h.wsConnsMu.Unlock()
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		h.closeSubscriptions(c)
+		conn.Close()
+		h.wsConnsMu.Lock()
+		delete(h.wsConns, c)
+		h.wsConnsMu.Unlock()
+	}()
+
+	ctx := r.Context()
+	sem := make(chan struct{}, maxWebSocketConcurrentRequests)
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if err == websocket.ErrReadLimit {
+				incIncorrectRequest(h.ServerName)
+			}
+			return
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// the method panics are responded with an internal error, this only catches the panics writing the
+			// response, e.g. of a JSONStreamer, that net/http would have recovered for HTTP requests
+			defer func() {
+				if r := recover(); r != nil {
+					incInternalErrors(h.ServerName)
+					if h.Log != nil {
+						h.Log.Error("websocket request panicked", slog.Any("panic", r), slog.String("stack", string(debug.Stack())),
+							slog.String("serverName", h.ServerName))
+					}
+				}
+			}()
+
+			startAt := time.Now()
+			ctx, span := h.startSpan(ctx, r.Header)
+			span.SetAttribute("rpc.jsonrpc.request_size", len(msg))
+			res := h.handleRequest(ctx, r.Header, msg, c)
+			defer endSpan(span, res)
+			defer func() { h.logRequest(res, time.Since(startAt)) }()
+			incRequestCount(res.method, h.ServerName)
+			incRequestDuration(res.method, time.Since(startAt).Milliseconds(), h.ServerName)
+
+			if !res.notification {
+				var err error
+				if res.stream != nil {
+					err = c.writeStream(res.response.ID, res.stream)
+				} else {
+					err = c.writeJSON(res.response)
+				}
+				if err != nil && h.Log != nil {
+					h.Log.Debug("failed to write websocket response", slog.Any("error", err), slog.String("serverName", h.ServerName))
+				}
+			}
+			if res.notifier != nil {
+				res.notifier.activate()
+			}
+		}()
+	}
+}
+
+// closeWebSockets closes the open websocket connections, their in-flight requests still complete
+func (h *JSONRPCHandler) closeWebSockets() {
+	h.wsConnsMu.Lock()
+	defer h.wsConnsMu.Unlock()
+	for c := range h.wsConns {
+		c.conn.Close()
+	}
+}
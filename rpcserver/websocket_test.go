@@ -0,0 +1,195 @@
+package rpcserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/go-utils/ratelimit"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocket(t *testing.T) {
+	handler := testHandler(JSONRPCHandlerOpts{EnableWebSocket: true, MaxRequestBodySizeBytes: 1024})
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	testCases := map[string]struct {
+		request          string
+		expectedResponse string
+	}{
+		"success": {
+			request:          `{"jsonrpc":"2.0","id":1,"method":"function","params":[1]}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":1,"result":{"field":1}}`,
+		},
+		"error": {
+			request:          `{"jsonrpc":"2.0","id":"a","method":"function","params":[-1]}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":"a","error":{"code":-32000,"message":"custom error"}}`,
+		},
+		"method not found": {
+			request:          `{"jsonrpc":"2.0","id":2,"method":"not_found","params":[1]}`,
+			expectedResponse: `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found"}}`,
+		},
+		"invalid json": {
+			request:          `{"jsonrpc":"2.0"`,
+			expectedResponse: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(testCase.request)))
+			_, msg, err := conn.ReadMessage()
+			require.NoError(t, err)
+			require.JSONEq(t, testCase.expectedResponse, string(msg))
+		})
+	}
+
+	// notifications are not responded, the next message is the response of the next request
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"function","params":[1]}`)))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":3,"method":"function","params":[3]}`)))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":3,"result":{"field":3}}`, string(msg))
+
+	// plain HTTP requests are still served
+	res, err := http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"function","params":[1]}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// messages above the size limit close the connection
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat(" ", 2048))))
+	_, _, err = conn.ReadMessage()
+	require.Error(t, err)
+}
+
+func TestWebSocketDisabled(t *testing.T) {
+	httpServer := httptest.NewServer(testHandler(JSONRPCHandlerOpts{}))
+	defer httpServer.Close()
+
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	httpServer = httptest.NewServer(testHandler(JSONRPCHandlerOpts{EnableWebSocket: true, VerifyRequestSignatureFromHeader: true}))
+	defer httpServer.Close()
+	_, res, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestWebSocketPanic(t *testing.T) {
+	handler, err := NewJSONRPCHandler(Methods{
+		"panic": func(ctx context.Context) (int, error) { panic("x") },
+		"ok":    func(ctx context.Context) (int, error) { return 1, nil },
+	}, JSONRPCHandlerOpts{
+		EnableWebSocket: true,
+		Subscriptions: Subscriptions{
+			"panic": func(ctx context.Context, n *Notifier) error { panic("x") },
+		},
+	})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	call := func(request string) string {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(request)))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		return string(msg)
+	}
+
+	// the panics are responded with an internal error, the connection is still served
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"internal error"}}`,
+		call(`{"jsonrpc":"2.0","id":1,"method":"panic","params":[]}`))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"internal error"}}`,
+		call(`{"jsonrpc":"2.0","id":2,"method":"eth_subscribe","params":["panic"]}`))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":3,"result":1}`, call(`{"jsonrpc":"2.0","id":3,"method":"ok","params":[]}`))
+}
+
+func TestWebSocketSubscriptionLimits(t *testing.T) {
+	allowed := common.HexToAddress("0x1")
+	limiter := ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Name: "test-subscribe", Rate: 0.001, Burst: 1})
+	defer limiter.Close()
+
+	handler, err := NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{
+		EnableWebSocket: true,
+		ExtractUnverifiedRequestSignatureFromHeader: true,
+		AllowedSigners: []common.Address{allowed},
+		MethodOpts: map[string]MethodOpts{
+			"eth_subscribe": {RateLimit: &RateLimit{Limiter: limiter, By: RateLimitByMethod}},
+		},
+		Subscriptions: Subscriptions{
+			"heads": func(ctx context.Context, n *Notifier) error { return nil },
+		},
+	})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	dial := func(signer common.Address) func(request string) string {
+		header := http.Header{"X-Flashbots-Signature": []string{signer.Hex() + ":0x"}}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), header)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return func(request string) string {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(request)))
+			_, msg, err := conn.ReadMessage()
+			require.NoError(t, err)
+			return string(msg)
+		}
+	}
+	subscribe := `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["heads"]}`
+
+	// the subscription methods are restricted to the allowed signers
+	call := dial(common.HexToAddress("0x2"))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"signer is not allowed"}}`, call(subscribe))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"signer is not allowed"}}`,
+		call(`{"jsonrpc":"2.0","id":1,"method":"eth_unsubscribe","params":["0x1"]}`))
+
+	// and rate limited
+	call = dial(allowed)
+	require.NotContains(t, call(subscribe), "error")
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"rate limit exceeded"}}`, call(subscribe))
+
+	// and count against the concurrent requests
+	release := make(chan struct{})
+	handler, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{
+		EnableWebSocket:       true,
+		MaxConcurrentRequests: 1,
+		Subscriptions: Subscriptions{
+			"slow": func(ctx context.Context, n *Notifier) error {
+				<-release
+				return nil
+			},
+		},
+	})
+	require.NoError(t, err)
+	httpServer = httptest.NewServer(handler)
+	defer httpServer.Close()
+	slow := dial(common.Address{})
+	subscribed := make(chan string)
+	go func() { subscribed <- slow(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["slow"]}`) }()
+	require.Eventually(t, func() bool { return handler.inFlightCount.Load() == 1 }, time.Second, time.Millisecond)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"too many concurrent requests"}}`,
+		dial(common.Address{})(`{"jsonrpc":"2.0","id":1,"method":"eth_unsubscribe","params":["0x1"]}`))
+	close(release)
+	require.NotContains(t, <-subscribed, "error")
+
+	_, err = NewJSONRPCHandler(Methods{}, JSONRPCHandlerOpts{MethodOpts: map[string]MethodOpts{"eth_subscribe": {}}})
+	require.ErrorIs(t, err, ErrMethodOptsForUnknownMethod)
+}
@@ -0,0 +1,16 @@
+package rpctypes
+
+import "github.com/google/uuid"
+
+// Order is implemented by the orderflow args, e.g. to deduplicate or archive them regardless of their type
+type Order interface {
+	UniqueKey() uuid.UUID
+}
+
+var (
+	_ Order = (*EthSendBundleArgs)(nil)
+	_ Order = (*MevSendBundleArgs)(nil)
+	_ Order = (*EthSendRawTransactionArgs)(nil)
+	_ Order = (*EthCancelBundleArgs)(nil)
+	_ Order = (*BidSubsisideBlockArgs)(nil)
+)
@@ -170,7 +170,20 @@ func (b *EthSendBundleArgs) UniqueKey() uuid.UUID {
 	for _, txHash := range b.RevertingTxHashes {
 		_, _ = hash.Write(txHash.Bytes())
 	}
-	_, _ = hash.Write(b.SigningAddress.Bytes())
+	if b.SigningAddress != nil {
+		_, _ = hash.Write(b.SigningAddress.Bytes())
+	}
+	// optional fields are prefixed with a tag so that they can't be confused with each other
+	if b.ReplacementUUID != nil {
+		_, _ = hash.Write([]byte{1})
+		_, _ = hash.Write([]byte(*b.ReplacementUUID))
+	}
+	for i, value := range []*uint64{b.ReplacementNonce, b.MinTimestamp, b.MaxTimestamp} {
+		if value != nil {
+			_, _ = hash.Write([]byte{byte(i + 2)})
+			_ = binary.Write(hash, binary.LittleEndian, *value)
+		}
+	}
 	return uuidFromHash(hash)
 }
 
@@ -231,7 +244,12 @@ func uniqueKeyMevSendBundle(b *MevSendBundleArgs, hash hash.Hash) {
 		}
 		hash.Write([]byte(body.RevertMode))
 	}
-	_, _ = hash.Write(b.Metadata.Signer.Bytes())
+	if b.Metadata != nil && b.Metadata.Signer != nil {
+		_, _ = hash.Write(b.Metadata.Signer.Bytes())
+	}
+	if b.Metadata != nil && b.Metadata.ReplacementNonce != nil {
+		_ = binary.Write(hash, binary.LittleEndian, int64(*b.Metadata.ReplacementNonce))
+	}
 }
 
 func (b *MevSendBundleArgs) Validate() (common.Hash, error) {
@@ -277,7 +295,9 @@ func (tx *EthSendRawTransactionArgs) UniqueKey() uuid.UUID {
 func (b *EthCancelBundleArgs) UniqueKey() uuid.UUID {
 	hash := newHash()
 	_, _ = hash.Write([]byte(b.ReplacementUUID))
-	_, _ = hash.Write(b.SigningAddress.Bytes())
+	if b.SigningAddress != nil {
+		_, _ = hash.Write(b.SigningAddress.Bytes())
+	}
 	return uuidFromHash(hash)
 }
 
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -136,3 +138,46 @@ func TestEthsendRawTransactionArgsJSON(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, rawTransaction, roundtripRawTransaction)
 }
+
+func TestUniqueKeyWithoutSigner(t *testing.T) {
+	bundle := EthSendBundleArgs{Txs: []hexutil.Bytes{{1}}}
+	unsigned := bundle.UniqueKey()
+	signer := common.HexToAddress("0x1")
+	bundle.SigningAddress = &signer
+	require.NotEqual(t, unsigned, bundle.UniqueKey())
+
+	mevBundle := MevSendBundleArgs{}
+	unsigned = mevBundle.UniqueKey()
+	mevBundle.Metadata = &MevBundleMetadata{Signer: &signer}
+	require.NotEqual(t, unsigned, mevBundle.UniqueKey())
+
+	cancel := EthCancelBundleArgs{ReplacementUUID: "uuid"}
+	unsigned = cancel.UniqueKey()
+	cancel.SigningAddress = &signer
+	require.NotEqual(t, unsigned, cancel.UniqueKey())
+}
+
+func TestUniqueKeyReplacement(t *testing.T) {
+	bundle := EthSendBundleArgs{Txs: []hexutil.Bytes{{1}}, BlockNumber: 1}
+	keys := map[uuid.UUID]bool{bundle.UniqueKey(): true}
+
+	replacementUUID := "uuid"
+	bundle.ReplacementUUID = &replacementUUID
+	keys[bundle.UniqueKey()] = true
+	nonce := uint64(1)
+	bundle.ReplacementNonce = &nonce
+	keys[bundle.UniqueKey()] = true
+	bundle.ReplacementNonce = nil
+	bundle.MinTimestamp = &nonce
+	keys[bundle.UniqueKey()] = true
+	bundle.MinTimestamp = nil
+	bundle.MaxTimestamp = &nonce
+	keys[bundle.UniqueKey()] = true
+	require.Len(t, keys, 5)
+
+	mevNonce := 1
+	mevBundle := MevSendBundleArgs{ReplacementUUID: "uuid"}
+	unsigned := mevBundle.UniqueKey()
+	mevBundle.Metadata = &MevBundleMetadata{ReplacementNonce: &mevNonce}
+	require.NotEqual(t, unsigned, mevBundle.UniqueKey())
+}
@@ -16,6 +16,10 @@ import (
 // HTTPHeader is the name of the X-Flashbots-Signature header.
 const HTTPHeader = "X-Flashbots-Signature"
 
+// TimestampHTTPHeader is the name of the X-Flashbots-Timestamp header, with the unix time of the request in milliseconds.
+// When set, the signature covers it: the signed payload is TimestampedBody(timestamp, body).
+const TimestampHTTPHeader = "X-Flashbots-Timestamp"
+
 var (
 	ErrNoSignature      = errors.New("no signature provided")
 	ErrInvalidSignature = errors.New("invalid signature provided")
@@ -72,6 +76,14 @@ func Verify(header string, body []byte) (common.Address, error) {
 	return recoveredSigner, nil
 }
 
+// TimestampedBody returns the payload signed with a X-Flashbots-Timestamp header: the timestamp, a colon and the body.
+func TimestampedBody(timestamp string, body []byte) []byte {
+	payload := make([]byte, 0, len(timestamp)+1+len(body))
+	payload = append(payload, timestamp...)
+	payload = append(payload, ':')
+	return append(payload, body...)
+}
+
 type Signer struct {
 	privateKey *ecdsa.PrivateKey
 	address    common.Address
@@ -0,0 +1,113 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig configures ACMEManager.
+type ACMEConfig struct {
+	// Hosts are the public hostnames to obtain certificates for, other hosts are refused by the ACME server
+	Hosts []string
+	// Email is the contact of the ACME account, optional
+	Email string
+	// CacheDir persists the account key and the certificates, so they survive restarts, optional but recommended
+	CacheDir string
+	// DirectoryURL is the ACME directory, Let's Encrypt production by default
+	DirectoryURL string
+
+	// FallbackValidFor is the validity of the self-signed certificate served when ACME is unavailable, 1 year by default
+	FallbackValidFor time.Duration
+	// OnFallback is called when the self-signed certificate is served because of the ACME error
+	OnFallback func(serverName string, err error)
+}
+
+// ACMEManager obtains and renews certificates via ACME (e.g. Let's Encrypt)
+// for services with public hostnames, falling back to a self-signed
+// certificate (see GenerateX509KeyPair) when ACME is unavailable. Use
+// GetCertificate as tls.Config callback, or TLSConfig.
+type ACMEManager struct {
+	cfg      ACMEConfig
+	manager  *autocert.Manager
+	fallback cryptotls.Certificate
+}
+
+// NewACMEManager creates an ACMEManager. Certificates are obtained on the
+// first handshake for each host, via the TLS-ALPN-01 challenge, or HTTP-01
+// when HTTPHandler is served on port 80.
+func NewACMEManager(cfg ACMEConfig) (*ACMEManager, error) {
+	if cfg.FallbackValidFor <= 0 {
+		cfg.FallbackValidFor = 365 * 24 * time.Hour
+	}
+	fallback, _, err := GenerateX509KeyPair(cfg.FallbackValidFor, cfg.Hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Hosts...),
+		Email:      cfg.Email,
+	}
+	if cfg.CacheDir != "" {
+		manager.Cache = autocert.DirCache(cfg.CacheDir)
+	}
+	if cfg.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+
+	return &ACMEManager{
+		cfg:      cfg,
+		manager:  manager,
+		fallback: fallback,
+	}, nil
+}
+
+// GetCertificate returns the ACME certificate of the requested host, or the
+// self-signed one if it can't be obtained.
+func (m *ACMEManager) GetCertificate(hello *cryptotls.ClientHelloInfo) (*cryptotls.Certificate, error) {
+	cert, err := m.manager.GetCertificate(hello)
+	if err == nil {
+		return cert, nil
+	}
+	for _, proto := range hello.SupportedProtos {
+		if proto == acme.ALPNProto {
+			// a failed challenge must not be answered with another certificate
+			return nil, err
+		}
+	}
+	if m.cfg.OnFallback != nil {
+		m.cfg.OnFallback(hello.ServerName, err)
+	}
+	return &m.fallback, nil
+}
+
+// HTTPHandler serves the HTTP-01 challenge, passing other requests to
+// fallback (redirecting to HTTPS if nil).
+func (m *ACMEManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return m.manager.HTTPHandler(fallback)
+}
+
+// TLSConfig returns a modern server tls.Config serving the certificates and
+// answering TLS-ALPN-01 challenges.
+func (m *ACMEManager) TLSConfig() *cryptotls.Config {
+	config := NewModernServerConfig()
+	config.GetCertificate = m.GetCertificate
+	config.NextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+	return config
+}
+
+// Obtain obtains the certificates of all hosts ahead of the first handshakes,
+// e.g. at startup to detect ACME issues early.
+func (m *ACMEManager) Obtain() error {
+	for _, host := range m.cfg.Hosts {
+		if _, err := m.manager.GetCertificate(&cryptotls.ClientHelloInfo{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
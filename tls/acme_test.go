@@ -0,0 +1,43 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+func TestACMEFallback(t *testing.T) {
+	// ACME directory being down
+	directory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer directory.Close()
+
+	var fallbacks []string
+	m, err := NewACMEManager(ACMEConfig{
+		Hosts:        []string{"builder.example.com"},
+		CacheDir:     t.TempDir(),
+		DirectoryURL: directory.URL,
+		OnFallback: func(serverName string, err error) {
+			fallbacks = append(fallbacks, serverName)
+		},
+	})
+	require.NoError(t, err)
+
+	require.Error(t, m.Obtain())
+	cert, err := m.GetCertificate(&cryptotls.ClientHelloInfo{ServerName: "builder.example.com"})
+	require.NoError(t, err)
+	leaf, err := leaf(cert)
+	require.NoError(t, err)
+	require.Equal(t, []string{"builder.example.com"}, leaf.DNSNames)
+	require.Equal(t, []string{"builder.example.com"}, fallbacks)
+
+	// challenges are not answered with the fallback
+	_, err = m.GetCertificate(&cryptotls.ClientHelloInfo{ServerName: "builder.example.com", SupportedProtos: []string{acme.ALPNProto}})
+	require.Error(t, err)
+	require.Contains(t, m.TLSConfig().NextProtos, acme.ALPNProto)
+}
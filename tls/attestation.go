@@ -0,0 +1,102 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Certificate extensions carrying attestation quotes, as used by RA-TLS
+var (
+	OIDSGXQuote = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 0}
+	OIDTDXQuote = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 5, 5, 1, 6}
+)
+
+var (
+	ErrNoAttestation      = errors.New("certificate has no attestation extension")
+	ErrNoPeerCertificates = errors.New("no peer certificates")
+)
+
+// AttestFunc produces an attestation quote (e.g. a TDX or SGX quote) with the
+// report data, which binds the quote to the key of the certificate.
+type AttestFunc func(reportData [64]byte) (quote []byte, err error)
+
+// VerifyQuoteFunc verifies an attestation quote, including that it contains
+// the report data. The quote format and its verification are platform
+// specific.
+type VerifyQuoteFunc func(quote []byte, reportData [64]byte) error
+
+// AttestationReportData returns the report data binding a quote to the public
+// key: the SHA-512 hash of its DER encoded SubjectPublicKeyInfo.
+func AttestationReportData(pub crypto.PublicKey) ([64]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return [64]byte{}, err
+	}
+	return sha512.Sum512(der), nil
+}
+
+// GenerateAttestedTLS is GenerateTLS embedding an attestation quote of the
+// generated key in the certificate extension with the oid (e.g. OIDTDXQuote),
+// for RA-TLS style connections between enclaves. The certificate can be used
+// for server and client auth.
+func GenerateAttestedTLS(validFor time.Duration, hosts []string, oid asn1.ObjectIdentifier, attest AttestFunc, opts ...CertOption) (cert, key []byte, err error) {
+	opts = append([]CertOption{
+		WithExtKeyUsage(x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth),
+		WithAttestation(oid, attest),
+	}, opts...)
+	return GenerateTLS(validFor, hosts, opts...)
+}
+
+// ExtractAttestation returns the value of the extension with the oid.
+func ExtractAttestation(cert *x509.Certificate, oid asn1.ObjectIdentifier) ([]byte, error) {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return ext.Value, nil
+		}
+	}
+	return nil, ErrNoAttestation
+}
+
+// VerifyAttestation extracts the quote from the certificate and verifies it
+// with verify, against the report data of the certificate's public key.
+func VerifyAttestation(cert *x509.Certificate, oid asn1.ObjectIdentifier, verify VerifyQuoteFunc) error {
+	quote, err := ExtractAttestation(cert, oid)
+	if err != nil {
+		return err
+	}
+	reportData, err := AttestationReportData(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	if err := verify(quote, reportData); err != nil {
+		return fmt.Errorf("invalid attestation: %w", err)
+	}
+	return nil
+}
+
+// VerifyPeerAttestation returns a tls.Config.VerifyPeerCertificate callback
+// verifying the attestation of the peer's certificate. Attested certificates
+// are self-signed, so it is meant to be used with InsecureSkipVerify (clients)
+// or ClientAuth RequireAnyClientCert (servers): the attestation replaces the
+// verification of the certificate chain.
+func VerifyPeerAttestation(oid asn1.ObjectIdentifier, verify VerifyQuoteFunc) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return ErrNoPeerCertificates
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return fmt.Errorf("certificate is not valid at %s", now.Format(time.RFC3339))
+		}
+		return VerifyAttestation(cert, oid, verify)
+	}
+}
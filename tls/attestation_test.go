@@ -0,0 +1,73 @@
+package tls
+
+import (
+	"bytes"
+	cryptotls "crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fake quotes are the report data with a prefix
+var quotePrefix = []byte("quote:")
+
+func fakeAttest(reportData [64]byte) ([]byte, error) {
+	return append(append([]byte{}, quotePrefix...), reportData[:]...), nil
+}
+
+func fakeVerify(quote []byte, reportData [64]byte) error {
+	if !bytes.Equal(quote, append(append([]byte{}, quotePrefix...), reportData[:]...)) {
+		return errors.New("report data mismatch")
+	}
+	return nil
+}
+
+func TestAttestation(t *testing.T) {
+	cert, key, err := GenerateAttestedTLS(time.Hour, []string{"127.0.0.1"}, OIDTDXQuote, fakeAttest)
+	require.NoError(t, err)
+	x509Cert, err := ParseCertificatePEM(cert)
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyAttestation(x509Cert, OIDTDXQuote, fakeVerify))
+	_, err = ExtractAttestation(x509Cert, OIDSGXQuote)
+	require.ErrorIs(t, err, ErrNoAttestation)
+
+	// a quote of another key is rejected
+	other, _, err := GenerateAttestedTLS(time.Hour, nil, OIDTDXQuote, fakeAttest)
+	require.NoError(t, err)
+	otherCert, err := ParseCertificatePEM(other)
+	require.NoError(t, err)
+	otherQuote, err := ExtractAttestation(otherCert, OIDTDXQuote)
+	require.NoError(t, err)
+	reportData, err := AttestationReportData(x509Cert.PublicKey)
+	require.NoError(t, err)
+	require.Error(t, fakeVerify(otherQuote, reportData))
+
+	certificate, err := cryptotls.X509KeyPair(cert, key)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &cryptotls.Config{Certificates: []cryptotls.Certificate{certificate}, MinVersion: cryptotls.VersionTLS13}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{
+		InsecureSkipVerify:    true, //nolint:gosec
+		VerifyPeerCertificate: VerifyPeerAttestation(OIDTDXQuote, fakeVerify),
+		MinVersion:            cryptotls.VersionTLS13,
+	}}}
+	res, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{
+		InsecureSkipVerify:    true, //nolint:gosec
+		VerifyPeerCertificate: VerifyPeerAttestation(OIDSGXQuote, fakeVerify),
+		MinVersion:            cryptotls.VersionTLS13,
+	}}}
+	_, err = client.Get(srv.URL)
+	require.ErrorContains(t, err, ErrNoAttestation.Error())
+}
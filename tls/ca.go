@@ -0,0 +1,219 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidCertificatePEM = errors.New("failed to decode PEM certificate")
+	ErrInvalidKeyPEM         = errors.New("failed to decode PEM private key")
+	ErrNotCA                 = errors.New("certificate is not a CA")
+	ErrUnsupportedKey        = errors.New("private key can not sign")
+)
+
+// CA is a certificate authority signing server and client certificates, e.g.
+// for mutual TLS between operators.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+
+	// CertPEM and KeyPEM are the PEM encodings of Cert and Key
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// GenerateCA generates a self-signed CA certificate and key.
+func GenerateCA(validFor time.Duration, commonName string) (*CA, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(validFor),
+
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, err
+	}
+	certPEM, err := encodeCertificate(derBytes)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodePrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return LoadCA(certPEM, keyPEM)
+}
+
+// LoadCA loads a CA from its PEM encoded certificate and key.
+func LoadCA(certPEM, keyPEM []byte) (*CA, error) {
+	cert, err := ParseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, ErrNotCA
+	}
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, Key: key, CertPEM: certPEM, KeyPEM: keyPEM}, nil
+}
+
+// CertPool returns a pool containing the CA certificate, to verify the
+// certificates signed by it.
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// SignCertificate generates a key and signs a certificate for it with the CA,
+// based on the template. The serial number and validity start are filled in if
+// missing.
+func SignCertificate(ca *CA, template *x509.Certificate) (cert, key []byte, err error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl := *template
+	if tmpl.SerialNumber == nil {
+		if tmpl.SerialNumber, err = newSerialNumber(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = time.Now()
+	}
+	if tmpl.NotAfter.After(ca.Cert.NotAfter) {
+		// a certificate outliving its CA would be rejected by verifiers anyway
+		tmpl.NotAfter = ca.Cert.NotAfter
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, ca.Cert, &priv.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err = encodeCertificate(derBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err = encodePrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// GenerateServerCertificate generates a server certificate for the hosts,
+// signed by the CA.
+func GenerateServerCertificate(ca *CA, validFor time.Duration, hosts []string) (cert, key []byte, err error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: firstOrEmpty(hosts)},
+		NotAfter:    time.Now().Add(validFor),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	addHosts(template, hosts)
+	return SignCertificate(ca, template)
+}
+
+// GenerateClientCertificate generates a client certificate identified by the
+// common name, signed by the CA.
+func GenerateClientCertificate(ca *CA, validFor time.Duration, commonName string) (cert, key []byte, err error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		NotAfter:    time.Now().Add(validFor),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	return SignCertificate(ca, template)
+}
+
+// NewMTLSServerConfig returns a tls.Config serving the certificate and
+// requiring client certificates signed by one of clientCAs.
+func NewMTLSServerConfig(certPEM, keyPEM []byte, clientCAs *x509.CertPool) (*cryptotls.Config, error) {
+	certificate, err := cryptotls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	config := NewModernServerConfig()
+	config.Certificates = []cryptotls.Certificate{certificate}
+	config.ClientAuth = cryptotls.RequireAndVerifyClientCert
+	config.ClientCAs = clientCAs
+	return config, nil
+}
+
+// NewMTLSClientConfig returns a tls.Config presenting the client certificate
+// and verifying the server certificate against rootCAs.
+func NewMTLSClientConfig(certPEM, keyPEM []byte, rootCAs *x509.CertPool) (*cryptotls.Config, error) {
+	certificate, err := cryptotls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	config := NewModernClientConfig()
+	config.Certificates = []cryptotls.Certificate{certificate}
+	config.RootCAs = rootCAs
+	return config, nil
+}
+
+func parsePrivateKeyPEM(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, ErrInvalidKeyPEM
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return signer, nil
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
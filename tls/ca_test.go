@@ -0,0 +1,83 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := GenerateCA(time.Hour, "BuilderNet test CA")
+	require.NoError(t, err)
+
+	serverCert, serverKey, err := GenerateServerCertificate(ca, time.Hour, []string{"127.0.0.1", "localhost"})
+	require.NoError(t, err)
+	clientCert, clientKey, err := GenerateClientCertificate(ca, time.Hour, "operator-1")
+	require.NoError(t, err)
+
+	serverConfig, err := NewMTLSServerConfig(serverCert, serverKey, ca.CertPool())
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = serverConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	clientConfig, err := NewMTLSClientConfig(clientCert, clientKey, ca.CertPool())
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+	res, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, "operator-1", string(body))
+
+	// without client certificate
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{RootCAs: ca.CertPool()}}}
+	_, err = client.Get(srv.URL)
+	require.Error(t, err)
+
+	// CA certificates can be loaded back
+	loaded, err := LoadCA(ca.CertPEM, ca.KeyPEM)
+	require.NoError(t, err)
+	require.Equal(t, ca.Cert.Raw, loaded.Cert.Raw)
+	_, err = LoadCA(serverCert, serverKey)
+	require.ErrorIs(t, err, ErrNotCA)
+}
+
+func TestGenerateTLSOptions(t *testing.T) {
+	cert, _, err := GenerateTLS(time.Hour, []string{"localhost"},
+		WithSubject(pkix.Name{Organization: []string{"Flashbots"}, CommonName: "builder"}),
+		WithClientAuth(),
+		WithIsCA(false),
+		WithSerialNumber(func() (*big.Int, error) { return big.NewInt(42), nil }),
+	)
+	require.NoError(t, err)
+	x509Cert, err := ParseCertificatePEM(cert)
+	require.NoError(t, err)
+
+	require.Equal(t, "builder", x509Cert.Subject.CommonName)
+	require.Equal(t, []string{"Flashbots"}, x509Cert.Subject.Organization)
+	require.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, x509Cert.ExtKeyUsage)
+	require.False(t, x509Cert.IsCA)
+	require.Zero(t, x509Cert.KeyUsage&x509.KeyUsageCertSign)
+	require.Equal(t, int64(42), x509Cert.SerialNumber.Int64())
+
+	// defaults are unchanged
+	cert, _, err = GenerateTLS(time.Hour, []string{"localhost"})
+	require.NoError(t, err)
+	x509Cert, err = ParseCertificatePEM(cert)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Acme"}, x509Cert.Subject.Organization)
+	require.True(t, x509Cert.IsCA)
+}
@@ -0,0 +1,60 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+)
+
+// IntermediateCipherSuites are the TLS 1.2 cipher suites of the intermediate
+// presets: ECDHE key exchange (forward secrecy) with AEAD ciphers only. TLS 1.3
+// cipher suites are not configurable, all of them are secure.
+var IntermediateCipherSuites = []uint16{
+	cryptotls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	cryptotls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	cryptotls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	cryptotls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	cryptotls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// NewModernServerConfig returns a server tls.Config accepting TLS 1.3 only,
+// for services whose clients are under our control. Set Certificates or
+// GetCertificate on it. The key exchanges of the presets are the defaults of
+// crypto/tls, which include the post-quantum X25519MLKEM768 since Go 1.24.
+func NewModernServerConfig() *cryptotls.Config {
+	return &cryptotls.Config{
+		MinVersion: cryptotls.VersionTLS13,
+	}
+}
+
+// NewModernClientConfig returns a client tls.Config requiring TLS 1.3.
+func NewModernClientConfig() *cryptotls.Config {
+	return &cryptotls.Config{
+		MinVersion: cryptotls.VersionTLS13,
+	}
+}
+
+// NewIntermediateServerConfig returns a server tls.Config also accepting TLS
+// 1.2 with IntermediateCipherSuites, for public endpoints with older clients.
+// Session tickets are disabled, as TLS 1.2 tickets break forward secrecy. This
+// disables TLS 1.3 resumption as well, each connection does a full handshake.
+func NewIntermediateServerConfig() *cryptotls.Config {
+	return &cryptotls.Config{
+		MinVersion:             cryptotls.VersionTLS12,
+		CipherSuites:           intermediateCipherSuites(),
+		SessionTicketsDisabled: true,
+	}
+}
+
+// NewIntermediateClientConfig returns a client tls.Config also accepting TLS
+// 1.2 with IntermediateCipherSuites.
+func NewIntermediateClientConfig() *cryptotls.Config {
+	return &cryptotls.Config{
+		MinVersion:   cryptotls.VersionTLS12,
+		CipherSuites: intermediateCipherSuites(),
+	}
+}
+
+// the configs get a copy, so modifying them doesn't affect the presets
+func intermediateCipherSuites() []uint16 {
+	return append([]uint16(nil), IntermediateCipherSuites...)
+}
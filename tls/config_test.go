@@ -0,0 +1,83 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPresets(t *testing.T) {
+	certificate, certPEM, err := GenerateX509KeyPair(time.Hour, []string{"127.0.0.1"})
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(certPEM))
+
+	newServer := func(config *cryptotls.Config) *httptest.Server {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.TLS = config
+		srv.TLS.Certificates = []cryptotls.Certificate{certificate}
+		srv.StartTLS()
+		return srv
+	}
+	get := func(url string, config *cryptotls.Config) (*cryptotls.ConnectionState, error) {
+		config.RootCAs = roots
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		res, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+		return res.TLS, nil
+	}
+	tls12Client := func(cipherSuites ...uint16) *cryptotls.Config {
+		return &cryptotls.Config{MaxVersion: cryptotls.VersionTLS12, CipherSuites: cipherSuites}
+	}
+
+	t.Run("modern", func(t *testing.T) {
+		srv := newServer(NewModernServerConfig())
+		defer srv.Close()
+
+		state, err := get(srv.URL, NewModernClientConfig())
+		require.NoError(t, err)
+		require.Equal(t, uint16(cryptotls.VersionTLS13), state.Version)
+		_, err = get(srv.URL, tls12Client())
+		require.Error(t, err)
+	})
+
+	t.Run("intermediate", func(t *testing.T) {
+		srv := newServer(NewIntermediateServerConfig())
+		defer srv.Close()
+
+		state, err := get(srv.URL, NewIntermediateClientConfig())
+		require.NoError(t, err)
+		require.Equal(t, uint16(cryptotls.VersionTLS13), state.Version)
+		state, err = get(srv.URL, tls12Client())
+		require.NoError(t, err)
+		require.Equal(t, uint16(cryptotls.VersionTLS12), state.Version)
+		require.Contains(t, IntermediateCipherSuites, state.CipherSuite)
+
+		// not an AEAD cipher
+		_, err = get(srv.URL, tls12Client(cryptotls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA))
+		require.Error(t, err)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		for _, config := range []*cryptotls.Config{
+			NewModernServerConfig(), NewModernClientConfig(), NewIntermediateServerConfig(), NewIntermediateClientConfig(),
+		} {
+			// the key exchanges of crypto/tls
+			require.Nil(t, config.CurvePreferences)
+		}
+
+		// the presets are copied
+		config := NewIntermediateServerConfig()
+		config.CipherSuites[0] = 0
+		require.NotEqual(t, uint16(0), NewIntermediateServerConfig().CipherSuites[0])
+		require.NotEqual(t, uint16(0), IntermediateCipherSuites[0])
+	})
+}
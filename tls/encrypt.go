@@ -0,0 +1,185 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	cryptotls "crypto/tls"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PBKDF2Iterations is the iteration count used to derive the key encrypting
+// private keys, as recommended by OWASP for PBKDF2-HMAC-SHA256.
+var PBKDF2Iterations = 600_000
+
+var (
+	ErrNotEncryptedKey     = errors.New("not an encrypted private key")
+	ErrUnsupportedKeyCrypt = errors.New("unsupported private key encryption")
+	ErrWrongPassphrase     = errors.New("failed to decrypt private key, wrong passphrase?")
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// ASN.1 structures of RFC 5958 and RFC 8018
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// EncryptPrivateKeyPEM encrypts a PEM encoded PKCS #8 private key with the
+// passphrase, returning an "ENCRYPTED PRIVATE KEY" PEM block (PBES2 with
+// PBKDF2-HMAC-SHA256 and AES-256-CBC, as `openssl pkcs8 -topk8 -v2 aes256`).
+func EncryptPrivateKeyPEM(keyPEM, passphrase []byte) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, ErrInvalidKeyPEM
+	}
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	encryptionKey := pbkdf2.Key(passphrase, salt, PBKDF2Iterations, 32, sha256.New)
+	aesCipher, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	// PKCS #7 padding
+	padding := aes.BlockSize - len(block.Bytes)%aes.BlockSize
+	encrypted := append(append([]byte{}, block.Bytes...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(aesCipher, iv).CryptBlocks(encrypted, encrypted)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: PBKDF2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData:       encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+}
+
+// DecryptPrivateKeyPEM decrypts an "ENCRYPTED PRIVATE KEY" PEM block produced
+// by EncryptPrivateKeyPEM (or OpenSSL with the same algorithms), returning a
+// "PRIVATE KEY" PEM block.
+func DecryptPrivateKeyPEM(encryptedPEM, passphrase []byte) ([]byte, error) {
+	block, _ := pem.Decode(encryptedPEM)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, ErrNotEncryptedKey
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, err
+	}
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedKeyCrypt
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) || !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, ErrUnsupportedKeyCrypt
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+	if !kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		return nil, ErrUnsupportedKeyCrypt
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrUnsupportedKeyCrypt
+	}
+
+	encryptionKey := pbkdf2.Key(passphrase, kdfParams.Salt, kdfParams.IterationCount, 32, sha256.New)
+	aesCipher, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(aesCipher, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrWrongPassphrase
+	}
+	decrypted = decrypted[:len(decrypted)-padding]
+	// padding may be valid by chance, the key must parse as well
+	if _, err := parsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: decrypted})); err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: decrypted}), nil
+}
+
+// WithEncryptedKey makes GenerateTLS (and GetOrGenerateTLS) return the key
+// encrypted with the passphrase, see EncryptPrivateKeyPEM and
+// LoadEncryptedX509KeyPair.
+func WithEncryptedKey(passphrase []byte) CertOption {
+	return func(c *certConfig) {
+		c.passphrase = passphrase
+	}
+}
+
+// LoadEncryptedX509KeyPair is tls.X509KeyPair for keys encrypted with
+// EncryptPrivateKeyPEM.
+func LoadEncryptedX509KeyPair(certPEM, encryptedKeyPEM, passphrase []byte) (cryptotls.Certificate, error) {
+	keyPEM, err := DecryptPrivateKeyPEM(encryptedKeyPEM, passphrase)
+	if err != nil {
+		return cryptotls.Certificate{}, err
+	}
+	return cryptotls.X509KeyPair(certPEM, keyPEM)
+}
@@ -0,0 +1,34 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedKey(t *testing.T) {
+	iterations := PBKDF2Iterations
+	PBKDF2Iterations = 1000
+	defer func() { PBKDF2Iterations = iterations }()
+
+	passphrase := []byte("correct horse battery staple")
+	dir := t.TempDir()
+	cert, key, err := GetOrGenerateTLS(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), time.Hour, []string{"localhost"}, WithEncryptedKey(passphrase))
+	require.NoError(t, err)
+	require.Contains(t, string(key), "ENCRYPTED PRIVATE KEY")
+
+	_, err = cryptotls.X509KeyPair(cert, key)
+	require.Error(t, err)
+	_, err = LoadEncryptedX509KeyPair(cert, key, passphrase)
+	require.NoError(t, err)
+	_, err = LoadEncryptedX509KeyPair(cert, key, []byte("wrong"))
+	require.ErrorIs(t, err, ErrWrongPassphrase)
+
+	keyPEM, err := DecryptPrivateKeyPEM(key, passphrase)
+	require.NoError(t, err)
+	_, err = DecryptPrivateKeyPEM(keyPEM, passphrase)
+	require.ErrorIs(t, err, ErrNotEncryptedKey)
+}
@@ -0,0 +1,107 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// CertificateSummary describes a certificate for operational tooling.
+type CertificateSummary struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serialNumber"`
+	DNSNames           []string  `json:"dnsNames,omitempty"`
+	IPAddresses        []string  `json:"ipAddresses,omitempty"`
+	NotBefore          time.Time `json:"notBefore"`
+	NotAfter           time.Time `json:"notAfter"`
+	KeyAlgorithm       string    `json:"keyAlgorithm"`
+	SignatureAlgorithm string    `json:"signatureAlgorithm"`
+	FingerprintSHA256  string    `json:"fingerprintSHA256"`
+	IsCA               bool      `json:"isCA"`
+	SelfSigned         bool      `json:"selfSigned"`
+}
+
+// ParseCertificatePEM parses the first certificate of the PEM data.
+func ParseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, ErrInvalidCertificatePEM
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// ParseCertificatesPEM parses all certificates of the PEM data, e.g. a chain.
+func ParseCertificatesPEM(certPEM []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, ErrInvalidCertificatePEM
+	}
+	return certs, nil
+}
+
+// Summarize returns the summary of the certificate.
+func Summarize(cert *x509.Certificate) CertificateSummary {
+	fingerprint := sha256.Sum256(cert.Raw)
+	summary := CertificateSummary{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       cert.SerialNumber.Text(16),
+		DNSNames:           cert.DNSNames,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		KeyAlgorithm:       keyAlgorithm(cert),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		FingerprintSHA256:  hex.EncodeToString(fingerprint[:]),
+		IsCA:               cert.IsCA,
+		SelfSigned:         cert.CheckSignatureFrom(cert) == nil,
+	}
+	for _, ip := range cert.IPAddresses {
+		summary.IPAddresses = append(summary.IPAddresses, ip.String())
+	}
+	return summary
+}
+
+func keyAlgorithm(cert *x509.Certificate) string {
+	switch pub := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + pub.Curve.Params().Name
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", pub.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return cert.PublicKeyAlgorithm.String()
+	}
+}
+
+// VerifyHostname parses the certificate and checks that it is valid for the
+// host (DNS name or IP address).
+func VerifyHostname(certPEM []byte, host string) error {
+	cert, err := ParseCertificatePEM(certPEM)
+	if err != nil {
+		return err
+	}
+	return cert.VerifyHostname(host)
+}
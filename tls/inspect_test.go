@@ -0,0 +1,37 @@
+package tls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	ca, err := GenerateCA(time.Hour, "test CA")
+	require.NoError(t, err)
+	cert, _, err := GenerateServerCertificate(ca, time.Hour, []string{"localhost", "10.0.0.1"})
+	require.NoError(t, err)
+
+	certs, err := ParseCertificatesPEM(append(append([]byte{}, cert...), ca.CertPEM...))
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+
+	summary := Summarize(certs[0])
+	require.Equal(t, "CN=localhost", summary.Subject)
+	require.Equal(t, "CN=test CA", summary.Issuer)
+	require.Equal(t, []string{"localhost"}, summary.DNSNames)
+	require.Equal(t, []string{"10.0.0.1"}, summary.IPAddresses)
+	require.Equal(t, "ECDSA P-256", summary.KeyAlgorithm)
+	require.Len(t, summary.FingerprintSHA256, 64)
+	require.False(t, summary.IsCA)
+	require.False(t, summary.SelfSigned)
+	require.True(t, Summarize(certs[1]).SelfSigned)
+
+	require.NoError(t, VerifyHostname(cert, "localhost"))
+	require.NoError(t, VerifyHostname(cert, "10.0.0.1"))
+	require.Error(t, VerifyHostname(cert, "example.com"))
+
+	_, err = ParseCertificatePEM([]byte("garbage"))
+	require.ErrorIs(t, err, ErrInvalidCertificatePEM)
+}
@@ -0,0 +1,136 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// days until the certificate expires, negative when expired
+const certExpiryDaysLabel = `goutils_tls_certificate_expiry_days{name="%s"}`
+
+// ExpiryMonitorConfig configures ExpiryMonitor.
+type ExpiryMonitorConfig struct {
+	// Interval is how often the certificates are inspected, 1 hour by default
+	Interval time.Duration
+	// Threshold is the remaining validity below which OnExpiring is called, 7 days by default
+	Threshold time.Duration
+	// OnExpiring is called at every check for the certificates expiring within Threshold
+	OnExpiring func(name string, cert *x509.Certificate, remaining time.Duration)
+}
+
+// ExpiryMonitor periodically inspects certificates, exposing the days until
+// their expiry as goutils_tls_certificate_expiry_days gauges and calling back
+// when they are about to expire.
+type ExpiryMonitor struct {
+	cfg ExpiryMonitorConfig
+
+	mu      sync.Mutex
+	sources map[string]func() *cryptotls.Certificate
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewExpiryMonitor creates a monitor and starts inspecting the certificates
+// added to it, call Close to stop.
+func NewExpiryMonitor(cfg ExpiryMonitorConfig) *ExpiryMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 7 * 24 * time.Hour
+	}
+	m := &ExpiryMonitor{
+		cfg:     cfg,
+		sources: make(map[string]func() *cryptotls.Certificate),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+// Add monitors the certificate returned by source under the name (used as
+// metric label), e.g. CertReloader.Certificate, so reloaded certificates are
+// taken into account. The certificate is inspected right away.
+func (m *ExpiryMonitor) Add(name string, source func() *cryptotls.Certificate) {
+	m.mu.Lock()
+	m.sources[name] = source
+	m.mu.Unlock()
+	m.check(name, source)
+}
+
+// AddCertificate monitors a fixed certificate.
+func (m *ExpiryMonitor) AddCertificate(name string, cert *cryptotls.Certificate) {
+	m.Add(name, func() *cryptotls.Certificate { return cert })
+}
+
+func (m *ExpiryMonitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check inspects all certificates.
+func (m *ExpiryMonitor) Check() {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.sources))
+	for name := range m.sources {
+		names = append(names, name)
+	}
+	sources := make(map[string]func() *cryptotls.Certificate, len(m.sources))
+	for name, source := range m.sources {
+		sources[name] = source
+	}
+	m.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		m.check(name, sources[name])
+	}
+}
+
+func (m *ExpiryMonitor) check(name string, source func() *cryptotls.Certificate) {
+	cert, err := leaf(source())
+	if err != nil {
+		return
+	}
+	remaining := time.Until(cert.NotAfter)
+	metrics.GetOrCreateGauge(fmt.Sprintf(certExpiryDaysLabel, name), nil).Set(remaining.Hours() / 24)
+	if remaining < m.cfg.Threshold && m.cfg.OnExpiring != nil {
+		m.cfg.OnExpiring(name, cert, remaining)
+	}
+}
+
+// leaf returns the parsed leaf certificate.
+func leaf(cert *cryptotls.Certificate) (*x509.Certificate, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, ErrNoCertificate
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+// Close stops monitoring.
+func (m *ExpiryMonitor) Close() {
+	m.closeOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
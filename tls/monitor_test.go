@@ -0,0 +1,41 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryMonitor(t *testing.T) {
+	var expiring []string
+	m := NewExpiryMonitor(ExpiryMonitorConfig{
+		Threshold: 48 * time.Hour,
+		OnExpiring: func(name string, _ *x509.Certificate, remaining time.Duration) {
+			expiring = append(expiring, name)
+			require.Less(t, remaining, 25*time.Hour)
+		},
+	})
+	defer m.Close()
+
+	generate := func(validFor time.Duration) *cryptotls.Certificate {
+		cert, key, err := GenerateTLS(validFor, []string{"localhost"})
+		require.NoError(t, err)
+		certificate, err := cryptotls.X509KeyPair(cert, key)
+		require.NoError(t, err)
+		return &certificate
+	}
+	m.AddCertificate("short", generate(24*time.Hour))
+	m.AddCertificate("long", generate(30*24*time.Hour))
+	m.Add("missing", func() *cryptotls.Certificate { return nil })
+	require.Equal(t, []string{"short"}, expiring)
+
+	days := metrics.GetOrCreateGauge(`goutils_tls_certificate_expiry_days{name="long"}`, nil).Get()
+	require.InDelta(t, 30, days, 0.01)
+
+	m.Check()
+	require.Equal(t, []string{"short", "short"}, expiring)
+}
@@ -0,0 +1,101 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+)
+
+// certConfig holds the certificate fields configurable with CertOption.
+type certConfig struct {
+	subject      pkix.Name
+	keyUsage     x509.KeyUsage
+	extKeyUsage  []x509.ExtKeyUsage
+	isCA         bool
+	serialNumber func() (*big.Int, error)
+	extensions   []pkix.Extension
+
+	attestationOID asn1.ObjectIdentifier
+	attest         AttestFunc
+
+	passphrase []byte
+}
+
+// CertOption customizes the certificates generated by GenerateTLS.
+type CertOption func(*certConfig)
+
+func defaultCertConfig() *certConfig {
+	return &certConfig{
+		subject: pkix.Name{
+			Organization: []string{"Acme"},
+		},
+		keyUsage:     x509.KeyUsageDigitalSignature,
+		extKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		isCA:         true,
+		serialNumber: newSerialNumber,
+	}
+}
+
+// WithSubject sets the subject of the certificate, by default the organization
+// is "Acme".
+func WithSubject(subject pkix.Name) CertOption {
+	return func(c *certConfig) {
+		c.subject = subject
+	}
+}
+
+// WithKeyUsage sets the key usage, digital signature by default (cert signing
+// is added for CA certificates).
+func WithKeyUsage(keyUsage x509.KeyUsage) CertOption {
+	return func(c *certConfig) {
+		c.keyUsage = keyUsage
+	}
+}
+
+// WithExtKeyUsage sets the extended key usages, server auth by default.
+func WithExtKeyUsage(extKeyUsage ...x509.ExtKeyUsage) CertOption {
+	return func(c *certConfig) {
+		c.extKeyUsage = extKeyUsage
+	}
+}
+
+// WithClientAuth adds client auth to the extended key usages, so the
+// certificate can be used for mutual TLS.
+func WithClientAuth() CertOption {
+	return func(c *certConfig) {
+		c.extKeyUsage = append(c.extKeyUsage, x509.ExtKeyUsageClientAuth)
+	}
+}
+
+// WithIsCA sets whether the certificate is its own CA, true by default. Some
+// verifiers reject leaf certificates marked as CA.
+func WithIsCA(isCA bool) CertOption {
+	return func(c *certConfig) {
+		c.isCA = isCA
+	}
+}
+
+// WithSerialNumber sets the source of serial numbers, random 128-bit numbers
+// by default.
+func WithSerialNumber(serialNumber func() (*big.Int, error)) CertOption {
+	return func(c *certConfig) {
+		c.serialNumber = serialNumber
+	}
+}
+
+// WithExtensions adds extensions to the certificate.
+func WithExtensions(extensions ...pkix.Extension) CertOption {
+	return func(c *certConfig) {
+		c.extensions = append(c.extensions, extensions...)
+	}
+}
+
+// WithAttestation embeds an attestation quote of the generated key in the
+// extension with the oid, see GenerateAttestedTLS.
+func WithAttestation(oid asn1.ObjectIdentifier, attest AttestFunc) CertOption {
+	return func(c *certConfig) {
+		c.attestationOID = oid
+		c.attest = attest
+	}
+}
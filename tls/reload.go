@@ -0,0 +1,180 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificate = errors.New("no certificate loaded")
+
+// CertReloaderConfig configures CertReloader.
+type CertReloaderConfig struct {
+	// CertPath and KeyPath are the PEM files to load the certificate from, they are reloaded when modified
+	CertPath string
+	KeyPath  string
+	// Interval is how often the files are checked for modifications, 10 seconds by default
+	Interval time.Duration
+
+	// Updates provides certificates programmatically, e.g. from a secret store or a renewal manager
+	Updates <-chan cryptotls.Certificate
+
+	// OnReload is called after a new certificate was loaded
+	OnReload func(cert *cryptotls.Certificate)
+	// OnError is called when reloading fails, the previous certificate is kept
+	OnError func(err error)
+}
+
+// CertReloader keeps a certificate up to date with its files (or an update
+// channel), so certificates can be rotated without restarting servers. Use
+// GetCertificate and GetClientCertificate as tls.Config callbacks.
+type CertReloader struct {
+	cfg CertReloaderConfig
+
+	mu      sync.RWMutex
+	cert    *cryptotls.Certificate
+	modTime time.Time
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCertReloader loads the certificate (if files are configured) and starts
+// watching for updates, call Close to stop.
+func NewCertReloader(cfg CertReloaderConfig) (*CertReloader, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	r := &CertReloader{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if cfg.CertPath != "" {
+		if err := r.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	go r.run()
+	return r, nil
+}
+
+func (r *CertReloader) run() {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if r.cfg.CertPath != "" {
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick:
+			modified, err := r.modified()
+			if err == nil && modified {
+				err = r.Reload()
+			}
+			if err != nil && r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+		case cert, ok := <-r.cfg.Updates:
+			if !ok {
+				r.cfg.Updates = nil
+				continue
+			}
+			r.Update(cert)
+		}
+	}
+}
+
+// lastModTime returns the latest modification time of the files.
+func (r *CertReloader) lastModTime() (time.Time, error) {
+	certInfo, err := os.Stat(r.cfg.CertPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.cfg.KeyPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
+
+func (r *CertReloader) modified() (bool, error) {
+	modTime, err := r.lastModTime()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !modTime.Equal(r.modTime), nil
+}
+
+// Reload loads the certificate from the files.
+func (r *CertReloader) Reload() error {
+	modTime, err := r.lastModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := cryptotls.LoadX509KeyPair(r.cfg.CertPath, r.cfg.KeyPath)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	if r.cfg.OnReload != nil {
+		r.cfg.OnReload(&cert)
+	}
+	return nil
+}
+
+// Update replaces the certificate.
+func (r *CertReloader) Update(cert cryptotls.Certificate) {
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+
+	if r.cfg.OnReload != nil {
+		r.cfg.OnReload(&cert)
+	}
+}
+
+// Certificate returns the current certificate, nil if none was loaded yet.
+func (r *CertReloader) Certificate() *cryptotls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*cryptotls.ClientHelloInfo) (*cryptotls.Certificate, error) {
+	if cert := r.Certificate(); cert != nil {
+		return cert, nil
+	}
+	return nil, ErrNoCertificate
+}
+
+// GetClientCertificate can be used as tls.Config.GetClientCertificate.
+func (r *CertReloader) GetClientCertificate(*cryptotls.CertificateRequestInfo) (*cryptotls.Certificate, error) {
+	return r.GetCertificate(nil)
+}
+
+// Close stops watching for updates.
+func (r *CertReloader) Close() {
+	r.closeOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
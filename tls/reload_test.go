@@ -0,0 +1,58 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert := func(modTime time.Time) []byte {
+		cert, key, err := GenerateTLS(time.Hour, []string{"localhost"})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(certPath, cert, 0o600))
+		require.NoError(t, os.WriteFile(keyPath, key, 0o600))
+		require.NoError(t, os.Chtimes(certPath, modTime, modTime))
+		require.NoError(t, os.Chtimes(keyPath, modTime, modTime))
+		return cert
+	}
+	now := time.Now()
+	writeCert(now)
+
+	updates := make(chan cryptotls.Certificate)
+	reloaded := make(chan struct{}, 10)
+	r, err := NewCertReloader(CertReloaderConfig{
+		CertPath: certPath,
+		KeyPath:  keyPath,
+		Interval: 10 * time.Millisecond,
+		Updates:  updates,
+		OnReload: func(*cryptotls.Certificate) { reloaded <- struct{}{} },
+	})
+	require.NoError(t, err)
+	defer r.Close()
+	<-reloaded
+
+	first, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	writeCert(now.Add(time.Second))
+	<-reloaded
+	second, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Certificate[0], second.Certificate[0])
+
+	updates <- *first
+	<-reloaded
+	third, err := r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.Certificate[0], third.Certificate[0])
+
+	_, err = NewCertReloader(CertReloaderConfig{CertPath: certPath + ".missing", KeyPath: keyPath})
+	require.Error(t, err)
+}
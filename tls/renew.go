@@ -0,0 +1,240 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// GetOrGenerateTLS loads the certificate and key from the files if they exist
+// and the certificate has not expired yet, otherwise it generates them with
+// GenerateTLS (with the options) and writes them to the files. Use
+// WithEncryptedKey to not store the key in plaintext.
+func GetOrGenerateTLS(certPath, keyPath string, validFor time.Duration, hosts []string, opts ...CertOption) (cert, key []byte, err error) {
+	cert, err = os.ReadFile(certPath)
+	if err == nil {
+		key, err = os.ReadFile(keyPath)
+	}
+	if err == nil {
+		if x509Cert, pErr := ParseCertificatePEM(cert); pErr == nil && time.Now().Before(x509Cert.NotAfter) {
+			return cert, key, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	cert, key, err = GenerateTLS(validFor, hosts, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writeKeyPair(certPath, keyPath, cert, key); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func writeKeyPair(certPath, keyPath string, cert, key []byte) error {
+	if err := writeFileAtomic(keyPath, key, 0o600); err != nil {
+		return err
+	}
+	return writeFileAtomic(certPath, cert, 0o644)
+}
+
+// writeFileAtomic writes the file to a temporary file renamed over it, readers
+// never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// RenewalConfig configures RenewalManager.
+type RenewalConfig struct {
+	// ValidFor, Hosts and CertOptions are passed to GenerateTLS. With
+	// WithEncryptedKey the key is stored encrypted, see GetOrGenerateTLS.
+	ValidFor    time.Duration
+	Hosts       []string
+	CertOptions []CertOption
+
+	// CertPath and KeyPath persist the certificate (see GetOrGenerateTLS), optional
+	CertPath string
+	KeyPath  string
+
+	// RenewBefore is the lead time before expiry when the certificate is renewed, a third of ValidFor by default
+	RenewBefore time.Duration
+	// CheckInterval is how often the expiry is checked, 1 minute by default
+	CheckInterval time.Duration
+
+	// OnRenew is called after the certificate was renewed
+	OnRenew func(cert *cryptotls.Certificate, certPEM []byte)
+	// OnError is called when renewal fails, it is retried at the next check
+	OnError func(err error)
+}
+
+// RenewalManager keeps a self-signed certificate valid by re-generating it
+// before it expires. Use GetCertificate as tls.Config callback.
+type RenewalManager struct {
+	*CertReloader
+	cfg RenewalConfig
+	// passphrase of the key, set with WithEncryptedKey
+	passphrase []byte
+
+	mu       sync.RWMutex
+	certPEM  []byte
+	notAfter time.Time
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRenewalManager loads or generates the certificate and starts renewing it,
+// call Close to stop.
+func NewRenewalManager(cfg RenewalConfig) (*RenewalManager, error) {
+	if cfg.RenewBefore <= 0 {
+		cfg.RenewBefore = cfg.ValidFor / 3
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = time.Minute
+	}
+	reloader, err := NewCertReloader(CertReloaderConfig{})
+	if err != nil {
+		return nil, err
+	}
+	certCfg := defaultCertConfig()
+	for _, opt := range cfg.CertOptions {
+		opt(certCfg)
+	}
+	m := &RenewalManager{
+		CertReloader: reloader,
+		cfg:          cfg,
+		passphrase:   certCfg.passphrase,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	var cert, key []byte
+	if cfg.CertPath != "" {
+		cert, key, err = GetOrGenerateTLS(cfg.CertPath, cfg.KeyPath, cfg.ValidFor, cfg.Hosts, cfg.CertOptions...)
+	} else {
+		cert, key, err = GenerateTLS(cfg.ValidFor, cfg.Hosts, cfg.CertOptions...)
+	}
+	if err == nil {
+		err = m.update(cert, key)
+	}
+	if err != nil {
+		reloader.Close()
+		return nil, err
+	}
+
+	go m.run()
+	return m, nil
+}
+
+func (m *RenewalManager) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if time.Until(m.NotAfter()) > m.cfg.RenewBefore {
+				continue
+			}
+			if err := m.Renew(); err != nil && m.cfg.OnError != nil {
+				m.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+// Renew generates a new certificate, regardless of the expiry of the current
+// one.
+func (m *RenewalManager) Renew() error {
+	cert, key, err := GenerateTLS(m.cfg.ValidFor, m.cfg.Hosts, m.cfg.CertOptions...)
+	if err != nil {
+		return err
+	}
+	if m.cfg.CertPath != "" {
+		if err := writeKeyPair(m.cfg.CertPath, m.cfg.KeyPath, cert, key); err != nil {
+			return err
+		}
+	}
+	if err := m.update(cert, key); err != nil {
+		return err
+	}
+	if m.cfg.OnRenew != nil {
+		m.cfg.OnRenew(m.Certificate(), cert)
+	}
+	return nil
+}
+
+func (m *RenewalManager) update(certPEM, keyPEM []byte) error {
+	var certificate cryptotls.Certificate
+	var err error
+	if m.passphrase != nil {
+		certificate, err = LoadEncryptedX509KeyPair(certPEM, keyPEM, m.passphrase)
+	} else {
+		certificate, err = cryptotls.X509KeyPair(certPEM, keyPEM)
+	}
+	if err != nil {
+		return err
+	}
+	x509Cert, err := ParseCertificatePEM(certPEM)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.certPEM = certPEM
+	m.notAfter = x509Cert.NotAfter
+	m.mu.Unlock()
+
+	m.Update(certificate)
+	return nil
+}
+
+// CertPEM returns the PEM encoding of the current certificate, e.g. to
+// distribute it to clients.
+func (m *RenewalManager) CertPEM() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.certPEM
+}
+
+// NotAfter returns the expiry of the current certificate.
+func (m *RenewalManager) NotAfter() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.notAfter
+}
+
+// Close stops renewing the certificate.
+func (m *RenewalManager) Close() {
+	m.closeOnce.Do(func() { close(m.stop) })
+	<-m.done
+	m.CertReloader.Close()
+}
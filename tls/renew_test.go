@@ -0,0 +1,88 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrGenerateTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	cert, key, err := GetOrGenerateTLS(certPath, keyPath, time.Hour, []string{"localhost"})
+	require.NoError(t, err)
+	info, err := os.Stat(keyPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	cert2, key2, err := GetOrGenerateTLS(certPath, keyPath, time.Hour, []string{"localhost"})
+	require.NoError(t, err)
+	require.Equal(t, cert, cert2)
+	require.Equal(t, key, key2)
+}
+
+func TestRenewalManager(t *testing.T) {
+	dir := t.TempDir()
+	renewed := make(chan []byte, 10)
+	m, err := NewRenewalManager(RenewalConfig{
+		ValidFor:      time.Hour,
+		Hosts:         []string{"localhost"},
+		CertPath:      filepath.Join(dir, "cert.pem"),
+		KeyPath:       filepath.Join(dir, "key.pem"),
+		RenewBefore:   2 * time.Hour, // renew at every check
+		CheckInterval: 10 * time.Millisecond,
+		OnRenew:       func(_ *cryptotls.Certificate, certPEM []byte) { renewed <- certPEM },
+	})
+	require.NoError(t, err)
+	defer m.Close()
+
+	first := m.CertPEM()
+	second := <-renewed
+	require.NotEqual(t, first, second)
+
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+	require.True(t, time.Until(m.NotAfter()) > 59*time.Minute)
+
+	m.Close()
+	persisted, err := os.ReadFile(filepath.Join(dir, "cert.pem"))
+	require.NoError(t, err)
+	require.Equal(t, m.CertPEM(), persisted)
+}
+
+func TestRenewalManagerCertOptions(t *testing.T) {
+	dir := t.TempDir()
+	passphrase := []byte("secret")
+	m, err := NewRenewalManager(RenewalConfig{
+		ValidFor:    time.Hour,
+		Hosts:       []string{"localhost"},
+		CertOptions: []CertOption{WithSubject(pkix.Name{CommonName: "renewed"}), WithEncryptedKey(passphrase)},
+		CertPath:    filepath.Join(dir, "cert.pem"),
+		KeyPath:     filepath.Join(dir, "key.pem"),
+	})
+	require.NoError(t, err)
+	defer m.Close()
+	require.NoError(t, m.Renew())
+
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "renewed", cert.Leaf.Subject.CommonName)
+
+	// the key is persisted encrypted, no temporary files are left
+	key, err := os.ReadFile(filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+	_, err = cryptotls.X509KeyPair(m.CertPEM(), key)
+	require.Error(t, err)
+	_, err = LoadEncryptedX509KeyPair(m.CertPEM(), key, passphrase)
+	require.NoError(t, err)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
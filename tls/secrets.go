@@ -0,0 +1,149 @@
+package tls
+
+import (
+	"bytes"
+	cryptotls "crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Keys of the certificate, key and CA in Kubernetes secrets of type kubernetes.io/tls
+const (
+	KubernetesTLSCertKey = "tls.crt"
+	KubernetesTLSKeyKey  = "tls.key"
+	KubernetesCACertKey  = "ca.crt"
+)
+
+// DefaultKubernetesSecretPath is the default mount path of the TLS secret
+// volume.
+const DefaultKubernetesSecretPath = "/var/run/secrets/tls"
+
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrInvalidSecretName = errors.New("invalid secret name")
+)
+
+// SecretStore provides secrets by name, e.g. the PEM encoded certificate and
+// key of a service.
+type SecretStore interface {
+	GetSecret(name string) ([]byte, error)
+}
+
+// EnvSecretStore reads the secrets from environment variables named by the
+// prefix and the upper case name (dots and dashes replaced with underscores),
+// e.g. TLS_CERT for the name "cert" with the prefix "TLS_". The values are
+// base64 encoded, or plain PEM.
+type EnvSecretStore struct {
+	Prefix string
+}
+
+// NewEnvSecretStore returns an EnvSecretStore with the prefix.
+func NewEnvSecretStore(prefix string) *EnvSecretStore {
+	return &EnvSecretStore{Prefix: prefix}
+}
+
+// GetSecret returns the decoded value of the environment variable.
+func (s *EnvSecretStore) GetSecret(name string) ([]byte, error) {
+	env := s.Prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+	value, ok := os.LookupEnv(env)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, env)
+	}
+	secret, err := decodePEMValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of %s: %w", env, err)
+	}
+	return secret, nil
+}
+
+// FileSecretStore reads the secrets from the files of a directory, named after
+// the secrets.
+type FileSecretStore struct {
+	Dir string
+}
+
+// NewFileSecretStore returns a FileSecretStore reading from the directory.
+func NewFileSecretStore(dir string) *FileSecretStore {
+	return &FileSecretStore{Dir: dir}
+}
+
+// GetSecret returns the content of the file.
+func (s *FileSecretStore) GetSecret(name string) ([]byte, error) {
+	if name == "" || name == "." || name == ".." || name != filepath.Base(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSecretName, name)
+	}
+	secret, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return secret, err
+}
+
+// KubernetesSecretStore reads the secrets from a Kubernetes secret mounted as
+// volume, the keys of the secret being the file names (e.g. tls.crt and
+// tls.key). Kubernetes updates mounted secrets by swapping the ..data symlink,
+// so the files are read again on every call.
+type KubernetesSecretStore struct {
+	FileSecretStore
+}
+
+// NewKubernetesSecretStore returns a KubernetesSecretStore for the volume
+// mounted at the path, DefaultKubernetesSecretPath if empty.
+func NewKubernetesSecretStore(mountPath string) *KubernetesSecretStore {
+	if mountPath == "" {
+		mountPath = DefaultKubernetesSecretPath
+	}
+	return &KubernetesSecretStore{FileSecretStore{Dir: mountPath}}
+}
+
+// GetSecret returns the value of the key of the secret.
+func (s *KubernetesSecretStore) GetSecret(name string) ([]byte, error) {
+	if strings.HasPrefix(name, "..") {
+		// ..data and the timestamped directories are internals of the volume
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSecretName, name)
+	}
+	return s.FileSecretStore.GetSecret(name)
+}
+
+// LoadX509KeyPairFromStore loads the certificate and key stored under the
+// names, e.g. KubernetesTLSCertKey and KubernetesTLSKeyKey.
+func LoadX509KeyPairFromStore(store SecretStore, certName, keyName string) (cryptotls.Certificate, error) {
+	certPEM, err := store.GetSecret(certName)
+	if err != nil {
+		return cryptotls.Certificate{}, err
+	}
+	keyPEM, err := store.GetSecret(keyName)
+	if err != nil {
+		return cryptotls.Certificate{}, err
+	}
+	return cryptotls.X509KeyPair(certPEM, keyPEM)
+}
+
+// LoadX509KeyPairFromEnv loads the certificate and key from the environment
+// variables, holding base64 encoded (or plain) PEM.
+func LoadX509KeyPairFromEnv(certEnv, keyEnv string) (cryptotls.Certificate, error) {
+	return LoadX509KeyPairFromStore(NewEnvSecretStore(""), certEnv, keyEnv)
+}
+
+// decodePEMValue decodes base64 encoded PEM, PEM is returned as is.
+func decodePEMValue(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	// line breaks are allowed, as in the output of base64
+	value = strings.Join(strings.Fields(value), "")
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(decoded, []byte("-----BEGIN")) {
+		return nil, errors.New("not PEM encoded")
+	}
+	return decoded, nil
+}
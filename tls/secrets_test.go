@@ -0,0 +1,48 @@
+package tls
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretStores(t *testing.T) {
+	cert, key, err := GenerateTLS(time.Hour, []string{"localhost"})
+	require.NoError(t, err)
+
+	// base64 and plain PEM values
+	t.Setenv("TEST_TLS_CERT", base64.StdEncoding.EncodeToString(cert))
+	t.Setenv("TEST_TLS_KEY", string(key))
+	certificate, err := LoadX509KeyPairFromEnv("TEST_TLS_CERT", "TEST_TLS_KEY")
+	require.NoError(t, err)
+	require.NotEmpty(t, certificate.Certificate)
+
+	_, err = LoadX509KeyPairFromStore(NewEnvSecretStore("TEST_TLS_"), "cert", "missing")
+	require.ErrorIs(t, err, ErrSecretNotFound)
+	t.Setenv("TEST_TLS_INVALID", "not base64")
+	_, err = NewEnvSecretStore("TEST_TLS_").GetSecret("invalid")
+	require.Error(t, err)
+
+	// mounted Kubernetes secret, the keys being symlinks into ..data
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..2024_01_01"), 0o700))
+	require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(dir, "..data")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..data", KubernetesTLSCertKey), cert, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..data", KubernetesTLSKeyKey), key, 0o600))
+	require.NoError(t, os.Symlink(filepath.Join("..data", KubernetesTLSCertKey), filepath.Join(dir, KubernetesTLSCertKey)))
+	require.NoError(t, os.Symlink(filepath.Join("..data", KubernetesTLSKeyKey), filepath.Join(dir, KubernetesTLSKeyKey)))
+
+	store := NewKubernetesSecretStore(dir)
+	_, err = LoadX509KeyPairFromStore(store, KubernetesTLSCertKey, KubernetesTLSKeyKey)
+	require.NoError(t, err)
+	_, err = store.GetSecret(KubernetesCACertKey)
+	require.ErrorIs(t, err, ErrSecretNotFound)
+	_, err = store.GetSecret("..data")
+	require.ErrorIs(t, err, ErrInvalidSecretName)
+	_, err = NewFileSecretStore(dir).GetSecret("../etc/passwd")
+	require.ErrorIs(t, err, ErrInvalidSecretName)
+}
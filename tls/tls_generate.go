@@ -6,9 +6,11 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	cryptotls "crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"net"
 	"time"
@@ -17,68 +19,153 @@ import (
 // GenerateTLS generated a TLS certificate and key.
 // based on https://go.dev/src/crypto/tls/generate_cert.go
 // - `hosts`: a list of ip / dns names to include in the certificate
-func GenerateTLS(validFor time.Duration, hosts []string) (cert, key []byte, err error) {
+// - `opts`: customize the certificate, by default it is a self-signed server certificate of "Acme"
+func GenerateTLS(validFor time.Duration, hosts []string, opts ...CertOption) (cert, key []byte, err error) {
+	cfg := defaultCertConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	derBytes, priv, err := generateCertificate(validFor, hosts, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err = encodeCertificate(derBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err = encodePrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.passphrase != nil {
+		if key, err = EncryptPrivateKeyPEM(key, cfg.passphrase); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cert, key, nil
+}
+
+// GenerateX509KeyPair is GenerateTLS returning the certificate ready to be
+// served, for ephemeral servers (tests, enclave-internal listeners) that keep
+// the key in memory only. certPEM is returned to be distributed to clients.
+func GenerateX509KeyPair(validFor time.Duration, hosts []string, opts ...CertOption) (certificate cryptotls.Certificate, certPEM []byte, err error) {
+	cfg := defaultCertConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	derBytes, priv, err := generateCertificate(validFor, hosts, cfg)
+	if err != nil {
+		return cryptotls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return cryptotls.Certificate{}, nil, err
+	}
+	certPEM, err = encodeCertificate(derBytes)
+	if err != nil {
+		return cryptotls.Certificate{}, nil, err
+	}
+
+	certificate = cryptotls.Certificate{
+		Certificate: [][]byte{derBytes},
+		PrivateKey:  priv,
+		Leaf:        leaf,
+	}
+	return certificate, certPEM, nil
+}
+
+// generateCertificate generates a key and the DER encoded certificate for it.
+func generateCertificate(validFor time.Duration, hosts []string, cfg *certConfig) ([]byte, *ecdsa.PrivateKey, error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, nil, err
 	}
-	keyUsage := x509.KeyUsageDigitalSignature
 
 	notBefore := time.Now()
 	notAfter := notBefore.Add(validFor)
 
-	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	serialNumber, err := cfg.serialNumber()
 	if err != nil {
 		return nil, nil, err
 	}
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization: []string{"Acme"},
-		},
-		NotBefore: notBefore,
-		NotAfter:  notAfter,
-
-		KeyUsage:              keyUsage,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		Subject:      cfg.subject,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+
+		KeyUsage:              cfg.keyUsage,
+		ExtKeyUsage:           cfg.extKeyUsage,
 		BasicConstraintsValid: true,
+		ExtraExtensions:       cfg.extensions,
 	}
+	addHosts(&template, hosts)
 
-	for _, h := range hosts {
-		if ip := net.ParseIP(h); ip != nil {
-			template.IPAddresses = append(template.IPAddresses, ip)
-		} else {
-			template.DNSNames = append(template.DNSNames, h)
-		}
+	if cfg.isCA {
+		// certificate is its own CA
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
 	}
 
-	// certificate is its own CA
-	template.IsCA = true
-	template.KeyUsage |= x509.KeyUsageCertSign
+	if cfg.attest != nil {
+		reportData, err := AttestationReportData(&priv.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		quote, err := cfg.attest(reportData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to attest the key: %w", err)
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: cfg.attestationOID, Value: quote})
+	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
 	if err != nil {
 		return nil, nil, err
 	}
+	return derBytes, priv, nil
+}
 
+// newSerialNumber returns a random 128-bit certificate serial number.
+func newSerialNumber() (*big.Int, error) {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	return rand.Int(rand.Reader, serialNumberLimit)
+}
+
+// addHosts adds the hosts to the IP addresses or DNS names of the template.
+func addHosts(template *x509.Certificate, hosts []string) {
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+}
+
+// encodeCertificate returns the PEM encoding of the DER certificate.
+func encodeCertificate(derBytes []byte) ([]byte, error) {
 	var certOut bytes.Buffer
-	if err = pem.Encode(&certOut, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes}); err != nil {
-		return nil, nil, err
+	if err := pem.Encode(&certOut, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes}); err != nil {
+		return nil, err
 	}
-	cert = certOut.Bytes()
+	return certOut.Bytes(), nil
+}
 
+// encodePrivateKey returns the PEM encoding of the key in PKCS #8 form.
+func encodePrivateKey(priv any) ([]byte, error) {
 	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	var keyOut bytes.Buffer
-	err = pem.Encode(&keyOut, &pem.Block{Type: "PRIVATE KEY", Bytes: privBytes})
-	if err != nil {
-		return nil, nil, err
+	if err = pem.Encode(&keyOut, &pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}); err != nil {
+		return nil, err
 	}
-	key = keyOut.Bytes()
-	return cert, key, nil
+	return keyOut.Bytes(), nil
 }
@@ -0,0 +1,36 @@
+package tls
+
+import (
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateX509KeyPair(t *testing.T) {
+	certificate, certPEM, err := GenerateX509KeyPair(time.Hour, []string{"127.0.0.1"})
+	require.NoError(t, err)
+	require.NotNil(t, certificate.Leaf)
+	require.Equal(t, certificate.Certificate[0], certificate.Leaf.Raw)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	srv.TLS = NewModernServerConfig()
+	srv.TLS.Certificates = []cryptotls.Certificate{certificate}
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(certPEM))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{RootCAs: roots}}}
+	res, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -8,22 +8,29 @@ import (
 
 var isTruthy = map[string]bool{
 	// truthy
-	"1":    true,
-	"t":    true,
-	"true": true,
-	"y":    true,
-	"yes":  true,
+	"1":       true,
+	"t":       true,
+	"true":    true,
+	"y":       true,
+	"yes":     true,
+	"on":      true,
+	"enable":  true,
+	"enabled": true,
 	// non-truthy
-	"":      false,
-	"0":     false,
-	"f":     false,
-	"false": false,
-	"n":     false,
-	"no":    false,
+	"":         false,
+	"0":        false,
+	"f":        false,
+	"false":    false,
+	"n":        false,
+	"no":       false,
+	"off":      false,
+	"disable":  false,
+	"disabled": false,
 }
 
 // Is returns `false` if the argument sounds like "false" (empty string, "0",
-// "f", "false", and so on), and `true` otherwise.
+// "f", "false", "off", "disable", and so on), `true` if it sounds like "true"
+// ("1", "yes", "on", "enable", and so on), and an error otherwise.
 func Is(val string) (bool, error) {
 	if res, known := isTruthy[strings.ToLower(val)]; known {
 		return res, nil
@@ -31,6 +38,31 @@ func Is(val string) (bool, error) {
 	return false, fmt.Errorf("can not resolve truthy-ness of \"%s\"", val)
 }
 
+// Parse is the stricter variant of Is: it returns nil for the empty (or
+// whitespace only) string, to distinguish "unset" from "false", and an error
+// for values that are neither truthy nor falsy.
+func Parse(val string) (*bool, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return nil, nil
+	}
+	res, err := Is(val)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MustIs is Is, panicking if the truthy-ness of the argument can not be
+// resolved.
+func MustIs(val string) bool {
+	res, err := Is(val)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
 // TrueOnError returns true if err is not nil, otherwise it returns res.
 func TrueOnError(res bool, err error) bool {
 	if err != nil {
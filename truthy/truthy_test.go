@@ -18,6 +18,8 @@ func TestIs(t *testing.T) {
 			"True",
 			"Y",
 			"yes",
+			"on",
+			"Enable",
 		} {
 			assert.True(
 				t,
@@ -36,6 +38,8 @@ func TestIs(t *testing.T) {
 			"False",
 			"N",
 			"no",
+			"off",
+			"Disable",
 		} {
 			assert.False(
 				t,
@@ -45,3 +49,32 @@ func TestIs(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	for _, unset := range []string{"", "  "} {
+		res, err := truthy.Parse(unset)
+		assert.NoError(t, err)
+		assert.Nil(t, res, fmt.Sprintf("Value '%s' must render as unset", unset))
+	}
+
+	res, err := truthy.Parse("off")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.False(t, *res)
+	}
+
+	res, err = truthy.Parse(" enabled ")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.True(t, *res)
+	}
+
+	_, err = truthy.Parse("maybe")
+	assert.Error(t, err)
+}
+
+func TestMustIs(t *testing.T) {
+	assert.True(t, truthy.MustIs("on"))
+	assert.False(t, truthy.MustIs("disable"))
+	assert.Panics(t, func() { truthy.MustIs("maybe") })
+}
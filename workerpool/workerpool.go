@@ -0,0 +1,237 @@
+// Package workerpool runs tasks on a bounded number of workers, with a bounded queue, per-task timeouts and panic
+// isolation.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// number of tasks waiting in the queue / being run by a worker
+	queuedGauge = `goutils_workerpool_queued{pool="%s"}`
+	activeGauge = `goutils_workerpool_active{pool="%s"}`
+	// incremented when a task is rejected because the queue is full, and when a task fails / panics
+	rejectedCounter = `goutils_workerpool_rejected_total{pool="%s"}`
+	failedCounter   = `goutils_workerpool_failed_total{pool="%s"}`
+	panicsCounter   = `goutils_workerpool_panics_total{pool="%s"}`
+	// duration of the tasks
+	taskDurationSummary = `goutils_workerpool_task_duration_milliseconds{pool="%s"}`
+)
+
+var (
+	ErrQueueFull = errors.New("worker pool queue is full")
+	ErrClosed    = errors.New("worker pool is closed")
+	ErrPanic     = errors.New("task panicked")
+)
+
+// Task is a unit of work run by the pool.
+type Task func(ctx context.Context) error
+
+// Config configures a Pool.
+type Config struct {
+	// Name of the pool in the metrics
+	Name string
+	// Workers is the number of tasks run concurrently, runtime.GOMAXPROCS by default
+	Workers int
+	// QueueSize is the number of tasks waiting for a worker, beyond which TrySubmit fails with ErrQueueFull
+	QueueSize int
+	// TaskTimeout cancels the context of each task after the duration, no timeout if 0
+	TaskTimeout time.Duration
+	// OnError is called with the errors of the submitted tasks (including panics, wrapped in ErrPanic), can be nil
+	OnError func(err error)
+}
+
+type job struct {
+	ctx  context.Context
+	task Task
+	// receives the result of the task if not nil
+	result chan error
+}
+
+// Pool runs the submitted tasks on a fixed number of workers.
+type Pool struct {
+	cfg    Config
+	queue  chan job
+	queued atomic.Int64
+	active atomic.Int64
+
+	// mu guards closed, submitters hold it for reading while enqueueing
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New starts the workers of a pool.
+func New(cfg Config) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	p := &Pool{
+		cfg:   cfg,
+		queue: make(chan job, cfg.QueueSize),
+	}
+	p.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// TrySubmit queues the task without blocking, or fails with ErrQueueFull. The task context is derived from
+// context.Background.
+func (p *Pool) TrySubmit(task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.queue <- p.newJob(context.Background(), task, nil):
+		return nil
+	default:
+		p.dequeued()
+		metrics.GetOrCreateCounter(fmt.Sprintf(rejectedCounter, p.cfg.Name)).Inc()
+		return ErrQueueFull
+	}
+}
+
+// Submit queues the task, waiting for room in the queue until the context is done. The task context is derived from
+// context.Background, ctx only bounds the wait.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	return p.enqueue(ctx, p.newJob(context.Background(), task, nil))
+}
+
+// Do runs the task on the pool and returns its error, the task context being derived from ctx.
+func (p *Pool) Do(ctx context.Context, task Task) error {
+	result := make(chan error, 1)
+	if err := p.enqueue(ctx, p.newJob(ctx, task, result)); err != nil {
+		return err
+	}
+	return <-result
+}
+
+// Do runs fn on the pool and returns its result, the context of fn being derived from ctx.
+func Do[T any](ctx context.Context, p *Pool, fn func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	err := p.Do(ctx, func(ctx context.Context) (err error) {
+		value, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
+// Queued returns the number of tasks waiting for a worker.
+func (p *Pool) Queued() int {
+	return int(p.queued.Load())
+}
+
+// Active returns the number of tasks being run.
+func (p *Pool) Active() int {
+	return int(p.active.Load())
+}
+
+// Close stops accepting tasks and waits for the queued and running tasks to finish.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *Pool) newJob(ctx context.Context, task Task, result chan error) job {
+	p.queued.Add(1)
+	p.updateGauges()
+	return job{ctx: ctx, task: task, result: result}
+}
+
+func (p *Pool) dequeued() {
+	p.queued.Add(-1)
+	p.updateGauges()
+}
+
+func (p *Pool) enqueue(ctx context.Context, j job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.dequeued()
+		return ErrClosed
+	}
+	select {
+	case p.queue <- j:
+		return nil
+	case <-ctx.Done():
+		p.dequeued()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		p.dequeued()
+		p.active.Add(1)
+		p.updateGauges()
+
+		err := p.run(j)
+
+		p.active.Add(-1)
+		p.updateGauges()
+		if err != nil {
+			metrics.GetOrCreateCounter(fmt.Sprintf(failedCounter, p.cfg.Name)).Inc()
+		}
+		if j.result != nil {
+			j.result <- err
+		} else if err != nil && p.cfg.OnError != nil {
+			p.cfg.OnError(err)
+		}
+	}
+}
+
+// run runs the task with its timeout, recovering panics
+func (p *Pool) run(j job) (err error) {
+	ctx := j.ctx
+	if p.cfg.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.TaskTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.GetOrCreateSummary(fmt.Sprintf(taskDurationSummary, p.cfg.Name)).Update(float64(time.Since(start).Milliseconds()))
+		if r := recover(); r != nil {
+			metrics.GetOrCreateCounter(fmt.Sprintf(panicsCounter, p.cfg.Name)).Inc()
+			err = fmt.Errorf("%w: %v\n%s", ErrPanic, r, debug.Stack())
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		// e.g. the caller of Do gave up while the task was queued
+		return err
+	}
+	return j.task(ctx)
+}
+
+func (p *Pool) updateGauges() {
+	metrics.GetOrCreateGauge(fmt.Sprintf(queuedGauge, p.cfg.Name), nil).Set(float64(p.queued.Load()))
+	metrics.GetOrCreateGauge(fmt.Sprintf(activeGauge, p.cfg.Name), nil).Set(float64(p.active.Load()))
+}
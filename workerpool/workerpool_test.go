@@ -0,0 +1,88 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolDo(t *testing.T) {
+	p := New(Config{Name: "test_do", Workers: 2})
+	defer p.Close()
+
+	value, err := Do(context.Background(), p, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+
+	errTest := errors.New("test")
+	_, err = Do(context.Background(), p, func(ctx context.Context) (int, error) {
+		return 1, errTest
+	})
+	require.ErrorIs(t, err, errTest)
+}
+
+func TestPoolQueueFull(t *testing.T) {
+	p := New(Config{Name: "test_queue", Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	require.NoError(t, p.TrySubmit(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+	<-started
+	require.Equal(t, 1, p.Active())
+
+	require.NoError(t, p.TrySubmit(func(ctx context.Context) error { return nil }))
+	require.Equal(t, 1, p.Queued())
+	require.ErrorIs(t, p.TrySubmit(func(ctx context.Context) error { return nil }), ErrQueueFull)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, p.Submit(ctx, func(ctx context.Context) error { return nil }), context.DeadlineExceeded)
+
+	close(release)
+	p.Close()
+	require.Equal(t, 0, p.Queued())
+	require.Equal(t, 0, p.Active())
+	require.ErrorIs(t, p.TrySubmit(func(ctx context.Context) error { return nil }), ErrClosed)
+}
+
+func TestPoolTimeoutAndPanic(t *testing.T) {
+	var errs atomic.Int32
+	p := New(Config{
+		Name:        "test_panic",
+		Workers:     1,
+		QueueSize:   1,
+		TaskTimeout: 10 * time.Millisecond,
+		OnError: func(err error) {
+			require.ErrorIs(t, err, ErrPanic)
+			errs.Add(1)
+		},
+	})
+
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = p.Do(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+	require.ErrorIs(t, err, ErrPanic)
+
+	// the worker survived the panic
+	require.NoError(t, p.Submit(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	}))
+	p.Close()
+	require.Equal(t, int32(1), errs.Load())
+}